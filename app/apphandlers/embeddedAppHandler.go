@@ -20,6 +20,7 @@ import (
 	ratelimiter "github.com/rudderlabs/rudder-server/rate-limiter"
 	"github.com/rudderlabs/rudder-server/router"
 	"github.com/rudderlabs/rudder-server/router/batchrouter"
+	"github.com/rudderlabs/rudder-server/router/deadletter"
 	routerManager "github.com/rudderlabs/rudder-server/router/manager"
 	"github.com/rudderlabs/rudder-server/services/db"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
@@ -84,13 +85,18 @@ func (embedded *EmbeddedApp) StartRudderCore(ctx context.Context, options *app.O
 
 	// This gwDBForProcessor should only be used by processor as this is supposed to be stopped and started with the
 	// Processor.
-	gwDBForProcessor := jobsdb.NewForRead(
-		"gw",
-		jobsdb.WithClearDB(options.ClearDB),
-		jobsdb.WithStatusHandler(),
-		jobsdb.WithPreBackupHandlers(prebackupHandlers),
-		jobsdb.WithDSLimit(&gatewayDSLimit),
-	)
+	gwDBForProcessor, err := newGatewayJobsDB(func() *jobsdb.HandleT {
+		return jobsdb.NewForRead(
+			"gw",
+			jobsdb.WithClearDB(options.ClearDB),
+			jobsdb.WithStatusHandler(),
+			jobsdb.WithPreBackupHandlers(prebackupHandlers),
+			jobsdb.WithDSLimit(&gatewayDSLimit),
+		)
+	})
+	if err != nil {
+		return err
+	}
 	defer gwDBForProcessor.Close()
 	routerDB := jobsdb.NewForReadWrite(
 		"rt",
@@ -131,6 +137,7 @@ func (embedded *EmbeddedApp) StartRudderCore(ctx context.Context, options *app.O
 			"batch_rt": &jobsdb.MultiTenantLegacy{HandleT: batchRouterDB},
 		}))
 	}
+	router.RegisterReplayJobsDBs(routerDB, batchRouterDB, multitenantStats)
 
 	var modeProvider cluster.ChangeEventProvider
 
@@ -151,14 +158,36 @@ func (embedded *EmbeddedApp) StartRudderCore(ctx context.Context, options *app.O
 	}
 
 	proc := processor.New(ctx, &options.ClearDB, gwDBForProcessor, routerDB, batchRouterDB, errDB, multitenantStats, reportingI, transientSources, rsourcesService)
+	destinationControls, err := router.NewDestinationControls(ctx, misc.GetConnectionString())
+	if err != nil {
+		return fmt.Errorf("could not setup destination controls: %w", err)
+	}
+	defer destinationControls.Close()
+	g.Go(func() error {
+		destinationControls.Run(ctx)
+		return nil
+	})
+
+	deadLetterForwarder, err := deadletter.New(ctx, backendconfig.DefaultBackendConfig, routerDB, reportingI, misc.GetConnectionString())
+	if err != nil {
+		return fmt.Errorf("could not setup dead-letter forwarder: %w", err)
+	}
+	defer deadLetterForwarder.Close()
+	g.Go(func() error {
+		deadLetterForwarder.Run(ctx)
+		return nil
+	})
+
 	rtFactory := &router.Factory{
-		Reporting:        reportingI,
-		Multitenant:      multitenantStats,
-		BackendConfig:    backendconfig.DefaultBackendConfig,
-		RouterDB:         tenantRouterDB,
-		ProcErrorDB:      errDB,
-		TransientSources: transientSources,
-		RsourcesService:  rsourcesService,
+		Reporting:           reportingI,
+		Multitenant:         multitenantStats,
+		BackendConfig:       backendconfig.DefaultBackendConfig,
+		RouterDB:            tenantRouterDB,
+		ProcErrorDB:         errDB,
+		TransientSources:    transientSources,
+		RsourcesService:     rsourcesService,
+		DestinationControls: destinationControls,
+		DeadLetterForwarder: deadLetterForwarder,
 	}
 	brtFactory := &batchrouter.Factory{
 		Reporting:        reportingI,
@@ -188,11 +217,16 @@ func (embedded *EmbeddedApp) StartRudderCore(ctx context.Context, options *app.O
 	// This separate gateway db is created just to be used with gateway because in case of degraded mode,
 	// the earlier created gwDb (which was created to be used mainly with processor) will not be running, and it
 	// will cause issues for gateway because gateway is supposed to receive jobs even in degraded mode.
-	gatewayDB = jobsdb.NewForWrite(
-		"gw",
-		jobsdb.WithClearDB(options.ClearDB),
-		jobsdb.WithStatusHandler(),
-	)
+	gatewayDB, err = newGatewayJobsDB(func() *jobsdb.HandleT {
+		return jobsdb.NewForWrite(
+			"gw",
+			jobsdb.WithClearDB(options.ClearDB),
+			jobsdb.WithStatusHandler(),
+		)
+	})
+	if err != nil {
+		return err
+	}
 	defer gwDBForProcessor.Close()
 	if err = gatewayDB.Start(); err != nil {
 		return fmt.Errorf("could not start gateway: %w", err)
@@ -219,7 +253,14 @@ func (embedded *EmbeddedApp) StartRudderCore(ctx context.Context, options *app.O
 	g.Go(func() error {
 		return gw.StartWebHandler(ctx)
 	})
+	g.Go(func() error {
+		return gw.StartGRPCHandler(ctx)
+	})
 	if enableReplay {
+		gwDB, ok := gatewayDB.(*jobsdb.HandleT)
+		if !ok {
+			return fmt.Errorf("replay requires a postgres backed gateway jobsdb")
+		}
 		var replayDB jobsdb.HandleT
 		err := replayDB.Setup(
 			jobsdb.ReadWrite, options.ClearDB, "replay",
@@ -229,7 +270,7 @@ func (embedded *EmbeddedApp) StartRudderCore(ctx context.Context, options *app.O
 			return fmt.Errorf("could not setup replayDB: %w", err)
 		}
 		defer replayDB.TearDown()
-		embedded.App.Features().Replay.Setup(ctx, &replayDB, gatewayDB, routerDB, batchRouterDB)
+		embedded.App.Features().Replay.Setup(ctx, &replayDB, gwDB, routerDB, batchRouterDB)
 	}
 
 	g.Go(func() error {
@@ -48,12 +48,17 @@ func (gatewayApp *GatewayApp) StartRudderCore(ctx context.Context, options *app.
 
 	sourcedebugger.Setup(backendconfig.DefaultBackendConfig)
 
-	gatewayDB := jobsdb.NewForWrite(
-		"gw",
-		jobsdb.WithClearDB(options.ClearDB),
-		jobsdb.WithStatusHandler(),
-		jobsdb.WithDSLimit(&gatewayDSLimit),
-	)
+	gatewayDB, err := newGatewayJobsDB(func() *jobsdb.HandleT {
+		return jobsdb.NewForWrite(
+			"gw",
+			jobsdb.WithClearDB(options.ClearDB),
+			jobsdb.WithStatusHandler(),
+			jobsdb.WithDSLimit(&gatewayDSLimit),
+		)
+	})
+	if err != nil {
+		return err
+	}
 	defer gatewayDB.Close()
 	if err := gatewayDB.Start(); err != nil {
 		return fmt.Errorf("could not start gatewayDB: %w", err)
@@ -115,6 +120,9 @@ func (gatewayApp *GatewayApp) StartRudderCore(ctx context.Context, options *app.
 	g.Go(func() error {
 		return gw.StartWebHandler(ctx)
 	})
+	g.Go(func() error {
+		return gw.StartGRPCHandler(ctx)
+	})
 	return g.Wait()
 }
 
@@ -26,6 +26,7 @@ import (
 	proc "github.com/rudderlabs/rudder-server/processor"
 	"github.com/rudderlabs/rudder-server/router"
 	"github.com/rudderlabs/rudder-server/router/batchrouter"
+	"github.com/rudderlabs/rudder-server/router/deadletter"
 	routerManager "github.com/rudderlabs/rudder-server/router/manager"
 	"github.com/rudderlabs/rudder-server/services/db"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
@@ -44,7 +45,7 @@ type ProcessorApp struct {
 }
 
 var (
-	gatewayDB          *jobsdb.HandleT
+	gatewayDB          gatewayJobsDB
 	ReadTimeout        time.Duration
 	ReadHeaderTimeout  time.Duration
 	WriteTimeout       time.Duration
@@ -115,13 +116,18 @@ func (processor *ProcessorApp) StartRudderCore(ctx context.Context, options *app
 		return err
 	}
 
-	gwDBForProcessor := jobsdb.NewForRead(
-		"gw",
-		jobsdb.WithClearDB(options.ClearDB),
-		jobsdb.WithStatusHandler(),
-		jobsdb.WithPreBackupHandlers(prebackupHandlers),
-		jobsdb.WithDSLimit(&gatewayDSLimit),
-	)
+	gwDBForProcessor, err := newGatewayJobsDB(func() *jobsdb.HandleT {
+		return jobsdb.NewForRead(
+			"gw",
+			jobsdb.WithClearDB(options.ClearDB),
+			jobsdb.WithStatusHandler(),
+			jobsdb.WithPreBackupHandlers(prebackupHandlers),
+			jobsdb.WithDSLimit(&gatewayDSLimit),
+		)
+	})
+	if err != nil {
+		return err
+	}
 	defer gwDBForProcessor.Close()
 	gatewayDB = gwDBForProcessor
 	routerDB := jobsdb.NewForReadWrite(
@@ -162,6 +168,7 @@ func (processor *ProcessorApp) StartRudderCore(ctx context.Context, options *app
 			"batch_rt": &jobsdb.MultiTenantLegacy{HandleT: batchRouterDB},
 		}))
 	}
+	router.RegisterReplayJobsDBs(routerDB, batchRouterDB, multitenantStats)
 
 	var modeProvider cluster.ChangeEventProvider
 
@@ -183,14 +190,36 @@ func (processor *ProcessorApp) StartRudderCore(ctx context.Context, options *app
 
 	p := proc.New(ctx, &options.ClearDB, gwDBForProcessor, routerDB, batchRouterDB, errDB, multitenantStats, reportingI, transientSources, rsourcesService)
 
+	destinationControls, err := router.NewDestinationControls(ctx, misc.GetConnectionString())
+	if err != nil {
+		return fmt.Errorf("could not setup destination controls: %w", err)
+	}
+	defer destinationControls.Close()
+	g.Go(func() error {
+		destinationControls.Run(ctx)
+		return nil
+	})
+
+	deadLetterForwarder, err := deadletter.New(ctx, backendconfig.DefaultBackendConfig, routerDB, reportingI, misc.GetConnectionString())
+	if err != nil {
+		return fmt.Errorf("could not setup dead-letter forwarder: %w", err)
+	}
+	defer deadLetterForwarder.Close()
+	g.Go(func() error {
+		deadLetterForwarder.Run(ctx)
+		return nil
+	})
+
 	rtFactory := &router.Factory{
-		Reporting:        reportingI,
-		Multitenant:      multitenantStats,
-		BackendConfig:    backendconfig.DefaultBackendConfig,
-		RouterDB:         tenantRouterDB,
-		ProcErrorDB:      errDB,
-		TransientSources: transientSources,
-		RsourcesService:  rsourcesService,
+		Reporting:           reportingI,
+		Multitenant:         multitenantStats,
+		BackendConfig:       backendconfig.DefaultBackendConfig,
+		RouterDB:            tenantRouterDB,
+		ProcErrorDB:         errDB,
+		TransientSources:    transientSources,
+		RsourcesService:     rsourcesService,
+		DestinationControls: destinationControls,
+		DeadLetterForwarder: deadLetterForwarder,
 	}
 	brtFactory := &batchrouter.Factory{
 		Reporting:        reportingI,
@@ -14,6 +14,7 @@ import (
 	"github.com/rudderlabs/rudder-server/router"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
 	"github.com/rudderlabs/rudder-server/services/rsources"
+	"github.com/rudderlabs/rudder-server/services/streammanager/kafka/client"
 	"github.com/rudderlabs/rudder-server/services/validators"
 	"github.com/rudderlabs/rudder-server/utils/logger"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -115,3 +116,35 @@ func NewRsourcesService(deploymentType deployment.Type) (rsources.JobService, er
 
 	return rsources.NewJobService(rsourcesConfig)
 }
+
+// gatewayJobsDB is a jobsdb for gateway jobs, along with its lifecycle methods
+type gatewayJobsDB interface {
+	jobsdb.JobsDB
+	Start() error
+	Stop()
+	Close()
+}
+
+// newGatewayJobsDB returns a kafka backed jobsdb for gateway jobs if JobsDB.gw.backend is set to kafka,
+// otherwise the postgres backed jobsdb returned by newPostgresDB
+func newGatewayJobsDB(newPostgresDB func() *jobsdb.HandleT) (gatewayJobsDB, error) {
+	switch backend := config.GetString("JobsDB.gw.backend", "postgres"); backend {
+	case "postgres":
+		return newPostgresDB(), nil
+	case "kafka":
+		brokers := config.Default.GetStringSlice("JobsDB.gw.kafka.brokers", []string{"localhost:9092"})
+		c, err := client.New("tcp", brokers, client.Config{
+			ClientID:    config.GetString("JobsDB.gw.kafka.clientId", "rudder-server"),
+			DialTimeout: config.GetDuration("JobsDB.gw.kafka.dialTimeout", 10, time.Second),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating kafka client: %w", err)
+		}
+		return jobsdb.NewKafka("gw", jobsdb.KafkaConfig{
+			Client: c,
+			Topic:  config.GetString("JobsDB.gw.kafka.topic", "rudder-gw-jobs"),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported gateway jobsdb backend %q", backend)
+	}
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/admin"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/jobsdb/prebackup"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	DefaultList = append(DefaultList, JOBSDB())
+}
+
+func JOBSDB() *cli.Command {
+	c := &cli.Command{
+		Name:  "jobsdb",
+		Usage: "interact with jobsdb",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "restore",
+				Usage:  "restore jobsdb datasets from backups into an empty jobsdb, using the JOBS_BACKUP_* storage configuration",
+				Action: Restore,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "prefix",
+						Usage:    "table prefix of the backed up jobsdb, e.g. gw",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "target-prefix",
+						Usage:    "table prefix of the jobsdb to restore the backups into",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "location",
+						Usage: "location of the backups in the storage, e.g. <path prefix>/<instance id>",
+					},
+					&cli.TimestampFlag{
+						Name:   "from",
+						Usage:  "restore jobs created after this time (RFC3339)",
+						Layout: time.RFC3339,
+					},
+					&cli.TimestampFlag{
+						Name:   "to",
+						Usage:  "restore jobs created, as well as statuses set, before this time (RFC3339)",
+						Layout: time.RFC3339,
+					},
+				},
+			},
+		},
+	}
+
+	return c
+}
+
+func Restore(c *cli.Context) error {
+	admin.Init()
+	misc.Init()
+	jobsdb.Init()
+	jobsdb.Init2()
+
+	req := jobsdb.RestoreRequest{
+		TablePrefix: c.String("prefix"),
+		Prefix:      c.String("location"),
+	}
+	if from := c.Timestamp("from"); from != nil {
+		req.From = *from
+	}
+	if to := c.Timestamp("to"); to != nil {
+		req.To = *to
+	}
+
+	fm, err := jobsdb.BackupFileManager(c.Context)
+	if err != nil {
+		return fmt.Errorf("creating file manager: %w", err)
+	}
+
+	var jd jobsdb.HandleT
+	if err := jd.Setup(jobsdb.Write, false, c.String("target-prefix"), false, []prebackup.Handler{}); err != nil {
+		return err
+	}
+	defer jd.TearDown()
+
+	if err := jd.Restore(c.Context, fm, req); err != nil {
+		return err
+	}
+	fmt.Printf("restored %s backups into %s\n", req.TablePrefix, c.String("target-prefix"))
+	return nil
+}
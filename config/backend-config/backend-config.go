@@ -138,6 +138,7 @@ func filterProcessorEnabledWorkspaceConfig(config map[string]ConfigT) map[string
 func filterProcessorEnabledDestinations(config ConfigT) ConfigT {
 	var modifiedConfig ConfigT
 	modifiedConfig.Libraries = config.Libraries
+	modifiedConfig.Settings = config.Settings
 	modifiedConfig.Sources = make([]SourceT, 0)
 	for _, source := range config.Sources {
 		var destinations []DestinationT
@@ -88,6 +88,19 @@ type ConfigT struct {
 	Sources         []SourceT       `json:"sources"`
 	Libraries       LibrariesT      `json:"libraries"`
 	ConnectionFlags ConnectionFlags `json:"flags"`
+	Settings        Settings        `json:"settings"`
+}
+
+type Settings struct {
+	Dedup DedupSettings `json:"dedup"`
+}
+
+// DedupSettings are workspace-level dedup settings, which can be overridden per source
+type DedupSettings struct {
+	// KeyPath is a JSON path into the event to dedup on, instead of messageId
+	KeyPath string `json:"keyPath"`
+	// Window is a duration string, e.g. 24h, for which dedup keys are remembered
+	Window string `json:"window"`
 }
 
 type ConnectionFlags struct {
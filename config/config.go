@@ -22,6 +22,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/viper"
@@ -40,10 +41,20 @@ func init() {
 	Default = New()
 }
 
+// reloads counts the changes of any config instance
+var reloads uint64
+
 // Reset resets the default, singleton config instance.
 // Shall only be used by tests, until we move to a proper DI framework
 func Reset() {
 	Default = New()
+	atomic.AddUint64(&reloads, 1)
+}
+
+// Reloads returns the number of times the config has changed, either by reloading its file or by calls to Set.
+// Values derived from the config can be cached for as long as it returns the same number
+func Reloads() uint64 {
+	return atomic.LoadUint64(&reloads)
 }
 
 // New creates a new config instance
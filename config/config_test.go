@@ -216,6 +216,14 @@ func Test_Set_CaseInsensitive(t *testing.T) {
 	require.Equal(t, "string", tc.GetString("String.one", "default"), "it should return the key value")
 }
 
+func Test_Reloads(t *testing.T) {
+	tc := New()
+	reloads := Reloads()
+	tc.Set("Key.Reloaded", "value")
+	require.Equal(t, reloads+1, Reloads(), "setting a key reloads the config")
+	require.Equal(t, reloads+1, Reloads())
+}
+
 func Test_Misc(t *testing.T) {
 	t.Setenv("KUBE_NAMESPACE", "value")
 	require.Equal(t, "value", GetKubeNamespace())
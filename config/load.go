@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -44,6 +45,7 @@ func (c *Config) onConfigChange() {
 			fmt.Println(err)
 		}
 	}()
+	defer atomic.AddUint64(&reloads, 1) // once the hot reloadable variables are updated
 	c.vLock.RLock()
 	defer c.vLock.RUnlock()
 	c.hotReloadableConfigLock.RLock()
@@ -90,6 +90,19 @@ func (manager *EventSchemaManagerT) GetJsonSchemas(w http.ResponseWriter, r *htt
 	_, _ = w.Write(jsonSchemas)
 }
 
+// JsonSchemas returns the json schemas of the event models of a write key, in the format of GetJsonSchemas.
+// It returns nil if the write key has no event models
+func (manager *EventSchemaManagerT) JsonSchemas(writeKey string) ([]byte, error) {
+	eventModels, err := manager.queryEventModelsByWriteKey(writeKey)
+	if err != nil {
+		return nil, fmt.Errorf("fetching event models of write key %s: %w", writeKey, err)
+	}
+	if len(eventModels) == 0 {
+		return nil, nil
+	}
+	return generateJsonSchFromEM(eventModels)
+}
+
 type JSPropertyTypeT struct {
 	Type []string `json:"type"`
 }
@@ -499,6 +512,12 @@ func (manager *EventSchemaManagerT) GetSchemaVersionMissingKeys(w http.ResponseW
 }
 
 func (manager *EventSchemaManagerT) fetchEventModelsByWriteKey(writeKey string) []*EventModelT {
+	eventModels, err := manager.queryEventModelsByWriteKey(writeKey)
+	assertError(err)
+	return eventModels
+}
+
+func (manager *EventSchemaManagerT) queryEventModelsByWriteKey(writeKey string) ([]*EventModelT, error) {
 	var eventModelsSelectSQL string
 	if writeKey == "" {
 		eventModelsSelectSQL = fmt.Sprintf(`SELECT id, uuid, write_key, event_type, event_model_identifier, created_at, schema, total_count, last_seen FROM %s`, EVENT_MODELS_TABLE)
@@ -507,7 +526,9 @@ func (manager *EventSchemaManagerT) fetchEventModelsByWriteKey(writeKey string)
 	}
 
 	rows, err := manager.dbHandle.Query(eventModelsSelectSQL)
-	assertError(err)
+	if err != nil {
+		return nil, err
+	}
 	defer func() { _ = rows.Close() }()
 
 	eventModels := make([]*EventModelT, 0)
@@ -516,12 +537,14 @@ func (manager *EventSchemaManagerT) fetchEventModelsByWriteKey(writeKey string)
 		var eventModel EventModelT
 		err := rows.Scan(&eventModel.ID, &eventModel.UUID, &eventModel.WriteKey, &eventModel.EventType,
 			&eventModel.EventIdentifier, &eventModel.CreatedAt, &eventModel.Schema, &eventModel.TotalCount, &eventModel.LastSeen)
-		assertError(err)
+		if err != nil {
+			return nil, err
+		}
 
 		eventModels = append(eventModels, &eventModel)
 	}
 
-	return eventModels
+	return eventModels, rows.Err()
 }
 
 func (manager *EventSchemaManagerT) fetchSchemaVersionsByEventID(eventID string) []*SchemaVersionT {
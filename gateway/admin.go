@@ -82,11 +82,28 @@ func (r *SqlRunner) getNumUniqueUsers() (int, error) {
 	return numUsers, err
 }
 
+// isCompressed returns true if the job table stores compressed payloads in a bytea column, which postgres cannot inspect
+func (r *SqlRunner) isCompressed() (bool, error) {
+	var dataType string
+	err := r.dbHandle.QueryRow(
+		`select data_type from information_schema.columns where table_schema = current_schema() and table_name = $1 and column_name = 'event_payload'`,
+		r.jobTableName,
+	).Scan(&dataType)
+	return dataType == "bytea", err
+}
+
 func (r *SqlRunner) getAvgBatchSize() (float64, error) {
 	var batchSize sql.NullFloat64
 	var avgBatchSize float64
-	var err error
+	compressed, err := r.isCompressed()
+	if err != nil {
+		return avgBatchSize, err
+	}
 	avgBatchSizeStmt := fmt.Sprintf(`select avg(jsonb_array_length(batch)) from (select event_payload->'batch' as batch from %s) t`, r.jobTableName)
+	if compressed {
+		// batches of compressed payloads cannot be inspected by postgres, their event counts are used instead
+		avgBatchSizeStmt = fmt.Sprintf(`select avg(event_count) from %s`, r.jobTableName)
+	}
 	err = runSQL(r, avgBatchSizeStmt, &batchSize)
 	if batchSize.Valid {
 		avgBatchSize = batchSize.Float64
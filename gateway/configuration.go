@@ -11,6 +11,12 @@ func loadConfig() {
 	config.RegisterIntConfigVariable(8080, &webPort, false, 1, "Gateway.webPort")
 	// Port where AdminHandler is running
 	config.RegisterIntConfigVariable(8089, &adminWebPort, false, 1, "Gateway.adminWebPort")
+	// Enables the gRPC ingest service. false by default
+	config.RegisterBoolConfigVariable(false, &enableGRPC, false, "Gateway.enableGRPC")
+	// Port where the gRPC ingest service is running
+	config.RegisterIntConfigVariable(8090, &grpcPort, false, 1, "Gateway.grpcPort")
+	// Number of batches of a gRPC stream waiting to be stored, before receiving more of them
+	config.RegisterIntConfigVariable(128, &maxInFlightGRPCBatches, false, 1, "Gateway.maxInFlightGRPCBatches")
 	// Number of incoming requests that are batched before handing off to write workers
 	config.RegisterIntConfigVariable(128, &maxUserWebRequestBatchSize, false, 1, "Gateway.maxUserRequestBatchSize")
 	// Number of userWorkerBatchRequest that are batched before initiating write
@@ -27,6 +33,10 @@ func loadConfig() {
 	config.RegisterStringConfigVariable("GW", &CustomVal, false, "Gateway.CustomVal")
 	// Maximum request size to gateway
 	config.RegisterIntConfigVariable(4000, &maxReqSize, true, 1024, "Gateway.maxReqSizeInKB")
+	// Maximum size of compressed request bodies once decompressed, larger ones are rejected without being fully decompressed
+	config.RegisterIntConfigVariable(10000, &maxDecompressedReqSize, true, 1024, "Gateway.maxDecompressedReqSizeInKB")
+	// Interval at which the json schemas of write keys with schema validation enabled are reloaded
+	config.RegisterDurationConfigVariable(60, &schemaValidationRefreshInterval, true, time.Second, []string{"Gateway.schemaValidation.refreshInterval", "Gateway.schemaValidation.refreshIntervalInS"}...)
 	// Enable rate limit on incoming events. false by default
 	config.RegisterBoolConfigVariable(false, &enableRateLimit, true, "Gateway.enableRateLimit")
 	// Enable suppress user feature. false by default
@@ -35,6 +45,10 @@ func loadConfig() {
 	config.RegisterBoolConfigVariable(false, &enableEventSchemasFeature, false, "EventSchemas.enableEventSchemasFeature")
 	// Time period for diagnosis ticker
 	config.RegisterDurationConfigVariable(60, &diagnosisTickerTime, false, time.Second, []string{"Diagnostics.gatewayTimePeriod", "Diagnostics.gatewayTimePeriodInS"}...)
+	// Attribute of OTLP log records and spans, or of their resource, holding the anonymousId of their events
+	config.RegisterStringConfigVariable("enduser.id", &otlpAnonymousIDAttribute, true, "Gateway.otlp.anonymousIdAttribute")
+	// Attribute of OTLP log records holding their event name
+	config.RegisterStringConfigVariable("event.name", &otlpEventNameAttribute, true, "Gateway.otlp.eventNameAttribute")
 	// Enables accepting requests without user id and anonymous id. This is added to prevent client 4xx retries.
 	config.RegisterBoolConfigVariable(false, &allowReqsWithoutUserIDAndAnonymousID, true, "Gateway.allowReqsWithoutUserIDAndAnonymousID")
 	config.RegisterBoolConfigVariable(true, &gwAllowPartialWriteWithErrors, true, "Gateway.allowPartialWriteWithErrors")
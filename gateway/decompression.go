@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	// errUnsupportedContentEncoding is returned for request bodies compressed with an algorithm the gateway can't decompress
+	errUnsupportedContentEncoding = errors.New("unsupported content encoding")
+	// errDecompressedBodyTooLarge is returned for request bodies which are larger than maxDecompressedReqSize once decompressed
+	errDecompressedBodyTooLarge = errors.New("decompressed request body too large")
+)
+
+// contentEncodings returns the encodings of a request, in the order they have been applied.
+// Requests without a Content-Encoding header or with the identity one have no encodings
+func contentEncodings(r *http.Request) []string {
+	var encodings []string
+	for _, header := range r.Header.Values("Content-Encoding") {
+		for _, encoding := range strings.Split(header, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding != "" && encoding != "identity" {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+	return encodings
+}
+
+// decompressingReader returns a reader of the decompressed body, undoing the encodings in reverse order.
+// The returned close function releases the decoders and must be called once the body has been read
+func decompressingReader(body io.Reader, encodings []string) (io.Reader, func(), error) {
+	var closers []func()
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch encodings[i] {
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(body)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("reading gzip header: %w", err)
+			}
+			closers = append(closers, func() { _ = gz.Close() })
+			body = gz
+		case "deflate":
+			// deflate is meant to be zlib wrapped, but some clients send raw deflate streams
+			br := bufio.NewReader(body)
+			if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+				zr, err := zlib.NewReader(br)
+				if err != nil {
+					closeAll()
+					return nil, nil, fmt.Errorf("reading zlib header: %w", err)
+				}
+				closers = append(closers, func() { _ = zr.Close() })
+				body = zr
+			} else {
+				fr := flate.NewReader(br)
+				closers = append(closers, func() { _ = fr.Close() })
+				body = fr
+			}
+		case "zstd":
+			zr, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxDecompressedReqSize)+1))
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("creating zstd decoder: %w", err)
+			}
+			closers = append(closers, zr.Close)
+			body = zr
+		case "br":
+			// brotli decoders hold no resources once read, the decompressed size is limited by readDecompressed like for the other encodings
+			body = brotli.NewReader(body)
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("%w: %q", errUnsupportedContentEncoding, encodings[i])
+		}
+	}
+	return body, closeAll, nil
+}
+
+// isZlibHeader returns true if the two bytes are a valid zlib header, with the deflate compression method
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+
+// readDecompressed reads and decompresses a request body, rejecting it once it exceeds maxDecompressedReqSize, so that decompression bombs are not read into memory
+func readDecompressed(body io.Reader, encodings []string) ([]byte, error) {
+	reader, closeReader, err := decompressingReader(body, encodings)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader()
+	payload, err := io.ReadAll(io.LimitReader(reader, int64(maxDecompressedReqSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing request body: %w", err)
+	}
+	if len(payload) > maxDecompressedReqSize {
+		return nil, errDecompressedBodyTooLarge
+	}
+	return payload, nil
+}
+
+// countingReader counts the bytes read from a reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -88,9 +88,14 @@ var (
 	sourceIDToNameMap                                                                 map[string]string
 	configSubscriberLock                                                              sync.RWMutex
 	maxReqSize                                                                        int
+	maxDecompressedReqSize                                                            int
 	enableRateLimit                                                                   bool
+	enableGRPC                                                                        bool
+	grpcPort, maxInFlightGRPCBatches                                                  int
+	otlpAnonymousIDAttribute, otlpEventNameAttribute                                  string
 	enableSuppressUserFeature                                                         bool
 	enableEventSchemasFeature                                                         bool
+	schemaValidationRefreshInterval                                                   time.Duration
 	diagnosisTickerTime                                                               time.Duration
 	ReadTimeout                                                                       time.Duration
 	ReadHeaderTimeout                                                                 time.Duration
@@ -155,6 +160,7 @@ type HandleT struct {
 	recvCount                    uint64
 	backendConfig                backendconfig.BackendConfig
 	rateLimiter                  ratelimiter.RateLimiter
+	schemaValidators             schemaValidatorsT
 
 	stats                                         stats.Stats
 	batchSizeStat                                 stats.Measurement
@@ -449,7 +455,6 @@ func (gateway *HandleT) userWebRequestWorkerProcess(userWebRequestWorker *userWe
 		sourceSuccessEventStats := make(map[string]int)
 		sourceFailStats := make(map[string]int)
 		sourceFailEventStats := make(map[string]int)
-		workspaceDropRequestStats := make(map[string]int)
 		sourceTagMap := make(map[string]string)
 		var preDbStoreCount int
 		// Saving the event data read from req.request.Body to the splice.
@@ -527,16 +532,6 @@ func (gateway *HandleT) userWebRequestWorkerProcess(userWebRequestWorker *userWe
 				continue
 			}
 
-			if enableRateLimit {
-				// In case of "batch" requests, if rate-limiter returns true for LimitReached, just drop the event batch and continue.
-				if gateway.rateLimiter.LimitReached(workspaceId) {
-					req.done <- response.GetStatus(response.TooManyRequests)
-					preDbStoreCount++
-					misc.IncrementMapByKey(workspaceDropRequestStats, sourceTag, 1)
-					continue
-				}
-			}
-
 			// set anonymousId if not set in payload
 			result := gjson.GetBytes(body, "batch")
 			var out []map[string]interface{}
@@ -694,9 +689,6 @@ func (gateway *HandleT) userWebRequestWorkerProcess(userWebRequestWorker *userWe
 		gateway.updateSourceStats(sourceStats, "gateway.write_key_requests", sourceTagMap)
 		gateway.updateSourceStats(sourceSuccessStats, "gateway.write_key_successful_requests", sourceTagMap)
 		gateway.updateFailedSourceStats(sourceFailStats, "gateway.write_key_failed_requests", sourceTagMap)
-		if enableRateLimit {
-			gateway.updateSourceStats(workspaceDropRequestStats, "gateway.work_space_dropped_requests", sourceTagMap)
-		}
 		// update stats event wise
 		gateway.updateSourceStats(sourceEventStats, "gateway.write_key_events", sourceTagMap)
 		gateway.updateSourceStats(sourceSuccessEventStats, "gateway.write_key_successful_events", sourceTagMap)
@@ -766,7 +758,7 @@ func (gateway *HandleT) eventSchemaWebHandler(wrappedFunc func(http.ResponseWrit
 	}
 }
 
-func (gateway *HandleT) getPayloadFromRequest(r *http.Request) ([]byte, error) {
+func (gateway *HandleT) getPayloadFromRequest(r *http.Request, writeKey string) ([]byte, error) {
 	if r.Body == nil {
 		return []byte{}, errors.New(response.RequestBodyNil)
 	}
@@ -774,15 +766,42 @@ func (gateway *HandleT) getPayloadFromRequest(r *http.Request) ([]byte, error) {
 	start := time.Now()
 	defer gateway.bodyReadTimeStat.Since(start)
 
-	payload, err := io.ReadAll(r.Body)
+	encodings := contentEncodings(r)
+	if len(encodings) == 0 {
+		payload, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			gateway.logger.Errorf(
+				"Error reading request body, 'Content-Length': %s, partial payload:\n\t%s\n",
+				r.Header.Get("Content-Length"),
+				string(payload),
+			)
+			return payload, errors.New(response.RequestBodyReadFailed)
+		}
+		return payload, nil
+	}
+
+	// compressed bodies are decompressed transparently, up to maxDecompressedReqSize
+	body := &countingReader{r: r.Body}
+	payload, err := readDecompressed(body, encodings)
 	_ = r.Body.Close()
 	if err != nil {
-		gateway.logger.Errorf(
-			"Error reading request body, 'Content-Length': %s, partial payload:\n\t%s\n",
-			r.Header.Get("Content-Length"),
-			string(payload),
-		)
-		return payload, errors.New(response.RequestBodyReadFailed)
+		gateway.logger.Infof("Error decompressing request body of write key %s, 'Content-Encoding': %s: %v", writeKey, r.Header.Get("Content-Encoding"), err)
+		switch {
+		case errors.Is(err, errUnsupportedContentEncoding):
+			return []byte{}, errors.New(response.UnsupportedContentEncoding)
+		case errors.Is(err, errDecompressedBodyTooLarge):
+			return []byte{}, errors.New(response.RequestBodyTooLarge)
+		default:
+			return []byte{}, errors.New(response.RequestBodyDecompressFailed)
+		}
+	}
+	if body.n > 0 {
+		gateway.stats.NewTaggedStat("gateway.request_compression_ratio", stats.HistogramType, stats.Tags{
+			"source":   gateway.getSourceTagFromWriteKey(writeKey),
+			"writeKey": writeKey,
+			"encoding": strings.Join(encodings, ","),
+		}).Observe(float64(len(payload)) / float64(body.n))
 	}
 	return payload, nil
 }
@@ -1107,8 +1126,12 @@ type ImportRequestHandler struct{}
 // RegularRequestHandler is an empty struct to capture non-import specific request handling functionality
 type RegularRequestHandler struct{}
 
-// ProcessWebRequest is an Interface wrapper for webhook
+// ProcessWebRequest is an Interface wrapper for webhook, rejecting requests with invalid events in schema validation's reject mode
 func (gateway *HandleT) ProcessWebRequest(w *http.ResponseWriter, r *http.Request, reqType string, payload []byte, writeKey string) string {
+	payload, violations := gateway.validateEventSchemas(writeKey, reqType, payload)
+	if len(violations) > 0 {
+		return response.GetStatus(response.InvalidEventSchema)
+	}
 	return gateway.rrh.ProcessRequest(gateway, w, r, reqType, payload, writeKey)
 }
 
@@ -1134,14 +1157,23 @@ func (gateway *HandleT) getPayloadAndWriteKey(_ http.ResponseWriter, r *http.Req
 
 		return []byte{}, "", err
 	}
-	payload, err := gateway.getPayloadFromRequest(r)
+	payload, err := gateway.getPayloadFromRequest(r, writeKey)
 	if err != nil {
 		sourceTag := gateway.getSourceTagFromWriteKey(writeKey)
 		misc.IncrementMapByKey(sourceFailStats, sourceTag, 1)
+		reason := "requestBodyReadFailed"
+		switch err.Error() {
+		case response.UnsupportedContentEncoding:
+			reason = "unsupportedContentEncoding"
+		case response.RequestBodyDecompressFailed:
+			reason = "requestBodyDecompressFailed"
+		case response.RequestBodyTooLarge:
+			reason = "requestBodyTooLarge"
+		}
 		gateway.updateSourceStats(sourceFailStats, "gateway.write_key_failed_requests", map[string]string{
 			sourceTag:  writeKey,
 			"reqType":  reqType,
-			"reason":   "requestBodyReadFailed",
+			"reason":   reason,
 			"sourceID": sourceID,
 		})
 		gateway.updateSourceStats(sourceFailStats, "gateway.write_key_requests", map[string]string{
@@ -1181,6 +1213,14 @@ func (gateway *HandleT) webRequestHandler(rh RequestHandler, w http.ResponseWrit
 		errorMessage = err.Error()
 		return
 	}
+	if errorMessage = gateway.checkRateLimit(w, writeKey, reqType); errorMessage != "" {
+		return
+	}
+	payload, violations := gateway.validateEventSchemas(writeKey, reqType, payload)
+	if len(violations) > 0 {
+		gateway.rejectSchemaViolations(w, r, violations)
+		return
+	}
 	errorMessage = rh.ProcessRequest(gateway, &w, r, reqType, payload, writeKey)
 	atomic.AddUint64(&gateway.ackCount, 1)
 	gateway.trackRequestMetrics(errorMessage)
@@ -1195,6 +1235,42 @@ func (gateway *HandleT) webRequestHandler(rh RequestHandler, w http.ResponseWrit
 	httpWriteTime.Since(httpWriteStartTime)
 }
 
+// checkRateLimit counts a request against the rate limits of its write key and workspace, setting the X-RateLimit-* headers of the response, if any.
+// It returns the error message of requests which are rejected because a limit has been reached
+func (gateway *HandleT) checkRateLimit(w http.ResponseWriter, writeKey, reqType string) string {
+	if !enableRateLimit || gateway.rateLimiter == nil {
+		return ""
+	}
+	configSubscriberLock.RLock()
+	workspaceID, ok := enabledWriteKeyWorkspaceMap[writeKey]
+	configSubscriberLock.RUnlock()
+	if !ok {
+		// requests of unknown write keys get rejected later on
+		return ""
+	}
+
+	status := gateway.rateLimiter.Check(workspaceID, writeKey)
+	if w != nil && status.Limit > 0 {
+		w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(status.Limit, 10))
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(status.Remaining, 10))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(status.Reset.Seconds())), 10))
+	}
+	if !status.Limited {
+		return ""
+	}
+	if w != nil {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(status.RetryAfter.Seconds())), 10))
+	}
+	gateway.stats.NewTaggedStat("gateway.work_space_dropped_requests", stats.CountType, stats.Tags{
+		"source":      gateway.getSourceTagFromWriteKey(writeKey),
+		"writeKey":    writeKey,
+		"reqType":     reqType,
+		"workspaceId": workspaceID,
+		"sourceID":    gateway.getSourceIDForWriteKey(writeKey),
+	}).Increment()
+	return response.GetStatus(response.TooManyRequests)
+}
+
 func (gateway *HandleT) pixelWebRequestHandler(rh RequestHandler, w http.ResponseWriter, r *http.Request, reqType string) {
 	sendPixelResponse(w)
 	gateway.logger.LogRequest(r)
@@ -1210,6 +1286,17 @@ func (gateway *HandleT) pixelWebRequestHandler(rh RequestHandler, w http.Respons
 		errorMessage = err.Error()
 		return
 	}
+	if errorMessage = gateway.checkRateLimit(w, writeKey, reqType); errorMessage != "" {
+		return
+	}
+	payload, violations := gateway.validateEventSchemas(writeKey, reqType, payload)
+	if len(violations) > 0 {
+		// the pixel has already been sent, invalid events are only dropped
+		errorMessage = response.GetStatus(response.InvalidEventSchema)
+		atomic.AddUint64(&gateway.ackCount, 1)
+		gateway.trackRequestMetrics(errorMessage)
+		return
+	}
 	errorMessage = rh.ProcessRequest(gateway, &w, r, reqType, payload, writeKey)
 
 	atomic.AddUint64(&gateway.ackCount, 1)
@@ -1445,6 +1532,8 @@ func (gateway *HandleT) StartWebHandler(ctx context.Context) error {
 	srvMux.HandleFunc("/pixel/v1/page", gateway.pixelPageHandler).Methods("GET")
 	srvMux.HandleFunc("/v1/webhook", gateway.webhookHandler.RequestHandler).Methods("POST", "GET")
 	srvMux.HandleFunc("/beacon/v1/batch", gateway.beaconBatchHandler).Methods("POST")
+	srvMux.HandleFunc("/otlp/v1/logs", gateway.otlpLogsHandler).Methods("POST")
+	srvMux.HandleFunc("/otlp/v1/traces", gateway.otlpTracesHandler).Methods("POST")
 	srvMux.PathPrefix("/v1/warehouse").Handler(http.HandlerFunc(warehouseHandler)).Methods("GET", "POST")
 	srvMux.HandleFunc("/version", WithContentType("application/json; charset=utf-8", gateway.versionHandler)).Methods("GET")
 	srvMux.HandleFunc("/robots.txt", gateway.robots).Methods("GET")
@@ -1546,6 +1635,8 @@ func (gateway *HandleT) backendConfigSubscriber() {
 		enabledWriteKeyWorkspaceMap = newEnabledWriteKeyWorkspaceMap
 		sourceIDToNameMap = newSourceIDToNameMap
 		configSubscriberLock.Unlock()
+		gateway.schemaValidators.resetSettings()
+		gateway.schemaValidators.triggerRefresh()
 	}
 }
 
@@ -1559,17 +1650,19 @@ Finds the worker for a particular userID and queues the webrequest with the work
 They are further batched together in userWebRequestBatcher
 */
 func (gateway *HandleT) addToWebRequestQ(_ *http.ResponseWriter, req *http.Request, done chan string, reqType string, requestPayload []byte, writeKey string) {
-	userIDHeader := req.Header.Get("AnonymousId")
-	workerKey := userIDHeader
-	if userIDHeader == "" {
+	gateway.enqueueWebRequest(&webRequestT{done: done, reqType: reqType, requestPayload: requestPayload, writeKey: writeKey, ipAddr: misc.GetIPFromReq(req), userIDHeader: req.Header.Get("AnonymousId")})
+}
+
+// enqueueWebRequest pushes the webrequest into the webRequestQ of the worker of its user
+func (gateway *HandleT) enqueueWebRequest(webReq *webRequestT) {
+	workerKey := webReq.userIDHeader
+	if workerKey == "" {
 		// If the request comes through proxy, proxy would already send this. So this shouldn't be happening in that case
 		workerKey = uuid.Must(uuid.NewV4()).String()
 		gateway.emptyAnonIdHeaderStat.Increment()
 	}
 	userWebRequestWorker := gateway.findUserWebRequestWorker(workerKey)
-	ipAddr := misc.GetIPFromReq(req)
-	webReq := webRequestT{done: done, reqType: reqType, requestPayload: requestPayload, writeKey: writeKey, ipAddr: ipAddr, userIDHeader: userIDHeader}
-	userWebRequestWorker.webRequestQ <- &webReq
+	userWebRequestWorker.webRequestQ <- webReq
 }
 
 // IncrementRecvCount increments the received count for gateway requests
@@ -1644,6 +1737,7 @@ func (gateway *HandleT) Setup(
 	gateway.processRequestTime = gateway.stats.NewStat("gateway.process_request_time", stats.TimerType)
 	gateway.backendConfig = backendConfig
 	gateway.rateLimiter = rateLimiter
+	gateway.schemaValidators.refresh = make(chan struct{}, 1)
 	gateway.userWorkerBatchRequestQ = make(chan *userWorkerBatchRequestT, maxDBBatchSize)
 	gateway.batchUserWorkerBatchRequestQ = make(chan *batchUserWorkerBatchRequestT, maxDBWriterProcess)
 	gateway.emptyAnonIdHeaderStat = gateway.stats.NewStat("gateway.empty_anonymous_id_header", stats.CountType)
@@ -1712,6 +1806,10 @@ func (gateway *HandleT) Setup(
 		gateway.collectMetrics(ctx)
 		return nil
 	}))
+	g.Go(misc.WithBugsnag(func() error {
+		gateway.refreshSchemaValidators(ctx)
+		return nil
+	}))
 	return nil
 }
 
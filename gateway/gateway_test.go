@@ -5,22 +5,36 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/andybalholm/brotli"
 	"github.com/gofrs/uuid"
 	"github.com/golang/mock/gomock"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 
 	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
@@ -33,6 +47,8 @@ import (
 	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
 	mocksRateLimiter "github.com/rudderlabs/rudder-server/mocks/rate-limiter"
 	mocksTypes "github.com/rudderlabs/rudder-server/mocks/utils/types"
+	proto "github.com/rudderlabs/rudder-server/proto/gateway"
+	ratelimiter "github.com/rudderlabs/rudder-server/rate-limiter"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	"github.com/rudderlabs/rudder-server/utils/logger"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -385,9 +401,9 @@ var _ = Describe("Gateway", func() {
 		})
 
 		It("should store messages successfully if rate limit is not reached for workspace", func() {
-			mockCall := c.mockRateLimiter.EXPECT().LimitReached(WorkspaceID).Return(false).Times(1)
+			mockCall := c.mockRateLimiter.EXPECT().Check(WorkspaceID, WriteKeyEnabled).Return(ratelimiter.LimitStatus{Limit: 10, Remaining: 9, Reset: 30 * time.Second}).Times(1)
 			tFunc := c.asyncHelper.ExpectAndNotifyCallbackWithName("")
-			mockCall.Do(func(interface{}) { tFunc() })
+			mockCall.Do(func(interface{}, interface{}) { tFunc() })
 
 			c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
 				_ = f(jobsdb.EmptyStoreSafeTx())
@@ -400,9 +416,384 @@ var _ = Describe("Gateway", func() {
 		})
 
 		It("should reject messages if rate limit is reached for workspace", func() {
-			c.mockRateLimiter.EXPECT().LimitReached(WorkspaceID).Return(true).Times(1)
+			c.mockRateLimiter.EXPECT().Check(WorkspaceID, WriteKeyEnabled).Return(ratelimiter.LimitStatus{Limited: true, Limit: 10, Reset: 30 * time.Second, RetryAfter: 1500 * time.Millisecond}).Times(1)
+
+			rr := httptest.NewRecorder()
+			gateway.webAliasHandler(rr, authorizedRequest(WriteKeyEnabled, bytes.NewBufferString("{}")))
+			Expect(rr.Code).To(Equal(429))
+			Expect(rr.Body.String()).To(Equal(response.TooManyRequests + "\n"))
+			Expect(rr.Header().Get("Retry-After")).To(Equal("2"))
+			Expect(rr.Header().Get("X-RateLimit-Limit")).To(Equal("10"))
+			Expect(rr.Header().Get("X-RateLimit-Remaining")).To(Equal("0"))
+			Expect(rr.Header().Get("X-RateLimit-Reset")).To(Equal("30"))
+		})
+	})
+
+	Context("Compressed requests", func() {
+		var gateway *HandleT
+
+		BeforeEach(func() {
+			gateway = &HandleT{}
+			err := gateway.Setup(c.mockApp, c.mockBackendConfig, c.mockJobsDB, nil, c.mockVersionHandler, rsources.NewNoOpService())
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			err := gateway.Shutdown()
+			Expect(err).To(BeNil())
+		})
+
+		compress := func(encoding string, body []byte) []byte {
+			var buf bytes.Buffer
+			var w io.WriteCloser
+			switch encoding {
+			case "gzip":
+				w = gzip.NewWriter(&buf)
+			case "deflate":
+				w = zlib.NewWriter(&buf)
+			case "raw-deflate":
+				w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+			case "zstd":
+				w, _ = zstd.NewWriter(&buf)
+			case "br":
+				w = brotli.NewWriter(&buf)
+			}
+			_, err := w.Write(body)
+			Expect(err).To(BeNil())
+			Expect(w.Close()).To(BeNil())
+			return buf.Bytes()
+		}
+
+		compressedRequest := func(encoding string, body []byte) *http.Request {
+			req := authorizedRequest(WriteKeyEnabled, bytes.NewBuffer(body))
+			req.Header.Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+			return req
+		}
+
+		It("should decompress gzip, deflate, zstd and brotli request bodies", func() {
+			validBody := []byte(`{"batch":[{"userId":"dummyId","properties":{"compressed":true}}]}`)
+			for _, encoding := range []string{"gzip", "deflate", "raw-deflate", "zstd", "br"} {
+				c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+					_ = f(jobsdb.EmptyStoreSafeTx())
+				}).Return(nil)
+				c.mockJobsDB.
+					EXPECT().StoreWithRetryEachInTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, tx jobsdb.StoreSafeTx, jobs []*jobsdb.JobT) (map[uuid.UUID]string, error) {
+						Expect(jobs).To(HaveLen(1))
+						Expect(gjson.GetBytes(jobs[0].EventPayload, "batch.0.properties.compressed").Bool()).To(BeTrue())
+						return jobsToEmptyErrors(ctx, tx, jobs)
+					}).
+					Times(1)
+
+				expectHandlerResponse(gateway.webBatchHandler, compressedRequest(encoding, compress(encoding, validBody)), 200, "OK")
+			}
+		})
+
+		It("should reject request bodies which can't be decompressed", func() {
+			expectHandlerResponse(gateway.webBatchHandler, compressedRequest("compress", []byte("lzw")), 415, response.UnsupportedContentEncoding+"\n")
+			expectHandlerResponse(gateway.webBatchHandler, compressedRequest("gzip", []byte("not gzip")), 400, response.RequestBodyDecompressFailed+"\n")
+			expectHandlerResponse(gateway.webBatchHandler, compressedRequest("br", []byte("not brotli")), 400, response.RequestBodyDecompressFailed+"\n")
+		})
+
+		It("should reject request bodies which are too large once decompressed", func() {
+			defer func(prev int) { maxDecompressedReqSize = prev }(maxDecompressedReqSize)
+			maxDecompressedReqSize = 1024
+			bomb := compress("gzip", bytes.Repeat([]byte(" "), 1024*1024))
+			Expect(len(bomb)).To(BeNumerically("<", 1024*1024/100))
+			expectHandlerResponse(gateway.webBatchHandler, compressedRequest("gzip", bomb), 413, response.RequestBodyTooLarge+"\n")
+			brotliBomb := compress("br", bytes.Repeat([]byte(" "), 1024*1024))
+			Expect(len(brotliBomb)).To(BeNumerically("<", 1024*1024/100))
+			expectHandlerResponse(gateway.webBatchHandler, compressedRequest("br", brotliBomb), 413, response.RequestBodyTooLarge+"\n")
+		})
+	})
+
+	Context("Schema validation", func() {
+		var (
+			gateway     *HandleT
+			schemasFile string
+		)
+
+		BeforeEach(func() {
+			f, err := os.CreateTemp("", "json-schemas-*.json")
+			Expect(err).To(BeNil())
+			_, err = f.WriteString(`[{
+				"schema": {"type": "object", "properties": {"revenue": {"type": "number"}}, "required": ["revenue"]},
+				"schemaType": "track",
+				"schemaIdentifier": "Order Completed"
+			}]`)
+			Expect(err).To(BeNil())
+			Expect(f.Close()).To(BeNil())
+			schemasFile = f.Name()
+			config.Set("Gateway.schemaValidation.schemasFile", schemasFile)
+		})
+
+		AfterEach(func() {
+			err := gateway.Shutdown()
+			Expect(err).To(BeNil())
+			config.Set("Gateway.schemaValidation.mode", schemaValidationOff)
+			config.Set("Gateway.schemaValidation.schemasFile", "")
+			config.Set("Gateway.schemaValidation.writeKeys", "")
+			Expect(os.Remove(schemasFile)).To(BeNil())
+		})
+
+		setup := func(mode string) {
+			config.Set("Gateway.schemaValidation.mode", mode)
+			gateway = &HandleT{}
+			err := gateway.Setup(c.mockApp, c.mockBackendConfig, c.mockJobsDB, nil, c.mockVersionHandler, rsources.NewNoOpService())
+			Expect(err).To(BeNil())
+			Eventually(func() bool { return gateway.schemaValidators.get(WriteKeyEnabled) != nil }).Should(BeTrue())
+		}
+
+		body := `{"batch":[` +
+			`{"userId":"dummyId","messageId":"valid","type":"track","event":"Order Completed","properties":{"revenue":10}},` +
+			`{"userId":"dummyId","messageId":"invalid","type":"track","event":"Order Completed","properties":{"revenue":"10"}},` +
+			`{"userId":"dummyId","messageId":"unknown","type":"track","event":"Product Viewed","properties":{"revenue":"10"}}` +
+			`]}`
+
+		It("should reject requests with invalid events, listing their errors", func() {
+			setup(schemaValidationReject)
+
+			expectHandlerResponse(gateway.webBatchHandler, authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(body)), 400,
+				`{"msg":"Invalid event schema","violations":[{"index":1,"messageId":"invalid","type":"track","event":"Order Completed","errors":["revenue: Invalid type. Expected: number, given: string"]}]}`)
+			expectHandlerResponse(gateway.webTrackHandler, authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(`{"userId":"dummyId","event":"Order Completed","properties":{}}`)), 400,
+				`{"msg":"Invalid event schema","violations":[{"index":0,"type":"track","event":"Order Completed","errors":["(root): revenue is required"]}]}`)
+		})
+
+		It("should reject OTLP exports with invalid events", func() {
+			setup(schemaValidationReject)
+
+			req := authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"attributes":[
+				{"key":"event.name","value":{"stringValue":"Order Completed"}},
+				{"key":"enduser.id","value":{"stringValue":"anon-1"}},
+				{"key":"revenue","value":{"stringValue":"10"}}
+			]}]}]}]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			gateway.otlpLogsHandler(rr, req)
+			Expect(rr.Code).To(Equal(400))
+			Expect(gjson.Get(rr.Body.String(), "msg").String()).To(Equal(response.InvalidEventSchema))
+			Expect(gjson.Get(rr.Body.String(), "violations.0.errors").Raw).To(Equal(`["revenue: Invalid type. Expected: number, given: string"]`))
+		})
+
+		It("should drop pixel requests with invalid events", func() {
+			setup(schemaValidationReject)
+
+			req := httptest.NewRequest(http.MethodGet, "/pixel/v1/track?writeKey="+WriteKeyEnabled+"&anonymousId=anon-1&event=Order%20Completed", http.NoBody)
+			rr := httptest.NewRecorder()
+			gateway.pixelTrackHandler(rr, req)
+			Expect(rr.Code).To(Equal(200))
+			Expect(rr.Body.String()).To(Equal(response.GetPixelResponse()))
+		})
+
+		It("should accept invalid events with their errors in their context in tag mode", func() {
+			setup(schemaValidationTag)
+
+			c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+				_ = f(jobsdb.EmptyStoreSafeTx())
+			}).Return(nil)
+			c.mockJobsDB.
+				EXPECT().StoreWithRetryEachInTx(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, tx jobsdb.StoreSafeTx, jobs []*jobsdb.JobT) (map[uuid.UUID]string, error) {
+					Expect(jobs).To(HaveLen(1))
+					Expect(gjson.GetBytes(jobs[0].EventPayload, "batch.0.context.schemaValidationErrors").Exists()).To(BeFalse())
+					Expect(gjson.GetBytes(jobs[0].EventPayload, "batch.1.context.schemaValidationErrors").Raw).To(Equal(`["revenue: Invalid type. Expected: number, given: string"]`))
+					Expect(gjson.GetBytes(jobs[0].EventPayload, "batch.2.context.schemaValidationErrors").Exists()).To(BeFalse())
+					return jobsToEmptyErrors(ctx, tx, jobs)
+				}).
+				Times(1)
+
+			expectHandlerResponse(gateway.webBatchHandler, authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(body)), 200, "OK")
+		})
+
+		It("should resolve the settings of write keys again only once the config or the backend config changes", func() {
+			setup(schemaValidationReject)
+			cached := func(writeKey string) bool {
+				gateway.schemaValidators.settingsMu.RLock()
+				defer gateway.schemaValidators.settingsMu.RUnlock()
+				_, ok := gateway.schemaValidators.settings[writeKey]
+				return ok && gateway.schemaValidators.settingsReloads == config.Reloads()
+			}
 
-			expectHandlerResponse(gateway.webAliasHandler, authorizedRequest(WriteKeyEnabled, bytes.NewBufferString("{}")), 429, response.TooManyRequests+"\n")
+			Expect(gateway.schemaValidators.settingsOf("cachedWriteKey")).To(Equal(schemaValidationSettingsT{mode: schemaValidationReject, schemasFile: schemasFile}))
+			Expect(cached("cachedWriteKey")).To(BeTrue())
+
+			config.Set("Gateway.schemaValidation.writeKeys", `{"cachedWriteKey": {"mode": "tag"}}`)
+			Expect(cached("cachedWriteKey")).To(BeFalse())
+			Expect(gateway.schemaValidators.settingsOf("cachedWriteKey")).To(Equal(schemaValidationSettingsT{mode: schemaValidationTag, schemasFile: schemasFile}))
+			Expect(cached("cachedWriteKey")).To(BeTrue())
+			Expect(gateway.schemaValidators.settingsOf("cachedwritekey")).To(Equal(schemaValidationSettingsT{mode: schemaValidationReject, schemasFile: schemasFile}), "write keys are case sensitive")
+
+			gateway.schemaValidators.resetSettings()
+			Expect(cached("cachedWriteKey")).To(BeFalse())
+		})
+	})
+
+	Context("OTLP ingestion", func() {
+		var gateway *HandleT
+
+		BeforeEach(func() {
+			gateway = &HandleT{}
+			err := gateway.Setup(c.mockApp, c.mockBackendConfig, c.mockJobsDB, nil, c.mockVersionHandler, rsources.NewNoOpService())
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			err := gateway.Shutdown()
+			Expect(err).To(BeNil())
+		})
+
+		otlpRequest := func(contentType, body string) *http.Request {
+			req := authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", contentType)
+			return req
+		}
+
+		It("should store log records as track events", func() {
+			c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+				_ = f(jobsdb.EmptyStoreSafeTx())
+			}).Return(nil)
+			c.mockJobsDB.
+				EXPECT().StoreWithRetryEachInTx(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, tx jobsdb.StoreSafeTx, jobs []*jobsdb.JobT) (map[uuid.UUID]string, error) {
+					Expect(jobs).To(HaveLen(1))
+					payload := []byte(jobs[0].EventPayload)
+					Expect(gjson.GetBytes(payload, "writeKey").String()).To(Equal(WriteKeyEnabled))
+					Expect(gjson.GetBytes(payload, "batch").Array()).To(HaveLen(1))
+					event := gjson.GetBytes(payload, "batch.0")
+					Expect(event.Get("type").String()).To(Equal("track"))
+					Expect(event.Get("event").String()).To(Equal("Signed Up"))
+					Expect(event.Get("anonymousId").String()).To(Equal("anon-1"))
+					Expect(event.Get("properties.plan").String()).To(Equal("pro"))
+					Expect(event.Get("messageId").String()).To(testutils.BeValidUUID())
+					return jobsToEmptyErrors(ctx, tx, jobs)
+				}).
+				Times(1)
+
+			rr := httptest.NewRecorder()
+			gateway.otlpLogsHandler(rr, otlpRequest("application/json", `{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"attributes":[
+				{"key":"event.name","value":{"stringValue":"Signed Up"}},
+				{"key":"enduser.id","value":{"stringValue":"anon-1"}},
+				{"key":"plan","value":{"stringValue":"pro"}}
+			]}]}]}]}`))
+			Expect(rr.Code).To(Equal(200))
+			Expect(rr.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(rr.Body.String()).To(Equal("{}"))
+		})
+
+		It("should accept exports without span events", func() {
+			rr := httptest.NewRecorder()
+			gateway.otlpTracesHandler(rr, otlpRequest("application/x-protobuf", ""))
+			Expect(rr.Code).To(Equal(200))
+			Expect(rr.Header().Get("Content-Type")).To(Equal("application/x-protobuf"))
+			Expect(rr.Body.Len()).To(Equal(0))
+		})
+
+		It("should reject invalid requests", func() {
+			expectHandlerResponse(gateway.otlpLogsHandler, otlpRequest("text/plain", "{}"), 415, response.UnsupportedMediaType+"\n")
+			expectHandlerResponse(gateway.otlpLogsHandler, otlpRequest("application/json", `{"resourceLogs":{}}`), 400, response.InvalidOTLPRequest+"\n")
+			expectHandlerResponse(gateway.otlpTracesHandler, otlpRequest("application/x-protobuf", "\x0a\x05"), 400, response.InvalidOTLPRequest+"\n")
+		})
+	})
+
+	Context("gRPC ingestion", func() {
+		var (
+			gateway *HandleT
+			client  proto.IngestClient
+			cancel  context.CancelFunc
+			served  chan error
+		)
+
+		BeforeEach(func() {
+			gateway = &HandleT{}
+			err := gateway.Setup(c.mockApp, c.mockBackendConfig, c.mockJobsDB, nil, c.mockVersionHandler, rsources.NewNoOpService())
+			Expect(err).To(BeNil())
+
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			listener := bufconn.Listen(1024 * 1024)
+			served = make(chan error, 1)
+			go func() {
+				served <- gateway.serveGRPC(ctx, listener)
+			}()
+			conn, err := grpc.Dial("bufconn",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+			Expect(err).To(BeNil())
+			DeferCleanup(conn.Close)
+			client = proto.NewIngestClient(conn)
+		})
+
+		AfterEach(func() {
+			cancel()
+			Eventually(served).Should(Receive(BeNil()))
+			err := gateway.Shutdown()
+			Expect(err).To(BeNil())
+		})
+
+		ingest := func(writeKey string, requests ...*proto.IngestRequest) ([]*proto.IngestResponse, error) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "writekey", writeKey, "x-forwarded-for", TestRemoteAddress)
+			stream, err := client.Ingest(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, request := range requests {
+				if err := stream.Send(request); err != nil {
+					return nil, err
+				}
+			}
+			if err := stream.CloseSend(); err != nil {
+				return nil, err
+			}
+			var responses []*proto.IngestResponse
+			for {
+				resp, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					return responses, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				responses = append(responses, resp)
+			}
+		}
+
+		It("should reject streams without a valid write key", func() {
+			_, err := ingest(WriteKeyEmpty, &proto.IngestRequest{Id: "1", Batch: []byte(`{"batch":[{"userId":"dummyId"}]}`)})
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+			Expect(status.Convert(err).Message()).To(Equal(response.NoWriteKeyInMetadata))
+
+			_, err = ingest(WriteKeyInvalid, &proto.IngestRequest{Id: "1", Batch: []byte(`{"batch":[{"userId":"dummyId"}]}`)})
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+			Expect(status.Convert(err).Message()).To(Equal(response.InvalidWriteKey))
+		})
+
+		It("should store batches and acknowledge each of them", func() {
+			c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+				_ = f(jobsdb.EmptyStoreSafeTx())
+			}).Return(nil)
+			c.mockJobsDB.
+				EXPECT().StoreWithRetryEachInTx(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, tx jobsdb.StoreSafeTx, jobs []*jobsdb.JobT) (map[uuid.UUID]string, error) {
+					Expect(jobs).To(HaveLen(1))
+					payload := []byte(jobs[0].EventPayload)
+					Expect(gjson.GetBytes(payload, "writeKey").String()).To(Equal(WriteKeyEnabled))
+					Expect(gjson.GetBytes(payload, "requestIP").String()).To(Equal(TestRemoteAddress))
+					Expect(gjson.GetBytes(payload, "batch").Array()).To(HaveLen(2))
+					Expect(gjson.GetBytes(payload, "batch.0.userId").String()).To(Equal("dummyId"))
+					return jobsToEmptyErrors(ctx, tx, jobs)
+				}).
+				Times(1)
+
+			responses, err := ingest(WriteKeyEnabled,
+				&proto.IngestRequest{Id: "valid", Batch: []byte(`{"batch":[{"userId":"dummyId"},{"anonymousId":"anonId"}]}`), AnonymousId: "anonId"},
+				&proto.IngestRequest{Id: "invalid", Batch: []byte(`{"batch":`)},
+			)
+			Expect(err).To(BeNil())
+			Expect(responses).To(ConsistOf(
+				WithTransform(func(r *proto.IngestResponse) []interface{} { return []interface{}{r.Id, r.Status, r.StatusCode} }, Equal([]interface{}{"valid", response.Ok, int32(200)})),
+				WithTransform(func(r *proto.IngestResponse) []interface{} { return []interface{}{r.Id, r.Status, r.StatusCode} }, Equal([]interface{}{"invalid", response.InvalidJSON, int32(400)})),
+			))
 		})
 	})
 
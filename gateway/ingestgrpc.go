@@ -0,0 +1,200 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/rudderlabs/rudder-server/gateway/response"
+	proto "github.com/rudderlabs/rudder-server/proto/gateway"
+	"github.com/rudderlabs/rudder-server/services/stats"
+)
+
+const (
+	// writeKeyMetadataKey is the metadata key of the write key authenticating ingest streams
+	writeKeyMetadataKey = "writekey"
+	// ingestReqType is the request type of batches received over gRPC, which are handled like the ones of /v1/batch
+	ingestReqType = "batch"
+	// grpcMessageOverhead leaves room for the fields of ingest requests besides their batch, within the maximum message size
+	grpcMessageOverhead = 1024
+)
+
+type ingestGRPC struct {
+	proto.UnimplementedIngestServer
+	gateway *HandleT
+}
+
+// Ingest authenticates the stream with the write key of its metadata and queues each received batch to the user web request workers,
+// the same way as /v1/batch requests, sending an acknowledgement per batch once it has been stored or rejected
+func (ig *ingestGRPC) Ingest(stream proto.Ingest_IngestServer) error {
+	gateway := ig.gateway
+	writeKey := writeKeyFromMetadata(stream.Context())
+	if writeKey == "" {
+		gateway.stats.NewTaggedStat("gateway.write_key_failed_requests", stats.CountType, stats.Tags{
+			"noWriteKey": "noWriteKey",
+			"reqType":    ingestReqType,
+			"reason":     "noWriteKeyInMetadata",
+		}).Increment()
+		return status.Error(codes.Unauthenticated, response.GetStatus(response.NoWriteKeyInMetadata))
+	}
+	if !gateway.isValidWriteKey(writeKey) {
+		gateway.stats.NewTaggedStat("gateway.write_key_failed_requests", stats.CountType, stats.Tags{
+			"source":  gateway.getSourceTagFromWriteKey(writeKey),
+			"reqType": ingestReqType,
+			"reason":  "invalidWriteKey",
+		}).Increment()
+		return status.Error(codes.Unauthenticated, response.GetStatus(response.InvalidWriteKey))
+	}
+	ipAddr := ipAddrFromContext(stream.Context())
+
+	var (
+		wg       sync.WaitGroup
+		sendMu   sync.Mutex
+		sendErr  error
+		inFlight = make(chan struct{}, maxInFlightGRPCBatches)
+	)
+	ack := func(id, errorMessage string) {
+		atomic.AddUint64(&gateway.ackCount, 1)
+		gateway.trackRequestMetrics(errorMessage)
+		ackStatus := response.GetStatus(response.Ok)
+		if errorMessage != "" {
+			ackStatus = errorMessage
+		}
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&proto.IngestResponse{
+			Id:         id,
+			Status:     ackStatus,
+			StatusCode: int32(response.GetErrorStatusCode(ackStatus)),
+		})
+	}
+	// acknowledgements can't be sent once the handler returns
+	defer wg.Wait()
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		atomic.AddUint64(&gateway.recvCount, 1)
+		if errorMessage := gateway.checkRateLimit(nil, writeKey, ingestReqType); errorMessage != "" {
+			ack(req.Id, errorMessage)
+			continue
+		}
+		batch, violations := gateway.validateEventSchemas(writeKey, ingestReqType, req.Batch)
+		if len(violations) > 0 {
+			ack(req.Id, response.GetStatus(response.InvalidEventSchema))
+			continue
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+		done := make(chan string, 1)
+		start := time.Now()
+		gateway.enqueueWebRequest(&webRequestT{
+			done:           done,
+			reqType:        ingestReqType,
+			requestPayload: batch,
+			writeKey:       writeKey,
+			ipAddr:         ipAddr,
+			userIDHeader:   req.AnonymousId,
+		})
+		gateway.addToWebRequestQWaitTime.SendTiming(time.Since(start))
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			errorMessage := <-done
+			gateway.processRequestTime.Since(start)
+			<-inFlight
+			ack(id, errorMessage)
+		}(req.Id)
+	}
+}
+
+// writeKeyFromMetadata returns the write key of the incoming metadata of a stream
+func writeKeyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(writeKeyMetadataKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ipAddrFromContext returns the ip address of the client of a stream, preferring the X-Forwarded-For metadata set by proxies
+func ipAddrFromContext(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-forwarded-for"); len(values) > 0 && values[0] != "" {
+			return strings.TrimSpace(strings.Split(values[0], ",")[0])
+		}
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+/*
+StartGRPCHandler starts the gRPC ingest service of the gateway, listening on the gateway gRPC port, if Gateway.enableGRPC is true.
+This function will block until the context is cancelled.
+*/
+func (gateway *HandleT) StartGRPCHandler(ctx context.Context) error {
+	if !enableGRPC {
+		return nil
+	}
+	gateway.logger.Infof("GRPCHandler waiting for BackendConfig before starting on %d", grpcPort)
+	gateway.backendConfig.WaitForConfig(ctx)
+	gateway.logger.Infof("GRPCHandler starting on %d", grpcPort)
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(grpcPort))
+	if err != nil {
+		return fmt.Errorf("listening on gRPC port %d: %w", grpcPort, err)
+	}
+	return gateway.serveGRPC(ctx, listener)
+}
+
+// serveGRPC serves the gRPC ingest service on the listener, stopping gracefully once the context is cancelled
+func (gateway *HandleT) serveGRPC(ctx context.Context, listener net.Listener) error {
+	srv := grpc.NewServer(grpc.MaxRecvMsgSize(maxReqSize + grpcMessageOverhead))
+	proto.RegisterIngestServer(srv, &ingestGRPC{gateway: gateway})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		srv.GracefulStop()
+		return nil
+	}
+}
@@ -0,0 +1,166 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// The types below are the subset of the OTLP logs and traces data model mapped into events.
+// Their json tags follow the OTLP/JSON encoding, fields which are not mapped are ignored.
+
+type LogsData struct {
+	ResourceLogs []ResourceLogs `json:"resourceLogs"`
+}
+
+type ResourceLogs struct {
+	Resource  Resource    `json:"resource"`
+	ScopeLogs []ScopeLogs `json:"scopeLogs"`
+}
+
+type ScopeLogs struct {
+	Scope      Scope       `json:"scope"`
+	LogRecords []LogRecord `json:"logRecords"`
+}
+
+type LogRecord struct {
+	TimeUnixNano         UnixNano   `json:"timeUnixNano"`
+	ObservedTimeUnixNano UnixNano   `json:"observedTimeUnixNano"`
+	SeverityNumber       int32      `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 AnyValue   `json:"body"`
+	Attributes           []KeyValue `json:"attributes"`
+	// TraceID and SpanID are hex encoded
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+}
+
+type TracesData struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+type ScopeSpans struct {
+	Scope Scope  `json:"scope"`
+	Spans []Span `json:"spans"`
+}
+
+type Span struct {
+	// TraceID, SpanID and ParentSpanID are hex encoded
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId"`
+	Name              string      `json:"name"`
+	Kind              int32       `json:"kind"`
+	StartTimeUnixNano UnixNano    `json:"startTimeUnixNano"`
+	EndTimeUnixNano   UnixNano    `json:"endTimeUnixNano"`
+	Attributes        []KeyValue  `json:"attributes"`
+	Events            []SpanEvent `json:"events"`
+}
+
+type SpanEvent struct {
+	TimeUnixNano UnixNano   `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []KeyValue `json:"attributes"`
+}
+
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+type Scope struct {
+	Name       string     `json:"name"`
+	Version    string     `json:"version"`
+	Attributes []KeyValue `json:"attributes"`
+}
+
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue is an attribute or log body value, converted to its json equivalent:
+// string, bool, int64, float64, []interface{} or map[string]interface{}. Bytes are base64 encoded
+type AnyValue struct {
+	Value interface{}
+}
+
+func (v *AnyValue) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		StringValue *string          `json:"stringValue"`
+		BoolValue   *bool            `json:"boolValue"`
+		IntValue    *json.RawMessage `json:"intValue"`
+		DoubleValue *float64         `json:"doubleValue"`
+		ArrayValue  *struct {
+			Values []AnyValue `json:"values"`
+		} `json:"arrayValue"`
+		KvlistValue *struct {
+			Values []KeyValue `json:"values"`
+		} `json:"kvlistValue"`
+		BytesValue *[]byte `json:"bytesValue"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch {
+	case raw.StringValue != nil:
+		v.Value = *raw.StringValue
+	case raw.BoolValue != nil:
+		v.Value = *raw.BoolValue
+	case raw.IntValue != nil:
+		// 64 bit integers are encoded as strings, numbers are accepted too
+		i, err := strconv.ParseInt(string(bytes.Trim(*raw.IntValue, `"`)), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid intValue %s: %w", *raw.IntValue, err)
+		}
+		v.Value = i
+	case raw.DoubleValue != nil:
+		v.Value = *raw.DoubleValue
+	case raw.ArrayValue != nil:
+		values := make([]interface{}, 0, len(raw.ArrayValue.Values))
+		for _, value := range raw.ArrayValue.Values {
+			values = append(values, value.Value)
+		}
+		v.Value = values
+	case raw.KvlistValue != nil:
+		v.Value = attributesMap(raw.KvlistValue.Values)
+	case raw.BytesValue != nil:
+		v.Value = base64.StdEncoding.EncodeToString(*raw.BytesValue)
+	default:
+		v.Value = nil
+	}
+	return nil
+}
+
+// UnixNano is a timestamp in nanoseconds since the unix epoch
+type UnixNano uint64
+
+func (t *UnixNano) UnmarshalJSON(data []byte) error {
+	// 64 bit integers are encoded as strings, numbers are accepted too
+	s := string(bytes.Trim(data, `"`))
+	if s == "" || s == "null" {
+		*t = 0
+		return nil
+	}
+	nanos, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s: %w", data, err)
+	}
+	*t = UnixNano(nanos)
+	return nil
+}
+
+// attributesMap converts attributes to a map, later attributes override earlier ones with the same key
+func attributesMap(attributes []KeyValue) map[string]interface{} {
+	m := make(map[string]interface{}, len(attributes))
+	for _, attribute := range attributes {
+		m[attribute.Key] = attribute.Value.Value
+	}
+	return m
+}
@@ -0,0 +1,196 @@
+// Package otlp maps OTLP/HTTP log records and span events into rudder track events.
+package otlp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+const (
+	// ContentTypeProtobuf is the content type of the OTLP/protobuf encoding
+	ContentTypeProtobuf = "application/x-protobuf"
+	// ContentTypeJSON is the content type of the OTLP/JSON encoding
+	ContentTypeJSON = "application/json"
+
+	// defaultLogEventName is the event name of log records without an event name attribute
+	defaultLogEventName = "log"
+	// serviceInstanceIDAttribute is the resource attribute identifying the service instance which emitted the records
+	serviceInstanceIDAttribute = "service.instance.id"
+)
+
+// ErrUnsupportedContentType is returned for requests which are neither OTLP/protobuf nor OTLP/JSON encoded
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// Encoding returns the encoding of a request with the content type, either ContentTypeProtobuf or ContentTypeJSON
+func Encoding(contentType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	switch mediaType {
+	case ContentTypeProtobuf, ContentTypeJSON:
+		return mediaType, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// UnmarshalLogs decodes the body of an OTLP logs export request with the encoding
+func UnmarshalLogs(encoding string, body []byte) (*LogsData, error) {
+	var logs LogsData
+	var err error
+	if encoding == ContentTypeProtobuf {
+		err = unmarshalLogsProto(body, &logs)
+	} else {
+		err = json.Unmarshal(body, &logs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding logs: %w", err)
+	}
+	return &logs, nil
+}
+
+// UnmarshalTraces decodes the body of an OTLP traces export request with the encoding
+func UnmarshalTraces(encoding string, body []byte) (*TracesData, error) {
+	var traces TracesData
+	var err error
+	if encoding == ContentTypeProtobuf {
+		err = unmarshalTracesProto(body, &traces)
+	} else {
+		err = json.Unmarshal(body, &traces)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding traces: %w", err)
+	}
+	return &traces, nil
+}
+
+// ExportResponse returns the body of a successful export response with the encoding.
+// Both the logs and traces export responses are empty messages
+func ExportResponse(encoding string) []byte {
+	if encoding == ContentTypeProtobuf {
+		return []byte{}
+	}
+	return []byte("{}")
+}
+
+// Mapper maps log records and span events into track events, with the attributes of the records as their properties
+type Mapper struct {
+	// AnonymousIDAttribute is the attribute holding the anonymousId of events, looked up in the record attributes first and then in the resource ones.
+	// Events without it are identified by the service.instance.id resource attribute, or else by their trace id
+	AnonymousIDAttribute string
+	// EventNameAttribute is the attribute of log records holding their event name
+	EventNameAttribute string
+}
+
+// LogEvents maps each log record into a track event
+func (m *Mapper) LogEvents(logs *LogsData) []map[string]interface{} {
+	var events []map[string]interface{}
+	for _, resourceLogs := range logs.ResourceLogs {
+		resource := attributesMap(resourceLogs.Resource.Attributes)
+		for _, scopeLogs := range resourceLogs.ScopeLogs {
+			for _, record := range scopeLogs.LogRecords {
+				properties := attributesMap(record.Attributes)
+				eventName, _ := properties[m.EventNameAttribute].(string)
+				if eventName == "" {
+					eventName = defaultLogEventName
+				}
+				if record.Body.Value != nil {
+					properties["body"] = record.Body.Value
+				}
+				if record.SeverityText != "" {
+					properties["severityText"] = record.SeverityText
+				}
+				if record.SeverityNumber != 0 {
+					properties["severityNumber"] = record.SeverityNumber
+				}
+				timestamp := record.TimeUnixNano
+				if timestamp == 0 {
+					timestamp = record.ObservedTimeUnixNano
+				}
+				anonymousID := m.anonymousID(resource, record.TraceID, properties)
+				events = append(events, m.event(eventName, properties, resource, scopeLogs.Scope, anonymousID, record.TraceID, record.SpanID, timestamp))
+			}
+		}
+	}
+	return events
+}
+
+// SpanEvents maps each event of the spans into a track event
+func (m *Mapper) SpanEvents(traces *TracesData) []map[string]interface{} {
+	var events []map[string]interface{}
+	for _, resourceSpans := range traces.ResourceSpans {
+		resource := attributesMap(resourceSpans.Resource.Attributes)
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			for _, span := range scopeSpans.Spans {
+				spanAttributes := attributesMap(span.Attributes)
+				for _, spanEvent := range span.Events {
+					properties := attributesMap(spanEvent.Attributes)
+					properties["spanName"] = span.Name
+					// events of a span are identified by the span attributes too
+					anonymousID := m.anonymousID(resource, span.TraceID, properties, spanAttributes)
+					events = append(events, m.event(spanEvent.Name, properties, resource, scopeSpans.Scope, anonymousID, span.TraceID, span.SpanID, spanEvent.TimeUnixNano))
+				}
+			}
+		}
+	}
+	return events
+}
+
+// event returns a track event, with the instrumentation scope as its library and the resource attributes and trace context in context.otlp
+func (m *Mapper) event(name string, properties, resource map[string]interface{}, scope Scope, anonymousID, traceID, spanID string, timestamp UnixNano) map[string]interface{} {
+	otlpContext := map[string]interface{}{"resource": resource}
+	if traceID != "" {
+		otlpContext["traceId"] = traceID
+	}
+	if spanID != "" {
+		otlpContext["spanId"] = spanID
+	}
+	event := map[string]interface{}{
+		"type":       "track",
+		"event":      name,
+		"properties": properties,
+		"context": map[string]interface{}{
+			"library": map[string]interface{}{"name": scope.Name, "version": scope.Version},
+			"otlp":    otlpContext,
+		},
+	}
+	if anonymousID != "" {
+		event["anonymousId"] = anonymousID
+	}
+	if timestamp != 0 {
+		event["originalTimestamp"] = time.Unix(0, int64(timestamp)).UTC().Format(misc.RFC3339Milli)
+	}
+	return event
+}
+
+// anonymousID returns the anonymousId of an event, the AnonymousIDAttribute of the first of its attributes and then its resource attributes having it.
+// Events without it fall back to the service instance and then to their trace, so that records without an identity attribute aren't rejected
+func (m *Mapper) anonymousID(resource map[string]interface{}, traceID string, attributes ...map[string]interface{}) string {
+	for _, a := range append(attributes, resource) {
+		if anonymousID := attributeString(a, m.AnonymousIDAttribute); anonymousID != "" {
+			return anonymousID
+		}
+	}
+	if instanceID := attributeString(resource, serviceInstanceIDAttribute); instanceID != "" {
+		return instanceID
+	}
+	return traceID
+}
+
+// attributeString returns the value of an attribute as a string, or an empty string if it isn't set
+func attributeString(attributes map[string]interface{}, key string) string {
+	value, ok := attributes[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
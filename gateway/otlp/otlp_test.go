@@ -0,0 +1,193 @@
+package otlp
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestEncoding(t *testing.T) {
+	encoding, err := Encoding("application/json; charset=utf-8")
+	require.NoError(t, err)
+	require.Equal(t, ContentTypeJSON, encoding)
+
+	encoding, err = Encoding("application/x-protobuf")
+	require.NoError(t, err)
+	require.Equal(t, ContentTypeProtobuf, encoding)
+
+	_, err = Encoding("text/plain")
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+}
+
+func TestLogEvents(t *testing.T) {
+	body := []byte(`{
+		"resourceLogs": [{
+			"resource": {"attributes": [
+				{"key": "service.name", "value": {"stringValue": "checkout"}},
+				{"key": "enduser.id", "value": {"stringValue": "resource-user"}}
+			]},
+			"scopeLogs": [{
+				"scope": {"name": "app-logger", "version": "1.0.0"},
+				"logRecords": [{
+					"timeUnixNano": "1660000000123000000",
+					"severityNumber": 9,
+					"severityText": "INFO",
+					"body": {"stringValue": "order placed"},
+					"attributes": [
+						{"key": "event.name", "value": {"stringValue": "Order Completed"}},
+						{"key": "enduser.id", "value": {"stringValue": "user-1"}},
+						{"key": "total", "value": {"doubleValue": 12.5}},
+						{"key": "items", "value": {"intValue": "3"}},
+						{"key": "coupons", "value": {"arrayValue": {"values": [{"stringValue": "a"}, {"boolValue": true}]}}},
+						{"key": "shipping", "value": {"kvlistValue": {"values": [{"key": "express", "value": {"boolValue": false}}]}}}
+					],
+					"traceId": "5b8efff798038103d269b633813fc60c",
+					"spanId": "eee19b7ec3c1b174"
+				}, {
+					"observedTimeUnixNano": 1660000001000000000,
+					"body": {"stringValue": "no event name"}
+				}]
+			}]
+		}]
+	}`)
+	logs, err := UnmarshalLogs(ContentTypeJSON, body)
+	require.NoError(t, err)
+
+	m := Mapper{AnonymousIDAttribute: "enduser.id", EventNameAttribute: "event.name"}
+	events := m.LogEvents(logs)
+	require.JSONEq(t, `[{
+		"type": "track",
+		"event": "Order Completed",
+		"anonymousId": "user-1",
+		"originalTimestamp": "2022-08-08T23:06:40.123Z",
+		"properties": {
+			"event.name": "Order Completed",
+			"enduser.id": "user-1",
+			"total": 12.5,
+			"items": 3,
+			"coupons": ["a", true],
+			"shipping": {"express": false},
+			"body": "order placed",
+			"severityText": "INFO",
+			"severityNumber": 9
+		},
+		"context": {
+			"library": {"name": "app-logger", "version": "1.0.0"},
+			"otlp": {
+				"resource": {"service.name": "checkout", "enduser.id": "resource-user"},
+				"traceId": "5b8efff798038103d269b633813fc60c",
+				"spanId": "eee19b7ec3c1b174"
+			}
+		}
+	}, {
+		"type": "track",
+		"event": "log",
+		"anonymousId": "resource-user",
+		"originalTimestamp": "2022-08-08T23:06:41.000Z",
+		"properties": {"body": "no event name"},
+		"context": {
+			"library": {"name": "app-logger", "version": "1.0.0"},
+			"otlp": {"resource": {"service.name": "checkout", "enduser.id": "resource-user"}}
+		}
+	}]`, marshal(t, events))
+
+	_, err = UnmarshalLogs(ContentTypeJSON, []byte(`{"resourceLogs": [{"scopeLogs": [{"logRecords": [{"timeUnixNano": "now"}]}]}]}`))
+	require.Error(t, err)
+}
+
+func TestLogEventsWithoutIdentityAttribute(t *testing.T) {
+	body := []byte(`{
+		"resourceLogs": [{
+			"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]},
+			"scopeLogs": [{"logRecords": [
+				{"body": {"stringValue": "traced"}, "traceId": "5b8efff798038103d269b633813fc60c"},
+				{"body": {"stringValue": "untraced"}}
+			]}]
+		}, {
+			"resource": {"attributes": [{"key": "service.instance.id", "value": {"stringValue": "checkout-1"}}]},
+			"scopeLogs": [{"logRecords": [{"body": {"stringValue": "instance"}, "traceId": "5b8efff798038103d269b633813fc60c"}]}]
+		}]
+	}`)
+	logs, err := UnmarshalLogs(ContentTypeJSON, body)
+	require.NoError(t, err)
+
+	m := Mapper{AnonymousIDAttribute: "enduser.id", EventNameAttribute: "event.name"}
+	events := m.LogEvents(logs)
+	require.Len(t, events, 3)
+	require.Equal(t, "5b8efff798038103d269b633813fc60c", events[0]["anonymousId"], "records are identified by their trace id")
+	require.NotContains(t, events[1], "anonymousId", "records without a trace id can't be identified")
+	require.Equal(t, "checkout-1", events[2]["anonymousId"], "the service instance takes precedence over the trace id")
+}
+
+func TestSpanEvents(t *testing.T) {
+	keyValue := func(key string, value []byte) []byte {
+		b := protowire.AppendTag(nil, 1, protowire.BytesType)
+		b = protowire.AppendString(b, key)
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		return protowire.AppendBytes(b, value)
+	}
+	stringValue := func(s string) []byte {
+		return protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), s)
+	}
+	message := func(fields ...[]byte) []byte {
+		var b []byte
+		for _, f := range fields {
+			b = append(b, f...)
+		}
+		return b
+	}
+	embedded := func(num protowire.Number, value []byte) []byte {
+		return protowire.AppendBytes(protowire.AppendTag(nil, num, protowire.BytesType), value)
+	}
+
+	event := message(
+		protowire.AppendFixed64(protowire.AppendTag(nil, 1, protowire.Fixed64Type), 1660000000000000000),
+		embedded(2, []byte("Product Viewed")),
+		embedded(3, keyValue("price", protowire.AppendFixed64(protowire.AppendTag(nil, 4, protowire.Fixed64Type), math.Float64bits(9.99)))),
+		embedded(3, keyValue("quantity", protowire.AppendVarint(protowire.AppendTag(nil, 3, protowire.VarintType), 2))),
+		protowire.AppendVarint(protowire.AppendTag(nil, 4, protowire.VarintType), 0), // dropped_attributes_count is skipped
+	)
+	span := message(
+		embedded(1, []byte{0x5b, 0x8e, 0xff, 0xf7}),
+		embedded(2, []byte{0xee, 0xe1}),
+		embedded(5, []byte("GET /products")),
+		embedded(9, keyValue("enduser.id", stringValue("user-2"))),
+		embedded(11, event),
+	)
+	body := embedded(1, message( // resource_spans
+		embedded(1, embedded(1, keyValue("service.name", stringValue("shop")))), // resource
+		embedded(2, message( // scope_spans
+			embedded(1, message(embedded(1, []byte("tracer")), embedded(2, []byte("2.0")))),
+			embedded(2, span),
+		)),
+	))
+
+	traces, err := UnmarshalTraces(ContentTypeProtobuf, body)
+	require.NoError(t, err)
+
+	m := Mapper{AnonymousIDAttribute: "enduser.id", EventNameAttribute: "event.name"}
+	require.JSONEq(t, `[{
+		"type": "track",
+		"event": "Product Viewed",
+		"anonymousId": "user-2",
+		"originalTimestamp": "2022-08-08T23:06:40.000Z",
+		"properties": {"price": 9.99, "quantity": 2, "spanName": "GET /products"},
+		"context": {
+			"library": {"name": "tracer", "version": "2.0"},
+			"otlp": {"resource": {"service.name": "shop"}, "traceId": "5b8efff7", "spanId": "eee1"}
+		}
+	}]`, marshal(t, m.SpanEvents(traces)))
+
+	_, err = UnmarshalTraces(ContentTypeProtobuf, []byte{0x0a, 0x05, 0x01})
+	require.Error(t, err)
+}
+
+func marshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,321 @@
+package otlp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Decoding of the OTLP/protobuf encoding, with the field numbers of the opentelemetry-proto messages.
+// Fields which are not mapped into events are skipped.
+
+// field is a field of a protobuf message, with its still encoded value
+type field struct {
+	num   protowire.Number
+	typ   protowire.Type
+	value []byte
+}
+
+// forEachField calls fn with each field of a protobuf encoded message
+func forEachField(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		if err := fn(field{num: num, typ: typ, value: b[:m]}); err != nil {
+			return err
+		}
+		b = b[m:]
+	}
+	return nil
+}
+
+func (f field) wireTypeError() error {
+	return fmt.Errorf("unexpected wire type %d of field %d", f.typ, f.num)
+}
+
+func (f field) bytes() ([]byte, error) {
+	if f.typ != protowire.BytesType {
+		return nil, f.wireTypeError()
+	}
+	v, _ := protowire.ConsumeBytes(f.value)
+	return v, nil
+}
+
+func (f field) string() (string, error) {
+	v, err := f.bytes()
+	return string(v), err
+}
+
+func (f field) hex() (string, error) {
+	v, err := f.bytes()
+	return hex.EncodeToString(v), err
+}
+
+func (f field) varint() (uint64, error) {
+	if f.typ != protowire.VarintType {
+		return 0, f.wireTypeError()
+	}
+	v, _ := protowire.ConsumeVarint(f.value)
+	return v, nil
+}
+
+func (f field) fixed64() (uint64, error) {
+	if f.typ != protowire.Fixed64Type {
+		return 0, f.wireTypeError()
+	}
+	v, _ := protowire.ConsumeFixed64(f.value)
+	return v, nil
+}
+
+// message decodes the fields of an embedded message
+func (f field) message(fn func(f field) error) error {
+	v, err := f.bytes()
+	if err != nil {
+		return err
+	}
+	return forEachField(v, fn)
+}
+
+func unmarshalLogsProto(b []byte, logs *LogsData) error {
+	return forEachField(b, func(f field) error {
+		if f.num != 1 { // resource_logs
+			return nil
+		}
+		var resourceLogs ResourceLogs
+		err := f.message(func(f field) error {
+			switch f.num {
+			case 1: // resource
+				return f.message(resourceField(&resourceLogs.Resource))
+			case 2: // scope_logs
+				var scopeLogs ScopeLogs
+				err := f.message(func(f field) error {
+					switch f.num {
+					case 1: // scope
+						return f.message(scopeField(&scopeLogs.Scope))
+					case 2: // log_records
+						var record LogRecord
+						if err := f.message(logRecordField(&record)); err != nil {
+							return err
+						}
+						scopeLogs.LogRecords = append(scopeLogs.LogRecords, record)
+					}
+					return nil
+				})
+				resourceLogs.ScopeLogs = append(resourceLogs.ScopeLogs, scopeLogs)
+				return err
+			}
+			return nil
+		})
+		logs.ResourceLogs = append(logs.ResourceLogs, resourceLogs)
+		return err
+	})
+}
+
+func logRecordField(record *LogRecord) func(f field) error {
+	return func(f field) (err error) {
+		switch f.num {
+		case 1: // time_unix_nano
+			var v uint64
+			v, err = f.fixed64()
+			record.TimeUnixNano = UnixNano(v)
+		case 11: // observed_time_unix_nano
+			var v uint64
+			v, err = f.fixed64()
+			record.ObservedTimeUnixNano = UnixNano(v)
+		case 2: // severity_number
+			var v uint64
+			v, err = f.varint()
+			record.SeverityNumber = int32(v)
+		case 3: // severity_text
+			record.SeverityText, err = f.string()
+		case 5: // body
+			err = f.message(anyValueField(&record.Body))
+		case 6: // attributes
+			err = appendKeyValue(f, &record.Attributes)
+		case 9: // trace_id
+			record.TraceID, err = f.hex()
+		case 10: // span_id
+			record.SpanID, err = f.hex()
+		}
+		return err
+	}
+}
+
+func unmarshalTracesProto(b []byte, traces *TracesData) error {
+	return forEachField(b, func(f field) error {
+		if f.num != 1 { // resource_spans
+			return nil
+		}
+		var resourceSpans ResourceSpans
+		err := f.message(func(f field) error {
+			switch f.num {
+			case 1: // resource
+				return f.message(resourceField(&resourceSpans.Resource))
+			case 2: // scope_spans
+				var scopeSpans ScopeSpans
+				err := f.message(func(f field) error {
+					switch f.num {
+					case 1: // scope
+						return f.message(scopeField(&scopeSpans.Scope))
+					case 2: // spans
+						var span Span
+						if err := f.message(spanField(&span)); err != nil {
+							return err
+						}
+						scopeSpans.Spans = append(scopeSpans.Spans, span)
+					}
+					return nil
+				})
+				resourceSpans.ScopeSpans = append(resourceSpans.ScopeSpans, scopeSpans)
+				return err
+			}
+			return nil
+		})
+		traces.ResourceSpans = append(traces.ResourceSpans, resourceSpans)
+		return err
+	})
+}
+
+func spanField(span *Span) func(f field) error {
+	return func(f field) (err error) {
+		switch f.num {
+		case 1: // trace_id
+			span.TraceID, err = f.hex()
+		case 2: // span_id
+			span.SpanID, err = f.hex()
+		case 4: // parent_span_id
+			span.ParentSpanID, err = f.hex()
+		case 5: // name
+			span.Name, err = f.string()
+		case 6: // kind
+			var v uint64
+			v, err = f.varint()
+			span.Kind = int32(v)
+		case 7: // start_time_unix_nano
+			var v uint64
+			v, err = f.fixed64()
+			span.StartTimeUnixNano = UnixNano(v)
+		case 8: // end_time_unix_nano
+			var v uint64
+			v, err = f.fixed64()
+			span.EndTimeUnixNano = UnixNano(v)
+		case 9: // attributes
+			err = appendKeyValue(f, &span.Attributes)
+		case 11: // events
+			var event SpanEvent
+			err = f.message(func(f field) (err error) {
+				switch f.num {
+				case 1: // time_unix_nano
+					var v uint64
+					v, err = f.fixed64()
+					event.TimeUnixNano = UnixNano(v)
+				case 2: // name
+					event.Name, err = f.string()
+				case 3: // attributes
+					err = appendKeyValue(f, &event.Attributes)
+				}
+				return err
+			})
+			span.Events = append(span.Events, event)
+		}
+		return err
+	}
+}
+
+func resourceField(resource *Resource) func(f field) error {
+	return func(f field) error {
+		if f.num == 1 { // attributes
+			return appendKeyValue(f, &resource.Attributes)
+		}
+		return nil
+	}
+}
+
+func scopeField(scope *Scope) func(f field) error {
+	return func(f field) (err error) {
+		switch f.num {
+		case 1: // name
+			scope.Name, err = f.string()
+		case 2: // version
+			scope.Version, err = f.string()
+		case 3: // attributes
+			err = appendKeyValue(f, &scope.Attributes)
+		}
+		return err
+	}
+}
+
+// appendKeyValue decodes a KeyValue message field, appending it to the attributes
+func appendKeyValue(f field, attributes *[]KeyValue) error {
+	var kv KeyValue
+	err := f.message(func(f field) (err error) {
+		switch f.num {
+		case 1: // key
+			kv.Key, err = f.string()
+		case 2: // value
+			err = f.message(anyValueField(&kv.Value))
+		}
+		return err
+	})
+	*attributes = append(*attributes, kv)
+	return err
+}
+
+func anyValueField(value *AnyValue) func(f field) error {
+	return func(f field) (err error) {
+		switch f.num {
+		case 1: // string_value
+			value.Value, err = f.string()
+		case 2: // bool_value
+			var v uint64
+			v, err = f.varint()
+			value.Value = v != 0
+		case 3: // int_value
+			var v uint64
+			v, err = f.varint()
+			value.Value = int64(v)
+		case 4: // double_value
+			var v uint64
+			v, err = f.fixed64()
+			value.Value = math.Float64frombits(v)
+		case 5: // array_value
+			values := []interface{}{}
+			err = f.message(func(f field) error {
+				if f.num != 1 { // values
+					return nil
+				}
+				var item AnyValue
+				if err := f.message(anyValueField(&item)); err != nil {
+					return err
+				}
+				values = append(values, item.Value)
+				return nil
+			})
+			value.Value = values
+		case 6: // kvlist_value
+			var kvs []KeyValue
+			err = f.message(func(f field) error {
+				if f.num != 1 { // values
+					return nil
+				}
+				return appendKeyValue(f, &kvs)
+			})
+			value.Value = attributesMap(kvs)
+		case 7: // bytes_value
+			var v []byte
+			v, err = f.bytes()
+			value.Value = base64.StdEncoding.EncodeToString(v)
+		}
+		return err
+	}
+}
@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/gateway/otlp"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+	"github.com/rudderlabs/rudder-server/services/stats"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+func (gateway *HandleT) otlpLogsHandler(w http.ResponseWriter, r *http.Request) {
+	gateway.otlpHandler(w, r, "otlp_logs", func(m *otlp.Mapper, encoding string, body []byte) ([]map[string]interface{}, error) {
+		logs, err := otlp.UnmarshalLogs(encoding, body)
+		if err != nil {
+			return nil, err
+		}
+		return m.LogEvents(logs), nil
+	})
+}
+
+func (gateway *HandleT) otlpTracesHandler(w http.ResponseWriter, r *http.Request) {
+	gateway.otlpHandler(w, r, "otlp_traces", func(m *otlp.Mapper, encoding string, body []byte) ([]map[string]interface{}, error) {
+		traces, err := otlp.UnmarshalTraces(encoding, body)
+		if err != nil {
+			return nil, err
+		}
+		return m.SpanEvents(traces), nil
+	})
+}
+
+// otlpHandler handles OTLP/HTTP export requests, authenticated with the write key in basic auth like the other web handlers.
+// The exported records are mapped into track events, which are validated and processed as a single batch request
+func (gateway *HandleT) otlpHandler(w http.ResponseWriter, r *http.Request, reqType string, toEvents func(m *otlp.Mapper, encoding string, body []byte) ([]map[string]interface{}, error)) {
+	webReqHandlerTime := gateway.stats.NewTaggedStat("gateway.web_req_handler_time", stats.TimerType, stats.Tags{"reqType": reqType})
+	webReqHandlerStartTime := time.Now()
+	defer webReqHandlerTime.Since(webReqHandlerStartTime)
+
+	gateway.logger.LogRequest(r)
+	atomic.AddUint64(&gateway.recvCount, 1)
+	var errorMessage string
+	defer func() {
+		if errorMessage != "" {
+			gateway.logger.Infof("IP: %s -- %s -- Response: %d, %s", misc.GetIPFromReq(r), r.URL.Path, response.GetErrorStatusCode(errorMessage), errorMessage)
+			http.Error(w, errorMessage, response.GetErrorStatusCode(errorMessage))
+		}
+	}()
+	encoding, err := otlp.Encoding(r.Header.Get("Content-Type"))
+	if err != nil {
+		errorMessage = response.GetStatus(response.UnsupportedMediaType)
+		return
+	}
+	body, writeKey, err := gateway.getPayloadAndWriteKey(w, r, reqType)
+	if err != nil {
+		errorMessage = err.Error()
+		return
+	}
+	mapper := otlp.Mapper{AnonymousIDAttribute: otlpAnonymousIDAttribute, EventNameAttribute: otlpEventNameAttribute}
+	events, err := toEvents(&mapper, encoding, body)
+	if err != nil {
+		gateway.logger.Debugf("Invalid OTLP request of write key %s: %v", writeKey, err)
+		errorMessage = response.GetStatus(response.InvalidOTLPRequest)
+		return
+	}
+	if len(events) > 0 {
+		if errorMessage = gateway.checkRateLimit(w, writeKey, reqType); errorMessage != "" {
+			return
+		}
+		payload, err := json.Marshal(map[string]interface{}{"batch": events})
+		if err != nil {
+			errorMessage = response.GetStatus(response.ErrorInMarshal)
+			return
+		}
+		payload, violations := gateway.validateEventSchemas(writeKey, "batch", payload)
+		if len(violations) > 0 {
+			gateway.rejectSchemaViolations(w, r, violations)
+			return
+		}
+		errorMessage = gateway.rrh.ProcessRequest(gateway, &w, r, "batch", payload, writeKey)
+		atomic.AddUint64(&gateway.ackCount, 1)
+		gateway.trackRequestMetrics(errorMessage)
+		if errorMessage != "" {
+			return
+		}
+	}
+	gateway.logger.Debugf("IP: %s -- %s -- Response: 200, %s", misc.GetIPFromReq(r), r.URL.Path, response.GetStatus(response.Ok))
+
+	w.Header().Set("Content-Type", encoding)
+	_, _ = w.Write(otlp.ExportResponse(encoding))
+}
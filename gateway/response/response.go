@@ -18,8 +18,16 @@ const (
 	NoWriteKeyInBasicAuth = "Failed to read writeKey from header"
 	// NoWriteKeyInQueryParams - Failed to read writeKey from Query Params
 	NoWriteKeyInQueryParams = "Failed to read writeKey from Query Params"
+	// NoWriteKeyInMetadata - Failed to read writeKey from gRPC metadata
+	NoWriteKeyInMetadata = "Failed to read writeKey from metadata"
 	// RequestBodyReadFailed - Failed to read body from request
 	RequestBodyReadFailed = "Failed to read body from request"
+	// RequestBodyDecompressFailed - Failed to decompress body of request
+	RequestBodyDecompressFailed = "Failed to decompress request body"
+	// UnsupportedContentEncoding - Content encoding of the request body is not supported
+	UnsupportedContentEncoding = "Unsupported content encoding"
+	// InvalidEventSchema - Events of the request do not match their json schemas
+	InvalidEventSchema = "Invalid event schema"
 	// RequestBodyTooLarge - Request size exceeds max limit
 	RequestBodyTooLarge = "Request size exceeds max limit"
 	// InvalidWriteKey - Invalid Write Key
@@ -50,6 +58,10 @@ const (
 	ErrorInParseForm = "Error during parsing form"
 	// ErrorInParseMultiform - Error during parsing multiform
 	ErrorInParseMultiform = "Error during parsing multiform"
+	// UnsupportedMediaType - Content type of the request is not supported
+	UnsupportedMediaType = "Unsupported media type"
+	// InvalidOTLPRequest - Request is not a valid OTLP export request
+	InvalidOTLPRequest = "Invalid OTLP request"
 	// NotRudderEvent = Event is not a Valid Rudder Event
 	NotRudderEvent = "Event is not a valid rudder event"
 
@@ -64,6 +76,7 @@ var statusMap = map[string]status{
 	TooManyRequests:         {message: TooManyRequests, code: http.StatusTooManyRequests},
 	NoWriteKeyInBasicAuth:   {message: NoWriteKeyInBasicAuth, code: http.StatusUnauthorized},
 	NoWriteKeyInQueryParams: {message: NoWriteKeyInQueryParams, code: http.StatusUnauthorized},
+	NoWriteKeyInMetadata:    {message: NoWriteKeyInMetadata, code: http.StatusUnauthorized},
 	RequestBodyReadFailed:   {message: RequestBodyReadFailed, code: http.StatusInternalServerError},
 	RequestBodyTooLarge:     {message: RequestBodyTooLarge, code: http.StatusRequestEntityTooLarge},
 	InvalidWriteKey:         {message: InvalidWriteKey, code: http.StatusUnauthorized},
@@ -82,6 +95,14 @@ var statusMap = map[string]status{
 	ErrorInParseForm:                               {message: ErrorInParseForm, code: http.StatusBadRequest},
 	ErrorInParseMultiform:                          {message: ErrorInParseMultiform, code: http.StatusBadRequest},
 	NotRudderEvent:                                 {message: NotRudderEvent, code: http.StatusBadRequest},
+	// otlp specific status
+	UnsupportedMediaType: {message: UnsupportedMediaType, code: http.StatusUnsupportedMediaType},
+	InvalidOTLPRequest:   {message: InvalidOTLPRequest, code: http.StatusBadRequest},
+	// compressed request bodies specific status
+	RequestBodyDecompressFailed: {message: RequestBodyDecompressFailed, code: http.StatusBadRequest},
+	UnsupportedContentEncoding:  {message: UnsupportedContentEncoding, code: http.StatusUnsupportedMediaType},
+	// schema validation specific status
+	InvalidEventSchema: {message: InvalidEventSchema, code: http.StatusBadRequest},
 }
 
 // status holds the gateway response status message and code
@@ -0,0 +1,303 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+	"github.com/rudderlabs/rudder-server/gateway/schemavalidator"
+	"github.com/rudderlabs/rudder-server/services/stats"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+const (
+	// schemaValidationOff doesn't validate events
+	schemaValidationOff = "off"
+	// schemaValidationReject rejects requests with invalid events, with a 400 response listing the errors of each invalid event
+	schemaValidationReject = "reject"
+	// schemaValidationTag accepts invalid events, adding their errors to their context
+	schemaValidationTag = "tag"
+
+	// schemaValidationErrorsKey is the context key of the validation errors of tagged events
+	schemaValidationErrorsKey = "schemaValidationErrors"
+)
+
+// schemaValidationSettingsT is the schema validation settings of a write key
+type schemaValidationSettingsT struct {
+	mode        string
+	schemasFile string
+}
+
+// schemaValidationWriteKeySettingsT is the schema validation settings of a write key in Gateway.schemaValidation.writeKeys,
+// unset settings defaulting to Gateway.schemaValidation.mode and .schemasFile
+type schemaValidationWriteKeySettingsT struct {
+	Mode        *string `json:"mode"`
+	SchemasFile *string `json:"schemasFile"`
+}
+
+// resolveSchemaValidationSettings reads the schema validation settings of a write key from Gateway.schemaValidation.writeKeys,
+// a json object of settings by write key, defaulting to Gateway.schemaValidation.mode and .schemasFile.
+// Write keys are case sensitive, while config paths are not, thus their settings can't be read from Gateway.schemaValidation.<writeKey>
+func resolveSchemaValidationSettings(writeKey string) schemaValidationSettingsT {
+	settings := schemaValidationSettingsT{
+		mode:        config.GetString("Gateway.schemaValidation.mode", schemaValidationOff),
+		schemasFile: config.GetString("Gateway.schemaValidation.schemasFile", ""),
+	}
+	writeKeysSettings := config.GetString("Gateway.schemaValidation.writeKeys", "")
+	if writeKeysSettings == "" {
+		return settings
+	}
+	var byWriteKey map[string]schemaValidationWriteKeySettingsT
+	if err := json.Unmarshal([]byte(writeKeysSettings), &byWriteKey); err != nil {
+		pkgLogger.Errorf("Invalid Gateway.schemaValidation.writeKeys: %v", err)
+		return settings
+	}
+	if writeKeySettings, ok := byWriteKey[writeKey]; ok {
+		if writeKeySettings.Mode != nil {
+			settings.mode = *writeKeySettings.Mode
+		}
+		if writeKeySettings.SchemasFile != nil {
+			settings.schemasFile = *writeKeySettings.SchemasFile
+		}
+	}
+	return settings
+}
+
+// schemaViolationT is the validation errors of an invalid event of a request
+type schemaViolationT struct {
+	Index     int      `json:"index"`
+	MessageID string   `json:"messageId,omitempty"`
+	Type      string   `json:"type"`
+	Event     string   `json:"event,omitempty"`
+	Errors    []string `json:"errors"`
+}
+
+type schemaValidatorsT struct {
+	mu         sync.RWMutex
+	byWriteKey map[string]*schemavalidator.Validator
+	refresh    chan struct{}
+
+	settingsMu      sync.RWMutex
+	settingsReloads uint64                               // config reloads the settings were resolved at
+	settings        map[string]schemaValidationSettingsT // writeKey -> settings
+}
+
+func (sv *schemaValidatorsT) get(writeKey string) *schemavalidator.Validator {
+	sv.mu.RLock()
+	defer sv.mu.RUnlock()
+	return sv.byWriteKey[writeKey]
+}
+
+// settingsOf returns the schema validation settings of a write key, resolving them again only if the config has been reloaded
+// or the backend config has changed since
+func (sv *schemaValidatorsT) settingsOf(writeKey string) schemaValidationSettingsT {
+	reloads := config.Reloads()
+	sv.settingsMu.RLock()
+	settings, ok := sv.settings[writeKey]
+	ok = ok && sv.settingsReloads == reloads
+	sv.settingsMu.RUnlock()
+	if ok {
+		return settings
+	}
+
+	settings = resolveSchemaValidationSettings(writeKey)
+	sv.settingsMu.Lock()
+	defer sv.settingsMu.Unlock()
+	if sv.settings == nil || sv.settingsReloads != reloads {
+		sv.settings = make(map[string]schemaValidationSettingsT)
+		sv.settingsReloads = reloads
+	}
+	sv.settings[writeKey] = settings
+	return settings
+}
+
+// resetSettings drops the cached settings, so that the settings of write keys removed from the backend config aren't kept
+func (sv *schemaValidatorsT) resetSettings() {
+	sv.settingsMu.Lock()
+	defer sv.settingsMu.Unlock()
+	sv.settings = nil
+}
+
+// triggerRefresh makes refreshSchemaValidators reload the schemas without waiting for the refresh interval
+func (sv *schemaValidatorsT) triggerRefresh() {
+	select {
+	case sv.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// refreshSchemaValidators loads the schemas of the write keys with schema validation enabled,
+// whenever the backend config changes and every Gateway.schemaValidation.refreshInterval
+func (gateway *HandleT) refreshSchemaValidators(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-gateway.schemaValidators.refresh:
+		case <-time.After(schemaValidationRefreshInterval):
+		}
+		gateway.loadSchemaValidators()
+	}
+}
+
+// loadSchemaValidators loads the schemas of the enabled write keys with schema validation enabled.
+// Schemas are read from the schemasFile of the write key's settings if set, in the format of the event schemas json-schemas api,
+// or from the event models of the write key otherwise. The previous schemas of a write key are kept if its new ones can't be loaded
+func (gateway *HandleT) loadSchemaValidators() {
+	configSubscriberLock.RLock()
+	writeKeys := make([]string, 0, len(enabledWriteKeyWorkspaceMap))
+	for writeKey := range enabledWriteKeyWorkspaceMap {
+		writeKeys = append(writeKeys, writeKey)
+	}
+	configSubscriberLock.RUnlock()
+
+	validators := make(map[string]*schemavalidator.Validator)
+	fileValidators := make(map[string]*schemavalidator.Validator)
+	for _, writeKey := range writeKeys {
+		settings := gateway.schemaValidators.settingsOf(writeKey)
+		if settings.mode == schemaValidationOff {
+			continue
+		}
+		var (
+			schemas []byte
+			err     error
+		)
+		schemasFile := settings.schemasFile
+		switch {
+		case schemasFile != "":
+			if validator, ok := fileValidators[schemasFile]; ok {
+				validators[writeKey] = validator
+				continue
+			}
+			schemas, err = os.ReadFile(schemasFile)
+		case gateway.eventSchemaHandler != nil:
+			schemas, err = gateway.eventSchemaHandler.JsonSchemas(writeKey)
+		default:
+			gateway.logger.Warnf("Schema validation of write key %s requires a schemas file or the event schemas feature", writeKey)
+			continue
+		}
+		if err == nil && schemas == nil {
+			continue
+		}
+		var validator *schemavalidator.Validator
+		if err == nil {
+			validator, err = schemavalidator.New(schemas)
+		}
+		if err != nil {
+			gateway.logger.Errorf("Loading json schemas of write key %s: %v", writeKey, err)
+			if validator = gateway.schemaValidators.get(writeKey); validator == nil {
+				continue
+			}
+		} else if schemasFile != "" {
+			fileValidators[schemasFile] = validator
+		}
+		validators[writeKey] = validator
+	}
+
+	gateway.schemaValidators.mu.Lock()
+	gateway.schemaValidators.byWriteKey = validators
+	gateway.schemaValidators.mu.Unlock()
+}
+
+// validateEventSchemas validates the events of a request against the schemas of its write key.
+// Invalid events are returned in reject mode, while in tag mode the payload is returned with their errors added to their context
+func (gateway *HandleT) validateEventSchemas(writeKey, reqType string, payload []byte) ([]byte, []schemaViolationT) {
+	mode := gateway.schemaValidators.settingsOf(writeKey).mode
+	if mode != schemaValidationReject && mode != schemaValidationTag {
+		return payload, nil
+	}
+	validator := gateway.schemaValidators.get(writeKey)
+	if validator == nil || !gjson.ValidBytes(payload) {
+		// requests with invalid json are rejected by the user web request workers
+		return payload, nil
+	}
+
+	start := time.Now()
+	defer gateway.stats.NewTaggedStat("gateway.schema_validation_time", stats.TimerType, stats.Tags{"reqType": reqType}).Since(start)
+
+	var events []gjson.Result
+	var pathPrefixes []string
+	if reqType == "batch" || reqType == "import" {
+		gjson.GetBytes(payload, "batch").ForEach(func(key, event gjson.Result) bool {
+			events = append(events, event)
+			pathPrefixes = append(pathPrefixes, "batch."+key.String()+".")
+			return true
+		})
+	} else {
+		events = append(events, gjson.ParseBytes(payload))
+		pathPrefixes = append(pathPrefixes, "")
+	}
+
+	var violations []schemaViolationT
+	for i, event := range events {
+		eventType := reqType
+		if pathPrefixes[i] != "" {
+			eventType = event.Get("type").String()
+		}
+		field, ok := schemavalidator.Field(eventType)
+		if !ok {
+			continue
+		}
+		eventName := event.Get("event").String()
+		errors, err := validator.Validate(eventType, eventName, []byte(event.Get(field).Raw))
+		if err != nil {
+			gateway.logger.Errorf("Validating %s event %q of write key %s: %v", eventType, eventName, writeKey, err)
+			continue
+		}
+		if len(errors) == 0 {
+			continue
+		}
+		gateway.stats.NewTaggedStat("gateway.schema_validation_failed_events", stats.CountType, stats.Tags{
+			"source":    gateway.getSourceTagFromWriteKey(writeKey),
+			"writeKey":  writeKey,
+			"reqType":   reqType,
+			"eventType": eventType,
+			"mode":      mode,
+		}).Increment()
+		if mode == schemaValidationTag {
+			if tagged, err := sjson.SetBytes(payload, pathPrefixes[i]+"context."+schemaValidationErrorsKey, errors); err == nil {
+				payload = tagged
+			}
+			continue
+		}
+		violations = append(violations, schemaViolationT{
+			Index:     i,
+			MessageID: event.Get("messageId").String(),
+			Type:      eventType,
+			Event:     eventName,
+			Errors:    errors,
+		})
+	}
+	return payload, violations
+}
+
+// rejectSchemaViolations responds to a request with invalid events with a 400 and the errors of each invalid event
+func (gateway *HandleT) rejectSchemaViolations(w http.ResponseWriter, r *http.Request, violations []schemaViolationT) {
+	errorMessage := response.GetStatus(response.InvalidEventSchema)
+	atomic.AddUint64(&gateway.ackCount, 1)
+	gateway.trackRequestMetrics(errorMessage)
+	gateway.logger.Infof("IP: %s -- %s -- Response: %d, %s", misc.GetIPFromReq(r), r.URL.Path, response.GetErrorStatusCode(errorMessage), errorMessage)
+
+	body, err := json.Marshal(struct {
+		Msg        string             `json:"msg"`
+		Violations []schemaViolationT `json:"violations"`
+	}{Msg: errorMessage, Violations: violations})
+	if err != nil {
+		http.Error(w, errorMessage, response.GetErrorStatusCode(errorMessage))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(response.GetErrorStatusCode(errorMessage))
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,85 @@
+// Package schemavalidator validates events against json schemas, in the format generated by event-schema from the event models of a write key.
+package schemavalidator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// JsonSchema is the json schema of the properties, or traits, of an event type and identifier
+type JsonSchema struct {
+	Schema           json.RawMessage `json:"schema"`
+	SchemaType       string          `json:"schemaType"`
+	SchemaIdentifier string          `json:"schemaIdentifier"`
+}
+
+type schemaKey struct {
+	eventType       string
+	eventIdentifier string
+}
+
+// Validator validates events against the schemas of their event type and identifier
+type Validator struct {
+	schemas map[schemaKey]*gojsonschema.Schema
+}
+
+// New returns a validator of the json schemas, a json array of JsonSchema
+func New(jsonSchemas []byte) (*Validator, error) {
+	var schemas []JsonSchema
+	if err := json.Unmarshal(jsonSchemas, &schemas); err != nil {
+		return nil, fmt.Errorf("unmarshalling json schemas: %w", err)
+	}
+	v := &Validator{schemas: make(map[schemaKey]*gojsonschema.Schema, len(schemas))}
+	for _, s := range schemas {
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(s.Schema))
+		if err != nil {
+			return nil, fmt.Errorf("compiling json schema of %s event %q: %w", s.SchemaType, s.SchemaIdentifier, err)
+		}
+		v.schemas[schemaKey{eventType: s.SchemaType, eventIdentifier: s.SchemaIdentifier}] = schema
+	}
+	return v, nil
+}
+
+// Field returns the field of events of the type which is validated, as in event-schema's event models:
+// properties for track, page and screen events, traits for identify and group ones
+func Field(eventType string) (string, bool) {
+	switch eventType {
+	case "track", "page", "screen":
+		return "properties", true
+	case "identify", "group":
+		return "traits", true
+	default:
+		return "", false
+	}
+}
+
+// Identifier returns the identifier of the event models of events of the type, which is the event name for track events only
+func Identifier(eventType, eventName string) string {
+	if eventType == "track" {
+		return eventName
+	}
+	return ""
+}
+
+// Validate validates the field of an event, returned by Field, against the schema of its event type and name.
+// It returns the validation errors, which are empty for valid events and events without a schema
+func (v *Validator) Validate(eventType, eventName string, field []byte) ([]string, error) {
+	schema, ok := v.schemas[schemaKey{eventType: eventType, eventIdentifier: Identifier(eventType, eventName)}]
+	if !ok {
+		return nil, nil
+	}
+	if len(field) == 0 {
+		field = []byte("{}")
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(field))
+	if err != nil {
+		return nil, fmt.Errorf("validating %s event %q: %w", eventType, eventName, err)
+	}
+	var errors []string
+	for _, resultError := range result.Errors() {
+		errors = append(errors, resultError.String())
+	}
+	return errors, nil
+}
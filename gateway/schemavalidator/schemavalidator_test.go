@@ -0,0 +1,70 @@
+package schemavalidator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var jsonSchemas = []byte(`[{
+	"schema": {
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {"revenue": {"type": "number"}, "currency": {"type": "string"}},
+		"required": ["revenue"]
+	},
+	"schemaType": "track",
+	"schemaIdentifier": "Order Completed"
+}, {
+	"schema": {
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {"email": {"type": "string"}}
+	},
+	"schemaType": "identify",
+	"schemaIdentifier": ""
+}]`)
+
+func TestValidate(t *testing.T) {
+	v, err := New(jsonSchemas)
+	require.NoError(t, err)
+
+	errors, err := v.Validate("track", "Order Completed", []byte(`{"revenue": 10, "currency": "EUR"}`))
+	require.NoError(t, err)
+	require.Empty(t, errors)
+
+	errors, err = v.Validate("track", "Order Completed", []byte(`{"revenue": "10"}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"revenue: Invalid type. Expected: number, given: string"}, errors)
+
+	errors, err = v.Validate("track", "Order Completed", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"(root): revenue is required"}, errors)
+
+	errors, err = v.Validate("identify", "ignored", []byte(`{"email": 1}`))
+	require.NoError(t, err)
+	require.Len(t, errors, 1)
+
+	errors, err = v.Validate("track", "Product Viewed", []byte(`{"revenue": "10"}`))
+	require.NoError(t, err)
+	require.Empty(t, errors, "events without a schema are valid")
+
+	_, err = New([]byte(`[{"schema": {"type": 1}, "schemaType": "track", "schemaIdentifier": "e"}]`))
+	require.Error(t, err)
+
+	_, err = New([]byte(`{}`))
+	require.Error(t, err)
+}
+
+func TestField(t *testing.T) {
+	field, ok := Field("page")
+	require.True(t, ok)
+	require.Equal(t, "properties", field)
+
+	field, ok = Field("group")
+	require.True(t, ok)
+	require.Equal(t, "traits", field)
+
+	_, ok = Field("alias")
+	require.False(t, ok)
+}
@@ -322,14 +322,19 @@ func (jd *HandleT) removeTableJSONDumps() {
 func (jd *HandleT) getBackupFileUploader(ctx context.Context) (filemanager.FileManager, error) {
 	var err error
 	if jd.jobsFileUploader == nil {
-		jd.jobsFileUploader, err = filemanager.DefaultFileManagerFactory.New(&filemanager.SettingsT{
-			Provider: config.GetString("JOBS_BACKUP_STORAGE_PROVIDER", "S3"),
-			Config:   filemanager.GetProviderConfigForBackupsFromEnv(ctx),
-		})
+		jd.jobsFileUploader, err = BackupFileManager(ctx)
 	}
 	return jd.jobsFileUploader, err
 }
 
+// BackupFileManager returns a file manager for the storage backups are uploaded to
+func BackupFileManager(ctx context.Context) (filemanager.FileManager, error) {
+	return filemanager.DefaultFileManagerFactory.New(&filemanager.SettingsT{
+		Provider: config.GetString("JOBS_BACKUP_STORAGE_PROVIDER", "S3"),
+		Config:   filemanager.GetProviderConfigForBackupsFromEnv(ctx),
+	})
+}
+
 func getFailedOnlyBackupQueryFn(backupDSRange *dataSetRangeT) func(int64) string {
 	return func(offSet int64) string {
 		return fmt.Sprintf(
@@ -538,6 +543,9 @@ func (jd *HandleT) createTableDump(queryFunc func(int64) string, path string, to
 			if err != nil {
 				return fmt.Errorf("scanning row failed with error : %w", err)
 			}
+			if rawJSONRows, err = decompressBackupRow(rawJSONRows); err != nil {
+				return fmt.Errorf("decompressing row: %w", err)
+			}
 			rawJSONRows = append(rawJSONRows, '\n') // appending '\n'
 			_, err = gzWriter.Write(rawJSONRows)
 			if err != nil {
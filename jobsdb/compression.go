@@ -0,0 +1,194 @@
+package jobsdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/rudderlabs/rudder-server/services/stats"
+)
+
+// payloadCompression is the algorithm used for compressing job payloads of a dataset
+type payloadCompression string
+
+const (
+	noCompression   payloadCompression = ""
+	zstdCompression payloadCompression = "zstd"
+	lz4Compression  payloadCompression = "lz4"
+)
+
+var (
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	lz4Magic  = []byte{0x04, 0x22, 0x4d, 0x18}
+
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func parsePayloadCompression(s string) (payloadCompression, error) {
+	switch c := payloadCompression(s); c {
+	case noCompression, zstdCompression, lz4Compression:
+		return c, nil
+	default:
+		return noCompression, fmt.Errorf("unsupported payload compression %q", s)
+	}
+}
+
+// compressPayload compresses a payload using the given algorithm
+func compressPayload(c payloadCompression, payload []byte) ([]byte, error) {
+	switch c {
+	case zstdCompression:
+		return zstdEncoder.EncodeAll(payload, make([]byte, 0, len(payload)/2)), nil
+	case lz4Compression:
+		var buf bytes.Buffer
+		w := lz4.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return payload, nil
+	}
+}
+
+// decompressPayload decompresses a payload, detecting the algorithm from the frame's magic number.
+// Payloads which are not compressed, i.e. JSON documents, are returned as is.
+func decompressPayload(payload []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(payload, zstdMagic):
+		return zstdDecoder.DecodeAll(payload, nil)
+	case bytes.HasPrefix(payload, lz4Magic):
+		return io.ReadAll(lz4.NewReader(bytes.NewReader(payload)))
+	default:
+		return payload, nil
+	}
+}
+
+// decompressPayload replaces the job's payload with its decompressed version, if it is compressed
+func (job *JobT) decompressPayload() error {
+	payload, err := decompressPayload(job.EventPayload)
+	if err != nil {
+		return fmt.Errorf("decompressing payload of job %d: %w", job.JobID, err)
+	}
+	job.EventPayload = payload
+	return nil
+}
+
+// decompressBackupRow replaces a compressed event_payload of a backup row, which postgres serializes as a
+// hex encoded bytea string, with the decompressed JSON payload
+func decompressBackupRow(row []byte) ([]byte, error) {
+	result := gjson.GetBytes(row, "event_payload")
+	if result.Type != gjson.String || len(result.Str) < 2 || result.Str[:2] != `\x` {
+		return row, nil
+	}
+	payload, err := hex.DecodeString(result.Str[2:])
+	if err != nil {
+		return nil, fmt.Errorf("decoding event_payload: %w", err)
+	}
+	if payload, err = decompressPayload(payload); err != nil {
+		return nil, fmt.Errorf("decompressing event_payload: %w", err)
+	}
+	return sjson.SetRawBytes(row, "event_payload", payload)
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// isCompressedDS returns true if the dataset stores its payloads in a bytea column, i.e. it was created with payload compression enabled
+func isCompressedDS(ctx context.Context, q rowQuerier, ds dataSetT) (bool, error) {
+	var dataType string
+	err := q.QueryRowContext(ctx,
+		`select data_type from information_schema.columns where table_schema = current_schema() and table_name = $1 and column_name = 'event_payload'`,
+		ds.JobTable,
+	).Scan(&dataType)
+	if err != nil {
+		return false, fmt.Errorf("getting event_payload type of %s: %w", ds.JobTable, err)
+	}
+	return dataType == "bytea", nil
+}
+
+// compressedDSCache caches whether datasets store compressed payloads, a property which doesn't change during a dataset's lifetime
+type compressedDSCache struct {
+	mu         sync.RWMutex
+	compressed map[string]bool
+}
+
+func (c *compressedDSCache) get(ctx context.Context, q rowQuerier, ds dataSetT) (bool, error) {
+	c.mu.RLock()
+	compressed, ok := c.compressed[ds.JobTable]
+	c.mu.RUnlock()
+	if ok {
+		return compressed, nil
+	}
+	compressed, err := isCompressedDS(ctx, q, ds)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.compressed == nil {
+		c.compressed = make(map[string]bool)
+	}
+	c.compressed[ds.JobTable] = compressed
+	return compressed, nil
+}
+
+func (c *compressedDSCache) remove(ds dataSetT) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.compressed, ds.JobTable)
+}
+
+// payloadEncoder returns a function encoding payloads for storing them in a dataset: compressed with the
+// configured algorithm into compressed datasets, as JSON strings otherwise.
+// Raw and stored payload sizes are reported for compressed datasets.
+func (jd *HandleT) payloadEncoder(compressed bool) func(payload []byte) (interface{}, error) {
+	if !compressed {
+		return func(payload []byte) (interface{}, error) {
+			return string(payload), nil
+		}
+	}
+	compression := jd.payloadCompression()
+	if compression == noCompression {
+		// compression got disabled after the dataset was created, keep compressing its payloads with the default algorithm
+		compression = zstdCompression
+	}
+	rawSizeStat, storedSizeStat := jd.payloadSizeStats(compression)
+	return func(payload []byte) (interface{}, error) {
+		compressedPayload, err := compressPayload(compression, payload)
+		if err != nil {
+			return nil, err
+		}
+		rawSizeStat.Count(len(payload))
+		storedSizeStat.Count(len(compressedPayload))
+		return compressedPayload, nil
+	}
+}
+
+// payloadCompression returns the compression configured for new datasets
+func (jd *HandleT) payloadCompression() payloadCompression {
+	compression, err := parsePayloadCompression(jd.payloadCompressionSetting)
+	if err != nil {
+		jd.logger.Errorf("Ignoring payload compression: %v", err)
+	}
+	return compression
+}
+
+func (jd *HandleT) payloadSizeStats(compression payloadCompression) (raw, stored stats.Measurement) {
+	tags := stats.Tags{"customVal": jd.tablePrefix, "compression": string(compression)}
+	return stats.Default.NewTaggedStat("jobsdb.payload_raw_bytes", stats.CountType, tags),
+		stats.Default.NewTaggedStat("jobsdb.payload_stored_bytes", stats.CountType, tags)
+}
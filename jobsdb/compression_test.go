@@ -0,0 +1,58 @@
+package jobsdb
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadCompression(t *testing.T) {
+	payload := []byte(`{"batch":[{"type":"track","event":"Demo Track","messageId":"b96f3d8a-7c26-4329-9671-4e3202f42f15"}]}`)
+
+	for _, compression := range []payloadCompression{zstdCompression, lz4Compression} {
+		t.Run(string(compression), func(t *testing.T) {
+			compressed, err := compressPayload(compression, payload)
+			require.NoError(t, err)
+			require.NotEqual(t, payload, compressed)
+
+			decompressed, err := decompressPayload(compressed)
+			require.NoError(t, err)
+			require.JSONEq(t, string(payload), string(decompressed))
+		})
+	}
+
+	t.Run("uncompressed payloads are returned as is", func(t *testing.T) {
+		decompressed, err := decompressPayload(payload)
+		require.NoError(t, err)
+		require.Equal(t, payload, decompressed)
+	})
+
+	t.Run("unsupported compression", func(t *testing.T) {
+		_, err := parsePayloadCompression("gzip")
+		require.Error(t, err)
+	})
+}
+
+func TestDecompressBackupRow(t *testing.T) {
+	payload := `{"batch":[{"type":"track"}]}`
+	compressed, err := compressPayload(zstdCompression, []byte(payload))
+	require.NoError(t, err)
+	row, err := json.Marshal(map[string]interface{}{
+		"job_id":        1,
+		"event_payload": `\x` + hex.EncodeToString(compressed),
+	})
+	require.NoError(t, err)
+
+	decompressed, err := decompressBackupRow(row)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"job_id":1,"event_payload":`+payload+`}`, string(decompressed))
+
+	t.Run("uncompressed rows are returned as is", func(t *testing.T) {
+		row := []byte(`{"job_id":1,"event_payload":` + payload + `}`)
+		decompressed, err := decompressBackupRow(row)
+		require.NoError(t, err)
+		require.Equal(t, row, decompressed)
+	})
+}
@@ -788,6 +788,181 @@ func TestJobsDB(t *testing.T) {
 		require.Equal(t, numFailedJobs, len(failedResult.Jobs))
 		require.Equal(t, expectedFailedJobIDs, actualFailedJobIDs)
 	})
+
+	t.Run(`compressed payloads are read transparently and migrated into uncompressed datasets`, func(t *testing.T) {
+		t.Setenv("RSERVER_JOBS_DB_PAYLOAD_COMPRESSION", string(zstdCompression))
+		customVal := "MOCKDS"
+		triggerAddNewDS := make(chan time.Time)
+		triggerMigrateDS := make(chan time.Time)
+
+		jobDB := HandleT{
+			TriggerAddNewDS: func() <-chan time.Time {
+				return triggerAddNewDS
+			},
+			TriggerMigrateDS: func() <-chan time.Time {
+				return triggerMigrateDS
+			},
+		}
+		tablePrefix := strings.ToLower(rand.String(5))
+		err := jobDB.Setup(ReadWrite, true, tablePrefix, true, []prebackup.Handler{})
+		require.NoError(t, err)
+		defer jobDB.TearDown()
+
+		jobDB.MaxDSRetentionPeriod = time.Second
+
+		jobs := genJobs(defaultWorkspaceID, customVal, 20, 1)
+		require.NoError(t, jobDB.Store(context.Background(), jobs))
+		compressed, err := isCompressedDS(context.Background(), jobDB.dbHandle, jobDB.getDSList()[0])
+		require.NoError(t, err)
+		require.True(t, compressed)
+
+		unprocessed, err := jobDB.GetUnprocessed(context.Background(), GetQueryParamsT{CustomValFilters: []string{customVal}, JobsLimit: 100})
+		require.NoError(t, err)
+		require.Len(t, unprocessed.Jobs, 20)
+		for _, job := range unprocessed.Jobs {
+			require.JSONEq(t, string(jobs[0].EventPayload), string(job.EventPayload))
+		}
+		require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[:10], Succeeded.State), []string{customVal}, []ParameterFilterT{}))
+
+		// disable compression, the dataset created by the migration stores payloads uncompressed
+		jobDB.payloadCompressionSetting = string(noCompression)
+		time.Sleep(time.Second * 2)    // wait for some time to pass
+		triggerAddNewDS <- time.Now()  // trigger addNewDSLoop to run
+		triggerAddNewDS <- time.Now()  // Second time, waits for the first loop to finish
+		time.Sleep(time.Second * 2)    // wait for some time to pass so that retention condition satisfies
+		triggerMigrateDS <- time.Now() // trigger migrateDSLoop to run
+		triggerMigrateDS <- time.Now() // Second time, waits for the first loop to finish
+
+		jobDBInspector := HandleInspector{HandleT: &jobDB}
+		dsIndicesList := jobDBInspector.DSIndicesList()
+		require.EqualValues(t, "1_1", dsIndicesList[0])
+		compressed, err = isCompressedDS(context.Background(), jobDB.dbHandle, jobDB.getDSList()[0])
+		require.NoError(t, err)
+		require.False(t, compressed)
+
+		unprocessed, err = jobDB.GetUnprocessed(context.Background(), GetQueryParamsT{CustomValFilters: []string{customVal}, JobsLimit: 100})
+		require.NoError(t, err)
+		require.Len(t, unprocessed.Jobs, 10)
+		for _, job := range unprocessed.Jobs {
+			require.JSONEq(t, string(jobs[0].EventPayload), string(job.EventPayload))
+		}
+	})
+
+	t.Run(`aborted jobs of compressed datasets are read decompressed`, func(t *testing.T) {
+		t.Setenv("RSERVER_JOBS_DB_PAYLOAD_COMPRESSION", string(zstdCompression))
+		customVal := "MOCKDS"
+		jobDB := HandleT{}
+		tablePrefix := strings.ToLower(rand.String(5))
+		err := jobDB.Setup(ReadWrite, true, tablePrefix, true, []prebackup.Handler{})
+		require.NoError(t, err)
+		defer jobDB.TearDown()
+
+		jobs := genJobs(defaultWorkspaceID, customVal, 2, 1)
+		for _, job := range jobs {
+			job.Parameters = []byte(`{"source_id":"sourceID","destination_id":"destinationID"}`)
+		}
+		require.NoError(t, jobDB.Store(context.Background(), jobs))
+		compressed, err := isCompressedDS(context.Background(), jobDB.dbHandle, jobDB.getDSList()[0])
+		require.NoError(t, err)
+		require.True(t, compressed)
+		require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs, Aborted.State), []string{customVal}, []ParameterFilterT{}))
+
+		readonlyDB := &ReadonlyHandleT{}
+		readonlyDB.Setup(tablePrefix)
+		defer readonlyDB.TearDown()
+		aborted, err := readonlyDB.GetAbortedJobs(context.Background(), AbortedJobsParamsT{DestinationID: "destinationID"})
+		require.NoError(t, err)
+		require.Len(t, aborted, 2)
+		for _, job := range aborted {
+			require.JSONEq(t, string(jobs[0].EventPayload), string(job.EventPayload))
+		}
+	})
+
+	t.Run(`subscribers are notified about stored jobs matching their filters`, func(t *testing.T) {
+		t.Setenv("RSERVER_JOBS_DB_ENABLE_NOTIFICATIONS", "true")
+		customVal := "MOCKDS"
+		jobDB := HandleT{}
+		err := jobDB.Setup(ReadWrite, true, strings.ToLower(rand.String(5)), true, []prebackup.Handler{})
+		require.NoError(t, err)
+		defer jobDB.TearDown()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		matching, err := jobDB.Subscribe(ctx, GetQueryParamsT{CustomValFilters: []string{customVal}})
+		require.NoError(t, err)
+		other, err := jobDB.Subscribe(ctx, GetQueryParamsT{CustomValFilters: []string{"OTHER"}})
+		require.NoError(t, err)
+
+		require.NoError(t, jobDB.Store(context.Background(), genJobs(defaultWorkspaceID, customVal, 2, 1)))
+		select {
+		case <-matching:
+		case <-time.After(10 * time.Second):
+			t.Fatal("matching subscriber wasn't notified")
+		}
+		select {
+		case <-other:
+			t.Fatal("other subscriber was notified")
+		case <-time.After(time.Second):
+		}
+
+		cancel()
+		require.Eventually(t, func() bool {
+			_, ok := <-matching
+			return !ok
+		}, 5*time.Second, 10*time.Millisecond, "subscription should be closed after its context is cancelled")
+	})
+
+	t.Run(`retention purges only terminal jobs past their state's TTL`, func(t *testing.T) {
+		customVal := "MOCKDS"
+		triggerRetention := make(chan time.Time)
+
+		jobDB := HandleT{
+			TriggerRetention: func() <-chan time.Time {
+				return triggerRetention
+			},
+		}
+		WithRetentionPolicy(RetentionPolicyFunc(func(state string) time.Duration {
+			switch state {
+			case Aborted.State, Failed.State:
+				return time.Hour
+			default:
+				return 0
+			}
+		}))(&jobDB)
+		tablePrefix := strings.ToLower(rand.String(5))
+		err := jobDB.Setup(ReadWrite, true, tablePrefix, true, []prebackup.Handler{})
+		require.NoError(t, err)
+		defer jobDB.TearDown()
+
+		jobs := genJobs(defaultWorkspaceID, customVal, 40, 1)
+		require.NoError(t, jobDB.Store(context.Background(), jobs))
+		withExecTime := func(statuses []*JobStatusT, execTime time.Time) []*JobStatusT {
+			for _, status := range statuses {
+				status.ExecTime = execTime
+			}
+			return statuses
+		}
+		twoHoursAgo := time.Now().Add(-2 * time.Hour)
+		var statuses []*JobStatusT
+		statuses = append(statuses, withExecTime(genJobStatuses(jobs[:10], Aborted.State), twoHoursAgo)...)     // expired
+		statuses = append(statuses, genJobStatuses(jobs[10:20], Aborted.State)...)                              // not expired yet
+		statuses = append(statuses, withExecTime(genJobStatuses(jobs[20:30], Succeeded.State), twoHoursAgo)...) // no TTL
+		statuses = append(statuses, withExecTime(genJobStatuses(jobs[30:], Failed.State), twoHoursAgo)...)      // non-terminal
+		require.NoError(t, jobDB.UpdateJobStatus(context.Background(), statuses, []string{customVal}, []ParameterFilterT{}))
+
+		triggerRetention <- time.Now() // trigger retentionLoop to run
+		triggerRetention <- time.Now() // Second time, waits for the first loop to finish
+
+		var numJobs, numStatuses int
+		require.NoError(t, jobDB.dbHandle.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %q`, tablePrefix+`_jobs_1`)).Scan(&numJobs))
+		require.NoError(t, jobDB.dbHandle.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %q`, tablePrefix+`_job_status_1`)).Scan(&numStatuses))
+		require.Equal(t, 30, numJobs)
+		require.Equal(t, 30, numStatuses)
+
+		var minJobID int64
+		require.NoError(t, jobDB.dbHandle.QueryRow(fmt.Sprintf(`SELECT MIN(job_id) FROM %q`, tablePrefix+`_jobs_1`)).Scan(&minJobID))
+		require.EqualValues(t, jobs[10].JobID, minJobID, "only the expired aborted jobs should be purged")
+	})
 }
 
 func TestMultiTenantLegacyGetAllJobs(t *testing.T) {
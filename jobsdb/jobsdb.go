@@ -69,8 +69,11 @@ type QueryConditions struct {
 	IgnoreCustomValFiltersInQuery bool
 	CustomValFilters              []string
 	ParameterFilters              []ParameterFilterT
-	StateFilters                  []string
-	AfterJobID                    *int64
+	// jobs matching any of the ExcludedParameterFilters are left out of the results.
+	// Queries with exclusions don't update the empty results cache
+	ExcludedParameterFilters []ParameterFilterT
+	StateFilters             []string
+	AfterJobID               *int64
 }
 
 // GetQueryParamsT is a struct to hold jobsdb query params.
@@ -81,8 +84,11 @@ type GetQueryParamsT struct {
 	IgnoreCustomValFiltersInQuery bool
 	CustomValFilters              []string
 	ParameterFilters              []ParameterFilterT
-	StateFilters                  []string
-	AfterJobID                    *int64
+	// jobs matching any of the ExcludedParameterFilters are left out of the results.
+	// Queries with exclusions don't update the empty results cache
+	ExcludedParameterFilters []ParameterFilterT
+	StateFilters             []string
+	AfterJobID               *int64
 
 	// query limits
 
@@ -464,6 +470,20 @@ type HandleT struct {
 	TriggerRefreshDS func() <-chan time.Time
 	refreshDSTimeout time.Duration
 
+	// TriggerRetention is useful for triggering retentionLoop to run from tests.
+	TriggerRetention func() <-chan time.Time
+	retentionTimeout time.Duration
+	retentionPolicy  RetentionPolicy
+
+	payloadCompressionSetting string
+	compressedDS              compressedDSCache
+
+	enableNotifications bool
+	subscriptions       subscriptions
+
+	// connectionString is used for connecting to postgres, both for the dbHandle and for listening to notifications
+	connectionString string
+
 	lifecycle struct {
 		mu      sync.Mutex
 		started bool
@@ -502,6 +522,7 @@ func (jd *HandleT) registerBackUpSettings() {
 	config.RegisterDurationConfigVariable(10, &jd.maxBackupRetryTime, false, time.Minute, "JobsDB.backup.maxRetry")
 	config.RegisterDurationConfigVariable(1, &jd.refreshDSTimeout, false, time.Minute, "JobsDB.refreshDS.timeout")
 	config.RegisterDurationConfigVariable(2, &jd.migrateDSTimeout, false, time.Minute, "JobsDB.migrateDS.timeout")
+	config.RegisterDurationConfigVariable(10, &jd.retentionTimeout, false, time.Minute, "JobsDB.retention.timeout")
 
 	jd.BackupSettings.PathPrefix = strings.TrimSpace(pathPrefix)
 }
@@ -641,12 +662,14 @@ func init() {
 
 var (
 	maxDSSize, maxMigrateOnce, maxMigrateDSProbe int
+	mixedMigrationBatchSize                      int
 	maxTableSize                                 int64
 	jobDoneMigrateThres, jobStatusMigrateThres   float64
 	jobMinRowsMigrateThres                       float64
 	migrateDSLoopSleepDuration                   time.Duration
 	addNewDSLoopSleepDuration                    time.Duration
 	refreshDSListLoopSleepDuration               time.Duration
+	retentionLoopSleepDuration                   time.Duration
 	backupCheckSleepDuration                     time.Duration
 	cacheExpiration                              time.Duration
 	backupRowsBatchSize                          int64
@@ -665,10 +688,12 @@ func loadConfig() {
 	maxDSSize: Maximum size of a DS. The process which adds new DS runs in the background
 			(every few seconds) so a DS may go beyond this size
 	maxMigrateOnce: Maximum number of DSs that are migrated together into one destination
+	mixedMigrationBatchSize: Number of jobs re-encoded at once when migrating between compressed and uncompressed DSs
 	maxMigrateDSProbe: Maximum number of DSs that are checked from left to right if they are eligible for migration
 	migrateDSLoopSleepDuration: How often is the loop (which checks for migrating DS) run
 	addNewDSLoopSleepDuration: How often is the loop (which checks for adding new DS) run
 	refreshDSListLoopSleepDuration: How often is the loop (which refreshes DSList) run
+	retentionLoopSleepDuration: How often is the loop (which purges terminal jobs past their retention) run
 	maxTableSizeInMB: Maximum Table size in MB
 	*/
 	config.RegisterFloat64ConfigVariable(0.8, &jobDoneMigrateThres, true, "JobsDB.jobDoneMigrateThres")
@@ -685,6 +710,8 @@ func loadConfig() {
 	config.RegisterDurationConfigVariable(5, &refreshDSListLoopSleepDuration, true, time.Second, []string{"JobsDB.refreshDSListLoopSleepDuration", "JobsDB.refreshDSListLoopSleepDurationInS"}...)
 	config.RegisterDurationConfigVariable(5, &backupCheckSleepDuration, true, time.Second, []string{"JobsDB.backupCheckSleepDuration", "JobsDB.backupCheckSleepDurationIns"}...)
 	config.RegisterDurationConfigVariable(5, &cacheExpiration, true, time.Minute, []string{"JobsDB.cacheExpiration"}...)
+	config.RegisterDurationConfigVariable(10, &retentionLoopSleepDuration, true, time.Minute, "JobsDB.retentionLoopSleepDuration")
+	config.RegisterIntConfigVariable(10000, &mixedMigrationBatchSize, true, 1, "JobsDB.mixedMigrationBatchSize")
 }
 
 func Init2() {
@@ -720,6 +747,13 @@ func WithDSLimit(limit *int) OptsFunc {
 	}
 }
 
+// WithConnectionString sets the postgres connection string of the jobsdb, instead of the one of the global postgres settings
+func WithConnectionString(connectionString string) OptsFunc {
+	return func(jd *HandleT) {
+		jd.connectionString = connectionString
+	}
+}
+
 func NewForRead(tablePrefix string, opts ...OptsFunc) *HandleT {
 	return newOwnerType(Read, tablePrefix, opts...)
 }
@@ -792,11 +826,20 @@ func (jd *HandleT) init() {
 		}
 	}
 
+	if jd.TriggerRetention == nil {
+		jd.TriggerRetention = func() <-chan time.Time {
+			return time.After(retentionLoopSleepDuration)
+		}
+	}
+
+	if jd.connectionString == "" {
+		jd.connectionString = misc.GetConnectionString()
+	}
+
 	// Initialize dbHandle if not already set
 	if jd.dbHandle == nil {
 		var err error
-		psqlInfo := misc.GetConnectionString()
-		sqlDB, err := sql.Open("postgres", psqlInfo)
+		sqlDB, err := sql.Open("postgres", jd.connectionString)
 		jd.assertError(err)
 
 		defer func() {
@@ -808,11 +851,11 @@ func (jd *HandleT) init() {
 			maxOpenConnections += jd.maxReaders + jd.maxWriters
 			switch jd.ownerType {
 			case Read:
-				maxOpenConnections += 3 // backup, migrate, refreshDsList
+				maxOpenConnections += 4 // backup, migrate, refreshDsList, retention
 			case Write:
 				maxOpenConnections += 1 // addNewDS
 			case ReadWrite:
-				maxOpenConnections += 4 // backup, migrate, addNewDS, archive
+				maxOpenConnections += 5 // backup, migrate, addNewDS, archive, retention
 			}
 			if maxOpenConnections < jd.maxOpenConnections {
 				sqlDB.SetMaxOpenConns(maxOpenConnections)
@@ -839,6 +882,9 @@ func (jd *HandleT) workersAndAuxSetup() {
 	}
 	jd.BackupSettings = &backupSettings{}
 	jd.registerBackUpSettings()
+	if jd.retentionPolicy == nil {
+		jd.retentionPolicy = configRetentionPolicy{tablePrefix: jd.tablePrefix}
+	}
 
 	jd.logger.Infof("Connected to %s DB", jd.tablePrefix)
 	jd.statPreDropTableCount = stats.Default.NewTaggedStat("jobsdb.pre_drop_tables_count", stats.GaugeType, stats.Tags{"customVal": jd.tablePrefix})
@@ -869,6 +915,10 @@ func (jd *HandleT) workersAndAuxSetup() {
 	config.RegisterDurationConfigVariable(0, &jd.MinDSRetentionPeriod, true, time.Minute, minDSRetentionPeriodKeys...)
 	maxDSRetentionPeriodKeys := []string{"JobsDB." + jd.tablePrefix + "." + "maxDSRetention", "JobsDB." + "maxDSRetention"}
 	config.RegisterDurationConfigVariable(90, &jd.MaxDSRetentionPeriod, true, time.Minute, maxDSRetentionPeriodKeys...)
+	enableNotificationsKeys := []string{"JobsDB." + jd.tablePrefix + "." + "enableNotifications", "JobsDB." + "enableNotifications"}
+	config.RegisterBoolConfigVariable(false, &jd.enableNotifications, false, enableNotificationsKeys...)
+	payloadCompressionKeys := []string{"JobsDB." + jd.tablePrefix + "." + "payloadCompression", "JobsDB." + "payloadCompression"}
+	config.RegisterStringConfigVariable(string(noCompression), &jd.payloadCompressionSetting, true, payloadCompressionKeys...)
 }
 
 // Start starts the jobsdb worker and housekeeping (migration, archive) threads.
@@ -952,6 +1002,7 @@ func (jd *HandleT) readerSetup(ctx context.Context, l lock.LockToken) {
 
 	jd.startBackupDSLoop(ctx)
 	jd.startMigrateDSLoop(ctx)
+	jd.startRetentionLoop(ctx)
 
 	g.Go(misc.WithBugsnag(func() error {
 		runArchiver(ctx, jd.tablePrefix, jd.dbHandle)
@@ -984,6 +1035,7 @@ func (jd *HandleT) readerWriterSetup(ctx context.Context, l lock.LockToken) {
 
 	jd.startBackupDSLoop(ctx)
 	jd.startMigrateDSLoop(ctx)
+	jd.startRetentionLoop(ctx)
 
 	jd.backgroundGroup.Go(misc.WithBugsnag(func() error {
 		runArchiver(ctx, jd.tablePrefix, jd.dbHandle)
@@ -1016,6 +1068,7 @@ func (jd *HandleT) TearDown() {
 //
 //	Stop should be called before Close.
 func (jd *HandleT) Close() {
+	jd.closeSubscriptions()
 	_ = jd.dbHandle.Close()
 }
 
@@ -1424,6 +1477,12 @@ func (jd *HandleT) createDSInTx(tx *Tx, newDS dataSetT) error {
 		return err
 	}
 
+	// Payloads of new datasets are stored compressed in a bytea column, if payload compression is enabled
+	payloadType := "JSONB"
+	if jd.payloadCompression() != noCompression {
+		payloadType = "BYTEA"
+	}
+
 	// Create the jobs and job_status tables
 	sqlStatement := fmt.Sprintf(`CREATE TABLE %q (
                                       job_id BIGSERIAL PRIMARY KEY,
@@ -1432,10 +1491,10 @@ func (jd *HandleT) createDSInTx(tx *Tx, newDS dataSetT) error {
 									  user_id TEXT NOT NULL,
 									  parameters JSONB NOT NULL,
                                       custom_val VARCHAR(64) NOT NULL,
-                                      event_payload JSONB NOT NULL,
+                                      event_payload %s NOT NULL,
 									  event_count INTEGER NOT NULL DEFAULT 1,
                                       created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-                                      expire_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW());`, newDS.JobTable)
+                                      expire_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW());`, newDS.JobTable, payloadType)
 
 	_, err = tx.ExecContext(context.TODO(), sqlStatement)
 	if err != nil {
@@ -1601,6 +1660,7 @@ func (jd *HandleT) dropDSForRecovery(ds dataSetT) {
 func (jd *HandleT) postDropDs(ds dataSetT) {
 	// Bursting Cache for this dataset
 	jd.invalidateCache(ds)
+	jd.compressedDS.remove(ds)
 
 	// Tracking time interval between drop ds operations. Hence calling end before start
 	if jd.isStatDropDSPeriodInitialized {
@@ -1767,6 +1827,18 @@ func (jd *HandleT) migrateJobsInTx(ctx context.Context, tx *Tx, srcDS, destDS da
 	queryStat.Start()
 	defer queryStat.End()
 
+	srcCompressed, err := isCompressedDS(ctx, tx, srcDS)
+	if err != nil {
+		return 0, err
+	}
+	destCompressed, err := isCompressedDS(ctx, tx, destDS)
+	if err != nil {
+		return 0, err
+	}
+	if srcCompressed != destCompressed {
+		return jd.migrateMixedJobsInTx(ctx, tx, srcDS, destDS, destCompressed)
+	}
+
 	compactDSQuery := fmt.Sprintf(
 		`with last_status as 
 		(
@@ -1790,7 +1862,7 @@ func (jd *HandleT) migrateJobsInTx(ctx context.Context, tx *Tx, srcDS, destDS da
 	)
 
 	var numJobsMigrated int64
-	err := tx.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		compactDSQuery,
 	).Scan(&numJobsMigrated)
@@ -1800,6 +1872,76 @@ func (jd *HandleT) migrateJobsInTx(ctx context.Context, tx *Tx, srcDS, destDS da
 	return int(numJobsMigrated), nil
 }
 
+// migrateMixedJobsInTx migrates non-terminal jobs between datasets storing their payloads differently, i.e. one of them compressed.
+// Payloads are re-encoded for the destination dataset, in batches of mixedMigrationBatchSize jobs.
+func (jd *HandleT) migrateMixedJobsInTx(ctx context.Context, tx *Tx, srcDS, destDS dataSetT, destCompressed bool) (int, error) {
+	encodePayload := jd.payloadEncoder(destCompressed)
+	selectJobsQuery := fmt.Sprintf(
+		`with last_status as
+		(
+			select * from %[1]q where id in (select max(id) from %[1]q group by job_id)
+		)
+		select j.job_id, j.uuid, j.user_id, j.custom_val, j.parameters, j.event_payload, j.event_count, j.created_at, j.expire_at, j.workspace_id
+			from %[2]q j left join last_status js on js.job_id = j.job_id
+			where (js.job_id is null or js.job_state = ANY('{%[3]s}')) and j.job_id > $1 order by j.job_id limit $2`,
+		srcDS.JobStatusTable,
+		srcDS.JobTable,
+		strings.Join(validNonTerminalStates, ","),
+	)
+	selectJobs := func(afterJobID int64) ([]*JobT, error) {
+		rows, err := tx.QueryContext(ctx, selectJobsQuery, afterJobID, mixedMigrationBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rows.Close() }()
+		var jobs []*JobT
+		for rows.Next() {
+			var job JobT
+			if err := rows.Scan(&job.JobID, &job.UUID, &job.UserID, &job.CustomVal, &job.Parameters, &job.EventPayload,
+				&job.EventCount, &job.CreatedAt, &job.ExpireAt, &job.WorkspaceId); err != nil {
+				return nil, err
+			}
+			if err := job.decompressPayload(); err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, &job)
+		}
+		return jobs, rows.Err()
+	}
+
+	var numJobsMigrated int
+	var afterJobID int64
+	for {
+		jobs, err := selectJobs(afterJobID)
+		if err != nil {
+			return 0, err
+		}
+		if len(jobs) == 0 {
+			break
+		}
+		if err := jd.copyJobsDSInTx(tx, destDS, jobs, encodePayload); err != nil {
+			return 0, err
+		}
+		numJobsMigrated += len(jobs)
+		afterJobID = jobs[len(jobs)-1].JobID
+	}
+
+	migrateStatusesQuery := fmt.Sprintf(
+		`with last_status as
+		(
+			select * from %[1]q where id in (select max(id) from %[1]q group by job_id)
+		)
+		insert into %[2]q (select * from last_status where job_state = ANY('{%[3]s}'))`,
+		srcDS.JobStatusTable,
+		destDS.JobStatusTable,
+		strings.Join(validNonTerminalStates, ","),
+	)
+	if _, err := tx.ExecContext(ctx, migrateStatusesQuery); err != nil {
+		return 0, err
+	}
+	return numJobsMigrated, nil
+}
+
 func (jd *HandleT) postMigrateHandleDS(tx *Tx, migrateFrom []dataSetT) error {
 	// Rename datasets before dropping them, so that they can be uploaded to s3
 	for _, ds := range migrateFrom {
@@ -1827,7 +1969,10 @@ func (jd *HandleT) internalStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSet
 		jd.clearCache(ds, jobList)
 	})
 
-	return jd.doStoreJobsInTx(ctx, tx, ds, jobList)
+	if err := jd.doStoreJobsInTx(ctx, tx, ds, jobList); err != nil {
+		return err
+	}
+	return jd.notifyStoredJobsInTx(ctx, tx, jobList)
 }
 
 /*
@@ -1842,7 +1987,11 @@ func (jd *HandleT) copyJobsDS(tx *Tx, ds dataSetT, jobList []*JobT) error { // W
 	tx.AddSuccessListener(func() {
 		jd.clearCache(ds, jobList)
 	})
-	return jd.copyJobsDSInTx(tx, ds, jobList)
+	compressed, err := jd.compressedDS.get(context.TODO(), tx, ds)
+	if err != nil {
+		return err
+	}
+	return jd.copyJobsDSInTx(tx, ds, jobList, jd.payloadEncoder(compressed))
 }
 
 func (jd *HandleT) WithStoreSafeTx(ctx context.Context, f func(tx StoreSafeTx) error) error {
@@ -1960,6 +2109,7 @@ func (jd *HandleT) internalStoreWithRetryEachInTx(ctx context.Context, tx *Tx, d
 	errorMessagesMap = make(map[uuid.UUID]string)
 
 	var txErr error
+	var storedJobs []*JobT
 	for _, job := range jobList {
 
 		if txErr != nil { // stop trying treat all remaining as failed
@@ -1983,8 +2133,18 @@ func (jd *HandleT) internalStoreWithRetryEachInTx(ctx context.Context, tx *Tx, d
 			errorMessagesMap[job.UUID] = err.Error()
 			// rollback to savepoint
 			_, txErr = tx.ExecContext(ctx, rollbackSql)
+			continue
+		}
+		storedJobs = append(storedJobs, job)
+	}
+	if txErr == nil {
+		// a failed notification shouldn't fail the stored jobs
+		if _, txErr = tx.ExecContext(ctx, savepointSql); txErr == nil {
+			if err := jd.notifyStoredJobsInTx(ctx, tx, storedJobs); err != nil {
+				jd.logger.Errorf("Failed to notify about stored jobs: %v", err)
+				_, _ = tx.ExecContext(ctx, rollbackSql)
+			}
 		}
-
 	}
 
 	return
@@ -2116,7 +2276,7 @@ func (jd *HandleT) GetPileUpCounts(ctx context.Context) (map[string]map[string]i
 	return statMap, nil
 }
 
-func (*HandleT) copyJobsDSInTx(txHandler transactionHandler, ds dataSetT, jobList []*JobT) error {
+func (*HandleT) copyJobsDSInTx(txHandler transactionHandler, ds dataSetT, jobList []*JobT, encodePayload func([]byte) (interface{}, error)) error {
 	var stmt *sql.Stmt
 	var err error
 
@@ -2135,8 +2295,12 @@ func (*HandleT) copyJobsDSInTx(txHandler transactionHandler, ds dataSetT, jobLis
 			eventCount = job.EventCount
 		}
 
+		payload, err := encodePayload(job.EventPayload)
+		if err != nil {
+			return err
+		}
 		_, err = stmt.Exec(job.JobID, job.UUID, job.UserID, job.CustomVal, string(job.Parameters),
-			string(job.EventPayload), eventCount, job.CreatedAt, job.ExpireAt, job.WorkspaceId)
+			payload, eventCount, job.CreatedAt, job.ExpireAt, job.WorkspaceId)
 
 		if err != nil {
 			return err
@@ -2155,6 +2319,11 @@ func (*HandleT) copyJobsDSInTx(txHandler transactionHandler, ds dataSetT, jobLis
 }
 
 func (jd *HandleT) doStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, jobList []*JobT) error {
+	compressed, err := jd.compressedDS.get(ctx, tx, ds)
+	if err != nil {
+		return err
+	}
+	encodePayload := jd.payloadEncoder(compressed)
 	store := func() error {
 		var stmt *sql.Stmt
 		var err error
@@ -2171,7 +2340,11 @@ func (jd *HandleT) doStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, job
 				eventCount = job.EventCount
 			}
 
-			if _, err = stmt.ExecContext(ctx, job.UUID, job.UserID, job.CustomVal, string(job.Parameters), string(job.EventPayload), eventCount, job.WorkspaceId); err != nil {
+			payload, err := encodePayload(job.EventPayload)
+			if err != nil {
+				return err
+			}
+			if _, err = stmt.ExecContext(ctx, job.UUID, job.UserID, job.CustomVal, string(job.Parameters), payload, eventCount, job.WorkspaceId); err != nil {
 				return err
 			}
 		}
@@ -2191,7 +2364,7 @@ func (jd *HandleT) doStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, job
 	if _, err := tx.ExecContext(ctx, savepointSql); err != nil {
 		return err
 	}
-	err := store()
+	err = store()
 
 	var e *pq.Error
 	if err != nil && errors.As(err, &e) {
@@ -2212,6 +2385,10 @@ func (jd *HandleT) doStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, job
 }
 
 func (jd *HandleT) storeJob(ctx context.Context, tx *Tx, ds dataSetT, job *JobT) (err error) {
+	compressed, err := jd.compressedDS.get(ctx, tx, ds)
+	if err != nil {
+		return err
+	}
 	sqlStatement := fmt.Sprintf(`INSERT INTO %q (uuid, user_id, custom_val, parameters, event_payload, workspace_id)
 		VALUES ($1, $2, $3, $4, $5, $6) RETURNING job_id`, ds.JobTable)
 	stmt, err := tx.PrepareContext(ctx, sqlStatement)
@@ -2220,7 +2397,11 @@ func (jd *HandleT) storeJob(ctx context.Context, tx *Tx, ds dataSetT, job *JobT)
 	}
 	defer func() { _ = stmt.Close() }()
 	job.sanitizeJson()
-	_, err = stmt.ExecContext(ctx, job.UUID, job.UserID, job.CustomVal, string(job.Parameters), string(job.EventPayload), job.WorkspaceId)
+	payload, err := jd.payloadEncoder(compressed)(job.EventPayload)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, job.UUID, job.UserID, job.CustomVal, string(job.Parameters), payload, job.WorkspaceId)
 	if err == nil {
 		tx.AddSuccessListener(func() {
 			// Empty customValFilters means we want to clear for all
@@ -2466,7 +2647,7 @@ func (jd *HandleT) getProcessedJobsDS(ctx context.Context, ds dataSetT, params G
 	start := time.Now()
 	defer jd.getTimerStat("processed_ds_time", &tags).Since(start)
 
-	skipCacheResult := params.AfterJobID != nil
+	skipCacheResult := params.AfterJobID != nil || len(params.ExcludedParameterFilters) > 0
 	if !skipCacheResult {
 		// We don't reset this in case of error for now, as any error in this function causes panic
 		jd.markClearEmptyResult(ds, allWorkspaces, stateFilters, customValFilters, parameterFilters, willTryToSet, nil)
@@ -2490,6 +2671,10 @@ func (jd *HandleT) getProcessedJobsDS(ctx context.Context, ds dataSetT, params G
 		filterConditions = append(filterConditions, constructParameterJSONQuery("jobs", parameterFilters))
 	}
 
+	if len(params.ExcludedParameterFilters) > 0 {
+		filterConditions = append(filterConditions, constructParameterExclusionQuery("jobs", params.ExcludedParameterFilters))
+	}
+
 	filterQuery := strings.Join(filterConditions, " AND ")
 	if filterQuery != "" {
 		filterQuery = " AND " + filterQuery
@@ -2573,6 +2758,9 @@ func (jd *HandleT) getProcessedJobsDS(ctx context.Context, ds dataSetT, params G
 		if err != nil {
 			return JobsResult{}, false, err
 		}
+		if err := job.decompressPayload(); err != nil {
+			return JobsResult{}, false, err
+		}
 
 		if params.EventsLimit > 0 && runningEventCount > params.EventsLimit && len(jobList) > 0 {
 			// events limit overflow is triggered as long as we have read at least one job
@@ -2634,7 +2822,7 @@ func (jd *HandleT) getUnprocessedJobsDS(ctx context.Context, ds dataSetT, params
 	start := time.Now()
 	defer jd.getTimerStat("unprocessed_ds_time", &tags).Since(start)
 
-	skipCacheResult := params.AfterJobID != nil
+	skipCacheResult := params.AfterJobID != nil || len(params.ExcludedParameterFilters) > 0
 	if !skipCacheResult {
 		// We don't reset this in case of error for now, as any error in this function causes panic
 		jd.markClearEmptyResult(ds, allWorkspaces, []string{NotProcessed.State}, customValFilters, parameterFilters, willTryToSet, nil)
@@ -2664,6 +2852,9 @@ func (jd *HandleT) getUnprocessedJobsDS(ctx context.Context, ds dataSetT, params
 	if len(parameterFilters) > 0 {
 		sqlStatement += " AND " + constructParameterJSONQuery("jobs", parameterFilters)
 	}
+	if len(params.ExcludedParameterFilters) > 0 {
+		sqlStatement += " AND " + constructParameterExclusionQuery("jobs", params.ExcludedParameterFilters)
+	}
 	sqlStatement += " ORDER BY jobs.job_id"
 	if params.JobsLimit > 0 {
 		sqlStatement += fmt.Sprintf(" LIMIT $%d", len(args)+1)
@@ -2713,6 +2904,9 @@ func (jd *HandleT) getUnprocessedJobsDS(ctx context.Context, ds dataSetT, params
 		if err != nil {
 			return JobsResult{}, false, err
 		}
+		if err := job.decompressPayload(); err != nil {
+			return JobsResult{}, false, err
+		}
 		if params.EventsLimit > 0 && runningEventCount > params.EventsLimit && len(jobList) > 0 {
 			// events limit overflow is triggered as long as we have read at least one job
 			limitsReached = true
@@ -4052,6 +4246,7 @@ func (jd *HandleT) GetLastJob() *JobT {
 	if err != nil && err != sql.ErrNoRows {
 		jd.assertError(err)
 	}
+	jd.assertError(job.decompressPayload())
 	return &job
 }
 
@@ -30,6 +30,7 @@ import (
 	"github.com/rudderlabs/rudder-server/services/archiver"
 	"github.com/rudderlabs/rudder-server/testhelper/destination"
 	rsRand "github.com/rudderlabs/rudder-server/testhelper/rand"
+	"github.com/rudderlabs/rudder-server/utils/bytesize"
 	"github.com/rudderlabs/rudder-server/utils/logger"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 )
@@ -1179,3 +1180,65 @@ func initJobsDB() {
 	Init3()
 	archiver.Init()
 }
+
+func TestExcludedParameterFiltersQueryParam(t *testing.T) {
+	_ = startPostgres(t)
+	customVal := "CUSTOMVAL"
+	generateJobs := func(numOfJob int, destinationID string) []*JobT {
+		js := make([]*JobT, numOfJob)
+		for i := 0; i < numOfJob; i++ {
+			js[i] = &JobT{
+				Parameters:   []byte(fmt.Sprintf(`{"batch_id":1,"source_id":"sourceID","destination_id":"%s"}`, destinationID)),
+				EventPayload: []byte(`{"testKey":"testValue"}`),
+				UserID:       "a-292e-4e79-9880-f8009e0ae4a3",
+				UUID:         uuid.Must(uuid.NewV4()),
+				CustomVal:    customVal,
+				EventCount:   1,
+				WorkspaceId:  defaultWorkspaceID,
+			}
+		}
+		return js
+	}
+
+	t.Run("get unprocessed", func(t *testing.T) {
+		prefix := strings.ToLower(rsRand.String(5))
+		jobsDB := NewForReadWrite(prefix)
+		require.NoError(t, jobsDB.Start())
+		defer jobsDB.TearDown()
+		require.NoError(t, jobsDB.Store(context.Background(), append(generateJobs(2, "paused"), generateJobs(1, "active")...)))
+
+		unprocessed, err := jobsDB.getUnprocessed(context.Background(), GetQueryParamsT{CustomValFilters: []string{customVal}, ExcludedParameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "paused"}}, JobsLimit: 1})
+		require.NoError(t, err)
+		require.Len(t, unprocessed.Jobs, 1)
+		require.JSONEq(t, `{"batch_id":1,"source_id":"sourceID","destination_id":"active"}`, string(unprocessed.Jobs[0].Parameters))
+
+		unprocessed, err = jobsDB.getUnprocessed(context.Background(), GetQueryParamsT{CustomValFilters: []string{customVal}, ExcludedParameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "paused"}, {Name: "destination_id", Value: "active"}}, JobsLimit: 100})
+		require.NoError(t, err)
+		require.Empty(t, unprocessed.Jobs)
+
+		unprocessed, err = jobsDB.getUnprocessed(context.Background(), GetQueryParamsT{CustomValFilters: []string{customVal}, JobsLimit: 100})
+		require.NoError(t, err)
+		require.Len(t, unprocessed.Jobs, 3, "excluding every job must not mark the dataset as empty in the cache")
+	})
+
+	t.Run("get all jobs", func(t *testing.T) {
+		maxDSSize := 10
+		jobsDB := MultiTenantHandleT{HandleT: &HandleT{MaxDSSize: &maxDSSize}}
+		require.NoError(t, jobsDB.Setup(ReadWrite, false, strings.ToLower(rsRand.String(5)), true, []prebackup.Handler{}))
+		defer jobsDB.TearDown()
+		require.NoError(t, jobsDB.Store(context.Background(), append(generateJobs(2, "paused"), generateJobs(1, "active")...)))
+
+		jobs, err := jobsDB.GetAllJobs(context.Background(), map[string]int{defaultWorkspaceID: 1}, GetQueryParamsT{CustomValFilters: []string{customVal}, ExcludedParameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "paused"}}, JobsLimit: 1, PayloadSizeLimit: 100 * bytesize.MB}, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, jobs.Jobs, 1)
+		require.JSONEq(t, `{"batch_id":1,"source_id":"sourceID","destination_id":"active"}`, string(jobs.Jobs[0].Parameters))
+
+		jobs, err = jobsDB.GetAllJobs(context.Background(), map[string]int{defaultWorkspaceID: 100}, GetQueryParamsT{CustomValFilters: []string{customVal}, ExcludedParameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "paused"}, {Name: "destination_id", Value: "active"}}, JobsLimit: 100, PayloadSizeLimit: 100 * bytesize.MB}, 10, nil)
+		require.NoError(t, err)
+		require.Empty(t, jobs.Jobs)
+
+		jobs, err = jobsDB.GetAllJobs(context.Background(), map[string]int{defaultWorkspaceID: 100}, GetQueryParamsT{CustomValFilters: []string{customVal}, JobsLimit: 100, PayloadSizeLimit: 100 * bytesize.MB}, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, jobs.Jobs, 3, "excluding every job must not mark the dataset as empty in the cache")
+	})
+}
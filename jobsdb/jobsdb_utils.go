@@ -169,6 +169,16 @@ func constructParameterJSONQuery(table string, parameterFilters []ParameterFilte
 	return fmt.Sprintf(`(%s.parameters @> '{%s}' %s)`, table, strings.Join(allKeyValues, ","), opQuery)
 }
 
+// constructParameterExclusionQuery constructs and returns a query excluding jobs with any of the parameter values
+func constructParameterExclusionQuery(table string, parameterFilters []ParameterFilterT) string {
+	// eg. NOT (jobs.parameters @> '{"destination_id":"<destination_id_1>"}' OR jobs.parameters @> '{"destination_id":"<destination_id_2>"}')
+	conditions := make([]string, 0, len(parameterFilters))
+	for _, parameter := range parameterFilters {
+		conditions = append(conditions, fmt.Sprintf(`%s.parameters @> '{%q:%q}'`, table, parameter.Name, parameter.Value))
+	}
+	return fmt.Sprintf(`NOT (%s)`, strings.Join(conditions, " OR "))
+}
+
 // Admin Handlers
 type JobsdbUtilsHandler struct{}
 
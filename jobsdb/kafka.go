@@ -0,0 +1,668 @@
+package jobsdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+	"github.com/segmentio/kafka-go"
+	"github.com/tidwall/gjson"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/services/stats"
+	"github.com/rudderlabs/rudder-server/services/streammanager/kafka/client"
+	"github.com/rudderlabs/rudder-server/utils/bytesize"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// kafkaPartition is the single partition of the topics backing kafka jobsdbs
+const kafkaPartition = 0
+
+var errKafkaNotSupported = errors.New("operation not supported by kafka backed jobsdb")
+
+// KafkaConfig configures the topic backing a kafka jobsdb
+type KafkaConfig struct {
+	Client *client.Client
+	// Topic needs to exist and have a single partition, so that jobs are read in the order they are stored
+	Topic string
+}
+
+// KafkaHandleT is a JobsDB storing jobs in a kafka topic, while tracking job statuses in postgres.
+//
+// Job ids are derived from the offsets of the messages jobs are stored in (offset + 1), thus jobs are
+// read in the order they were stored. Jobs are published as soon as they are stored, even when storing
+// in a transaction, therefore a job might be stored more than once if its store transaction fails.
+// Jobs are dropped according to the topic's retention, regardless of their statuses.
+type KafkaHandleT struct {
+	tablePrefix string
+	topic       string
+	client      *client.Client
+	producer    *client.Producer
+	dbHandle    *sql.DB
+	logger      logger.Logger
+
+	fetchMaxBytes   int
+	cleanupInterval time.Duration
+
+	// unprocessedFrom is the id of the first job which might not have a status yet
+	unprocessedFromMu sync.Mutex
+	unprocessedFrom   int64
+
+	lifecycle struct {
+		mu      sync.Mutex
+		started bool
+	}
+	backgroundCancel context.CancelFunc
+	backgroundGroup  *errgroup.Group
+}
+
+// NewKafka returns a jobsdb storing jobs in the topic of conf. Start needs to be called before using it
+func NewKafka(tablePrefix string, conf KafkaConfig) *KafkaHandleT {
+	jd := &KafkaHandleT{
+		tablePrefix: tablePrefix,
+		topic:       conf.Topic,
+		client:      conf.Client,
+		logger:      pkgLogger.Child(tablePrefix).Child("kafka"),
+	}
+	config.RegisterIntConfigVariable(int(10*bytesize.MB), &jd.fetchMaxBytes, true, 1, "JobsDB."+tablePrefix+".kafka.fetchMaxBytes", "JobsDB.kafka.fetchMaxBytes")
+	config.RegisterDurationConfigVariable(5, &jd.cleanupInterval, true, time.Minute, "JobsDB."+tablePrefix+".kafka.cleanupInterval", "JobsDB.kafka.cleanupInterval")
+	return jd
+}
+
+func (jd *KafkaHandleT) statusTable() string {
+	return jd.tablePrefix + "_kafka_job_status"
+}
+
+// Start verifies the topic, creates the job status table if needed and starts the cleanup of statuses of dropped jobs
+func (jd *KafkaHandleT) Start() error {
+	jd.lifecycle.mu.Lock()
+	defer jd.lifecycle.mu.Unlock()
+	if jd.lifecycle.started {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if jd.dbHandle == nil {
+		db, err := sql.Open("postgres", misc.GetConnectionString())
+		if err != nil {
+			return fmt.Errorf("opening database: %w", err)
+		}
+		if err := db.PingContext(ctx); err != nil {
+			_ = db.Close()
+			return fmt.Errorf("pinging database: %w", err)
+		}
+		jd.dbHandle = db
+	}
+
+	partitions, err := jd.client.Partitions(ctx, jd.topic)
+	if err != nil {
+		return err
+	}
+	if len(partitions) != 1 {
+		return fmt.Errorf("topic %q needs to have a single partition, found %d", jd.topic, len(partitions))
+	}
+
+	if _, err := jd.dbHandle.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q (
+		id BIGSERIAL PRIMARY KEY,
+		job_id BIGINT NOT NULL,
+		job_state VARCHAR(64),
+		attempt SMALLINT,
+		exec_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		retry_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		error_code VARCHAR(32),
+		error_response JSONB DEFAULT '{}'::JSONB,
+		parameters JSONB DEFAULT '{}'::JSONB)`, jd.statusTable())); err != nil {
+		return fmt.Errorf("creating job status table: %w", err)
+	}
+	if _, err := jd.dbHandle.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %q ON %q (job_id, id)`, "idx_"+jd.statusTable()+"_job_id", jd.statusTable())); err != nil {
+		return fmt.Errorf("creating job status index: %w", err)
+	}
+
+	if jd.producer, err = jd.client.NewProducer(jd.topic, client.ProducerConfig{}); err != nil {
+		return fmt.Errorf("creating producer: %w", err)
+	}
+	if err := jd.resetUnprocessedFrom(ctx); err != nil {
+		return err
+	}
+
+	backgroundCtx, backgroundCancel := context.WithCancel(context.Background())
+	g, backgroundCtx := errgroup.WithContext(backgroundCtx)
+	jd.backgroundCancel = backgroundCancel
+	jd.backgroundGroup = g
+	g.Go(misc.WithBugsnag(func() error {
+		jd.cleanupLoop(backgroundCtx)
+		return nil
+	}))
+
+	jd.lifecycle.started = true
+	return nil
+}
+
+// Stop stops the background cleanup and closes the producer
+func (jd *KafkaHandleT) Stop() {
+	jd.lifecycle.mu.Lock()
+	defer jd.lifecycle.mu.Unlock()
+	if !jd.lifecycle.started {
+		return
+	}
+	jd.backgroundCancel()
+	_ = jd.backgroundGroup.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := jd.producer.Close(ctx); err != nil {
+		jd.logger.Warnf("Closing producer: %v", err)
+	}
+	jd.lifecycle.started = false
+}
+
+// Close closes the database connection
+func (jd *KafkaHandleT) Close() {
+	if jd.dbHandle != nil {
+		_ = jd.dbHandle.Close()
+	}
+}
+
+// TearDown stops and closes the jobsdb
+func (jd *KafkaHandleT) TearDown() {
+	jd.Stop()
+	jd.Close()
+}
+
+func (jd *KafkaHandleT) Identifier() string {
+	return jd.tablePrefix
+}
+
+func (jd *KafkaHandleT) WithTx(f func(tx *Tx) error) error {
+	sqltx, err := jd.dbHandle.Begin()
+	if err != nil {
+		return err
+	}
+	tx := &Tx{Tx: sqltx}
+	err = f(tx)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w; %s", err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// WithStoreSafeTx starts a postgres transaction, which jobs don't take part in, since they are published to kafka
+func (jd *KafkaHandleT) WithStoreSafeTx(_ context.Context, f func(tx StoreSafeTx) error) error {
+	return jd.WithTx(func(tx *Tx) error { return f(&storeSafeTx{tx: tx, identity: jd.tablePrefix}) })
+}
+
+func (jd *KafkaHandleT) WithUpdateSafeTx(_ context.Context, f func(tx UpdateSafeTx) error) error {
+	return jd.WithTx(func(tx *Tx) error { return f(&updateSafeTx{tx: tx, identity: jd.tablePrefix}) })
+}
+
+// kafkaJob is the message value of a job stored in kafka
+type kafkaJob struct {
+	UUID         uuid.UUID       `json:"uuid"`
+	UserID       string          `json:"user_id"`
+	CustomVal    string          `json:"custom_val"`
+	Parameters   json.RawMessage `json:"parameters"`
+	EventPayload json.RawMessage `json:"event_payload"`
+	EventCount   int             `json:"event_count"`
+	WorkspaceID  string          `json:"workspace_id"`
+}
+
+func (jd *KafkaHandleT) Store(ctx context.Context, jobList []*JobT) error {
+	return jd.publish(ctx, jobList)
+}
+
+// StoreInTx publishes the jobs right away, i.e. before the transaction is committed
+func (jd *KafkaHandleT) StoreInTx(ctx context.Context, _ StoreSafeTx, jobList []*JobT) error {
+	return jd.publish(ctx, jobList)
+}
+
+func (jd *KafkaHandleT) StoreWithRetryEach(ctx context.Context, jobList []*JobT) map[uuid.UUID]string {
+	res, _ := jd.StoreWithRetryEachInTx(ctx, nil, jobList)
+	return res
+}
+
+// StoreWithRetryEachInTx publishes the jobs right away, i.e. before the transaction is committed,
+// returning the uuids of the jobs which failed to be published
+func (jd *KafkaHandleT) StoreWithRetryEachInTx(ctx context.Context, _ StoreSafeTx, jobList []*JobT) (map[uuid.UUID]string, error) {
+	err := jd.publish(ctx, jobList)
+	if err == nil {
+		return nil, nil
+	}
+	failed := make(map[uuid.UUID]string)
+	var writeErrors kafka.WriteErrors
+	if errors.As(err, &writeErrors) && len(writeErrors) == len(jobList) {
+		for i := range writeErrors {
+			if writeErrors[i] != nil {
+				failed[jobList[i].UUID] = writeErrors[i].Error()
+			}
+		}
+		return failed, nil
+	}
+	for _, job := range jobList {
+		failed[job.UUID] = err.Error()
+	}
+	return failed, nil
+}
+
+func (jd *KafkaHandleT) publish(ctx context.Context, jobList []*JobT) error {
+	if len(jobList) == 0 {
+		return nil
+	}
+	start := time.Now()
+	defer jd.timerStat("store_jobs").Since(start)
+
+	messages := make([]client.Message, len(jobList))
+	for i, job := range jobList {
+		job.sanitizeJson()
+		value, err := json.Marshal(kafkaJob{
+			UUID:         job.UUID,
+			UserID:       job.UserID,
+			CustomVal:    job.CustomVal,
+			Parameters:   job.Parameters,
+			EventPayload: job.EventPayload,
+			EventCount:   job.EventCount,
+			WorkspaceID:  job.WorkspaceId,
+		})
+		if err != nil {
+			return fmt.Errorf("marshalling job %s: %w", job.UUID, err)
+		}
+		messages[i] = client.Message{Value: value}
+	}
+	return jd.producer.Publish(ctx, messages...)
+}
+
+func (jd *KafkaHandleT) UpdateJobStatus(ctx context.Context, statusList []*JobStatusT, customValFilters []string, parameterFilters []ParameterFilterT) error {
+	return jd.WithUpdateSafeTx(ctx, func(tx UpdateSafeTx) error {
+		return jd.UpdateJobStatusInTx(ctx, tx, statusList, customValFilters, parameterFilters)
+	})
+}
+
+func (jd *KafkaHandleT) UpdateJobStatusInTx(ctx context.Context, tx UpdateSafeTx, statusList []*JobStatusT, _ []string, _ []ParameterFilterT) error {
+	if len(statusList) == 0 {
+		return nil
+	}
+	start := time.Now()
+	defer jd.timerStat("update_job_status").Since(start)
+
+	stmt, err := tx.SqlTx().PrepareContext(ctx, pq.CopyIn(jd.statusTable(), "job_id", "job_state", "attempt", "exec_time",
+		"retry_time", "error_code", "error_response", "parameters"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	jobIDs := make([]int64, len(statusList))
+	for i, status := range statusList {
+		status.sanitizeJson()
+		if !utf8.ValidString(string(status.ErrorResponse)) {
+			status.ErrorResponse = []byte(`{}`)
+		}
+		if _, err = stmt.ExecContext(ctx, status.JobID, status.JobState, status.AttemptNum, status.ExecTime,
+			status.RetryTime, status.ErrorCode, string(status.ErrorResponse), string(status.Parameters)); err != nil {
+			return err
+		}
+		jobIDs[i] = status.JobID
+	}
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+	tx.Tx().AddSuccessListener(func() {
+		jd.advanceUnprocessedFrom(jobIDs)
+	})
+	return nil
+}
+
+// GetUnprocessed reads jobs without a status from the topic, starting from the first job which might not have a status
+func (jd *KafkaHandleT) GetUnprocessed(ctx context.Context, params GetQueryParamsT) (JobsResult, error) {
+	if params.JobsLimit <= 0 {
+		return JobsResult{}, nil
+	}
+	start := time.Now()
+	defer jd.timerStat("unprocessed_jobs").Since(start)
+
+	first, last, err := jd.client.PartitionOffsets(ctx, jd.topic, kafkaPartition)
+	if err != nil {
+		return JobsResult{}, err
+	}
+	offset := jd.getUnprocessedFrom() - 1
+	if offset < first {
+		jd.logger.Warnf("Jobs %d to %d have been dropped by the topic's retention before being processed", offset+1, first)
+		stats.Default.NewTaggedStat("jobsdb.kafka_dropped_unprocessed_jobs", stats.CountType, stats.Tags{"customVal": jd.tablePrefix}).Count(int(first - offset))
+		offset = first
+	}
+
+	var result JobsResult
+	for offset < last && !result.LimitsReached {
+		messages, err := jd.client.Fetch(ctx, jd.topic, kafkaPartition, offset, jd.fetchMaxBytes)
+		if err != nil {
+			return JobsResult{}, err
+		}
+		if len(messages) == 0 {
+			break
+		}
+		withStatus, err := jd.jobsWithStatus(ctx, messages[0].Offset+1, messages[len(messages)-1].Offset+1)
+		if err != nil {
+			return JobsResult{}, err
+		}
+		for _, msg := range messages {
+			if _, ok := withStatus[msg.Offset+1]; ok {
+				offset = msg.Offset + 1
+				continue
+			}
+			job, err := decodeKafkaJob(msg)
+			if err != nil {
+				return JobsResult{}, err
+			}
+			if !job.matches(params) {
+				offset = msg.Offset + 1
+				continue
+			}
+			if result.add(job, params) {
+				break
+			}
+			offset = msg.Offset + 1
+		}
+	}
+	return result, nil
+}
+
+func (jd *KafkaHandleT) GetProcessed(ctx context.Context, params GetQueryParamsT) (JobsResult, error) {
+	return jd.getProcessed(ctx, params)
+}
+
+func (jd *KafkaHandleT) GetToRetry(ctx context.Context, params GetQueryParamsT) (JobsResult, error) {
+	params.StateFilters = []string{Failed.State}
+	return jd.getProcessed(ctx, params)
+}
+
+func (jd *KafkaHandleT) GetWaiting(ctx context.Context, params GetQueryParamsT) (JobsResult, error) {
+	params.StateFilters = []string{Waiting.State}
+	return jd.getProcessed(ctx, params)
+}
+
+func (jd *KafkaHandleT) GetExecuting(ctx context.Context, params GetQueryParamsT) (JobsResult, error) {
+	params.StateFilters = []string{Executing.State}
+	return jd.getProcessed(ctx, params)
+}
+
+func (jd *KafkaHandleT) GetImporting(ctx context.Context, params GetQueryParamsT) (JobsResult, error) {
+	params.StateFilters = []string{Importing.State}
+	return jd.getProcessed(ctx, params)
+}
+
+// getProcessed returns the jobs whose last status is in one of the state filters, reading their statuses
+// from postgres and their payloads from the topic
+func (jd *KafkaHandleT) getProcessed(ctx context.Context, params GetQueryParamsT) (JobsResult, error) {
+	if params.JobsLimit <= 0 {
+		return JobsResult{}, nil
+	}
+	start := time.Now()
+	defer jd.timerStat("processed_jobs").Since(start)
+
+	first, _, err := jd.client.PartitionOffsets(ctx, jd.topic, kafkaPartition)
+	if err != nil {
+		return JobsResult{}, err
+	}
+	// only the latest status of each job counts, which is looked up through the (job_id, id) index so that
+	// postgres can stop reading statuses once the jobs limit is reached. Like in postgres jobsdbs, jobs are
+	// returned once their retry time has passed, while custom val and parameter filters are applied to the
+	// jobs read from the topic.
+	rows, err := jd.dbHandle.QueryContext(ctx, fmt.Sprintf(`SELECT job_id, job_state, attempt, exec_time, retry_time, error_code, error_response, parameters
+		FROM %[1]q s WHERE job_id > $1 AND job_state = ANY($2) AND retry_time < $3
+		AND NOT EXISTS (SELECT 1 FROM %[1]q later WHERE later.job_id = s.job_id AND later.id > s.id)
+		ORDER BY job_id LIMIT $4`, jd.statusTable()), first, pq.Array(params.StateFilters), time.Now(), params.JobsLimit)
+	if err != nil {
+		return JobsResult{}, err
+	}
+	defer func() { _ = rows.Close() }()
+	var statuses []JobStatusT
+	for rows.Next() {
+		var status JobStatusT
+		if err := rows.Scan(&status.JobID, &status.JobState, &status.AttemptNum, &status.ExecTime, &status.RetryTime,
+			&status.ErrorCode, &status.ErrorResponse, &status.Parameters); err != nil {
+			return JobsResult{}, err
+		}
+		statuses = append(statuses, status)
+	}
+	if err := rows.Err(); err != nil {
+		return JobsResult{}, err
+	}
+
+	var result JobsResult
+	for i := 0; i < len(statuses) && !result.LimitsReached; {
+		messages, err := jd.client.Fetch(ctx, jd.topic, kafkaPartition, statuses[i].JobID-1, jd.fetchMaxBytes)
+		if err != nil {
+			return JobsResult{}, err
+		}
+		if len(messages) == 0 {
+			break
+		}
+		lastJobID := messages[len(messages)-1].Offset + 1
+		for _, msg := range messages {
+			// skipping statuses of jobs missing from the topic
+			for i < len(statuses) && statuses[i].JobID < msg.Offset+1 {
+				i++
+			}
+			if i == len(statuses) || result.LimitsReached {
+				break
+			}
+			if msg.Offset+1 != statuses[i].JobID {
+				continue
+			}
+			job, err := decodeKafkaJob(msg)
+			if err != nil {
+				return JobsResult{}, err
+			}
+			job.LastJobStatus = statuses[i]
+			i++
+			if job.matches(params) {
+				result.add(job, params)
+			}
+		}
+		// skipping statuses of jobs not found in the fetched range
+		for i < len(statuses) && statuses[i].JobID <= lastJobID {
+			i++
+		}
+	}
+	return result, nil
+}
+
+// add appends the job to the result, unless adding it would overflow the events or payload size limits.
+// Returns true if any of the limits is reached
+func (r *JobsResult) add(job *JobT, params GetQueryParamsT) bool {
+	if len(r.Jobs) > 0 &&
+		(params.EventsLimit > 0 && r.EventsCount+job.EventCount > params.EventsLimit ||
+			params.PayloadSizeLimit > 0 && r.PayloadSize+job.PayloadSize > params.PayloadSizeLimit) {
+		r.LimitsReached = true
+		return true
+	}
+	r.Jobs = append(r.Jobs, job)
+	r.EventsCount += job.EventCount
+	r.PayloadSize += job.PayloadSize
+	r.LimitsReached = len(r.Jobs) == params.JobsLimit ||
+		params.EventsLimit > 0 && r.EventsCount >= params.EventsLimit ||
+		params.PayloadSizeLimit > 0 && r.PayloadSize >= params.PayloadSizeLimit
+	return r.LimitsReached
+}
+
+// matches returns true if the job matches the custom val and parameter filters of params
+func (job *JobT) matches(params GetQueryParamsT) bool {
+	if len(params.CustomValFilters) > 0 && !misc.Contains(params.CustomValFilters, job.CustomVal) {
+		return false
+	}
+	optionalMatch, optionalMissing := true, true
+	for _, filter := range params.ParameterFilters {
+		value := gjson.GetBytes(job.Parameters, filter.Name)
+		if !filter.Optional {
+			if value.String() != filter.Value {
+				return false
+			}
+			continue
+		}
+		optionalMatch = optionalMatch && value.String() == filter.Value
+		optionalMissing = optionalMissing && !value.Exists()
+	}
+	return optionalMatch || optionalMissing
+}
+
+func decodeKafkaJob(msg client.Message) (*JobT, error) {
+	var kj kafkaJob
+	if err := json.Unmarshal(msg.Value, &kj); err != nil {
+		return nil, fmt.Errorf("unmarshalling job at offset %d: %w", msg.Offset, err)
+	}
+	return &JobT{
+		UUID:         kj.UUID,
+		JobID:        msg.Offset + 1,
+		UserID:       kj.UserID,
+		CreatedAt:    msg.Timestamp,
+		ExpireAt:     msg.Timestamp,
+		CustomVal:    kj.CustomVal,
+		EventCount:   kj.EventCount,
+		EventPayload: kj.EventPayload,
+		PayloadSize:  int64(len(kj.EventPayload)),
+		Parameters:   kj.Parameters,
+		WorkspaceId:  kj.WorkspaceID,
+	}, nil
+}
+
+// jobsWithStatus returns the ids of the jobs in [from, to] which have at least one status
+func (jd *KafkaHandleT) jobsWithStatus(ctx context.Context, from, to int64) (map[int64]struct{}, error) {
+	rows, err := jd.dbHandle.QueryContext(ctx, fmt.Sprintf(`SELECT DISTINCT job_id FROM %q WHERE job_id BETWEEN $1 AND $2`, jd.statusTable()), from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	jobIDs := make(map[int64]struct{})
+	for rows.Next() {
+		var jobID int64
+		if err := rows.Scan(&jobID); err != nil {
+			return nil, err
+		}
+		jobIDs[jobID] = struct{}{}
+	}
+	return jobIDs, rows.Err()
+}
+
+func (jd *KafkaHandleT) getUnprocessedFrom() int64 {
+	jd.unprocessedFromMu.Lock()
+	defer jd.unprocessedFromMu.Unlock()
+	return jd.unprocessedFrom
+}
+
+// advanceUnprocessedFrom moves unprocessedFrom past the consecutive jobs which got a status
+func (jd *KafkaHandleT) advanceUnprocessedFrom(jobIDs []int64) {
+	sort.Slice(jobIDs, func(i, j int) bool { return jobIDs[i] < jobIDs[j] })
+	jd.unprocessedFromMu.Lock()
+	defer jd.unprocessedFromMu.Unlock()
+	for _, jobID := range jobIDs {
+		if jobID == jd.unprocessedFrom {
+			jd.unprocessedFrom++
+		}
+	}
+}
+
+// resetUnprocessedFrom sets unprocessedFrom to the first job, among the ones still in the topic, without a status
+func (jd *KafkaHandleT) resetUnprocessedFrom(ctx context.Context) error {
+	first, _, err := jd.client.PartitionOffsets(ctx, jd.topic, kafkaPartition)
+	if err != nil {
+		return err
+	}
+	// job ids up to first (the last dropped job) are treated as if they had a status
+	var unprocessedFrom int64
+	if err := jd.dbHandle.QueryRowContext(ctx, fmt.Sprintf(`SELECT MIN(job_id) + 1 FROM (
+			SELECT job_id FROM %[1]q WHERE job_id > $1 UNION ALL SELECT $1
+		) s WHERE NOT EXISTS (SELECT 1 FROM %[1]q n WHERE n.job_id = s.job_id + 1)`, jd.statusTable()), first).Scan(&unprocessedFrom); err != nil {
+		return fmt.Errorf("getting first unprocessed job: %w", err)
+	}
+	jd.unprocessedFromMu.Lock()
+	defer jd.unprocessedFromMu.Unlock()
+	jd.unprocessedFrom = unprocessedFrom
+	return nil
+}
+
+// DeleteExecuting deletes executing statuses, so that their jobs are read again as unprocessed
+func (jd *KafkaHandleT) DeleteExecuting() {
+	ctx := context.Background()
+	if _, err := jd.dbHandle.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %[1]q WHERE id IN (
+			SELECT id FROM (SELECT DISTINCT ON (job_id) id, job_state FROM %[1]q ORDER BY job_id, id DESC) s WHERE job_state = $1
+		)`, jd.statusTable()), Executing.State); err != nil {
+		panic(fmt.Errorf("deleting executing statuses: %w", err))
+	}
+	if err := jd.resetUnprocessedFrom(ctx); err != nil {
+		panic(err)
+	}
+}
+
+func (jd *KafkaHandleT) cleanupLoop(ctx context.Context) {
+	for {
+		select {
+		case <-time.After(jd.cleanupInterval):
+		case <-ctx.Done():
+			return
+		}
+		if err := jd.cleanupStatuses(ctx); err != nil && ctx.Err() == nil {
+			jd.logger.Errorf("Failed to clean up statuses of dropped jobs: %v", err)
+		}
+	}
+}
+
+// cleanupStatuses deletes the statuses of jobs which have been dropped by the topic's retention
+func (jd *KafkaHandleT) cleanupStatuses(ctx context.Context) error {
+	first, _, err := jd.client.PartitionOffsets(ctx, jd.topic, kafkaPartition)
+	if err != nil {
+		return err
+	}
+	_, err = jd.dbHandle.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %q WHERE job_id <= $1`, jd.statusTable()), first)
+	return err
+}
+
+// GetPileUpCounts is not supported, since pile up counts are only tracked for router jobsdbs
+func (*KafkaHandleT) GetPileUpCounts(context.Context) (map[string]map[string]int, error) {
+	return nil, errKafkaNotSupported
+}
+
+func (jd *KafkaHandleT) Status() interface{} {
+	return map[string]interface{}{
+		"topic":            jd.topic,
+		"unprocessed-from": jd.getUnprocessedFrom(),
+	}
+}
+
+// Ping checks the connectivity to both postgres and kafka
+func (jd *KafkaHandleT) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := jd.dbHandle.PingContext(ctx); err != nil {
+		return err
+	}
+	return jd.client.Ping(ctx)
+}
+
+// Kafka backed jobsdbs don't keep a journal, since they have no datasets to migrate or backup
+
+func (*KafkaHandleT) GetJournalEntries(string) []JournalEntryT {
+	return nil
+}
+
+func (*KafkaHandleT) JournalDeleteEntry(int64) {}
+
+func (*KafkaHandleT) JournalMarkStart(string, json.RawMessage) int64 {
+	return 0
+}
+
+func (jd *KafkaHandleT) timerStat(name string) stats.Measurement {
+	return stats.Default.NewTaggedStat("jobsdb.kafka_"+name+"_time", stats.TimerType, stats.Tags{"customVal": jd.tablePrefix})
+}
@@ -0,0 +1,177 @@
+package jobsdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/services/streammanager/kafka/client"
+	"github.com/rudderlabs/rudder-server/services/streammanager/kafka/client/testutil"
+	"github.com/rudderlabs/rudder-server/testhelper/destination"
+	"github.com/rudderlabs/rudder-server/testhelper/rand"
+)
+
+func TestKafkaJobMatches(t *testing.T) {
+	job := &JobT{CustomVal: "GW", Parameters: []byte(`{"source_id":"s1","destination_id":"d1"}`)}
+
+	require.True(t, job.matches(GetQueryParamsT{}))
+	require.True(t, job.matches(GetQueryParamsT{CustomValFilters: []string{"GW"}}))
+	require.False(t, job.matches(GetQueryParamsT{CustomValFilters: []string{"RT"}}))
+	require.True(t, job.matches(GetQueryParamsT{ParameterFilters: []ParameterFilterT{{Name: "source_id", Value: "s1"}}}))
+	require.False(t, job.matches(GetQueryParamsT{ParameterFilters: []ParameterFilterT{{Name: "source_id", Value: "s2"}}}))
+	require.False(t, job.matches(GetQueryParamsT{ParameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "d2", Optional: true}}}))
+
+	jobWithoutDestination := &JobT{Parameters: []byte(`{"source_id":"s1"}`)}
+	require.True(t, jobWithoutDestination.matches(GetQueryParamsT{ParameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "d2", Optional: true}}}))
+}
+
+func TestKafkaJobsResultLimits(t *testing.T) {
+	job := func(events int) *JobT { return &JobT{EventCount: events, PayloadSize: 10} }
+
+	t.Run("jobs limit", func(t *testing.T) {
+		var r JobsResult
+		params := GetQueryParamsT{JobsLimit: 2}
+		require.False(t, r.add(job(1), params))
+		require.True(t, r.add(job(1), params))
+		require.Len(t, r.Jobs, 2)
+	})
+
+	t.Run("events limit doesn't overflow, unless for the first job", func(t *testing.T) {
+		var r JobsResult
+		params := GetQueryParamsT{JobsLimit: 10, EventsLimit: 3}
+		require.False(t, r.add(job(2), params))
+		require.True(t, r.add(job(2), params))
+		require.Len(t, r.Jobs, 1)
+
+		r = JobsResult{}
+		require.True(t, r.add(job(5), params))
+		require.Len(t, r.Jobs, 1)
+	})
+
+	t.Run("payload size limit", func(t *testing.T) {
+		var r JobsResult
+		params := GetQueryParamsT{JobsLimit: 10, PayloadSizeLimit: 20}
+		require.False(t, r.add(job(1), params))
+		require.True(t, r.add(job(1), params))
+		require.Len(t, r.Jobs, 2)
+		require.EqualValues(t, 20, r.PayloadSize)
+	})
+}
+
+func TestKafkaJobsDB(t *testing.T) {
+	_ = startPostgres(t)
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	kafkaContainer, err := destination.SetupKafka(pool, t)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	kafkaHost := fmt.Sprintf("localhost:%s", kafkaContainer.Port)
+	c, err := client.New("tcp", []string{kafkaHost}, client.Config{})
+	require.NoError(t, err)
+	topic := "jobs-" + strings.ToLower(rand.String(5))
+	tc := testutil.New("tcp", kafkaHost)
+	require.Eventually(t, func() bool {
+		return tc.CreateTopic(ctx, topic, 1, 1) == nil
+	}, time.Minute, time.Second)
+
+	newJobsDB := func() *KafkaHandleT {
+		jd := NewKafka("gw", KafkaConfig{Client: c, Topic: topic})
+		require.NoError(t, jd.Start())
+		t.Cleanup(jd.TearDown)
+		return jd
+	}
+	writer := newJobsDB()
+	reader := newJobsDB()
+
+	customVal := "GW"
+	jobs := genJobs(defaultWorkspaceID, customVal, 5, 1)
+	require.NoError(t, writer.WithStoreSafeTx(ctx, func(tx StoreSafeTx) error {
+		failed, err := writer.StoreWithRetryEachInTx(ctx, tx, jobs[:3])
+		require.Empty(t, failed)
+		return err
+	}))
+	require.NoError(t, writer.Store(ctx, jobs[3:]))
+
+	params := GetQueryParamsT{CustomValFilters: []string{customVal}, JobsLimit: 3}
+	unprocessed, err := reader.GetUnprocessed(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, unprocessed.Jobs, 3)
+	require.True(t, unprocessed.LimitsReached)
+	for i, job := range unprocessed.Jobs {
+		require.EqualValues(t, i+1, job.JobID)
+		require.Equal(t, jobs[i].UUID, job.UUID)
+		require.JSONEq(t, string(jobs[i].EventPayload), string(job.EventPayload))
+	}
+
+	t.Run("jobs with a status are not unprocessed", func(t *testing.T) {
+		require.NoError(t, reader.UpdateJobStatus(ctx, genJobStatuses(unprocessed.Jobs, Executing.State), []string{customVal}, nil))
+		res, err := reader.GetUnprocessed(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 2)
+		require.EqualValues(t, 4, res.Jobs[0].JobID)
+
+		executing, err := reader.GetExecuting(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, executing.Jobs, 3)
+		require.Equal(t, Executing.State, executing.Jobs[0].LastJobStatus.JobState)
+	})
+
+	t.Run("deleting executing statuses makes jobs unprocessed again", func(t *testing.T) {
+		reader.DeleteExecuting()
+		res, err := reader.GetUnprocessed(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 3)
+		require.EqualValues(t, 1, res.Jobs[0].JobID)
+	})
+
+	t.Run("a new jobsdb resumes from the first unprocessed job", func(t *testing.T) {
+		require.NoError(t, reader.WithUpdateSafeTx(ctx, func(tx UpdateSafeTx) error {
+			return reader.UpdateJobStatusInTx(ctx, tx, genJobStatuses(unprocessed.Jobs, Succeeded.State), []string{customVal}, nil)
+		}))
+		require.EqualValues(t, 4, reader.getUnprocessedFrom())
+
+		res, err := newJobsDB().GetUnprocessed(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 2)
+		require.EqualValues(t, 4, res.Jobs[0].JobID)
+		require.Equal(t, jobs[3].UUID, res.Jobs[0].UUID)
+	})
+
+	t.Run("failed jobs are retried once their retry time has passed, up to the jobs limit", func(t *testing.T) {
+		remaining, err := reader.GetUnprocessed(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, remaining.Jobs, 2)
+		statuses := genJobStatuses(remaining.Jobs, Failed.State)
+		statuses[0].RetryTime = time.Now().Add(time.Hour)
+		require.NoError(t, reader.UpdateJobStatus(ctx, statuses, []string{customVal}, nil))
+
+		toRetry, err := reader.GetToRetry(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, toRetry.Jobs, 1)
+		require.EqualValues(t, 5, toRetry.Jobs[0].JobID)
+
+		statuses = genJobStatuses(remaining.Jobs[:1], Failed.State)
+		require.NoError(t, reader.UpdateJobStatus(ctx, statuses, []string{customVal}, nil))
+		toRetry, err = reader.GetToRetry(ctx, GetQueryParamsT{CustomValFilters: []string{customVal}, JobsLimit: 1})
+		require.NoError(t, err)
+		require.Len(t, toRetry.Jobs, 1)
+		require.EqualValues(t, 4, toRetry.Jobs[0].JobID)
+		require.Equal(t, 1, toRetry.Jobs[0].LastJobStatus.AttemptNum)
+	})
+
+	t.Run("topics with multiple partitions are rejected", func(t *testing.T) {
+		multiPartitionTopic := topic + "-multi"
+		require.Eventually(t, func() bool {
+			return tc.CreateTopic(ctx, multiPartitionTopic, 2, 1) == nil
+		}, time.Minute, time.Second)
+		jd := NewKafka("gw", KafkaConfig{Client: c, Topic: multiPartitionTopic})
+		defer jd.Close()
+		require.Error(t, jd.Start())
+	})
+}
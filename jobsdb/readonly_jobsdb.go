@@ -32,6 +32,16 @@ type ReadonlyJobsDB interface {
 	GetDSListString() (string, error)
 	GetJobIDStatus(job_id, prefix string) (string, error)
 	GetJobByID(job_id, prefix string) (string, error)
+	GetAbortedJobs(ctx context.Context, params AbortedJobsParamsT) ([]*JobT, error)
+}
+
+// AbortedJobsParamsT selects the aborted jobs returned by GetAbortedJobs
+type AbortedJobsParamsT struct {
+	DestinationID    string
+	ErrorCode        string    // only jobs aborted with this error code, if not empty
+	ExcludeErrorCode string    // no jobs aborted with this error code, if not empty
+	From, To         time.Time // only jobs aborted at or after From and before To, if they are not zero
+	Limit            int       // maximum number of jobs returned, no limit if zero
 }
 
 type ReadonlyHandleT struct {
@@ -234,9 +244,20 @@ func (jd *ReadonlyHandleT) getUnprocessedJobsDSCount(ctx context.Context, ds dat
 		return 0, err
 	}
 
+	// batches of compressed payloads cannot be inspected by postgres, their event counts are summed up instead
+	compressed, err := isCompressedDS(ctx, txn, ds)
+	if err != nil {
+		if rollbackErr := txn.Rollback(); rollbackErr != nil {
+			jd.logger.Warnf("Unable to rollback after error: %v", rollbackErr)
+		}
+		return 0, err
+	}
+
 	var selectColumn string
-	if jd.tablePrefix == "gw" {
+	if jd.tablePrefix == "gw" && !compressed {
 		selectColumn = "event_payload->'batch' as batch"
+	} else if jd.tablePrefix == "gw" {
+		selectColumn = "SUM(event_count)"
 	} else {
 		selectColumn = "COUNT(*)"
 	}
@@ -251,7 +272,7 @@ func (jd *ReadonlyHandleT) getUnprocessedJobsDSCount(ctx context.Context, ds dat
 		sqlStatement += " AND " + constructParameterJSONQuery(ds.JobTable, parameterFilters)
 	}
 
-	if jd.tablePrefix == "gw" {
+	if jd.tablePrefix == "gw" && !compressed {
 		sqlStatement = fmt.Sprintf("select sum(jsonb_array_length(batch)) from (%s) t", sqlStatement)
 	}
 
@@ -386,9 +407,20 @@ func (jd *ReadonlyHandleT) getProcessedJobsDSCount(ctx context.Context, ds dataS
 		return 0, err
 	}
 
+	// batches of compressed payloads cannot be inspected by postgres, their event counts are summed up instead
+	compressed, err := isCompressedDS(ctx, txn, ds)
+	if err != nil {
+		if rollbackErr := txn.Rollback(); rollbackErr != nil {
+			jd.logger.Warnf("Unable to rollback after error: %v", rollbackErr)
+		}
+		return 0, err
+	}
+
 	var selectColumn string
-	if jd.tablePrefix == "gw" {
+	if jd.tablePrefix == "gw" && !compressed {
 		selectColumn = fmt.Sprintf("%[1]s.event_payload->'batch' as batch", ds.JobTable)
+	} else if jd.tablePrefix == "gw" {
+		selectColumn = fmt.Sprintf("SUM(%[1]s.event_count)", ds.JobTable)
 	} else {
 		selectColumn = fmt.Sprintf("COUNT(%[1]s.job_id)", ds.JobTable)
 	}
@@ -402,7 +434,7 @@ func (jd *ReadonlyHandleT) getProcessedJobsDSCount(ctx context.Context, ds dataS
                                              AND job_latest_state.retry_time < $1`,
 		ds.JobTable, ds.JobStatusTable, stateQuery, customValQuery, sourceQuery, selectColumn)
 
-	if jd.tablePrefix == "gw" {
+	if jd.tablePrefix == "gw" && !compressed {
 		sqlStatement = fmt.Sprintf("select sum(jsonb_array_length(batch)) from (%s) t", sqlStatement)
 	}
 
@@ -654,6 +686,9 @@ func (jd *ReadonlyHandleT) GetJobByID(job_id, _ string) (string, error) {
 				return "", err1
 			}
 		}
+		if err = event.decompressPayload(); err != nil {
+			return "", err
+		}
 		response, err = json.MarshalIndent(event, "", " ")
 		if err != nil {
 			return "", err
@@ -794,6 +829,61 @@ func (jd *ReadonlyHandleT) GetFailedStatusErrorCodeCountsByDestination(args []st
 	return string(response), nil
 }
 
+// GetAbortedJobs returns the jobs of a destination whose latest status is aborted, ordered by job id
+func (jd *ReadonlyHandleT) GetAbortedJobs(ctx context.Context, params AbortedJobsParamsT) ([]*JobT, error) {
+	var from, to sql.NullTime
+	if !params.From.IsZero() {
+		from = sql.NullTime{Time: params.From, Valid: true}
+	}
+	if !params.To.IsZero() {
+		to = sql.NullTime{Time: params.To, Valid: true}
+	}
+	jobs := make([]*JobT, 0)
+	for _, ds := range jd.getDSList() {
+		if params.Limit > 0 && len(jobs) >= params.Limit {
+			break
+		}
+		sqlStatement := fmt.Sprintf(`SELECT j.job_id, j.uuid, j.user_id, j.custom_val, j.parameters, j.event_payload, j.event_count, j.created_at, j.expire_at, j.workspace_id
+					FROM %[1]q j INNER JOIN
+					(SELECT DISTINCT ON (job_id) job_id, job_state, exec_time, error_code FROM %[2]q ORDER BY job_id, id DESC) AS job_latest_state
+					ON j.job_id = job_latest_state.job_id
+					WHERE job_latest_state.job_state = 'aborted' AND j.parameters->>'destination_id' = $1
+					AND ($2 = '' OR job_latest_state.error_code = $2)
+					AND ($3::timestamptz IS NULL OR job_latest_state.exec_time >= $3)
+					AND ($4::timestamptz IS NULL OR job_latest_state.exec_time < $4)
+					AND ($5 = '' OR job_latest_state.error_code IS DISTINCT FROM $5)
+					ORDER BY j.job_id`, ds.JobTable, ds.JobStatusTable)
+		args := []interface{}{params.DestinationID, params.ErrorCode, from, to, params.ExcludeErrorCode}
+		if params.Limit > 0 {
+			sqlStatement += ` LIMIT $6`
+			args = append(args, params.Limit-len(jobs))
+		}
+		rows, err := jd.DbHandle.QueryContext(ctx, sqlStatement, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var job JobT
+			if err := rows.Scan(&job.JobID, &job.UUID, &job.UserID, &job.CustomVal, &job.Parameters, &job.EventPayload,
+				&job.EventCount, &job.CreatedAt, &job.ExpireAt, &job.WorkspaceId); err != nil {
+				_ = rows.Close()
+				return nil, err
+			}
+			if err := job.decompressPayload(); err != nil {
+				_ = rows.Close()
+				return nil, err
+			}
+			jobs = append(jobs, &job)
+		}
+		if err := rows.Err(); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		_ = rows.Close()
+	}
+	return jobs, nil
+}
+
 func (jd *ReadonlyHandleT) GetDSListString() (string, error) {
 	var response string
 	dsList := jd.getDSList()
@@ -0,0 +1,310 @@
+package jobsdb
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/lock"
+	"github.com/rudderlabs/rudder-server/services/filemanager"
+)
+
+// RestoreRequest describes the backups to be restored
+type RestoreRequest struct {
+	// TablePrefix is the table prefix of the jobsdb the backups were taken from
+	TablePrefix string
+	// Prefix is the location of the backups in the file manager, e.g. <pathPrefix>/<instanceID>
+	Prefix string
+	// From and To limit the restored jobs to the ones created between them, while statuses set after To are not restored.
+	// A zero To restores all statuses
+	From, To time.Time
+}
+
+// backupDump is a jobs table dump along with the dump of its status table
+type backupDump struct {
+	jobsKey, statusKey string
+	minJobID           int64
+	minCreatedAt       time.Time
+	maxCreatedAt       time.Time
+}
+
+type backupJobRow struct {
+	JobID        int64           `json:"job_id"`
+	WorkspaceID  string          `json:"workspace_id"`
+	UUID         uuid.UUID       `json:"uuid"`
+	UserID       string          `json:"user_id"`
+	Parameters   json.RawMessage `json:"parameters"`
+	CustomVal    string          `json:"custom_val"`
+	EventPayload json.RawMessage `json:"event_payload"`
+	EventCount   int             `json:"event_count"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ExpireAt     time.Time       `json:"expire_at"`
+}
+
+type backupStatusRow struct {
+	JobID         int64           `json:"job_id"`
+	JobState      string          `json:"job_state"`
+	Attempt       int             `json:"attempt"`
+	ExecTime      time.Time       `json:"exec_time"`
+	RetryTime     time.Time       `json:"retry_time"`
+	ErrorCode     string          `json:"error_code"`
+	ErrorResponse json.RawMessage `json:"error_response"`
+	Parameters    json.RawMessage `json:"parameters"`
+}
+
+// Restore recreates, into jd, the datasets of the jobs table backups found under the request's prefix which contain
+// jobs created in the requested time range, along with the statuses of the restored jobs. Job ids are preserved,
+// thus jd needs to be empty. Failed-only backups cannot be restored, since they don't contain complete datasets.
+func (jd *HandleT) Restore(ctx context.Context, fm filemanager.FileManager, req RestoreRequest) error {
+	dumps, err := listBackupDumps(ctx, fm, req)
+	if err != nil {
+		return err
+	}
+	if len(dumps) == 0 {
+		jd.logger.Infof("[[ restore ]]: No backups of %s found under %q", req.TablePrefix, req.Prefix)
+		return nil
+	}
+
+	if !jd.dsMigrationLock.TryLockWithCtx(ctx) {
+		return fmt.Errorf("could not acquire a migration lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.Unlock()
+	return jd.dsListLock.WithLockInCtx(ctx, func(l lock.LockToken) error {
+		dsList := jd.refreshDSList(l)
+		for _, ds := range dsList {
+			var hasJobs bool
+			if err := jd.dbHandle.QueryRowContext(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %q)`, ds.JobTable)).Scan(&hasJobs); err != nil {
+				return err
+			}
+			if hasJobs {
+				return fmt.Errorf("cannot restore into %s, since dataset %s is not empty", jd.tablePrefix, ds.Index)
+			}
+		}
+
+		for _, dump := range dumps {
+			ds := newDataSet(jd.tablePrefix, jd.doComputeNewIdxForAppend(dsList))
+			restored, err := jd.restoreDump(ctx, fm, dump, ds, req)
+			if err != nil {
+				return fmt.Errorf("restoring %s: %w", dump.jobsKey, err)
+			}
+			if restored {
+				dsList = append(dsList, ds)
+			}
+		}
+
+		// new jobs are stored in a new dataset, whose job ids start after the restored ones
+		err := jd.WithTx(func(tx *Tx) error {
+			return jd.addNewDSInTx(tx, l, dsList, newDataSet(jd.tablePrefix, jd.doComputeNewIdxForAppend(dsList)))
+		})
+		if err != nil {
+			return fmt.Errorf("adding new dataset: %w", err)
+		}
+		jd.refreshDSRangeList(l)
+		return nil
+	})
+}
+
+// restoreDump restores a jobs table dump along with its status table dump into a new dataset.
+// Returns false if none of the dump's jobs were created in the requested time range, in which case the dataset isn't created
+func (jd *HandleT) restoreDump(ctx context.Context, fm filemanager.FileManager, dump backupDump, ds dataSetT, req RestoreRequest) (bool, error) {
+	batchSize := config.GetInt("JobsDB.restoreBatchSize", 10000)
+	restoredJobs := make(map[int64]struct{})
+	err := jd.WithTx(func(tx *Tx) error {
+		if err := jd.addDSInTx(tx, ds); err != nil {
+			return err
+		}
+		compressed, err := jd.compressedDS.get(ctx, tx, ds)
+		if err != nil {
+			return err
+		}
+		encodePayload := jd.payloadEncoder(compressed)
+
+		var jobs []*JobT
+		err = readBackupDump(ctx, fm, dump.jobsKey, func(line []byte) error {
+			var row backupJobRow
+			if err := json.Unmarshal(line, &row); err != nil {
+				return fmt.Errorf("unmarshalling job: %w", err)
+			}
+			if row.CreatedAt.Before(req.From) || !req.To.IsZero() && row.CreatedAt.After(req.To) {
+				return nil
+			}
+			jobs = append(jobs, &JobT{
+				JobID:        row.JobID,
+				UUID:         row.UUID,
+				UserID:       row.UserID,
+				CreatedAt:    row.CreatedAt,
+				ExpireAt:     row.ExpireAt,
+				CustomVal:    row.CustomVal,
+				EventCount:   row.EventCount,
+				EventPayload: row.EventPayload,
+				Parameters:   row.Parameters,
+				WorkspaceId:  row.WorkspaceID,
+			})
+			restoredJobs[row.JobID] = struct{}{}
+			if len(jobs) < batchSize {
+				return nil
+			}
+			err := jd.copyJobsDSInTx(tx, ds, jobs, encodePayload)
+			jobs = nil
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if len(restoredJobs) == 0 {
+			return errNothingRestored
+		}
+		if len(jobs) > 0 {
+			if err := jd.copyJobsDSInTx(tx, ds, jobs, encodePayload); err != nil {
+				return err
+			}
+		}
+
+		if dump.statusKey == "" {
+			jd.logger.Warnf("[[ restore ]]: No status backup found for %s, its jobs are restored without statuses", dump.jobsKey)
+			return nil
+		}
+		var statuses []*JobStatusT
+		err = readBackupDump(ctx, fm, dump.statusKey, func(line []byte) error {
+			var row backupStatusRow
+			if err := json.Unmarshal(line, &row); err != nil {
+				return fmt.Errorf("unmarshalling job status: %w", err)
+			}
+			if _, ok := restoredJobs[row.JobID]; !ok || !req.To.IsZero() && row.ExecTime.After(req.To) {
+				return nil
+			}
+			statuses = append(statuses, &JobStatusT{
+				JobID:         row.JobID,
+				JobState:      row.JobState,
+				AttemptNum:    row.Attempt,
+				ExecTime:      row.ExecTime,
+				RetryTime:     row.RetryTime,
+				ErrorCode:     row.ErrorCode,
+				ErrorResponse: row.ErrorResponse,
+				Parameters:    row.Parameters,
+			})
+			if len(statuses) < batchSize {
+				return nil
+			}
+			_, err := jd.updateJobStatusDSInTx(ctx, tx, ds, statuses, statTags{})
+			statuses = nil
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		_, err = jd.updateJobStatusDSInTx(ctx, tx, ds, statuses, statTags{})
+		return err
+	})
+	if errors.Is(err, errNothingRestored) {
+		jd.compressedDS.remove(ds)
+		return false, nil
+	}
+	if err != nil {
+		jd.compressedDS.remove(ds)
+		return false, err
+	}
+	jd.logger.Infof("[[ restore ]]: Restored %d jobs of %s into %s", len(restoredJobs), dump.jobsKey, ds.JobTable)
+	return true, nil
+}
+
+var errNothingRestored = errors.New("no jobs to restore")
+
+// listBackupDumps returns the jobs table dumps of the request's table prefix which overlap with its time range, ordered by job id.
+// Dump names follow the ones of backupJobsTable and backupStatusTable, i.e.
+// <prefix>_jobs_<index>.<min job id>.<max job id>.<min created at>.<max created at>.gz and <prefix>_job_status_<index>.gz
+func listBackupDumps(ctx context.Context, fm filemanager.FileManager, req RestoreRequest) ([]backupDump, error) {
+	jobsRegexp := regexp.MustCompile(`^` + regexp.QuoteMeta(req.TablePrefix) + `_jobs_([0-9_]+)\.(\d+)\.(\d+)\.(\d+)\.(\d+)\.gz$`)
+	statusRegexp := regexp.MustCompile(`^` + regexp.QuoteMeta(req.TablePrefix) + `_job_status_([0-9_]+)\.gz$`)
+
+	var dumps []backupDump
+	var dumpIndices []string
+	statusKeys := make(map[string]string)
+	iter := filemanager.IterateFilesWithPrefix(ctx, "", req.Prefix, 1000, &fm)
+	for iter.Next() {
+		key := iter.Get().Key
+		dir, name := path.Split(key)
+		if m := statusRegexp.FindStringSubmatch(name); m != nil {
+			statusKeys[dir+m[1]] = key
+			continue
+		}
+		m := jobsRegexp.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		minJobID, _ := strconv.ParseInt(m[2], 10, 64)
+		minCreatedAt, _ := strconv.ParseInt(m[4], 10, 64)
+		maxCreatedAt, _ := strconv.ParseInt(m[5], 10, 64)
+		dump := backupDump{
+			jobsKey:      key,
+			minJobID:     minJobID,
+			minCreatedAt: time.UnixMilli(minCreatedAt),
+			maxCreatedAt: time.UnixMilli(maxCreatedAt),
+		}
+		if dump.maxCreatedAt.Before(req.From) || !req.To.IsZero() && dump.minCreatedAt.After(req.To) {
+			continue
+		}
+		dumps = append(dumps, dump)
+		dumpIndices = append(dumpIndices, dir+m[1])
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("listing backups: %w", err)
+	}
+	for i := range dumps {
+		dumps[i].statusKey = statusKeys[dumpIndices[i]]
+	}
+	sort.Slice(dumps, func(i, j int) bool { return dumps[i].minJobID < dumps[j].minJobID })
+	return dumps, nil
+}
+
+// readBackupDump downloads a gzipped dump and calls f for each of its lines
+func readBackupDump(ctx context.Context, fm filemanager.FileManager, key string, f func(line []byte) error) error {
+	file, err := os.CreateTemp("", "jobsdb-restore-*.gz")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	if err := fm.Download(ctx, file, key); err != nil {
+		return fmt.Errorf("downloading %s: %w", key, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", key, err)
+	}
+	defer func() { _ = gzReader.Close() }()
+
+	reader := bufio.NewReader(gzReader)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 1 {
+			if err := f(line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", key, err)
+		}
+	}
+}
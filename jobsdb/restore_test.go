@@ -0,0 +1,139 @@
+package jobsdb
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/jobsdb/prebackup"
+	mock_filemanager "github.com/rudderlabs/rudder-server/mocks/services/filemanager"
+	"github.com/rudderlabs/rudder-server/services/filemanager"
+	"github.com/rudderlabs/rudder-server/testhelper/destination"
+	"github.com/rudderlabs/rudder-server/testhelper/rand"
+)
+
+func TestListBackupDumps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	fm := mock_filemanager.NewMockFileManager(ctrl)
+	keys := []string{
+		"backups/1/rt_jobs_3.201.300.3000.4000.gz",
+		"backups/1/rt_job_status_3.gz",
+		"backups/1/rt_jobs_1_1.1.100.1000.2000.gz",
+		"backups/1/rt_job_status_1_1.gz",
+		"backups/1/rt_jobs_2.101.200.2500.2600.gz",
+		"backups/1/rt_job_status_2_aborted.gz",
+		"backups/1/batch_rt_jobs_1.1.100.1000.2000.gz",
+		"backups/1/rt_jobs_4.301.400.5000.6000.gz",
+	}
+	var objects []*filemanager.FileObject
+	for _, key := range keys {
+		objects = append(objects, &filemanager.FileObject{Key: key})
+	}
+	gomock.InOrder(
+		fm.EXPECT().ListFilesWithPrefix(gomock.Any(), "", "backups", gomock.Any()).Return(objects, nil),
+		fm.EXPECT().ListFilesWithPrefix(gomock.Any(), "", "backups", gomock.Any()).Return(nil, nil),
+	)
+
+	dumps, err := listBackupDumps(context.Background(), fm, RestoreRequest{
+		TablePrefix: "rt",
+		Prefix:      "backups",
+		From:        time.UnixMilli(1500),
+		To:          time.UnixMilli(4500),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []backupDump{
+		{
+			jobsKey:      "backups/1/rt_jobs_1_1.1.100.1000.2000.gz",
+			statusKey:    "backups/1/rt_job_status_1_1.gz",
+			minJobID:     1,
+			minCreatedAt: time.UnixMilli(1000),
+			maxCreatedAt: time.UnixMilli(2000),
+		},
+		{
+			jobsKey:      "backups/1/rt_jobs_2.101.200.2500.2600.gz",
+			minJobID:     101,
+			minCreatedAt: time.UnixMilli(2500),
+			maxCreatedAt: time.UnixMilli(2600),
+		},
+		{
+			jobsKey:      "backups/1/rt_jobs_3.201.300.3000.4000.gz",
+			statusKey:    "backups/1/rt_job_status_3.gz",
+			minJobID:     201,
+			minCreatedAt: time.UnixMilli(3000),
+			maxCreatedAt: time.UnixMilli(4000),
+		},
+	}, dumps)
+}
+
+func TestRestore(t *testing.T) {
+	_ = startPostgres(t)
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	minioResource, err := destination.SetupMINIO(pool, t)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	prefix := "restore-test"
+	fm, err := filemanager.DefaultFileManagerFactory.New(&filemanager.SettingsT{
+		Provider: "MINIO",
+		Config: map[string]interface{}{
+			"bucketName":      minioResource.BucketName,
+			"prefix":          prefix,
+			"endPoint":        minioResource.Endpoint,
+			"accessKeyID":     minioResource.AccessKey,
+			"secretAccessKey": minioResource.SecretKey,
+			"useSSL":          false,
+		},
+	})
+	require.NoError(t, err)
+
+	// uploading the golden backup files with the names backups are uploaded with
+	upload := func(goldenFile, name string) {
+		data, err := os.ReadFile(goldenFile)
+		require.NoError(t, err)
+		path := t.TempDir() + "/" + name
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+		_, err = fm.Upload(ctx, f, "1")
+		require.NoError(t, err)
+	}
+	upload("testdata/backupJobs.json.gz", "batch_rt_jobs_1.1.17.0.0.gz")
+	upload("testdata/backupStatus.json.gz", "batch_rt_job_status_1.gz")
+
+	jd := &HandleT{}
+	require.NoError(t, jd.Setup(ReadWrite, true, "restored_"+strings.ToLower(rand.String(5)), true, []prebackup.Handler{}))
+	defer jd.TearDown()
+
+	req := RestoreRequest{TablePrefix: "batch_rt", Prefix: prefix}
+	require.NoError(t, jd.Restore(ctx, fm, req))
+
+	aborted, err := jd.GetProcessed(ctx, GetQueryParamsT{StateFilters: []string{Aborted.State}, JobsLimit: 100})
+	require.NoError(t, err)
+	require.Len(t, aborted.Jobs, 17)
+	require.EqualValues(t, 1, aborted.Jobs[0].JobID)
+	require.Equal(t, "404", aborted.Jobs[0].LastJobStatus.ErrorCode)
+
+	unprocessed, err := jd.GetUnprocessed(ctx, GetQueryParamsT{JobsLimit: 100})
+	require.NoError(t, err)
+	require.Empty(t, unprocessed.Jobs)
+
+	t.Run("new jobs are stored after the restored ones", func(t *testing.T) {
+		require.NoError(t, jd.Store(ctx, genJobs(defaultWorkspaceID, "MOCKDS", 1, 1)))
+		unprocessed, err := jd.GetUnprocessed(ctx, GetQueryParamsT{JobsLimit: 100})
+		require.NoError(t, err)
+		require.Len(t, unprocessed.Jobs, 1)
+		require.EqualValues(t, 18, unprocessed.Jobs[0].JobID)
+	})
+
+	t.Run("restoring into a jobsdb with jobs fails", func(t *testing.T) {
+		require.Error(t, jd.Restore(ctx, fm, req))
+	})
+}
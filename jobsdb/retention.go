@@ -0,0 +1,146 @@
+package jobsdb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/services/stats"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// RetentionPolicy decides for how long jobs are kept in jobsdb after reaching a terminal state.
+type RetentionPolicy interface {
+	// TTL returns the retention period of jobs whose last status is state. A non-positive TTL keeps the jobs until their dataset is dropped
+	TTL(state string) time.Duration
+}
+
+// RetentionPolicyFunc is an adapter to allow the use of ordinary functions as retention policies
+type RetentionPolicyFunc func(state string) time.Duration
+
+func (f RetentionPolicyFunc) TTL(state string) time.Duration {
+	return f(state)
+}
+
+// configRetentionPolicy reads TTLs from JobsDB.<tablePrefix>.retention.<state>, falling back to JobsDB.retention.<state>.
+// TTLs are read on every call, so that they can be changed without restarting.
+type configRetentionPolicy struct {
+	tablePrefix string
+}
+
+func (p configRetentionPolicy) TTL(state string) time.Duration {
+	key := fmt.Sprintf("JobsDB.%s.retention.%s", p.tablePrefix, state)
+	if !config.IsSet(key) {
+		key = fmt.Sprintf("JobsDB.retention.%s", state)
+	}
+	return config.GetDuration(key, 0, time.Second)
+}
+
+// WithRetentionPolicy, sets the retention policy used for purging terminal jobs. Defaults to a policy read from config
+func WithRetentionPolicy(policy RetentionPolicy) OptsFunc {
+	return func(jd *HandleT) {
+		jd.retentionPolicy = policy
+	}
+}
+
+func (jd *HandleT) startRetentionLoop(ctx context.Context) {
+	jd.backgroundGroup.Go(misc.WithBugsnag(func() error {
+		jd.retentionLoop(ctx)
+		return nil
+	}))
+}
+
+func (jd *HandleT) retentionLoop(ctx context.Context) {
+	for {
+		select {
+		case <-jd.TriggerRetention():
+		case <-ctx.Done():
+			return
+		}
+		start := time.Now()
+		timeoutCtx, cancel := context.WithTimeout(ctx, jd.retentionTimeout)
+		err := jd.doPurgeExpiredJobs(timeoutCtx)
+		cancel()
+		if err != nil {
+			jd.logger.Errorf("Failed to purge expired jobs: %v", err)
+		}
+		stats.Default.NewTaggedStat("jobsdb.retention_loop", stats.TimerType, stats.Tags{"customVal": jd.tablePrefix, "error": strconv.FormatBool(err != nil)}).Since(start)
+	}
+}
+
+// doPurgeExpiredJobs deletes jobs, along with their statuses, whose last status is a terminal state
+// which has been reached before the state's TTL. Non-terminal states are never purged.
+func (jd *HandleT) doPurgeExpiredJobs(ctx context.Context) error {
+	var states []string
+	var ttls []float64
+	for _, state := range validTerminalStates {
+		if ttl := jd.retentionPolicy.TTL(state); ttl > 0 {
+			states = append(states, state)
+			ttls = append(ttls, ttl.Seconds())
+		}
+	}
+	if len(states) == 0 {
+		return nil
+	}
+
+	// holding a migration read lock, so that datasets are not migrated or dropped while purging them
+	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
+		return fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.RUnlock()
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	dsList := jd.getDSList()
+	jd.dsListLock.RUnlock()
+
+	now := time.Now()
+	purged := make(map[string]int)
+	for _, ds := range dsList {
+		if err := jd.purgeExpiredJobsDS(ctx, ds, states, ttls, now, purged); err != nil {
+			return fmt.Errorf("purging expired jobs of %s: %w", ds.JobTable, err)
+		}
+	}
+	for state, count := range purged {
+		jd.logger.Infof("[[ retentionLoop ]]: Purged %d %s jobs", count, state)
+		stats.Default.NewTaggedStat("jobsdb.retention_purged_jobs", stats.CountType, stats.Tags{"customVal": jd.tablePrefix, "state": state}).Count(count)
+	}
+	return nil
+}
+
+// purgeExpiredJobsDS purges expired jobs of a single dataset, adding the number of purged jobs per state to purged
+func (jd *HandleT) purgeExpiredJobsDS(ctx context.Context, ds dataSetT, states []string, ttls []float64, now time.Time, purged map[string]int) error {
+	sqlStatement := fmt.Sprintf(`with ttl (state, secs) as (
+			select * from unnest($1::text[], $2::float8[])
+		),
+		expired as (
+			select s.job_id, s.job_state from %[1]q s join ttl on s.job_state = ttl.state
+			where s.id in (select max(id) from %[1]q group by job_id) and s.exec_time < $3::timestamp - make_interval(secs => ttl.secs)
+		),
+		deleted_statuses as (
+			delete from %[1]q where job_id in (select job_id from expired)
+		),
+		deleted_jobs as (
+			delete from %[2]q where job_id in (select job_id from expired) returning job_id
+		)
+		select e.job_state, count(*) from expired e join deleted_jobs d on e.job_id = d.job_id group by e.job_state`,
+		ds.JobStatusTable, ds.JobTable)
+	rows, err := jd.dbHandle.QueryContext(ctx, sqlStatement, pq.Array(states), pq.Array(ttls), now)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var state string
+		var count int
+		if err := rows.Scan(&state, &count); err != nil {
+			return err
+		}
+		purged[state] += count
+	}
+	return rows.Err()
+}
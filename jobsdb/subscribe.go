@@ -0,0 +1,210 @@
+package jobsdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// maxNotificationPayloadSize is the maximum payload size postgres accepts for a notification, minus some slack
+const maxNotificationPayloadSize = 7900
+
+// ErrNotificationsDisabled is returned when subscribing to a jobsdb which doesn't notify about stored jobs
+var ErrNotificationsDisabled = errors.New("jobsdb notifications are disabled")
+
+// Subscriber is implemented by jobsdbs which can notify readers about newly stored jobs
+type Subscriber interface {
+	// Subscribe returns a channel receiving a signal whenever new jobs matching the custom val and parameter filters of params are stored.
+	// Signals are coalesced, i.e. a single signal may be received for multiple stores, and may be spurious.
+	// The channel is closed when ctx is cancelled.
+	Subscribe(ctx context.Context, params GetQueryParamsT) (<-chan struct{}, error)
+}
+
+// storeNotification describes a group of stored jobs sharing the same custom val and cache key parameters
+type storeNotification struct {
+	CustomVal  string            `json:"cv"`
+	Parameters map[string]string `json:"p,omitempty"`
+}
+
+type subscription struct {
+	customValFilters []string
+	parameterFilters []ParameterFilterT
+	signal           chan struct{}
+}
+
+// matches returns true if any of the notifications matches the subscription's filters.
+// Parameter filters on parameters which are not part of the notifications always match.
+func (s *subscription) matches(notifications []storeNotification) bool {
+	for _, n := range notifications {
+		if len(s.customValFilters) > 0 && !misc.Contains(s.customValFilters, n.CustomVal) {
+			continue
+		}
+		matches := true
+		for _, filter := range s.parameterFilters {
+			if value, ok := n.Parameters[filter.Name]; ok && !filter.Optional && value != filter.Value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *subscription) notify() {
+	select {
+	case s.signal <- struct{}{}:
+	default: // a signal is already pending
+	}
+}
+
+type subscriptions struct {
+	mu       sync.Mutex
+	listener *pq.Listener
+	subs     map[*subscription]struct{}
+}
+
+func (jd *HandleT) notificationChannel() string {
+	return "jobsdb_" + jd.tablePrefix
+}
+
+// Subscribe returns a channel receiving a signal whenever new jobs matching the custom val and parameter filters of params are stored.
+// Notifications are sent through postgres LISTEN/NOTIFY, thus subscribers are notified about jobs stored by any node.
+func (jd *HandleT) Subscribe(ctx context.Context, params GetQueryParamsT) (<-chan struct{}, error) {
+	if !jd.enableNotifications {
+		return nil, ErrNotificationsDisabled
+	}
+	s := &subscription{
+		customValFilters: params.CustomValFilters,
+		parameterFilters: params.ParameterFilters,
+		signal:           make(chan struct{}, 1),
+	}
+
+	jd.subscriptions.mu.Lock()
+	defer jd.subscriptions.mu.Unlock()
+	if jd.subscriptions.listener == nil {
+		listener := pq.NewListener(jd.connectionString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				jd.logger.Warnf("Notification listener event %d: %v", event, err)
+			}
+		})
+		if err := listener.Listen(jd.notificationChannel()); err != nil {
+			_ = listener.Close()
+			return nil, err
+		}
+		jd.subscriptions.listener = listener
+		jd.subscriptions.subs = make(map[*subscription]struct{})
+		go jd.dispatchNotifications(listener)
+	}
+	jd.subscriptions.subs[s] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		jd.unsubscribe(s)
+	}()
+	return s.signal, nil
+}
+
+func (jd *HandleT) unsubscribe(s *subscription) {
+	jd.subscriptions.mu.Lock()
+	if _, ok := jd.subscriptions.subs[s]; !ok {
+		jd.subscriptions.mu.Unlock()
+		return
+	}
+	delete(jd.subscriptions.subs, s)
+	close(s.signal)
+	var listener *pq.Listener
+	if len(jd.subscriptions.subs) == 0 {
+		listener = jd.subscriptions.listener
+		jd.subscriptions.listener = nil
+	}
+	jd.subscriptions.mu.Unlock()
+
+	// closing the listener outside the lock, since the dispatching goroutine might be waiting for it
+	if listener != nil {
+		_ = listener.Close()
+	}
+}
+
+// closeSubscriptions closes all subscriptions along with the listener
+func (jd *HandleT) closeSubscriptions() {
+	jd.subscriptions.mu.Lock()
+	for s := range jd.subscriptions.subs {
+		delete(jd.subscriptions.subs, s)
+		close(s.signal)
+	}
+	listener := jd.subscriptions.listener
+	jd.subscriptions.listener = nil
+	jd.subscriptions.mu.Unlock()
+
+	if listener != nil {
+		_ = listener.Close()
+	}
+}
+
+// dispatchNotifications signals matching subscribers for every notification received, until the listener is closed
+func (jd *HandleT) dispatchNotifications(listener *pq.Listener) {
+	for n := range listener.Notify {
+		var notifications []storeNotification
+		// a nil notification is sent after reconnecting, when notifications might have been lost.
+		// An empty payload is sent when jobs don't fit in a single notification.
+		wakeAll := n == nil || n.Extra == ""
+		if !wakeAll {
+			if err := json.Unmarshal([]byte(n.Extra), &notifications); err != nil {
+				jd.logger.Warnf("Invalid notification payload %q: %v", n.Extra, err)
+				wakeAll = true
+			}
+		}
+		jd.subscriptions.mu.Lock()
+		for s := range jd.subscriptions.subs {
+			if wakeAll || s.matches(notifications) {
+				s.notify()
+			}
+		}
+		jd.subscriptions.mu.Unlock()
+	}
+}
+
+// notifyStoredJobsInTx notifies subscribers about the stored jobs, once the transaction is committed
+func (jd *HandleT) notifyStoredJobsInTx(ctx context.Context, tx *Tx, jobList []*JobT) error {
+	if !jd.enableNotifications || len(jobList) == 0 {
+		return nil
+	}
+	type key struct {
+		customVal  string
+		parameters string
+	}
+	seen := make(map[key]struct{})
+	var notifications []storeNotification
+	for _, job := range jobList {
+		n := storeNotification{CustomVal: job.CustomVal, Parameters: make(map[string]string)}
+		for _, name := range CacheKeyParameterFilters {
+			n.Parameters[name] = gjson.GetBytes(job.Parameters, name).String()
+		}
+		parameters, _ := json.Marshal(n.Parameters)
+		k := key{customVal: n.CustomVal, parameters: string(parameters)}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		notifications = append(notifications, n)
+	}
+	payload, err := json.Marshal(notifications)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotificationPayloadSize {
+		payload = nil // wake up all subscribers
+	}
+	_, err = tx.ExecContext(ctx, `select pg_notify($1, $2)`, jd.notificationChannel(), string(payload))
+	return err
+}
@@ -0,0 +1,73 @@
+package jobsdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionMatches(t *testing.T) {
+	notifications := []storeNotification{
+		{CustomVal: "WEBHOOK", Parameters: map[string]string{"destination_id": "dest-1"}},
+		{CustomVal: "GA", Parameters: map[string]string{"destination_id": "dest-2"}},
+	}
+
+	tests := []struct {
+		name         string
+		subscription subscription
+		matches      bool
+	}{
+		{
+			name:         "no filters",
+			subscription: subscription{},
+			matches:      true,
+		},
+		{
+			name:         "matching custom val",
+			subscription: subscription{customValFilters: []string{"GA"}},
+			matches:      true,
+		},
+		{
+			name:         "other custom val",
+			subscription: subscription{customValFilters: []string{"AM"}},
+			matches:      false,
+		},
+		{
+			name: "matching custom val and parameter",
+			subscription: subscription{
+				customValFilters: []string{"WEBHOOK"},
+				parameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "dest-1"}},
+			},
+			matches: true,
+		},
+		{
+			name: "matching custom val of another parameter",
+			subscription: subscription{
+				customValFilters: []string{"WEBHOOK"},
+				parameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "dest-2"}},
+			},
+			matches: false,
+		},
+		{
+			name: "optional parameter",
+			subscription: subscription{
+				customValFilters: []string{"WEBHOOK"},
+				parameterFilters: []ParameterFilterT{{Name: "destination_id", Value: "dest-2", Optional: true}},
+			},
+			matches: true,
+		},
+		{
+			name: "parameter not part of notifications",
+			subscription: subscription{
+				customValFilters: []string{"GA"},
+				parameterFilters: []ParameterFilterT{{Name: "source_id", Value: "source-1"}},
+			},
+			matches: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.matches, tt.subscription.matches(notifications))
+		})
+	}
+}
@@ -125,6 +125,7 @@ func (mj *MultiTenantHandleT) GetAllJobs(ctx context.Context, pickup map[string]
 		IgnoreCustomValFiltersInQuery: params.IgnoreCustomValFiltersInQuery,
 		CustomValFilters:              params.CustomValFilters,
 		ParameterFilters:              params.ParameterFilters,
+		ExcludedParameterFilters:      params.ExcludedParameterFilters,
 		StateFilters:                  params.StateFilters,
 	}
 	mToken := &moreToken{
@@ -170,7 +171,7 @@ func (mj *MultiTenantHandleT) getUnionDS(ctx context.Context, ds dataSetT, picku
 
 	skipCache := map[string]struct{}{}
 	for workspace, wp := range pickup {
-		if wp.AfterJobID != nil {
+		if wp.AfterJobID != nil || len(conditions.ExcludedParameterFilters) > 0 {
 			skipCache[workspace] = struct{}{}
 		}
 	}
@@ -239,6 +240,9 @@ func (mj *MultiTenantHandleT) getUnionDS(ctx context.Context, ds dataSetT, picku
 		if err != nil {
 			return jobList, err
 		}
+		if err = job.decompressPayload(); err != nil {
+			return jobList, err
+		}
 
 		job.LastJobStatus = JobStatusT{}
 		if _nullJS.Valid {
@@ -350,6 +354,10 @@ func (*MultiTenantHandleT) getInitialSingleWorkspaceQueryString(ds dataSetT, con
 		sourceQuery = ""
 	}
 
+	if len(conditions.ExcludedParameterFilters) > 0 {
+		sourceQuery += " AND " + constructParameterExclusionQuery("jobs", conditions.ExcludedParameterFilters)
+	}
+
 	sqlStatement = fmt.Sprintf(
 		`with rt_jobs_view AS (
 			SELECT
@@ -35,6 +35,20 @@ func (m *MockTransformer) EXPECT() *MockTransformerMockRecorder {
 	return m.recorder
 }
 
+// DestinationTransform mocks base method.
+func (m *MockTransformer) DestinationTransform(arg0 context.Context, arg1 []transformer.TransformerEventT, arg2 string, arg3 int) transformer.ResponseT {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DestinationTransform", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(transformer.ResponseT)
+	return ret0
+}
+
+// DestinationTransform indicates an expected call of DestinationTransform.
+func (mr *MockTransformerMockRecorder) DestinationTransform(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DestinationTransform", reflect.TypeOf((*MockTransformer)(nil).DestinationTransform), arg0, arg1, arg2, arg3)
+}
+
 // Setup mocks base method.
 func (m *MockTransformer) Setup() {
 	m.ctrl.T.Helper()
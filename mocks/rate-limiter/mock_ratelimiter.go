@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	ratelimiter "github.com/rudderlabs/rudder-server/rate-limiter"
 )
 
 // MockRateLimiter is a mock of RateLimiter interface.
@@ -33,16 +34,16 @@ func (m *MockRateLimiter) EXPECT() *MockRateLimiterMockRecorder {
 	return m.recorder
 }
 
-// LimitReached mocks base method.
-func (m *MockRateLimiter) LimitReached(arg0 string) bool {
+// Check mocks base method.
+func (m *MockRateLimiter) Check(arg0, arg1 string) ratelimiter.LimitStatus {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LimitReached", arg0)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "Check", arg0, arg1)
+	ret0, _ := ret[0].(ratelimiter.LimitStatus)
 	return ret0
 }
 
-// LimitReached indicates an expected call of LimitReached.
-func (mr *MockRateLimiterMockRecorder) LimitReached(arg0 interface{}) *gomock.Call {
+// Check indicates an expected call of Check.
+func (mr *MockRateLimiterMockRecorder) Check(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LimitReached", reflect.TypeOf((*MockRateLimiter)(nil).LimitReached), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockRateLimiter)(nil).Check), arg0, arg1)
 }
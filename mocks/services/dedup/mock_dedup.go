@@ -5,9 +5,11 @@
 package mock_dedup
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	dedup "github.com/rudderlabs/rudder-server/services/dedup"
 )
 
 // MockDedupI is a mock of DedupI interface.
@@ -46,31 +48,32 @@ func (mr *MockDedupIMockRecorder) Close() *gomock.Call {
 }
 
 // FindDuplicates mocks base method.
-func (m *MockDedupI) FindDuplicates(arg0 []string, arg1 map[string]struct{}) []int {
+func (m *MockDedupI) FindDuplicates(arg0 context.Context, arg1 []string, arg2 map[string]struct{}) ([]int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindDuplicates", arg0, arg1)
+	ret := m.ctrl.Call(m, "FindDuplicates", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]int)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // FindDuplicates indicates an expected call of FindDuplicates.
-func (mr *MockDedupIMockRecorder) FindDuplicates(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockDedupIMockRecorder) FindDuplicates(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicates", reflect.TypeOf((*MockDedupI)(nil).FindDuplicates), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicates", reflect.TypeOf((*MockDedupI)(nil).FindDuplicates), arg0, arg1, arg2)
 }
 
 // MarkProcessed mocks base method.
-func (m *MockDedupI) MarkProcessed(arg0 []string) error {
+func (m *MockDedupI) MarkProcessed(arg0 context.Context, arg1 []dedup.KeyT) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkProcessed", arg0)
+	ret := m.ctrl.Call(m, "MarkProcessed", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // MarkProcessed indicates an expected call of MarkProcessed.
-func (mr *MockDedupIMockRecorder) MarkProcessed(arg0 interface{}) *gomock.Call {
+func (mr *MockDedupIMockRecorder) MarkProcessed(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkProcessed", reflect.TypeOf((*MockDedupI)(nil).MarkProcessed), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkProcessed", reflect.TypeOf((*MockDedupI)(nil).MarkProcessed), arg0, arg1)
 }
 
 // PrintHistogram mocks base method.
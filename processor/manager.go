@@ -18,7 +18,7 @@ type LifecycleManager struct {
 	mainCtx          context.Context
 	currentCancel    context.CancelFunc
 	waitGroup        interface{ Wait() }
-	gatewayDB        *jobsdb.HandleT
+	gatewayDB        jobsdb.JobsDB
 	routerDB         *jobsdb.HandleT
 	batchRouterDB    *jobsdb.HandleT
 	errDB            *jobsdb.HandleT
@@ -68,7 +68,7 @@ func (proc *LifecycleManager) Stop() {
 }
 
 // New creates a new Processor instance
-func New(ctx context.Context, clearDb *bool, gwDb, rtDb, brtDb, errDb *jobsdb.HandleT,
+func New(ctx context.Context, clearDb *bool, gwDb jobsdb.JobsDB, rtDb, brtDb, errDb *jobsdb.HandleT,
 	tenantDB multitenant.MultiTenantI, reporting types.ReportingI, transientSources transientsource.Service,
 	rsourcesService rsources.JobService,
 ) *LifecycleManager {
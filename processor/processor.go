@@ -471,6 +471,7 @@ var (
 	writeKeySourceMap         map[string]backendconfig.SourceT
 	workspaceLibrariesMap     map[string]backendconfig.LibrariesT
 	destinationIDtoTypeMap    map[string]string
+	dedupRules                dedup.Rules
 	batchDestinations         []string
 	configSubscriberLock      sync.RWMutex
 	pkgLogger                 logger.Logger
@@ -610,6 +611,7 @@ func (proc *HandleT) backendConfigSubscriber() {
 			}
 			workspaceLibrariesMap[workspaceID] = wConfig.Libraries
 		}
+		dedupRules = dedup.NewRules(config)
 		configSubscriberLock.Unlock()
 	}
 }
@@ -632,6 +634,14 @@ func getSourceByWriteKey(writeKey string) (*backendconfig.SourceT, error) {
 	return &source, err
 }
 
+// getDedupRule returns the id of the source with the given writeKey, along with the dedup rule applying to it
+func getDedupRule(writeKey string) (string, dedup.Rule) {
+	configSubscriberLock.RLock()
+	defer configSubscriberLock.RUnlock()
+	source := writeKeySourceMap[writeKey]
+	return source.ID, dedupRules.For(source.WorkspaceID, source.ID)
+}
+
 func getEnabledDestinations(writeKey, destinationName string) []backendconfig.DestinationT {
 	configSubscriberLock.RLock()
 	defer configSubscriberLock.RUnlock()
@@ -1149,7 +1159,9 @@ func getDiffMetrics(inPU, pu string, inCountMetadataMap map[string]MetricMetadat
 	return diffMetrics
 }
 
-func (proc *HandleT) processJobsForDest(subJobs subJob, parsedEventList [][]types.SingularEventT) *transformationMessage {
+// processJobsForDest dedups and groups the events of the jobs by destination.
+// It fails only if the dedup store can't be queried, either until ctx is done or after Dedup.maxRetryElapsedTime
+func (proc *HandleT) processJobsForDest(ctx context.Context, subJobs subJob, parsedEventList [][]types.SingularEventT) (*transformationMessage, error) {
 	jobList := subJobs.subJobs
 	start := time.Now()
 
@@ -1178,6 +1190,7 @@ func (proc *HandleT) processJobsForDest(subJobs subJob, parsedEventList [][]type
 
 	marshalStart := time.Now()
 	uniqueMessageIds := make(map[string]struct{})
+	dedupKeyWindows := make(map[string]time.Duration)
 	uniqueMessageIdsBySrcDestKey := make(map[string]map[string]struct{})
 	sourceDupStats := make(map[string]int)
 
@@ -1206,26 +1219,34 @@ func (proc *HandleT) processJobsForDest(subJobs subJob, parsedEventList [][]type
 
 		if ok {
 			var duplicateIndexes []int
+			dedupSourceID, dedupRule := getDedupRule(writeKey)
+			dedupKeys := make([]string, len(singularEvents))
+			for eventIndex, singularEvent := range singularEvents {
+				dedupKeys[eventIndex] = dedupRule.Key(dedupSourceID, singularEvent)
+			}
 			if enableDedup {
-				var allMessageIdsInBatch []string
-				for _, singularEvent := range singularEvents {
-					allMessageIdsInBatch = append(allMessageIdsInBatch, misc.GetStringifiedData(singularEvent["messageId"]))
+				var err error
+				duplicateIndexes, err = proc.dedupHandler.FindDuplicates(ctx, dedupKeys, uniqueMessageIds)
+				if err != nil {
+					return nil, fmt.Errorf("finding duplicate events: %w", err)
 				}
-				duplicateIndexes = proc.dedupHandler.FindDuplicates(allMessageIdsInBatch, uniqueMessageIds)
 			}
 
 			// Iterate through all the events in the batch
 			for eventIndex, singularEvent := range singularEvents {
 				messageId := misc.GetStringifiedData(singularEvent["messageId"])
 				if enableDedup && misc.Contains(duplicateIndexes, eventIndex) {
-					proc.logger.Debugf("Dropping event with duplicate messageId: %s", messageId)
+					proc.logger.Debugf("Dropping event with duplicate messageId: %s, dedup key: %s", messageId, dedupKeys[eventIndex])
 					misc.IncrementMapByKey(sourceDupStats, writeKey, 1)
 					continue
 				}
 
 				proc.updateSourceEventStatsDetailed(singularEvent, writeKey)
 
-				uniqueMessageIds[messageId] = struct{}{}
+				uniqueMessageIds[dedupKeys[eventIndex]] = struct{}{}
+				if dedupRule.Window > 0 {
+					dedupKeyWindows[dedupKeys[eventIndex]] = dedupRule.Window
+				}
 				// We count this as one, not destination specific ones
 				totalEvents++
 				eventsByMessageID[messageId] = types.SingularEventWithReceivedAt{
@@ -1426,13 +1447,14 @@ func (proc *HandleT) processJobsForDest(subJobs subJob, parsedEventList [][]type
 		procErrorJobs,
 		sourceDupStats,
 		uniqueMessageIds,
+		dedupKeyWindows,
 
 		totalEvents,
 		start,
 
 		subJobs.hasMore,
 		subJobs.rsourcesStats,
-	}
+	}, nil
 }
 
 type transformationMessage struct {
@@ -1446,6 +1468,7 @@ type transformationMessage struct {
 	procErrorJobs                []*jobsdb.JobT
 	sourceDupStats               map[string]int
 	uniqueMessageIds             map[string]struct{}
+	dedupKeyWindows              map[string]time.Duration
 
 	totalEvents int
 	start       time.Time
@@ -1518,6 +1541,7 @@ func (proc *HandleT) transformations(in *transformationMessage) *storeMessage {
 		in.reportMetrics,
 		in.sourceDupStats,
 		in.uniqueMessageIds,
+		in.dedupKeyWindows,
 		in.totalEvents,
 		in.start,
 		in.hasMore,
@@ -1536,6 +1560,7 @@ type storeMessage struct {
 	reportMetrics    []*types.PUReportedMetric
 	sourceDupStats   map[string]int
 	uniqueMessageIds map[string]struct{}
+	dedupKeyWindows  map[string]time.Duration
 
 	totalEvents int
 	start       time.Time
@@ -1681,11 +1706,11 @@ func (proc *HandleT) Store(in *storeMessage) {
 			if enableDedup {
 				proc.updateSourceStats(in.sourceDupStats, "processor.write_key_duplicate_events")
 				if len(in.uniqueMessageIds) > 0 {
-					var dedupedMessageIdsAcrossJobs []string
+					var dedupedMessageIdsAcrossJobs []dedup.KeyT
 					for k := range in.uniqueMessageIds {
-						dedupedMessageIdsAcrossJobs = append(dedupedMessageIdsAcrossJobs, k)
+						dedupedMessageIdsAcrossJobs = append(dedupedMessageIdsAcrossJobs, dedup.KeyT{Key: k, Window: in.dedupKeyWindows[k]})
 					}
-					err = proc.dedupHandler.MarkProcessed(dedupedMessageIdsAcrossJobs)
+					err = proc.dedupHandler.MarkProcessed(ctx, dedupedMessageIdsAcrossJobs)
 					if err != nil {
 						return err
 					}
@@ -1934,7 +1959,7 @@ func (proc *HandleT) transformSrcDest(
 			trace.Logf(ctx, "Dest Transform", "input size %d", len(eventsToTransform))
 			proc.logger.Debug("Dest Transform input size", len(eventsToTransform))
 			s := time.Now()
-			response = proc.transformer.Transform(ctx, eventsToTransform, url, transformBatchSize)
+			response = proc.transformer.DestinationTransform(ctx, eventsToTransform, url, transformBatchSize)
 
 			destTransformationStat := proc.newDestinationTransformationStat(sourceID, workspaceID, transformAt, destination)
 			destTransformationStat.transformTime.Since(s)
@@ -2289,7 +2314,7 @@ func (proc *HandleT) markExecuting(jobs []*jobsdb.JobT) error {
 
 // handlePendingGatewayJobs is checking for any pending gateway jobs (failed and unprocessed), and routes them appropriately
 // Returns true if any job is handled, otherwise returns false.
-func (proc *HandleT) handlePendingGatewayJobs() bool {
+func (proc *HandleT) handlePendingGatewayJobs(ctx context.Context) bool {
 	s := time.Now()
 
 	unprocessedList := proc.getJobs()
@@ -2301,15 +2326,18 @@ func (proc *HandleT) handlePendingGatewayJobs() bool {
 	rsourcesStats := rsources.NewStatsCollector(proc.rsourcesService)
 	rsourcesStats.BeginProcessing(unprocessedList.Jobs)
 
-	proc.Store(
-		proc.transformations(
-			proc.processJobsForDest(subJob{
-				subJobs:       unprocessedList.Jobs,
-				hasMore:       false,
-				rsourcesStats: rsourcesStats,
-			}, nil),
-		),
-	)
+	msg, err := proc.processJobsForDest(ctx, subJob{
+		subJobs:       unprocessedList.Jobs,
+		hasMore:       false,
+		rsourcesStats: rsourcesStats,
+	}, nil)
+	if err != nil {
+		if ctx.Err() != nil { // shutting down, the jobs are picked up again on restart
+			return false
+		}
+		panic(err)
+	}
+	proc.Store(proc.transformations(msg))
 	proc.stats.statLoopTime.Since(s)
 
 	return true
@@ -2325,6 +2353,7 @@ func (proc *HandleT) mainLoop(ctx context.Context) {
 	}
 
 	proc.logger.Info("Processor loop started")
+	newJobs := proc.subscribeToNewJobs(ctx)
 	var currLoopSleep time.Duration
 	for {
 		select {
@@ -2332,7 +2361,7 @@ func (proc *HandleT) mainLoop(ctx context.Context) {
 			return
 		case <-time.After(mainLoopTimeout):
 			if isUnLocked {
-				found := proc.handlePendingGatewayJobs()
+				found := proc.handlePendingGatewayJobs(ctx)
 				if found {
 					currLoopSleep = 0
 				} else {
@@ -2340,7 +2369,7 @@ func (proc *HandleT) mainLoop(ctx context.Context) {
 					if currLoopSleep > maxLoopSleep {
 						currLoopSleep = maxLoopSleep
 					}
-					if sleepTrueOnDone(ctx, currLoopSleep) {
+					if sleepTrueOnDoneOrNewJobs(ctx, newJobs, currLoopSleep) {
 						return
 					}
 				}
@@ -2363,6 +2392,35 @@ func sleepTrueOnDone(ctx context.Context, duration time.Duration) bool {
 	}
 }
 
+// sleepTrueOnDoneOrNewJobs is like sleepTrueOnDone, but it wakes up as soon as new jobs are signalled
+func sleepTrueOnDoneOrNewJobs(ctx context.Context, newJobs <-chan struct{}, duration time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-time.After(duration):
+		return false
+	case _, ok := <-newJobs:
+		return !ok // the subscription is closed once ctx is done
+	}
+}
+
+// subscribeToNewJobs subscribes to new jobs stored in the gateway jobsdb, if it supports it.
+// Idle processor loops then read new jobs as soon as they are stored, instead of sleeping until their next read.
+func (proc *HandleT) subscribeToNewJobs(ctx context.Context) <-chan struct{} {
+	subscriber, ok := proc.gatewayDB.(jobsdb.Subscriber)
+	if !ok {
+		return nil
+	}
+	newJobs, err := subscriber.Subscribe(ctx, jobsdb.GetQueryParamsT{CustomValFilters: []string{GWCustomVal}})
+	if err != nil {
+		if !errors.Is(err, jobsdb.ErrNotificationsDisabled) {
+			proc.logger.Warnf("Failed to subscribe to new gateway jobs, polling instead: %v", err)
+		}
+		return nil
+	}
+	return newJobs
+}
+
 // `jobSplitter` func Splits the read Jobs into sub-batches after reading from DB to process.
 // `subJobMerger` func merges the split jobs into a single batch before writing to DB.
 // So, to keep track of sub-batch we have `hasMore` variable.
@@ -2424,11 +2482,19 @@ func (proc *HandleT) mainPipeline(ctx context.Context) {
 		defer wg.Done()
 		defer close(chProc)
 		nextSleepTime := time.Duration(0)
+		newJobs := proc.subscribeToNewJobs(ctx)
+		var newJobsWhenIdle <-chan struct{} // new jobs only cut sleeps short when the last read found no jobs
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case _, ok := <-newJobsWhenIdle:
+				if !ok {
+					return
+				}
+				nextSleepTime = 0
+				newJobsWhenIdle = nil
 			case <-time.After(nextSleepTime):
 				if !isUnLocked {
 					nextSleepTime = proc.maxLoopSleep
@@ -2439,6 +2505,7 @@ func (proc *HandleT) mainPipeline(ctx context.Context) {
 				rsourcesStats := rsources.NewStatsCollector(proc.rsourcesService)
 				rsourcesStats.BeginProcessing(jobs.Jobs)
 				if len(jobs.Jobs) == 0 {
+					newJobsWhenIdle = newJobs
 					// no jobs found, double sleep time until maxLoopSleep
 					nextSleepTime = 2 * nextSleepTime
 					if nextSleepTime > proc.maxLoopSleep {
@@ -2449,6 +2516,7 @@ func (proc *HandleT) mainPipeline(ctx context.Context) {
 					continue
 				}
 
+				newJobsWhenIdle = nil
 				err := proc.markExecuting(jobs.Jobs)
 				if err != nil {
 					pkgLogger.Error(err)
@@ -2478,7 +2546,17 @@ func (proc *HandleT) mainPipeline(ctx context.Context) {
 		defer wg.Done()
 		defer close(chTrans)
 		for jobs := range chProc {
-			chTrans <- proc.processJobsForDest(jobs, nil)
+			msg, err := proc.processJobsForDest(ctx, jobs, nil)
+			if err != nil {
+				if ctx.Err() == nil {
+					panic(err)
+				}
+				// shutting down, the remaining jobs are left executing and picked up again on restart
+				for range chProc {
+				}
+				return
+			}
+			chTrans <- msg
 		}
 	}()
 
@@ -2509,6 +2587,7 @@ func (proc *HandleT) mainPipeline(ctx context.Context) {
 				mergedJob = storeMessage{}
 				mergedJob.rsourcesStats = subJob.rsourcesStats
 				mergedJob.uniqueMessageIds = make(map[string]struct{})
+				mergedJob.dedupKeyWindows = make(map[string]time.Duration)
 				mergedJob.procErrorJobsByDestID = make(map[string][]*jobsdb.JobT)
 				mergedJob.sourceDupStats = make(map[string]int)
 
@@ -2544,6 +2623,9 @@ func subJobMerger(mergedJob, subJob *storeMessage) *storeMessage {
 	for id := range subJob.uniqueMessageIds {
 		mergedJob.uniqueMessageIds[id] = struct{}{}
 	}
+	for id, window := range subJob.dedupKeyWindows {
+		mergedJob.dedupKeyWindows[id] = window
+	}
 	mergedJob.totalEvents += subJob.totalEvents
 
 	return mergedJob
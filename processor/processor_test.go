@@ -3,6 +3,7 @@ package processor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -384,7 +385,7 @@ var _ = Describe("Processor", func() {
 			payloadLimit := processor.payloadLimit
 			c.mockGatewayJobsDB.EXPECT().GetUnprocessed(gomock.Any(), jobsdb.GetQueryParamsT{CustomValFilters: gatewayCustomVal, JobsLimit: c.dbReadBatchSize, EventsLimit: c.processEventSize, PayloadSizeLimit: payloadLimit}).Return(jobsdb.JobsResult{Jobs: emptyJobsList}, nil).Times(1)
 
-			didWork := processor.handlePendingGatewayJobs()
+			didWork := processor.handlePendingGatewayJobs(context.Background())
 			Expect(didWork).To(Equal(false))
 		})
 
@@ -522,7 +523,7 @@ var _ = Describe("Processor", func() {
 			}
 
 			// We expect one transform call to destination A, after callUnprocessed.
-			mockTransformer.EXPECT().Transform(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).After(callUnprocessed).
+			mockTransformer.EXPECT().DestinationTransform(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).After(callUnprocessed).
 				DoAndReturn(assertDestinationTransform(messages, SourceIDEnabledNoUT, DestinationIDEnabledA, transformExpectations[DestinationIDEnabledA]))
 
 			assertStoreJob := func(job *jobsdb.JobT, i int, destination string) {
@@ -719,7 +720,7 @@ var _ = Describe("Processor", func() {
 				})
 
 			// We expect one transform call to destination B, after user transform for destination B.
-			mockTransformer.EXPECT().Transform(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+			mockTransformer.EXPECT().DestinationTransform(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
 				After(callUserTransform).DoAndReturn(assertDestinationTransform(messages, SourceIDEnabledOnlyUT, DestinationIDEnabledB, transformExpectations[DestinationIDEnabledB]))
 
 			assertStoreJob := func(job *jobsdb.JobT, i int, destination string) {
@@ -830,11 +831,11 @@ var _ = Describe("Processor", func() {
 			mockTransformer.EXPECT().Setup().Times(1)
 
 			callUnprocessed := c.mockGatewayJobsDB.EXPECT().GetUnprocessed(gomock.Any(), gomock.Any()).Return(jobsdb.JobsResult{Jobs: unprocessedJobsList}, nil).Times(1)
-			c.MockDedup.EXPECT().FindDuplicates(gomock.Any(), gomock.Any()).Return([]int{1}).After(callUnprocessed).Times(2)
-			c.MockDedup.EXPECT().MarkProcessed(gomock.Any()).Times(1)
+			c.MockDedup.EXPECT().FindDuplicates(gomock.Any(), gomock.Any(), gomock.Any()).Return([]int{1}, nil).After(callUnprocessed).Times(2)
+			c.MockDedup.EXPECT().MarkProcessed(gomock.Any(), gomock.Any()).Times(1)
 
 			// We expect one transform call to destination A, after callUnprocessed.
-			mockTransformer.EXPECT().Transform(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0).After(callUnprocessed)
+			mockTransformer.EXPECT().DestinationTransform(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0).After(callUnprocessed)
 			// One Store call is expected for all events
 			c.mockRouterJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
 				_ = f(jobsdb.EmptyStoreSafeTx())
@@ -856,6 +857,51 @@ var _ = Describe("Processor", func() {
 			processor.multitenantI = c.MockMultitenantHandle
 			handlePendingGatewayJobs(processor)
 		})
+
+		It("should not store jobs whose events can't be deduped", func() {
+			unprocessedJobsList := []*jobsdb.JobT{
+				{
+					UUID:      uuid.Must(uuid.NewV4()),
+					JobID:     1010,
+					CreatedAt: time.Date(2020, 0o4, 28, 23, 26, 0o0, 0o0, time.UTC),
+					ExpireAt:  time.Date(2020, 0o4, 28, 23, 26, 0o0, 0o0, time.UTC),
+					CustomVal: gatewayCustomVal[0],
+					EventPayload: createBatchPayloadWithSameMessageId(WriteKeyEnabled, "2001-01-02T02:23:45.000Z", []mockEventData{
+						{
+							id:                        "some-id",
+							jobid:                     1010,
+							originalTimestamp:         "2000-02-02T01:23:45",
+							expectedOriginalTimestamp: "2000-02-02T01:23:45.000Z",
+							expectedReceivedAt:        "2001-01-02T02:23:45.000Z",
+							integrations:              map[string]bool{"All": false, "enabled-destination-c-definition-display-name": true},
+						},
+					}),
+					EventCount: 1,
+					Parameters: createBatchParameters(SourceIDEnabled),
+				},
+			}
+
+			mockTransformer := mocksTransformer.NewMockTransformer(c.mockCtrl)
+			mockTransformer.EXPECT().Setup().Times(1)
+
+			c.mockGatewayJobsDB.EXPECT().GetUnprocessed(gomock.Any(), gomock.Any()).Return(jobsdb.JobsResult{Jobs: unprocessedJobsList}, nil).Times(2)
+			dedupErr := errors.New("dedup store unavailable")
+			c.MockDedup.EXPECT().FindDuplicates(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, dedupErr).Times(2)
+
+			processor := &HandleT{
+				transformer: mockTransformer,
+			}
+
+			Setup(processor, c, true, false)
+			processor.dedupHandler = c.MockDedup
+			processor.multitenantI = c.MockMultitenantHandle
+			Expect(func() { processor.handlePendingGatewayJobs(context.Background()) }).To(PanicWith(MatchError(dedupErr)))
+
+			// while shutting down the jobs are left to be picked up again on restart
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			Expect(processor.handlePendingGatewayJobs(ctx)).To(BeFalse())
+		})
 	})
 
 	Context("transformations", func() {
@@ -954,7 +1000,7 @@ var _ = Describe("Processor", func() {
 				PayloadSizeLimit: payloadLimit,
 			}).Return(jobsdb.JobsResult{Jobs: unprocessedJobsList}, nil).Times(1)
 			// Test transformer failure
-			mockTransformer.EXPECT().Transform(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+			mockTransformer.EXPECT().DestinationTransform(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
 				Return(transformer.ResponseT{
 					Events:       []transformer.TransformerResponseT{},
 					FailedEvents: transformerResponses,
@@ -1169,7 +1215,7 @@ var _ = Describe("Processor", func() {
 			}).Return(jobsdb.JobsResult{Jobs: unprocessedJobsList}, nil).Times(1)
 
 			// Test transformer failure
-			mockTransformer.EXPECT().Transform(gomock.Any(), gomock.Len(0), gomock.Any(), gomock.Any()).Times(0)
+			mockTransformer.EXPECT().DestinationTransform(gomock.Any(), gomock.Len(0), gomock.Any(), gomock.Any()).Times(0)
 
 			c.MockMultitenantHandle.EXPECT().ReportProcLoopAddStats(gomock.Any(), gomock.Any()).Times(0)
 
@@ -2036,7 +2082,7 @@ func Setup(processor *HandleT, c *testContext, enableDedup, enableReporting bool
 }
 
 func handlePendingGatewayJobs(processor *HandleT) {
-	didWork := processor.handlePendingGatewayJobs()
+	didWork := processor.handlePendingGatewayJobs(context.Background())
 	Expect(didWork).To(Equal(true))
 }
 
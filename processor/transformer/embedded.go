@@ -0,0 +1,80 @@
+package transformer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/services/stats"
+)
+
+// ErrFallback can be returned by embedded transformers for events they can't transform, so that the batch is sent to the transformer service instead
+var ErrFallback = errors.New("fall back to the transformer service")
+
+// DestinationTransformer transforms the events of a destination type in-process, instead of the transformer service.
+// It returns one or more responses per event, with the same shape as the ones of the transformer service
+type DestinationTransformer interface {
+	Transform(ctx context.Context, events []TransformerEventT) ([]TransformerResponseT, error)
+}
+
+// DestinationTransformerFunc is a function implementing DestinationTransformer
+type DestinationTransformerFunc func(ctx context.Context, events []TransformerEventT) ([]TransformerResponseT, error)
+
+func (f DestinationTransformerFunc) Transform(ctx context.Context, events []TransformerEventT) ([]TransformerResponseT, error) {
+	return f(ctx, events)
+}
+
+var destinationTransformers = struct {
+	sync.RWMutex
+	byDestType map[string]DestinationTransformer
+}{byDestType: make(map[string]DestinationTransformer)}
+
+// RegisterDestinationTransformer registers the embedded transformer of a destination type, replacing any previously registered one.
+// Destination transformations of the type are done by it, unless Processor.embeddedTransformer.<DEST>.enabled is false
+func RegisterDestinationTransformer(destType string, transformer DestinationTransformer) {
+	destinationTransformers.Lock()
+	defer destinationTransformers.Unlock()
+	destinationTransformers.byDestType[destType] = transformer
+}
+
+// DeregisterDestinationTransformer removes the embedded transformer of a destination type
+func DeregisterDestinationTransformer(destType string) {
+	destinationTransformers.Lock()
+	defer destinationTransformers.Unlock()
+	delete(destinationTransformers.byDestType, destType)
+}
+
+// destinationTransformerFor returns the embedded transformer of the destination type, if it is enabled
+func destinationTransformerFor(destType string) (DestinationTransformer, bool) {
+	destinationTransformers.RLock()
+	transformer, ok := destinationTransformers.byDestType[destType]
+	destinationTransformers.RUnlock()
+	if !ok || !config.GetBool("Processor.embeddedTransformer."+destType+".enabled", config.GetBool("Processor.embeddedTransformer.enabled", true)) {
+		return nil, false
+	}
+	return transformer, true
+}
+
+// embeddedTransform transforms a batch of events for their destination with the embedded transformer of their destination type.
+// It returns false if there isn't one or it fell back to the transformer service
+func (trans *HandleT) embeddedTransform(ctx context.Context, data []TransformerEventT) ([]TransformerResponseT, bool) {
+	destType := data[0].Destination.DestinationDefinition.Name
+	transformer, ok := destinationTransformerFor(destType)
+	if !ok {
+		return nil, false
+	}
+	tags := statsTags(data[0])
+	s := time.Now()
+	responses, err := transformer.Transform(ctx, data)
+	stats.Default.NewTaggedStat("processor.embedded_transformer_request_time", stats.TimerType, tags).SendTiming(time.Since(s))
+	if err != nil {
+		if !errors.Is(err, ErrFallback) {
+			trans.logger.Errorf("Embedded transformer of %s failed, falling back to the transformer service: %v", destType, err)
+		}
+		stats.Default.NewTaggedStat("processor.embedded_transformer_fallbacks", stats.CountType, tags).Increment()
+		return nil, false
+	}
+	return responses, true
+}
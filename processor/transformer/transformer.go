@@ -94,6 +94,7 @@ type HandleT struct {
 type Transformer interface {
 	Setup()
 	Transform(ctx context.Context, clientEvents []TransformerEventT, url string, batchSize int) ResponseT
+	DestinationTransform(ctx context.Context, clientEvents []TransformerEventT, url string, batchSize int) ResponseT
 	Validate(clientEvents []TransformerEventT, url string, batchSize int) ResponseT
 }
 
@@ -198,6 +199,20 @@ func GetVersion() (transformerBuildVersion string) {
 // Transform function is used to invoke transformer API
 func (trans *HandleT) Transform(ctx context.Context, clientEvents []TransformerEventT,
 	url string, batchSize int,
+) ResponseT {
+	return trans.transform(ctx, clientEvents, url, batchSize, false)
+}
+
+// DestinationTransform invokes the destination transformation of the events, which is done in-process
+// by the embedded transformer of their destination type if one is registered and enabled
+func (trans *HandleT) DestinationTransform(ctx context.Context, clientEvents []TransformerEventT,
+	url string, batchSize int,
+) ResponseT {
+	return trans.transform(ctx, clientEvents, url, batchSize, true)
+}
+
+func (trans *HandleT) transform(ctx context.Context, clientEvents []TransformerEventT,
+	url string, batchSize int, destinationStage bool,
 ) ResponseT {
 	if len(clientEvents) == 0 {
 		return ResponseT{}
@@ -233,7 +248,7 @@ func (trans *HandleT) Transform(ctx context.Context, clientEvents []TransformerE
 		trans.guardConcurrency <- struct{}{}
 		go func() {
 			trace.WithRegion(ctx, "request", func() {
-				transformResponse[i] = trans.request(ctx, url, clientEvents[from:to])
+				transformResponse[i] = trans.request(ctx, url, clientEvents[from:to], destinationStage)
 			})
 			<-trans.guardConcurrency
 			wg.Done()
@@ -287,7 +302,13 @@ func statsTags(event TransformerEventT) stats.Tags {
 	}
 }
 
-func (trans *HandleT) request(ctx context.Context, url string, data []TransformerEventT) []TransformerResponseT {
+func (trans *HandleT) request(ctx context.Context, url string, data []TransformerEventT, destinationStage bool) []TransformerResponseT {
+	if destinationStage && len(data) > 0 {
+		if transformerResponses, ok := trans.embeddedTransform(ctx, data); ok {
+			return transformerResponses
+		}
+	}
+
 	// Call remote transformation
 	var (
 		rawJSON []byte
@@ -206,3 +206,62 @@ func (elt *endlessLoopTransformer) ServeHTTP(w http.ResponseWriter, r *http.Requ
 	w.Header().Set("apiVersion", "2")
 	require.NoError(elt.t, json.NewEncoder(w).Encode(resps))
 }
+
+func Test_EmbeddedTransformer(t *testing.T) {
+	config.Reset()
+	t.Cleanup(config.Reset)
+	logger.Reset()
+	transformer.Init()
+
+	ft := &fakeTransformer{}
+	srv := httptest.NewServer(ft)
+	defer srv.Close()
+
+	tr := transformer.NewTransformer()
+	tr.Client = srv.Client()
+	tr.Setup()
+
+	var fallback bool
+	transformer.RegisterDestinationTransformer("EMBEDDED", transformer.DestinationTransformerFunc(func(_ context.Context, events []transformer.TransformerEventT) ([]transformer.TransformerResponseT, error) {
+		if fallback {
+			return nil, transformer.ErrFallback
+		}
+		resps := make([]transformer.TransformerResponseT, len(events))
+		for i := range events {
+			resps[i] = transformer.TransformerResponseT{
+				Output:     map[string]interface{}{"embedded": events[i].Message["src-key-1"]},
+				Metadata:   events[i].Metadata,
+				StatusCode: 200,
+			}
+		}
+		return resps, nil
+	}))
+	t.Cleanup(func() { transformer.DeregisterDestinationTransformer("EMBEDDED") })
+
+	events := make([]transformer.TransformerEventT, 3)
+	for i := range events {
+		events[i].Metadata.MessageID = fmt.Sprintf("messageID-%d", i)
+		events[i].Message = map[string]interface{}{"src-key-1": events[i].Metadata.MessageID, "forceStatusCode": 200}
+		events[i].Destination.DestinationDefinition.Name = "EMBEDDED"
+	}
+
+	// the destination transformation url doesn't matter, e.g. behind a proxy with a path prefix and warehouse query params
+	destinationURL := srv.URL + "/transformer/v0/embedded?whSchemaVersion=v1"
+	rsp := tr.DestinationTransform(context.TODO(), events, destinationURL, 2)
+	require.Len(t, rsp.Events, 3)
+	require.Equal(t, "messageID-0", rsp.Events[0].Output["embedded"])
+	require.Empty(t, ft.requests, "destination transformations are done in-process")
+
+	tr.Transform(context.TODO(), events, srv.URL+"/v0/embedded", 2)
+	require.Len(t, ft.requests, 2, "other transformations are sent to the transformer service")
+
+	config.Set("Processor.embeddedTransformer.EMBEDDED.enabled", false)
+	tr.DestinationTransform(context.TODO(), events, destinationURL, 2)
+	require.Len(t, ft.requests, 4, "disabled embedded transformers aren't used")
+	config.Set("Processor.embeddedTransformer.EMBEDDED.enabled", true)
+
+	fallback = true
+	rsp = tr.DestinationTransform(context.TODO(), events, destinationURL, 2)
+	require.Len(t, ft.requests, 6, "destination transformations fall back to the transformer service")
+	require.Equal(t, "messageID-0", rsp.Events[0].Output["echo-key-1"])
+}
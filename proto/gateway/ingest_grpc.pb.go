@@ -0,0 +1,141 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.7
+// source: proto/gateway/ingest.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// IngestClient is the client API for Ingest service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IngestClient interface {
+	// Ingest receives batches of events, acknowledging each of them once it has been stored or rejected.
+	// Acknowledgements can arrive in a different order than the batches.
+	Ingest(ctx context.Context, opts ...grpc.CallOption) (Ingest_IngestClient, error)
+}
+
+type ingestClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIngestClient(cc grpc.ClientConnInterface) IngestClient {
+	return &ingestClient{cc}
+}
+
+func (c *ingestClient) Ingest(ctx context.Context, opts ...grpc.CallOption) (Ingest_IngestClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ingest_ServiceDesc.Streams[0], "/proto.Ingest/Ingest", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingestIngestClient{stream}
+	return x, nil
+}
+
+type Ingest_IngestClient interface {
+	Send(*IngestRequest) error
+	Recv() (*IngestResponse, error)
+	grpc.ClientStream
+}
+
+type ingestIngestClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingestIngestClient) Send(m *IngestRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *ingestIngestClient) Recv() (*IngestResponse, error) {
+	m := new(IngestResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IngestServer is the server API for Ingest service.
+// All implementations must embed UnimplementedIngestServer
+// for forward compatibility
+type IngestServer interface {
+	// Ingest receives batches of events, acknowledging each of them once it has been stored or rejected.
+	// Acknowledgements can arrive in a different order than the batches.
+	Ingest(Ingest_IngestServer) error
+	mustEmbedUnimplementedIngestServer()
+}
+
+// UnimplementedIngestServer must be embedded to have forward compatible implementations.
+type UnimplementedIngestServer struct {
+}
+
+func (UnimplementedIngestServer) Ingest(Ingest_IngestServer) error {
+	return status.Errorf(codes.Unimplemented, "method Ingest not implemented")
+}
+func (UnimplementedIngestServer) mustEmbedUnimplementedIngestServer() {}
+
+// UnsafeIngestServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IngestServer will
+// result in compilation errors.
+type UnsafeIngestServer interface {
+	mustEmbedUnimplementedIngestServer()
+}
+
+func RegisterIngestServer(s grpc.ServiceRegistrar, srv IngestServer) {
+	s.RegisterService(&Ingest_ServiceDesc, srv)
+}
+
+func _Ingest_Ingest_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IngestServer).Ingest(&ingestIngestServer{stream})
+}
+
+type Ingest_IngestServer interface {
+	Send(*IngestResponse) error
+	Recv() (*IngestRequest, error)
+	grpc.ServerStream
+}
+
+type ingestIngestServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingestIngestServer) Send(m *IngestResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *ingestIngestServer) Recv() (*IngestRequest, error) {
+	m := new(IngestRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Ingest_ServiceDesc is the grpc.ServiceDesc for Ingest service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ingest_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.Ingest",
+	HandlerType: (*IngestServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Ingest",
+			Handler:       _Ingest_Ingest_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "proto/gateway/ingest.proto",
+}
@@ -3,55 +3,275 @@ package ratelimiter
 //go:generate mockgen -destination=../mocks/rate-limiter/mock_ratelimiter.go -package=mocks_ratelimiter github.com/rudderlabs/rudder-server/rate-limiter RateLimiter
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
 	"time"
 
-	"github.com/EagleChen/restrictor"
+	"github.com/go-redis/redis"
+
 	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/services/ratelimiter"
 	"github.com/rudderlabs/rudder-server/utils/logger"
 )
 
 var (
 	eventLimit            int
 	rateLimitWindowInMins time.Duration
-	noOfBucketsInWindow   int
+	writeKeyEventLimit    int
 	pkgLogger             logger.Logger
 )
 
 // RateLimiter is an interface for rate limiting functions
 type RateLimiter interface {
-	LimitReached(key string) bool
+	// Check counts a request of a write key and its workspace, unless one of their limits has been reached
+	Check(workspaceID, writeKey string) LimitStatus
+}
+
+// LimitStatus is the status of the most restrictive limit of a request
+type LimitStatus struct {
+	// Limited is true if the request has been rejected
+	Limited bool
+	// Limit is the number of requests allowed within the window
+	Limit int64
+	// Remaining is the number of requests still allowed within the window
+	Remaining int64
+	// Reset is the time until the current window ends
+	Reset time.Duration
+	// RetryAfter is the time until requests are allowed again, if Limited
+	RetryAfter time.Duration
 }
 
-// HandleT is a Handle for event limiter
+// HandleT is a Handle for event limiter.
+// Requests are limited per workspace, with RateLimit.eventLimit requests per RateLimit.rateLimitWindow by default,
+// and per write key, with RateLimit.writeKeyEventLimit requests per RateLimit.writeKeyRateLimitWindow, if it is set.
+// Limits of specific workspaces and write keys are read from RateLimit.workspaces and RateLimit.writeKeys, json objects of limits
+// by workspace id and write key, once per config reload. Counters are kept in memory by default, thus each gateway enforces limits on its own.
+// With RateLimit.store set to redis, limits are shared by all the gateways using it
 type HandleT struct {
-	restrictor restrictor.Restrictor
+	now func() time.Time
+
+	limitersMu sync.Mutex
+	limiters   map[time.Duration]*ratelimiter.RateLimiter // window -> limiter
+	newStore   func(window time.Duration) ratelimiter.LimitStore
+
+	limitsMu      sync.RWMutex
+	limitsReloads uint64             // config reloads the limits were resolved at
+	limits        map[string][]limit // workspaceID:writeKey -> limits
 }
 
 func Init() {
-	loadConfig()
 	pkgLogger = logger.NewLogger().Child("rate-limiter")
+	loadConfig()
 }
 
 func loadConfig() {
 	// Event limit when rate limit is enabled. 1000 by default
-	config.RegisterIntConfigVariable(1000, &eventLimit, false, 1, "RateLimit.eventLimit")
+	config.RegisterIntConfigVariable(1000, &eventLimit, true, 1, "RateLimit.eventLimit")
 	// Rolling time window for event limit. 60 mins by default
-	config.RegisterDurationConfigVariable(60, &rateLimitWindowInMins, false, time.Minute, []string{"RateLimit.rateLimitWindow", "RateLimit.rateLimitWindowInMins"}...)
-	// Number of buckets in time window. 12 by default
-	config.RegisterIntConfigVariable(12, &noOfBucketsInWindow, false, 1, "RateLimit.noOfBucketsInWindow")
+	config.RegisterDurationConfigVariable(60, &rateLimitWindowInMins, true, time.Minute, []string{"RateLimit.rateLimitWindow", "RateLimit.rateLimitWindowInMins"}...)
+	// Event limit of write keys. Write keys are only limited by their workspace's limit by default
+	config.RegisterIntConfigVariable(0, &writeKeyEventLimit, true, 1, "RateLimit.writeKeyEventLimit")
+	if config.IsSet("RateLimit.noOfBucketsInWindow") {
+		pkgLogger.Warn("RateLimit.noOfBucketsInWindow is deprecated and ignored, requests are limited over a sliding window")
+	}
 }
 
 // SetUp eventLimiter
 func (rateLimiter *HandleT) SetUp() {
-	store, err := restrictor.NewMemoryStore()
-	if err != nil {
-		pkgLogger.Error("memory store failed")
+	rateLimiter.now = time.Now
+	rateLimiter.limiters = make(map[time.Duration]*ratelimiter.RateLimiter)
+	rateLimiter.limits = make(map[string][]limit)
+	switch store := config.GetString("RateLimit.store", "memory"); store {
+	case "memory":
+		rateLimiter.newStore = func(window time.Duration) ratelimiter.LimitStore {
+			return ratelimiter.NewMapLimitStore(2*window, 10*time.Second)
+		}
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     config.GetString("RateLimit.redis.addr", "localhost:6379"),
+			Password: config.GetString("RateLimit.redis.password", ""),
+			DB:       config.GetInt("RateLimit.redis.db", 0),
+		})
+		rateLimiter.newStore = func(window time.Duration) ratelimiter.LimitStore {
+			return ratelimiter.NewRedisLimitStore(client, 2*window)
+		}
+	default:
+		panic(fmt.Errorf("unsupported rate limit store: %q", store))
+	}
+}
+
+// limit is the number of requests allowed for a key within a window
+type limit struct {
+	key    string
+	limit  int64
+	window time.Duration
+}
+
+// limitsOf returns the limits of a workspace and a write key, resolving them again only if the config has been reloaded
+func (rateLimiter *HandleT) limitsOf(workspaceID, writeKey string) []limit {
+	reloads := config.Reloads()
+	key := workspaceID + ":" + writeKey
+	rateLimiter.limitsMu.RLock()
+	limits, ok := rateLimiter.limits[key]
+	ok = ok && rateLimiter.limitsReloads == reloads
+	rateLimiter.limitsMu.RUnlock()
+	if ok {
+		return limits
 	}
 
-	rateLimiter.restrictor = restrictor.NewRestrictor(rateLimitWindowInMins, uint32(eventLimit), uint32(noOfBucketsInWindow), store)
+	limits = resolveLimits(workspaceID, writeKey)
+	rateLimiter.limitsMu.Lock()
+	defer rateLimiter.limitsMu.Unlock()
+	if rateLimiter.limitsReloads != reloads {
+		rateLimiter.limits = make(map[string][]limit)
+		rateLimiter.limitsReloads = reloads
+	}
+	rateLimiter.limits[key] = limits
+	return limits
 }
 
-// LimitReached returns true if number of events in the rolling window is less than the max events allowed, else false
-func (rateLimiter *HandleT) LimitReached(key string) bool {
-	return rateLimiter.restrictor.LimitReached(key)
+// limitSettingsT is the limit of a workspace or a write key in RateLimit.workspaces or RateLimit.writeKeys
+type limitSettingsT struct {
+	EventLimit      *int        `json:"eventLimit"`
+	RateLimitWindow interface{} `json:"rateLimitWindow"` // a duration, e.g. "30s", or a number of seconds
+}
+
+// window returns the window of the settings, or defaultWindow if it isn't set or valid
+func (s limitSettingsT) window(defaultWindow time.Duration) time.Duration {
+	switch window := s.RateLimitWindow.(type) {
+	case string:
+		if d, err := time.ParseDuration(window); err == nil {
+			return d
+		}
+		if seconds, err := strconv.ParseFloat(window, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	case float64:
+		return time.Duration(window * float64(time.Second))
+	}
+	return defaultWindow
+}
+
+// limitSettingsOf reads the limit settings of an id from key, a json object of settings by id.
+// Ids are case sensitive, while config paths are not, thus their settings can't be read from <key>.<id>
+func limitSettingsOf(key, id string) limitSettingsT {
+	var settings map[string]limitSettingsT
+	value := config.GetString(key, "")
+	if value == "" {
+		return limitSettingsT{}
+	}
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		pkgLogger.Errorf("Invalid %s: %v", key, err)
+		return limitSettingsT{}
+	}
+	return settings[id]
+}
+
+// resolveLimits reads the limits of a workspace and a write key from the config.
+// Windows are durations, e.g. 30s, plain numbers being seconds
+func resolveLimits(workspaceID, writeKey string) []limit {
+	workspaceSettings := limitSettingsOf("RateLimit.workspaces", workspaceID)
+	workspaceLimit := eventLimit
+	if workspaceSettings.EventLimit != nil {
+		workspaceLimit = *workspaceSettings.EventLimit
+	}
+	limits := []limit{{
+		key:    "workspace:" + workspaceID,
+		limit:  int64(workspaceLimit),
+		window: workspaceSettings.window(rateLimitWindowInMins),
+	}}
+
+	writeKeySettings := limitSettingsOf("RateLimit.writeKeys", writeKey)
+	writeKeyLimit := writeKeyEventLimit
+	if writeKeySettings.EventLimit != nil {
+		writeKeyLimit = *writeKeySettings.EventLimit
+	}
+	if writeKey != "" && writeKeyLimit > 0 {
+		defaultWindow := config.GetDuration("RateLimit.writeKeyRateLimitWindow", int64(rateLimitWindowInMins/time.Second), time.Second)
+		limits = append(limits, limit{
+			key:    "writeKey:" + writeKey,
+			limit:  int64(writeKeyLimit),
+			window: writeKeySettings.window(defaultWindow),
+		})
+	}
+	return limits
+}
+
+// limiterOf returns the limiter of a window, each window has its own store
+func (rateLimiter *HandleT) limiterOf(window time.Duration) *ratelimiter.RateLimiter {
+	rateLimiter.limitersMu.Lock()
+	defer rateLimiter.limitersMu.Unlock()
+	limiter, ok := rateLimiter.limiters[window]
+	if !ok {
+		limiter = ratelimiter.New(rateLimiter.newStore(window), 0, window)
+		rateLimiter.limiters[window] = limiter
+	}
+	return limiter
+}
+
+// Check counts a request of a write key and its workspace, unless one of their limits has been reached.
+// Each counter is checked and incremented atomically by its store, and the counters already incremented are decremented again
+// if a later limit has been reached. Requests are allowed if the counters can't be read from the store
+func (rateLimiter *HandleT) Check(workspaceID, writeKey string) LimitStatus {
+	now := rateLimiter.now()
+	var status LimitStatus
+	status.Remaining = math.MaxInt64
+	var counted []limit // limits whose counters have been incremented
+	for _, l := range rateLimiter.limitsOf(workspaceID, writeKey) {
+		if l.window <= 0 {
+			continue
+		}
+		limiter := rateLimiter.limiterOf(l.window)
+		var limitStatus *ratelimiter.LimitStatus
+		var err error
+		if status.Limited {
+			// the request is rejected anyway, only looking for the most restrictive limit
+			limitStatus, err = limiter.CheckWithLimit(l.key, l.limit, now)
+		} else {
+			limitStatus, err = limiter.IncWithLimit(l.key, l.limit, now)
+		}
+		if err != nil {
+			pkgLogger.Errorf("Checking rate limit of %s: %v", l.key, err)
+			continue
+		}
+		reset := l.window - now.UTC().Sub(now.UTC().Truncate(l.window))
+		if limitStatus.IsLimited {
+			var retryAfter time.Duration
+			if limitStatus.LimitDuration != nil && *limitStatus.LimitDuration > 0 {
+				retryAfter = *limitStatus.LimitDuration
+			} else {
+				retryAfter = reset
+			}
+			if !status.Limited || retryAfter > status.RetryAfter {
+				status = LimitStatus{Limited: true, Limit: l.limit, Remaining: 0, Reset: reset, RetryAfter: retryAfter}
+			}
+			continue
+		}
+		if status.Limited {
+			continue
+		}
+		counted = append(counted, l)
+		remaining := l.limit - int64(math.Ceil(limitStatus.CurrentRate)) - 1
+		if remaining < 0 {
+			remaining = 0
+		}
+		if remaining < status.Remaining {
+			status.Limit, status.Remaining, status.Reset = l.limit, remaining, reset
+		}
+	}
+	if status.Limited {
+		for _, l := range counted {
+			if err := rateLimiter.limiterOf(l.window).Dec(l.key, 1, now); err != nil {
+				pkgLogger.Errorf("Uncounting request of %s: %v", l.key, err)
+			}
+		}
+		return status
+	}
+	if status.Remaining == math.MaxInt64 {
+		status.Remaining = 0
+	}
+	return status
 }
@@ -0,0 +1,90 @@
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+func TestRateLimiter(t *testing.T) {
+	config.Reset()
+	logger.Reset()
+	t.Cleanup(config.Reset)
+	config.Set("RateLimit.eventLimit", 3)
+	config.Set("RateLimit.rateLimitWindow", "1m")
+	config.Set("RateLimit.workspaces", `{"big": {"eventLimit": 100}}`)
+	config.Set("RateLimit.writeKeys", `{"limited": {"eventLimit": 2, "rateLimitWindow": "10s"}}`)
+	Init()
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var rateLimiter HandleT
+	rateLimiter.SetUp()
+	rateLimiter.now = func() time.Time { return now }
+
+	t.Run("workspaces are limited by default", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			status := rateLimiter.Check("w1", "key1")
+			require.False(t, status.Limited)
+			require.EqualValues(t, 3, status.Limit)
+			require.EqualValues(t, 2-i, status.Remaining)
+			require.Equal(t, time.Minute, status.Reset)
+		}
+		status := rateLimiter.Check("w1", "key2")
+		require.True(t, status.Limited, "write keys of a workspace share its limit")
+		require.EqualValues(t, 0, status.Remaining)
+		require.Positive(t, status.RetryAfter)
+
+		require.False(t, rateLimiter.Check("w2", "key3").Limited, "workspaces have their own counters")
+	})
+
+	t.Run("limits of workspaces and write keys can be configured", func(t *testing.T) {
+		status := rateLimiter.Check("big", "limited")
+		require.False(t, status.Limited)
+		require.EqualValues(t, 2, status.Limit, "the most restrictive limit is reported")
+		require.EqualValues(t, 1, status.Remaining)
+		require.Equal(t, 10*time.Second, status.Reset)
+
+		require.False(t, rateLimiter.Check("big", "limited").Limited)
+		status = rateLimiter.Check("big", "limited")
+		require.True(t, status.Limited)
+		require.EqualValues(t, 2, status.Limit)
+		require.LessOrEqual(t, status.RetryAfter, 20*time.Second)
+
+		require.False(t, rateLimiter.Check("big", "unlimited").Limited, "write keys are only limited by their workspace by default")
+
+		workspaceStatus, err := rateLimiter.limiterOf(time.Minute).CheckWithLimit("workspace:big", 100, now)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, workspaceStatus.CurrentRate, "requests rejected by the write key limit aren't counted by the workspace")
+
+		require.EqualValues(t, 100, rateLimiter.Check("big", "Limited").Limit, "write keys are case sensitive")
+	})
+
+	t.Run("limits are resolved again when the config changes", func(t *testing.T) {
+		require.EqualValues(t, 100, rateLimiter.Check("big", "reloaded").Limit)
+		config.Set("RateLimit.writeKeys", `{"limited": {"eventLimit": 2, "rateLimitWindow": "10s"}, "reloaded": {"eventLimit": 1}}`)
+		require.EqualValues(t, 1, rateLimiter.Check("big", "reloaded").Limit)
+	})
+
+	t.Run("windows can be shorter than a minute", func(t *testing.T) {
+		config.Set("RateLimit.workspaces", `{"big": {"eventLimit": 100}, "short": {"rateLimitWindow": 30}}`)
+		status := rateLimiter.Check("short", "")
+		require.False(t, status.Limited)
+		require.Equal(t, 30*time.Second, status.Reset)
+
+		config.Set("RateLimit.rateLimitWindow", "20s")
+		status = rateLimiter.Check("w3", "")
+		require.False(t, status.Limited)
+		require.Equal(t, 20*time.Second, status.Reset)
+		config.Set("RateLimit.rateLimitWindow", "1m")
+	})
+
+	t.Run("limits are lifted once the window passes", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		require.False(t, rateLimiter.Check("w1", "key1").Limited)
+		require.False(t, rateLimiter.Check("big", "limited").Limited)
+	})
+}
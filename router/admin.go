@@ -1,6 +1,7 @@
 package router
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -63,6 +64,9 @@ func (ra *Admin) Status() interface{} {
 		if len(barriersMap) > 0 {
 			routerStatus["worker-barriers"] = barriersMap
 		}
+		if circuitBreakers := router.circuitBreakers.states(); len(circuitBreakers) > 0 {
+			routerStatus["circuit-breakers"] = circuitBreakers
+		}
 
 		statusList = append(statusList, routerStatus)
 	}
@@ -241,6 +245,91 @@ func (r *RouterRpcHandler) GetDSList(_ string, result *string) (err error) {
 	return nil
 }
 
+// DrainDestinationArg is the argument of the DrainDestination admin function
+type DrainDestinationArg struct {
+	DestinationID string
+	Reason        string
+}
+
+// PauseDestination stops the router from picking up the jobs of a destination, until it gets resumed
+func (r *RouterRpcHandler) PauseDestination(destinationID string, result *string) (err error) {
+	return r.setDestinationControl(destinationID, &destinationControl{State: destinationPaused}, result)
+}
+
+// DrainDestination makes the router abort the jobs of a destination with the given reason, until it gets resumed
+func (r *RouterRpcHandler) DrainDestination(arg DrainDestinationArg, result *string) (err error) {
+	reason := arg.Reason
+	if reason == "" {
+		reason = "destination drained by admin"
+	}
+	return r.setDestinationControl(arg.DestinationID, &destinationControl{State: destinationDraining, Reason: reason}, result)
+}
+
+// ResumeDestination makes the router process the jobs of a paused or drained destination again
+func (r *RouterRpcHandler) ResumeDestination(destinationID string, result *string) (err error) {
+	return r.setDestinationControl(destinationID, nil, result)
+}
+
+// setDestinationControl persists the control of a destination, or removes it if control is nil.
+// The routers of this server reload their destination controls before replying, while the routers of other servers
+// pick up the change the next time they reload theirs, within Router.destinationControls.refreshInterval
+func (r *RouterRpcHandler) setDestinationControl(destinationID string, control *destinationControl, result *string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pkgLogger.Error(r)
+			err = fmt.Errorf("internal Rudder server error: %v", r)
+		}
+	}()
+	if r.jobsDBPrefix != "rt" {
+		return fmt.Errorf("destination controls are not supported by %s", r.jobsDBPrefix)
+	}
+	if destinationID == "" {
+		return fmt.Errorf("destination id is required")
+	}
+	dbHandle, err := sql.Open("postgres", misc.GetConnectionString())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dbHandle.Close() }()
+
+	ctx := context.Background()
+	state := "resumed"
+	if control == nil {
+		err = removeDestinationControl(ctx, dbHandle, destinationID)
+	} else {
+		state = control.State
+		err = setDestinationControl(ctx, dbHandle, destinationID, *control)
+	}
+	if err != nil {
+		return err
+	}
+	*result = fmt.Sprintf("destination %s %s", destinationID, state)
+	if err := reloadDestinationControls(ctx); err != nil {
+		pkgLogger.Errorf("Failed to reload destination controls: %v", err)
+		*result += ", taking effect within the destination controls refresh interval"
+	}
+	return nil
+}
+
+// reloadDestinationControls reloads the destination controls of the routers of this server, so that changes take effect right away
+func reloadDestinationControls(ctx context.Context) error {
+	if adminInstance == nil {
+		return nil
+	}
+	reloaded := make(map[*DestinationControls]bool)
+	for _, handle := range adminInstance.handles {
+		dc := handle.destinationControls
+		if dc == nil || reloaded[dc] {
+			continue
+		}
+		if err := dc.load(ctx); err != nil {
+			return err
+		}
+		reloaded[dc] = true
+	}
+	return nil
+}
+
 /*
 JobCountsByStateAndDestination
 ================================================================================
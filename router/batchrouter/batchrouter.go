@@ -24,6 +24,7 @@ import (
 
 	"github.com/rudderlabs/rudder-server/router"
 	"github.com/rudderlabs/rudder-server/router/batchrouter/asyncdestinationmanager"
+	"github.com/rudderlabs/rudder-server/router/deliveryschedule"
 	"github.com/rudderlabs/rudder-server/router/rterror"
 	destinationConnectionTester "github.com/rudderlabs/rudder-server/services/destination-connection-tester"
 	"github.com/rudderlabs/rudder-server/services/metric"
@@ -96,6 +97,7 @@ type HandleT struct {
 	asyncUploadTimeout          time.Duration
 	uploadIntervalMap           map[string]time.Duration
 	retryTimeWindow             time.Duration
+	deliverySchedules           *deliveryschedule.Schedules
 	reporting                   types.ReportingI
 	reportingEnabled            bool
 	workers                     []*workerT
@@ -217,6 +219,11 @@ func (brt *HandleT) backendConfigSubscriber() {
 			}
 		}
 
+		destinations := make([]backendconfig.DestinationT, 0, len(brt.destinationsMap))
+		for _, destination := range brt.destinationsMap {
+			destinations = append(destinations, destination.Destination)
+		}
+		brt.deliverySchedules.Set(destinations)
 		if !brt.isBackendConfigInitialized {
 			brt.isBackendConfigInitialized = true
 			brt.backendConfigInitialized <- true
@@ -1218,7 +1225,8 @@ func (brt *HandleT) setJobStatus(batchJobs *BatchJobsT, isWarehouse bool, errOcc
 			brt.logger.Error("Unmarshal of job parameters failed. ", string(job.Parameters))
 		}
 
-		timeElapsed := time.Since(firstAttemptedAt)
+		// time spent outside of the delivery windows of the destination doesn't count against the retry time window
+		timeElapsed := brt.deliverySchedules.Get(parameters.DestinationID).ActiveDuration(firstAttemptedAt, time.Now())
 		switch jobState {
 		case jobsdb.Failed.State:
 			if !postToWarehouseErr && timeElapsed > brt.retryTimeWindow && job.LastJobStatus.AttemptNum >= brt.
@@ -1960,6 +1968,10 @@ func (brt *HandleT) readAndProcess() {
 				brt.logger.Debugf("BRT: Skipping batch router upload loop since %s:%s upload freq not exceeded", batchDest.Destination.DestinationDefinition.Name, destID)
 				continue
 			}
+			if !brt.deliverySchedules.Get(destID).Allows(time.Now()) {
+				brt.logger.Debugf("BRT: Skipping batch router upload loop since %s:%s is outside of its delivery windows", batchDest.Destination.DestinationDefinition.Name, destID)
+				continue
+			}
 			brt.setDestInProgress(destID, true)
 
 			brt.processQ <- &BatchDestinationDataT{batchDestination: *batchDest, jobs: jobs, parentWG: nil}
@@ -1975,10 +1987,19 @@ func (brt *HandleT) readAndProcess() {
 		brtQueryStat.Start()
 
 		if !brt.holdFetchingJobs([]jobsdb.ParameterFilterT{}) {
+			// jobs of destinations outside of their delivery windows are left out of the query, instead of using up the jobs limit
+			var excludedDestinations []jobsdb.ParameterFilterT
+			now := time.Now()
+			for destID := range destinationsMap {
+				if !brt.deliverySchedules.Get(destID).Allows(now) {
+					excludedDestinations = append(excludedDestinations, jobsdb.ParameterFilterT{Name: "destination_id", Value: destID})
+				}
+			}
 			queryParams := jobsdb.GetQueryParamsT{
-				CustomValFilters: []string{brt.destType},
-				JobsLimit:        brt.jobQueryBatchSize,
-				PayloadSizeLimit: brt.payloadLimit,
+				CustomValFilters:         []string{brt.destType},
+				ExcludedParameterFilters: excludedDestinations,
+				JobsLimit:                brt.jobQueryBatchSize,
+				PayloadSizeLimit:         brt.payloadLimit,
 			}
 			toRetry, err := misc.QueryWithRetriesAndNotify(context.Background(), brt.jobdDBQueryRequestTimeout, brt.jobdDBMaxRetries, func(ctx context.Context) (jobsdb.JobsResult, error) {
 				return brt.jobsDB.GetToRetry(ctx, queryParams)
@@ -2019,6 +2040,10 @@ func (brt *HandleT) readAndProcess() {
 			var wg sync.WaitGroup
 			for destID, destJobs := range jobsByDesID {
 				if batchDest, ok := destinationsMap[destID]; ok {
+					if !brt.deliverySchedules.Get(destID).Allows(time.Now()) {
+						brt.logger.Debugf("BRT: %s: Skipping destination %s since it is outside of its delivery windows", brt.destType, destID)
+						continue
+					}
 					brt.setDestInProgress(destID, true)
 					wg.Add(1)
 					brt.processQ <- &BatchDestinationDataT{batchDestination: *batchDest, jobs: destJobs, parentWG: &wg}
@@ -2032,11 +2057,17 @@ func (brt *HandleT) readAndProcess() {
 }
 
 func (brt *HandleT) mainLoop(ctx context.Context) {
+	newJobs := brt.subscribeToNewJobs(ctx)
 loop:
 	for {
 		select {
 		case <-ctx.Done():
 			break loop
+		case _, ok := <-newJobs:
+			if !ok { // subscription closed
+				break loop
+			}
+			brt.readAndProcess()
 		case <-time.After(mainLoopSleep):
 			brt.readAndProcess()
 		}
@@ -2046,6 +2077,23 @@ loop:
 	close(brt.processQ)
 }
 
+// subscribeToNewJobs subscribes to new jobs stored for the batch router's destination type, if jobsDB supports it.
+// The main loop then reads new jobs as soon as they are stored, instead of waiting for mainLoopSleep.
+func (brt *HandleT) subscribeToNewJobs(ctx context.Context) <-chan struct{} {
+	subscriber, ok := brt.jobsDB.(jobsdb.Subscriber)
+	if !ok {
+		return nil
+	}
+	newJobs, err := subscriber.Subscribe(ctx, jobsdb.GetQueryParamsT{CustomValFilters: []string{brt.destType}})
+	if err != nil {
+		if !errors.Is(err, jobsdb.ErrNotificationsDisabled) {
+			brt.logger.Warnf("BRT: %s: Failed to subscribe to new jobs, polling instead: %v", brt.destType, err)
+		}
+		return nil
+	}
+	return newJobs
+}
+
 // Enable enables a router :)
 func (brt *HandleT) Enable() {
 	brt.isEnabled = true
@@ -2250,7 +2298,7 @@ func (brt *HandleT) collectMetrics(ctx context.Context) {
 func loadConfig() {
 	config.RegisterDurationConfigVariable(2, &mainLoopSleep, true, time.Second, []string{"BatchRouter.mainLoopSleep", "BatchRouter.mainLoopSleepInS"}...)
 	config.RegisterInt64ConfigVariable(30, &uploadFreqInS, true, 1, "BatchRouter.uploadFreqInS")
-	objectStorageDestinations = []string{"S3", "GCS", "AZURE_BLOB", "MINIO", "DIGITAL_OCEAN_SPACES"}
+	objectStorageDestinations = []string{"S3", "GCS", "AZURE_BLOB", "MINIO", "DIGITAL_OCEAN_SPACES", "LOCAL_FS", "SFTP"}
 	asyncDestinations = []string{"MARKETO_BULK_UPLOAD"}
 	warehouseURL = misc.GetWarehouseURL()
 	// Time period for diagnosis ticker
@@ -2298,6 +2346,7 @@ func (brt *HandleT) Setup(backendConfig backendconfig.BackendConfig, jobsDB, err
 
 	brt.inProgressMap = map[string]bool{}
 	brt.lastExecMap = map[string]int64{}
+	brt.deliverySchedules = deliveryschedule.NewSchedules()
 	brt.encounteredMergeRuleMap = map[string]map[string]bool{}
 	brt.uploadedRawDataJobsCache = make(map[string]map[string]bool)
 
@@ -18,6 +18,7 @@ import (
 	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
 	mocksFileManager "github.com/rudderlabs/rudder-server/mocks/services/filemanager"
 	mocksMultitenant "github.com/rudderlabs/rudder-server/mocks/services/multitenant"
+	"github.com/rudderlabs/rudder-server/router/deliveryschedule"
 	router_utils "github.com/rudderlabs/rudder-server/router/utils"
 	"github.com/rudderlabs/rudder-server/services/filemanager"
 	"github.com/rudderlabs/rudder-server/services/rsources"
@@ -259,6 +260,31 @@ var _ = Describe("BatchRouter", func() {
 			batchrouter.readAndProcess()
 		})
 
+		It("should leave jobs of destinations outside of their delivery windows out of the query", func() {
+			batchrouter := &HandleT{}
+
+			batchrouter.Setup(c.mockBackendConfig, c.mockBatchRouterJobsDB, c.mockProcErrorsDB, s3DestinationDefinition.Name, nil, c.mockMultitenantI, transientsource.NewEmptyService(), rsources.NewNoOpService())
+			readPerDestination = false
+
+			queryParams := jobsdb.GetQueryParamsT{
+				CustomValFilters:         []string{CustomVal["S3"]},
+				ExcludedParameterFilters: []jobsdb.ParameterFilterT{{Name: "destination_id", Value: S3DestinationID}},
+				JobsLimit:                c.jobQueryBatchSize,
+				PayloadSizeLimit:         batchrouter.payloadLimit,
+			}
+			callRetry := c.mockBatchRouterJobsDB.EXPECT().GetToRetry(gomock.Any(), queryParams).Return(jobsdb.JobsResult{}, nil).Times(1)
+			c.mockBatchRouterJobsDB.EXPECT().GetUnprocessed(gomock.Any(), queryParams).Return(jobsdb.JobsResult{}, nil).Times(1).After(callRetry)
+
+			<-batchrouter.backendConfigInitialized
+			batchrouter.deliverySchedules.Set([]backendconfig.DestinationT{{
+				ID: S3DestinationID,
+				Config: map[string]interface{}{deliveryschedule.DestinationConfigKey: map[string]interface{}{
+					"deny": []interface{}{"* 00:00-24:00"},
+				}},
+			}})
+			batchrouter.readAndProcess()
+		})
+
 		// It("should split batchJobs based on timeWindow for s3 datalake destination", func() {
 
 		// 	batchJobs := BatchJobsT{
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/services/stats"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+// circuitBreakers keeps a circuit breaker per destination id around the requests sent to the destinations of a router.
+// A breaker opens after consecutiveFailures failed requests, short-circuiting the jobs of its destination back to the failed state
+// for openTimeout, after which it lets halfOpenRequests requests probe the destination before closing again
+type circuitBreakers struct {
+	destName            string
+	enabled             bool
+	consecutiveFailures int
+	openTimeout         time.Duration
+	halfOpenRequests    int
+	logger              logger.Logger
+
+	breakersMu sync.Mutex
+	breakers   map[string]*gobreaker.TwoStepCircuitBreaker
+}
+
+func newCircuitBreakers(destName string) *circuitBreakers {
+	cb := &circuitBreakers{
+		destName: destName,
+		logger:   logger.NewLogger().Child("router").Child("circuitbreaker"),
+		breakers: make(map[string]*gobreaker.TwoStepCircuitBreaker),
+	}
+	config.RegisterBoolConfigVariable(false, &cb.enabled, false, "Router."+destName+".circuitBreaker.enabled", "Router.circuitBreaker.enabled")
+	config.RegisterIntConfigVariable(10, &cb.consecutiveFailures, false, 1, "Router."+destName+".circuitBreaker.consecutiveFailures", "Router.circuitBreaker.consecutiveFailures")
+	config.RegisterDurationConfigVariable(60, &cb.openTimeout, false, time.Second, "Router."+destName+".circuitBreaker.openTimeout", "Router.circuitBreaker.openTimeout")
+	config.RegisterIntConfigVariable(1, &cb.halfOpenRequests, false, 1, "Router."+destName+".circuitBreaker.halfOpenRequests", "Router.circuitBreaker.halfOpenRequests")
+	return cb
+}
+
+// allow checks whether a request can be sent to a destination, returning an error if its breaker is open or already probing it.
+// Otherwise, done must be called with the status code of the destination response
+func (cb *circuitBreakers) allow(destID string) (done func(statusCode int), err error) {
+	if cb == nil || !cb.enabled {
+		return func(int) {}, nil
+	}
+	breakerDone, err := cb.get(destID).Allow()
+	if err != nil {
+		return nil, fmt.Errorf("circuit breaker of destination %s: %w", destID, err)
+	}
+	return func(statusCode int) {
+		breakerDone(!isDestinationFailure(statusCode))
+	}, nil
+}
+
+// states returns the state of the breakers which aren't closed
+func (cb *circuitBreakers) states() map[string]string {
+	states := make(map[string]string)
+	if cb == nil {
+		return states
+	}
+	cb.breakersMu.Lock()
+	defer cb.breakersMu.Unlock()
+	for destID, breaker := range cb.breakers {
+		if state := breaker.State(); state != gobreaker.StateClosed {
+			states[destID] = state.String()
+		}
+	}
+	return states
+}
+
+func (cb *circuitBreakers) get(destID string) *gobreaker.TwoStepCircuitBreaker {
+	cb.breakersMu.Lock()
+	defer cb.breakersMu.Unlock()
+	breaker, ok := cb.breakers[destID]
+	if !ok {
+		breaker = gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+			Name:        destID,
+			MaxRequests: uint32(cb.halfOpenRequests),
+			Timeout:     cb.openTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= uint32(cb.consecutiveFailures)
+			},
+			OnStateChange: cb.onStateChange,
+		})
+		cb.breakers[destID] = breaker
+	}
+	return breaker
+}
+
+func (cb *circuitBreakers) onStateChange(destID string, from, to gobreaker.State) {
+	cb.logger.Infof("[%s Router] :: Circuit breaker of destination %s changed from %s to %s", cb.destName, destID, from, to)
+	stats.Default.NewTaggedStat("router_circuit_breaker_state_changes", stats.CountType, stats.Tags{
+		"destType": cb.destName,
+		"destId":   destID,
+		"from":     from.String(),
+		"to":       to.String(),
+	}).Increment()
+}
+
+// isDestinationFailure returns true if a status code means that the destination is unavailable, as opposed to rejecting a request
+func isDestinationFailure(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusTooManyRequests
+}
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+)
+
+func TestCircuitBreakers(t *testing.T) {
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	t.Run("disabled", func(t *testing.T) {
+		cb := newCircuitBreakers("TEST_DISABLED")
+		for i := 0; i < 20; i++ {
+			done, err := cb.allow("dest")
+			require.NoError(t, err)
+			done(http.StatusInternalServerError)
+		}
+		require.Empty(t, cb.states())
+
+		var nilBreakers *circuitBreakers
+		_, err := nilBreakers.allow("dest")
+		require.NoError(t, err)
+		require.Empty(t, nilBreakers.states())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		config.Set("Router.TEST.circuitBreaker.enabled", true)
+		config.Set("Router.TEST.circuitBreaker.consecutiveFailures", 3)
+		config.Set("Router.TEST.circuitBreaker.openTimeout", "100ms")
+		cb := newCircuitBreakers("TEST")
+
+		send := func(destID string, statusCode int) error {
+			done, err := cb.allow(destID)
+			if err != nil {
+				return err
+			}
+			done(statusCode)
+			return nil
+		}
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, send("dest", http.StatusBadRequest), "rejected requests aren't failures")
+		}
+		for i := 0; i < 3; i++ {
+			require.NoError(t, send("dest", http.StatusServiceUnavailable))
+		}
+		require.ErrorIs(t, send("dest", http.StatusOK), gobreaker.ErrOpenState)
+		require.NoError(t, send("other", http.StatusOK), "breakers are per destination id")
+		require.Equal(t, map[string]string{"dest": "open"}, cb.states())
+
+		time.Sleep(150 * time.Millisecond)
+		probeDone, err := cb.allow("dest")
+		require.NoError(t, err, "half-open breaker allows a probe")
+		require.ErrorIs(t, send("dest", http.StatusOK), gobreaker.ErrTooManyRequests)
+		require.Equal(t, map[string]string{"dest": "half-open"}, cb.states())
+		probeDone(http.StatusOK)
+		require.Empty(t, cb.states())
+		require.NoError(t, send("dest", http.StatusOK))
+	})
+}
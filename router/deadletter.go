@@ -0,0 +1,30 @@
+package router
+
+import (
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/router/deadletter"
+)
+
+// newDeadLetter returns the dead letter of an aborted job along with its dead-letter target, if its destination has one.
+// Jobs which are dead letters themselves are never forwarded again
+func (rt *HandleT) newDeadLetter(job *jobsdb.JobT, status *jobsdb.JobStatusT, parameters JobParametersT) (deadletter.Letter, string, bool) {
+	target := rt.deadLetterForwarder.Target(parameters.DestinationID)
+	if target == "" || gjson.GetBytes(job.Parameters, deadletter.ParameterKey).Bool() {
+		return deadletter.Letter{}, "", false
+	}
+	return deadletter.Letter{
+		JobID:         job.JobID,
+		WorkspaceID:   job.WorkspaceId,
+		SourceID:      parameters.SourceID,
+		DestinationID: parameters.DestinationID,
+		UserID:        job.UserID,
+		AttemptNum:    status.AttemptNum,
+		ErrorCode:     status.ErrorCode,
+		ErrorResponse: status.ErrorResponse,
+		Payload:       job.EventPayload,
+		AbortedAt:     status.ExecTime,
+		Parameters:    job.Parameters,
+	}, target, true
+}
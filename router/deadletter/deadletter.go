@@ -0,0 +1,684 @@
+// Package deadletter forwards the jobs aborted by the router to the dead-letter target configured for their destination.
+//
+// Dead-letter targets are configured through Router.deadLetter.destinations.<destination id>: <target>, where target is either
+//   - the id of another destination of the workspace. Letters are uploaded as gzipped json lines files to object storage
+//     destinations (S3, GCS, MINIO, etc.), produced as messages to the topic of kafka destinations (KAFKA, CONFLUENT_CLOUD,
+//     AZURE_EVENT_HUB) and stored as router jobs of WEBHOOK destinations, which post them to their webhook url
+//   - bucket:<bucket>, uploading letters to a bucket of the Router.deadLetter.bucket.provider object storage provider,
+//     using the same credentials as jobs backups (see filemanager.GetProviderConfigFromEnv)
+//   - kafka:<topic>, producing letters to a topic of the Router.deadLetter.kafka broker
+//
+// Letters are enqueued in the rt_dead_letters table of the router jobsdb database, in the same transaction updating the
+// status of their aborted jobs, and are forwarded from there in the background, with retries. Letters stored as webhook jobs
+// are removed from the queue in the same transaction storing them, so they are stored exactly once, while letters uploaded
+// or produced are sent again if removing them from the queue fails.
+package deadletter
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/services/filemanager"
+	"github.com/rudderlabs/rudder-server/services/metric"
+	"github.com/rudderlabs/rudder-server/services/stats"
+	"github.com/rudderlabs/rudder-server/services/streammanager"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const (
+	// ParameterKey is the job parameter marking router jobs which are dead letters, so that they don't get forwarded again
+	ParameterKey = "dead_letter"
+
+	queueTable         = "rt_dead_letters"
+	bucketTargetPrefix = "bucket:"
+	kafkaTargetPrefix  = "kafka:"
+)
+
+var (
+	pkgLogger                 = logger.NewLogger().Child("router").Child("deadletter")
+	objectStorageDestinations = []string{"S3", "GCS", "AZURE_BLOB", "MINIO", "DIGITAL_OCEAN_SPACES"}
+	kafkaDestinations         = []string{"KAFKA", "CONFLUENT_CLOUD", "AZURE_EVENT_HUB"}
+)
+
+// Letter is an aborted job along with the last error it got aborted with
+type Letter struct {
+	JobID         int64           `json:"jobId"`
+	WorkspaceID   string          `json:"workspaceId"`
+	SourceID      string          `json:"sourceId"`
+	DestinationID string          `json:"destinationId"`
+	UserID        string          `json:"userId"`
+	AttemptNum    int             `json:"attemptNum"`
+	ErrorCode     string          `json:"errorCode"`
+	ErrorResponse json.RawMessage `json:"errorResponse"`
+	Payload       json.RawMessage `json:"payload"`
+	AbortedAt     time.Time       `json:"abortedAt"`
+
+	// Parameters are the parameters of the aborted job, used for reporting the letter once forwarded
+	Parameters json.RawMessage `json:"-"`
+}
+
+// jobParameters are the job parameters needed for reporting letters
+type jobParameters struct {
+	SourceID                string `json:"source_id"`
+	DestinationID           string `json:"destination_id"`
+	SourceBatchID           string `json:"source_batch_id"`
+	SourceTaskID            string `json:"source_task_id"`
+	SourceTaskRunID         string `json:"source_task_run_id"`
+	SourceJobID             string `json:"source_job_id"`
+	SourceJobRunID          string `json:"source_job_run_id"`
+	SourceDefinitionID      string `json:"source_definition_id"`
+	DestinationDefinitionID string `json:"destination_definition_id"`
+	SourceCategory          string `json:"source_category"`
+}
+
+// queuedLetter is a letter waiting in the queue to be forwarded
+type queuedLetter struct {
+	id      int64
+	attempt int
+	letter  Letter
+}
+
+// producerHolder keeps the config of a kafka destination along with its producer
+type producerHolder struct {
+	config   map[string]interface{}
+	producer common.StreamProducer
+}
+
+// Forwarder forwards letters to dead-letter targets
+type Forwarder struct {
+	backendConfig      backendconfig.BackendConfig
+	jobsDB             jobsdb.JobsDB // router jobsdb, where letters for webhook destinations are stored
+	reporting          types.ReportingI
+	dbHandle           *sql.DB // router jobsdb database, where letters are queued
+	fileManagerFactory filemanager.FileManagerFactory
+
+	timeout          time.Duration
+	pollInterval     time.Duration
+	batchSize        int
+	maxAttempts      int
+	minRetryInterval time.Duration
+	maxRetryInterval time.Duration
+
+	initialized    chan struct{}
+	destinationsMu sync.RWMutex
+	destinations   map[string]backendconfig.DestinationT // destinationID -> destination, of all workspaces
+
+	producersMu sync.Mutex
+	producers   map[string]*producerHolder // target -> producer
+}
+
+// New creates the dead-letter queue table in the router jobsdb database, if it doesn't exist, and returns a forwarder
+// storing letters for webhook destinations into the router jobsdb
+func New(ctx context.Context, backendConfig backendconfig.BackendConfig, routerDB jobsdb.JobsDB, reporting types.ReportingI, connInfo string) (*Forwarder, error) {
+	dbHandle, err := sql.Open("postgres", connInfo)
+	if err != nil {
+		return nil, err
+	}
+	if err := setupQueueTable(ctx, dbHandle); err != nil {
+		_ = dbHandle.Close()
+		return nil, err
+	}
+	f := newForwarder(backendConfig, routerDB, reporting)
+	f.dbHandle = dbHandle
+	return f, nil
+}
+
+func newForwarder(backendConfig backendconfig.BackendConfig, routerDB jobsdb.JobsDB, reporting types.ReportingI) *Forwarder {
+	f := &Forwarder{
+		backendConfig:      backendConfig,
+		jobsDB:             routerDB,
+		reporting:          reporting,
+		fileManagerFactory: filemanager.DefaultFileManagerFactory,
+		initialized:        make(chan struct{}),
+		destinations:       make(map[string]backendconfig.DestinationT),
+		producers:          make(map[string]*producerHolder),
+	}
+	config.RegisterDurationConfigVariable(30, &f.timeout, true, time.Second, "Router.deadLetter.timeout")
+	config.RegisterDurationConfigVariable(1, &f.pollInterval, true, time.Second, "Router.deadLetter.pollInterval")
+	config.RegisterIntConfigVariable(1000, &f.batchSize, true, 1, "Router.deadLetter.batchSize")
+	config.RegisterIntConfigVariable(10, &f.maxAttempts, true, 1, "Router.deadLetter.maxAttempts")
+	config.RegisterDurationConfigVariable(10, &f.minRetryInterval, true, time.Second, "Router.deadLetter.minRetryInterval")
+	config.RegisterDurationConfigVariable(3600, &f.maxRetryInterval, true, time.Second, "Router.deadLetter.maxRetryInterval")
+	return f
+}
+
+// Close closes the connection to the dead-letter queue
+func (f *Forwarder) Close() {
+	_ = f.dbHandle.Close()
+}
+
+// Run keeps the destinations up to date with the backend config and forwards the queued letters, until the context is cancelled
+func (f *Forwarder) Run(ctx context.Context) {
+	defer f.closeProducers()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.forwardLoop(ctx)
+	}()
+	ch := f.backendConfig.Subscribe(ctx, backendconfig.TopicBackendConfig)
+	for configEvent := range ch {
+		destinations := make(map[string]backendconfig.DestinationT)
+		for _, wConfig := range configEvent.Data.(map[string]backendconfig.ConfigT) {
+			for i := range wConfig.Sources {
+				for _, destination := range wConfig.Sources[i].Destinations {
+					destinations[destination.ID] = destination
+				}
+			}
+		}
+		f.destinationsMu.Lock()
+		f.destinations = destinations
+		f.destinationsMu.Unlock()
+		select {
+		case <-f.initialized:
+		default:
+			close(f.initialized)
+		}
+	}
+	<-done
+}
+
+// Target returns the dead-letter target of a destination, or an empty string if it doesn't have one.
+// It is safe to be called on a nil Forwarder
+func (f *Forwarder) Target(destinationID string) string {
+	if f == nil || destinationID == "" {
+		return ""
+	}
+	return config.GetString("Router.deadLetter.destinations."+destinationID, "")
+}
+
+// Enqueue queues the letters to be forwarded to a dead-letter target, as part of the transaction updating the status of their jobs
+func (f *Forwarder) Enqueue(ctx context.Context, tx *sql.Tx, target string, letters []Letter) error {
+	if len(letters) == 0 {
+		return nil
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO %s (target, letter, parameters) VALUES ($1, $2, $3)`, queueTable))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	for i := range letters {
+		letter, err := json.Marshal(letters[i])
+		if err != nil {
+			return err
+		}
+		parameters := letters[i].Parameters
+		if len(parameters) == 0 {
+			parameters = []byte(`{}`)
+		}
+		if _, err := stmt.ExecContext(ctx, target, string(letter), string(parameters)); err != nil {
+			return fmt.Errorf("enqueuing dead letter of job %d: %w", letters[i].JobID, err)
+		}
+	}
+	return nil
+}
+
+// forwardLoop forwards the queued letters every Router.deadLetter.pollInterval, once the destinations are known
+func (f *Forwarder) forwardLoop(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-f.initialized:
+	}
+	if err := f.reporting.WaitForSetup(ctx, types.CORE_REPORTING_CLIENT); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.pollInterval):
+			if err := f.forwardQueued(ctx); err != nil && ctx.Err() == nil {
+				pkgLogger.Errorf("Failed to forward dead letters: %v", err)
+			}
+		}
+	}
+}
+
+// forwardQueued forwards a batch of the letters due for forwarding, grouped by target
+func (f *Forwarder) forwardQueued(ctx context.Context) error {
+	rows, err := f.dbHandle.QueryContext(ctx, fmt.Sprintf(`SELECT id, target, attempt, letter, parameters FROM %s WHERE retry_at <= NOW() ORDER BY id LIMIT $1`, queueTable), f.batchSize)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	var targets []string
+	queued := make(map[string][]queuedLetter) // target -> letters
+	for rows.Next() {
+		var (
+			target             string
+			q                  queuedLetter
+			letter, parameters []byte
+		)
+		if err := rows.Scan(&q.id, &target, &q.attempt, &letter, &parameters); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(letter, &q.letter); err != nil {
+			return fmt.Errorf("unmarshalling dead letter %d: %w", q.id, err)
+		}
+		q.letter.Parameters = parameters
+		if _, ok := queued[target]; !ok {
+			targets = append(targets, target)
+		}
+		queued[target] = append(queued[target], q)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_ = rows.Close()
+
+	for _, target := range targets {
+		if err := f.forwardTarget(ctx, target, queued[target]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forwardTarget forwards the queued letters of a target. Letters which fail to be forwarded are retried with an exponential backoff,
+// up to Router.deadLetter.maxAttempts, after which they are dropped
+func (f *Forwarder) forwardTarget(ctx context.Context, target string, queued []queuedLetter) error {
+	ids := make([]int64, 0, len(queued))
+	letters := make([]Letter, 0, len(queued))
+	for i := range queued {
+		ids = append(ids, queued[i].id)
+		letters = append(letters, queued[i].letter)
+	}
+	forwardErr := f.forward(ctx, target, letters, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1)`, queueTable), pq.Array(ids)); err != nil {
+			return err
+		}
+		f.reporting.Report(reportMetrics(letters, jobsdb.Succeeded.State, 200, `{}`), tx)
+		return nil
+	})
+	if forwardErr == nil {
+		f.countLetters(target, jobsdb.Succeeded.State, len(letters))
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	pkgLogger.Errorf("Failed to forward %d dead letters to %s: %v", len(letters), target, forwardErr)
+
+	var retryIDs, droppedIDs []int64
+	var dropped []Letter
+	for i := range queued {
+		if queued[i].attempt+1 >= f.maxAttempts {
+			droppedIDs = append(droppedIDs, queued[i].id)
+			dropped = append(dropped, queued[i].letter)
+		} else {
+			retryIDs = append(retryIDs, queued[i].id)
+		}
+	}
+	err := f.withTx(ctx, func(tx *sql.Tx) error {
+		if len(retryIDs) > 0 {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET attempt = attempt + 1, retry_at = NOW() + LEAST($2 * POWER(2, attempt), $3) * INTERVAL '1 millisecond' WHERE id = ANY($1)`, queueTable),
+				pq.Array(retryIDs), f.minRetryInterval.Milliseconds(), f.maxRetryInterval.Milliseconds()); err != nil {
+				return err
+			}
+		}
+		if len(droppedIDs) > 0 {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1)`, queueTable), pq.Array(droppedIDs)); err != nil {
+				return err
+			}
+			errorResponse, _ := json.Marshal(map[string]string{"reason": forwardErr.Error()})
+			f.reporting.Report(reportMetrics(dropped, jobsdb.Aborted.State, 500, string(errorResponse)), tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(dropped) > 0 {
+		pkgLogger.Errorf("Dropping %d dead letters for %s after %d attempts: %v", len(dropped), target, f.maxAttempts, forwardErr)
+		f.countLetters(target, jobsdb.Aborted.State, len(dropped))
+	}
+	f.countLetters(target, jobsdb.Failed.State, len(retryIDs))
+	return nil
+}
+
+func (*Forwarder) countLetters(target, state string, count int) {
+	if count == 0 {
+		return
+	}
+	stats.Default.NewTaggedStat("router_dead_letter_events", stats.CountType, stats.Tags{
+		"target": target,
+		"state":  state,
+	}).Count(count)
+}
+
+// forward sends the letters to a dead-letter target, running commit in the transaction storing them for webhook targets,
+// or in a transaction of its own after sending them otherwise
+func (f *Forwarder) forward(ctx context.Context, target string, letters []Letter, commit func(tx *sql.Tx) error) error {
+	if len(letters) == 0 {
+		return nil
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	switch {
+	case strings.HasPrefix(target, bucketTargetPrefix):
+		settings := bucketSettings(ctx, strings.TrimPrefix(target, bucketTargetPrefix))
+		if err := f.upload(sendCtx, settings, letters); err != nil {
+			return err
+		}
+	case strings.HasPrefix(target, kafkaTargetPrefix):
+		if err := f.produce(kafkaDestination(strings.TrimPrefix(target, kafkaTargetPrefix)), letters); err != nil {
+			return err
+		}
+	default:
+		f.destinationsMu.RLock()
+		destination, ok := f.destinations[target]
+		f.destinationsMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("dead-letter destination %s not found", target)
+		}
+		destType := destination.DestinationDefinition.Name
+		var err error
+		switch {
+		case misc.Contains(objectStorageDestinations, destType):
+			err = f.upload(sendCtx, &filemanager.SettingsT{
+				Provider: destType,
+				Config: misc.GetObjectStorageConfig(misc.ObjectStorageOptsT{
+					Provider:    destType,
+					Config:      destination.Config,
+					WorkspaceID: destination.WorkspaceID,
+				}),
+			}, letters)
+		case misc.Contains(kafkaDestinations, destType):
+			err = f.produce(&destination, letters)
+		case destType == "WEBHOOK":
+			return f.store(sendCtx, &destination, letters, commit)
+		default:
+			err = fmt.Errorf("destination type %s is not supported as a dead-letter destination", destType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if commit == nil {
+		return nil
+	}
+	return f.withTx(ctx, commit)
+}
+
+func (f *Forwarder) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := f.dbHandle.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// bucketSettings returns the file manager settings of a bucket:<bucket> target, using the credentials of jobs backups
+func bucketSettings(ctx context.Context, bucket string) *filemanager.SettingsT {
+	provider := config.GetString("Router.deadLetter.bucket.provider", config.GetString("JOBS_BACKUP_STORAGE_PROVIDER", "S3"))
+	providerConfig := filemanager.GetProviderConfigFromEnv(ctx, provider)
+	switch provider {
+	case "AZURE_BLOB":
+		providerConfig["containerName"] = bucket
+	case "LOCAL_FS", "SFTP":
+		providerConfig["rootPath"] = bucket
+	default:
+		providerConfig["bucketName"] = bucket
+	}
+	providerConfig["prefix"] = config.GetString("Router.deadLetter.bucket.prefix", "")
+	return &filemanager.SettingsT{Provider: provider, Config: providerConfig}
+}
+
+// kafkaDestination returns the kafka destination of a kafka:<topic> target, producing to the Router.deadLetter.kafka broker
+func kafkaDestination(topic string) *backendconfig.DestinationT {
+	return &backendconfig.DestinationT{
+		ID:                    kafkaTargetPrefix + topic,
+		DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "KAFKA"},
+		Config: map[string]interface{}{
+			"topic":         topic,
+			"hostname":      config.GetString("Router.deadLetter.kafka.hostname", ""),
+			"port":          config.GetString("Router.deadLetter.kafka.port", "9092"),
+			"sslEnabled":    config.GetBool("Router.deadLetter.kafka.sslEnabled", false),
+			"caCertificate": config.GetString("Router.deadLetter.kafka.caCertificate", ""),
+			"useSASL":       config.GetBool("Router.deadLetter.kafka.useSASL", false),
+			"saslType":      config.GetString("Router.deadLetter.kafka.saslType", ""),
+			"username":      config.GetString("Router.deadLetter.kafka.username", ""),
+			"password":      config.GetString("Router.deadLetter.kafka.password", ""),
+		},
+	}
+}
+
+// upload uploads the letters as a gzipped json lines file, under rudder-dead-letters/<destination id>/<date>
+func (f *Forwarder) upload(ctx context.Context, settings *filemanager.SettingsT, letters []Letter) error {
+	dir, err := os.MkdirTemp("", "rudder-dead-letters")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	now := time.Now()
+	filePath := filepath.Join(dir, fmt.Sprintf("%d.%d.%d.json.gz", now.UnixMilli(), letters[0].JobID, letters[len(letters)-1].JobID))
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	gzWriter := gzip.NewWriter(file)
+	encoder := json.NewEncoder(gzWriter)
+	for i := range letters {
+		if err := encoder.Encode(letters[i]); err != nil {
+			return err
+		}
+	}
+	if err := gzWriter.Close(); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return err
+	}
+
+	fm, err := f.fileManagerFactory.New(settings)
+	if err != nil {
+		return err
+	}
+	_, err = fm.Upload(ctx, file, "rudder-dead-letters", letters[0].DestinationID, now.Format("2006-01-02"))
+	return err
+}
+
+// produce produces the letters to the topic of a kafka destination, keyed by their user id
+func (f *Forwarder) produce(destination *backendconfig.DestinationT, letters []Letter) error {
+	producer, err := f.producer(destination)
+	if err != nil {
+		return err
+	}
+	type message struct {
+		Message Letter `json:"message"`
+		UserID  string `json:"userId"`
+	}
+	send := func(v interface{}) error {
+		payload, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		statusCode, _, response := producer.Produce(payload, destination.Config)
+		if statusCode != 200 {
+			return fmt.Errorf("producing to %s: %d: %s", destination.ID, statusCode, response)
+		}
+		return nil
+	}
+
+	if config.GetBool("Router.KAFKA.enableBatching", false) {
+		batch := make([]message, 0, len(letters))
+		for i := range letters {
+			batch = append(batch, message{Message: letters[i], UserID: letters[i].UserID})
+		}
+		return send(batch)
+	}
+	for i := range letters {
+		if err := send(message{Message: letters[i], UserID: letters[i].UserID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// producer returns the producer of a kafka destination, creating a new one if its config has changed
+func (f *Forwarder) producer(destination *backendconfig.DestinationT) (common.StreamProducer, error) {
+	f.producersMu.Lock()
+	defer f.producersMu.Unlock()
+	if holder, ok := f.producers[destination.ID]; ok {
+		if reflect.DeepEqual(holder.config, destination.Config) {
+			return holder.producer, nil
+		}
+		_ = holder.producer.Close()
+		delete(f.producers, destination.ID)
+	}
+	producer, err := streammanager.NewProducer(destination, common.Opts{Timeout: f.timeout})
+	if err != nil {
+		return nil, err
+	}
+	f.producers[destination.ID] = &producerHolder{config: destination.Config, producer: producer}
+	return producer, nil
+}
+
+func (f *Forwarder) closeProducers() {
+	f.producersMu.Lock()
+	defer f.producersMu.Unlock()
+	for destinationID, holder := range f.producers {
+		if err := holder.producer.Close(); err != nil {
+			pkgLogger.Warnf("Closing dead-letter producer of %s: %v", destinationID, err)
+		}
+		delete(f.producers, destinationID)
+	}
+}
+
+// store stores the letters as router jobs of a webhook destination, posting each letter to its webhook url.
+// commit, if not nil, runs in the transaction storing them
+func (f *Forwarder) store(ctx context.Context, destination *backendconfig.DestinationT, letters []Letter, commit func(tx *sql.Tx) error) error {
+	webhookURL, _ := destination.Config["webhookUrl"].(string)
+	if webhookURL == "" {
+		return fmt.Errorf("dead-letter destination %s has no webhook url", destination.ID)
+	}
+	destType := destination.DestinationDefinition.Name
+	now := time.Now()
+	jobs := make([]*jobsdb.JobT, 0, len(letters))
+	for i := range letters {
+		letter := &letters[i]
+		payload, err := json.Marshal(map[string]interface{}{
+			"version":  "1",
+			"type":     "REST",
+			"method":   "POST",
+			"endpoint": webhookURL,
+			"userId":   letter.UserID,
+			"headers":  map[string]interface{}{"Content-Type": "application/json"},
+			"params":   map[string]interface{}{},
+			"files":    map[string]interface{}{},
+			"body": map[string]interface{}{
+				"JSON":       letter,
+				"JSON_ARRAY": map[string]interface{}{},
+				"XML":        map[string]interface{}{},
+				"FORM":       map[string]interface{}{},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		messageID := uuid.Must(uuid.NewV4())
+		parameters, err := json.Marshal(map[string]interface{}{
+			"source_id":      letter.SourceID,
+			"destination_id": destination.ID,
+			"message_id":     messageID.String(),
+			"received_at":    now.Format(misc.RFC3339Milli),
+			"transform_at":   "processor",
+			ParameterKey:     true,
+		})
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, &jobsdb.JobT{
+			UUID:         messageID,
+			UserID:       letter.UserID,
+			CreatedAt:    now,
+			ExpireAt:     now,
+			CustomVal:    destType,
+			EventCount:   1,
+			EventPayload: payload,
+			Parameters:   parameters,
+			WorkspaceId:  destination.WorkspaceID,
+		})
+	}
+	err := f.jobsDB.WithStoreSafeTx(ctx, func(tx jobsdb.StoreSafeTx) error {
+		if err := f.jobsDB.StoreInTx(ctx, tx, jobs); err != nil {
+			return err
+		}
+		if commit == nil {
+			return nil
+		}
+		return commit(tx.SqlTx())
+	})
+	if err != nil {
+		return err
+	}
+	metric.IncreasePendingEvents("rt", destination.WorkspaceID, destType, float64(len(jobs)))
+	return nil
+}
+
+// reportMetrics returns the reporting metrics of forwarded or dropped letters, per connection
+func reportMetrics(letters []Letter, state string, errorCode int, errorResponse string) []*types.PUReportedMetric {
+	connectionDetailsMap := make(map[string]*types.ConnectionDetails)
+	statusDetailsMap := make(map[string]*types.StatusDetail)
+	var keys []string
+	for i := range letters {
+		var parameters jobParameters
+		_ = json.Unmarshal(letters[i].Parameters, &parameters)
+		key := fmt.Sprintf("%s:%s:%s", parameters.SourceID, parameters.DestinationID, parameters.SourceBatchID)
+		if _, ok := connectionDetailsMap[key]; !ok {
+			keys = append(keys, key)
+			connectionDetailsMap[key] = types.CreateConnectionDetail(parameters.SourceID, parameters.DestinationID, parameters.SourceBatchID, parameters.SourceTaskID, parameters.SourceTaskRunID, parameters.SourceJobID, parameters.SourceJobRunID, parameters.SourceDefinitionID, parameters.DestinationDefinitionID, parameters.SourceCategory)
+			statusDetailsMap[key] = types.CreateStatusDetail(state, 0, errorCode, errorResponse, letters[i].Payload, "", "")
+		}
+		statusDetailsMap[key].Count++
+	}
+	metrics := make([]*types.PUReportedMetric, 0, len(keys))
+	for _, key := range keys {
+		metrics = append(metrics, &types.PUReportedMetric{
+			ConnectionDetails: *connectionDetailsMap[key],
+			PUDetails:         *types.CreatePUDetails(types.ROUTER, types.DEAD_LETTER, true, false),
+			StatusDetail:      statusDetailsMap[key],
+		})
+	}
+	return metrics
+}
+
+func setupQueueTable(ctx context.Context, dbHandle *sql.DB) error {
+	if _, err := dbHandle.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id BIGSERIAL PRIMARY KEY,
+		target TEXT NOT NULL,
+		letter JSON NOT NULL,
+		parameters JSON NOT NULL,
+		attempt INT NOT NULL DEFAULT 0,
+		retry_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW())`, queueTable)); err != nil {
+		return fmt.Errorf("creating %s table: %w", queueTable, err)
+	}
+	if _, err := dbHandle.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_retry_at_idx ON %[1]s (retry_at)`, queueTable)); err != nil {
+		return fmt.Errorf("creating %s index: %w", queueTable, err)
+	}
+	return nil
+}
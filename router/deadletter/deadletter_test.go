@@ -0,0 +1,249 @@
+package deadletter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/enterprise/reporting"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksBackendConfig "github.com/rudderlabs/rudder-server/mocks/config/backend-config"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+	mock_filemanager "github.com/rudderlabs/rudder-server/mocks/services/filemanager"
+	"github.com/rudderlabs/rudder-server/services/filemanager"
+	"github.com/rudderlabs/rudder-server/testhelper/destination"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/pubsub"
+)
+
+func TestForwarder(t *testing.T) {
+	config.Reset()
+	defer config.Reset()
+
+	ctrl := gomock.NewController(t)
+	mockBackendConfig := mocksBackendConfig.NewMockBackendConfig(ctrl)
+	mockJobsDB := mocksJobsDB.NewMockJobsDB(ctrl)
+	mockFileManagerFactory := mock_filemanager.NewMockFileManagerFactory(ctrl)
+
+	destination := func(id, destType string, config map[string]interface{}) backendconfig.DestinationT {
+		return backendconfig.DestinationT{
+			ID:                    id,
+			WorkspaceID:           "workspace",
+			Config:                config,
+			DestinationDefinition: backendconfig.DestinationDefinitionT{Name: destType},
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockBackendConfig.EXPECT().Subscribe(gomock.Any(), backendconfig.TopicBackendConfig).DoAndReturn(func(ctx context.Context, topic backendconfig.Topic) pubsub.DataChannel {
+		ch := make(chan pubsub.DataEvent, 1)
+		ch <- pubsub.DataEvent{Data: map[string]backendconfig.ConfigT{"workspace": {
+			Sources: []backendconfig.SourceT{{
+				ID: "source",
+				Destinations: []backendconfig.DestinationT{
+					destination("webhook", "WEBHOOK", map[string]interface{}{"webhookUrl": "https://example.com/dead-letters"}),
+					destination("webhook-without-url", "WEBHOOK", map[string]interface{}{}),
+					destination("s3", "S3", map[string]interface{}{"bucketName": "dead-letters"}),
+					destination("ga", "GA", map[string]interface{}{}),
+				},
+			}},
+		}}, Topic: string(topic)}
+		go func() {
+			<-ctx.Done()
+			close(ch)
+		}()
+		return ch
+	})
+
+	f := newForwarder(mockBackendConfig, mockJobsDB, &reporting.NOOP{})
+	f.fileManagerFactory = mockFileManagerFactory
+	f.pollInterval = time.Hour // there is no queue to poll
+	done := make(chan struct{})
+	go func() {
+		f.Run(ctx)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		f.destinationsMu.RLock()
+		defer f.destinationsMu.RUnlock()
+		return len(f.destinations) == 4
+	}, time.Second, 10*time.Millisecond)
+
+	letters := []Letter{
+		{JobID: 1, WorkspaceID: "workspace", SourceID: "source", DestinationID: "ga", UserID: "u1", ErrorCode: "400", ErrorResponse: []byte(`{"reason":"bad request"}`), Payload: []byte(`{"event":"e1"}`)},
+		{JobID: 2, WorkspaceID: "workspace", SourceID: "source", DestinationID: "ga", UserID: "u2", ErrorCode: "500", ErrorResponse: []byte(`{}`), Payload: []byte(`{"event":"e2"}`)},
+	}
+
+	t.Run("target", func(t *testing.T) {
+		config.Set("Router.deadLetter.destinations.ga", "webhook")
+		require.Equal(t, "webhook", f.Target("ga"))
+		require.Empty(t, f.Target("other"))
+
+		var nilForwarder *Forwarder
+		require.Empty(t, nilForwarder.Target("ga"))
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) error {
+			return f(jobsdb.EmptyStoreSafeTx())
+		})
+		mockJobsDB.EXPECT().StoreInTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Do(func(_ context.Context, _ jobsdb.StoreSafeTx, jobs []*jobsdb.JobT) {
+			require.Len(t, jobs, 2)
+			for i, job := range jobs {
+				require.Equal(t, "WEBHOOK", job.CustomVal)
+				require.Equal(t, letters[i].UserID, job.UserID)
+				require.Equal(t, "webhook", gjson.GetBytes(job.Parameters, "destination_id").String())
+				require.True(t, gjson.GetBytes(job.Parameters, ParameterKey).Bool())
+				require.Equal(t, "https://example.com/dead-letters", gjson.GetBytes(job.EventPayload, "endpoint").String())
+				require.Equal(t, letters[i].ErrorCode, gjson.GetBytes(job.EventPayload, "body.JSON.errorCode").String())
+				require.JSONEq(t, string(letters[i].Payload), gjson.GetBytes(job.EventPayload, "body.JSON.payload").Raw)
+			}
+		}).Return(nil)
+		require.NoError(t, f.forward(context.Background(), "webhook", letters, nil))
+		require.Error(t, f.forward(context.Background(), "webhook-without-url", letters, nil))
+	})
+
+	t.Run("object storage", func(t *testing.T) {
+		mockFileManager := mock_filemanager.NewMockFileManager(ctrl)
+		mockFileManagerFactory.EXPECT().New(gomock.Any()).Times(1).DoAndReturn(func(settings *filemanager.SettingsT) (filemanager.FileManager, error) {
+			require.Equal(t, "S3", settings.Provider)
+			return mockFileManager, nil
+		})
+		mockFileManager.EXPECT().Upload(gomock.Any(), gomock.Any(), "rudder-dead-letters", "ga", gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, file *os.File, _ ...string) (filemanager.UploadOutput, error) {
+			gzReader, err := gzip.NewReader(file)
+			require.NoError(t, err)
+			scanner := bufio.NewScanner(gzReader)
+			var uploaded []Letter
+			for scanner.Scan() {
+				var letter Letter
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &letter))
+				uploaded = append(uploaded, letter)
+			}
+			require.Equal(t, letters, uploaded)
+			return filemanager.UploadOutput{}, nil
+		})
+		require.NoError(t, f.forward(context.Background(), "s3", letters, nil))
+	})
+
+	t.Run("bucket", func(t *testing.T) {
+		config.Set("Router.deadLetter.bucket.provider", "MINIO")
+		config.Set("Router.deadLetter.bucket.prefix", "aborted")
+		mockFileManager := mock_filemanager.NewMockFileManager(ctrl)
+		mockFileManagerFactory.EXPECT().New(gomock.Any()).Times(1).DoAndReturn(func(settings *filemanager.SettingsT) (filemanager.FileManager, error) {
+			require.Equal(t, "MINIO", settings.Provider)
+			require.Equal(t, "dead-letters", settings.Config["bucketName"])
+			require.Equal(t, "aborted", settings.Config["prefix"])
+			return mockFileManager, nil
+		})
+		mockFileManager.EXPECT().Upload(gomock.Any(), gomock.Any(), "rudder-dead-letters", "ga", gomock.Any()).Times(1).Return(filemanager.UploadOutput{}, nil)
+		require.NoError(t, f.forward(context.Background(), "bucket:dead-letters", letters, nil))
+	})
+
+	t.Run("kafka topic", func(t *testing.T) {
+		config.Set("Router.deadLetter.kafka.hostname", "kafka.example.com")
+		destination := kafkaDestination("dead-letters")
+		require.Equal(t, "KAFKA", destination.DestinationDefinition.Name)
+		require.Equal(t, "dead-letters", destination.Config["topic"])
+		require.Equal(t, "kafka.example.com", destination.Config["hostname"])
+		require.Equal(t, "9092", destination.Config["port"])
+	})
+
+	t.Run("unsupported destinations", func(t *testing.T) {
+		require.Error(t, f.forward(context.Background(), "ga", letters, nil))
+		require.Error(t, f.forward(context.Background(), "unknown", letters, nil))
+	})
+
+	cancel()
+	<-done
+}
+
+func TestForwarderQueue(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	postgresContainer, err := destination.SetupPostgres(pool, t)
+	require.NoError(t, err)
+	config.Set("DB.host", postgresContainer.Host)
+	config.Set("DB.port", postgresContainer.Port)
+	config.Set("DB.user", postgresContainer.User)
+	config.Set("DB.name", postgresContainer.Database)
+	config.Set("DB.password", postgresContainer.Password)
+	t.Cleanup(config.Reset)
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mockFileManagerFactory := mock_filemanager.NewMockFileManagerFactory(ctrl)
+	mockFileManager := mock_filemanager.NewMockFileManager(ctrl)
+	mockFileManagerFactory.EXPECT().New(gomock.Any()).AnyTimes().Return(mockFileManager, nil)
+
+	f, err := New(ctx, mocksBackendConfig.NewMockBackendConfig(ctrl), mocksJobsDB.NewMockJobsDB(ctrl), &reporting.NOOP{}, misc.GetConnectionString())
+	require.NoError(t, err)
+	defer f.Close()
+	f.fileManagerFactory = mockFileManagerFactory
+	f.maxAttempts = 2
+	f.destinations = map[string]backendconfig.DestinationT{"s3": {ID: "s3", DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "S3"}, Config: map[string]interface{}{}}}
+
+	enqueue := func(letters ...Letter) {
+		require.NoError(t, f.withTx(ctx, func(tx *sql.Tx) error {
+			return f.Enqueue(ctx, tx, "s3", letters)
+		}))
+	}
+	queued := func() (count, attempt int) {
+		require.NoError(t, f.dbHandle.QueryRow(`SELECT COUNT(*), COALESCE(MAX(attempt), 0) FROM rt_dead_letters`).Scan(&count, &attempt))
+		return count, attempt
+	}
+	makeDue := func() {
+		_, err := f.dbHandle.Exec(`UPDATE rt_dead_letters SET retry_at = NOW()`)
+		require.NoError(t, err)
+	}
+
+	letter := Letter{JobID: 1, DestinationID: "ga", Payload: []byte(`{"event":"e1"}`), ErrorResponse: []byte(`{}`), Parameters: []byte(`{"source_id":"source","destination_id":"ga"}`)}
+
+	t.Run("failed letters are retried later, then dropped", func(t *testing.T) {
+		enqueue(letter)
+		mockFileManager.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(filemanager.UploadOutput{}, errors.New("unavailable"))
+		require.NoError(t, f.forwardQueued(ctx))
+		count, attempt := queued()
+		require.Equal(t, 1, count)
+		require.Equal(t, 1, attempt)
+
+		require.NoError(t, f.forwardQueued(ctx), "letters are not retried before their retry time")
+
+		makeDue()
+		mockFileManager.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(filemanager.UploadOutput{}, errors.New("unavailable"))
+		require.NoError(t, f.forwardQueued(ctx))
+		count, _ = queued()
+		require.Zero(t, count)
+	})
+
+	t.Run("forwarded letters are removed from the queue", func(t *testing.T) {
+		enqueue(letter, letter)
+		mockFileManager.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, file *os.File, _ ...string) (filemanager.UploadOutput, error) {
+			gzReader, err := gzip.NewReader(file)
+			require.NoError(t, err)
+			scanner := bufio.NewScanner(gzReader)
+			var lines int
+			for scanner.Scan() {
+				require.JSONEq(t, `{"event":"e1"}`, gjson.GetBytes(scanner.Bytes(), "payload").Raw)
+				lines++
+			}
+			require.Equal(t, 2, lines)
+			return filemanager.UploadOutput{}, nil
+		})
+		require.NoError(t, f.forwardQueued(ctx))
+		count, _ := queued()
+		require.Zero(t, count)
+	})
+}
@@ -0,0 +1,279 @@
+// Package deliveryschedule restricts the delivery of router and batch router jobs to the delivery windows of their destinations.
+//
+// A schedule consists of a time zone and lists of allow and deny windows, each one formatted as "<days> <from>-<to>", e.g.
+// "mon-fri 09:00-17:00" or "* 22:00-06:00". Days are a comma separated list of days or day ranges, "*" meaning every day.
+// Jobs can be delivered at a time which is in any of the allow windows (or any time if there are none) and in none of the deny windows.
+package deliveryschedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+// DestinationConfigKey is the key of the destination config holding the delivery schedule of a destination
+const DestinationConfigKey = "deliverySchedule"
+
+const minutesPerDay = 24 * 60
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Schedule is the delivery schedule of a destination, with minute precision. A nil schedule allows delivery at any time
+type Schedule struct {
+	location *time.Location
+	allowed  [7][minutesPerDay]bool
+	// allowedBefore holds the number of allowed minutes of each weekday before each minute of the day
+	allowedBefore [7][minutesPerDay + 1]int
+}
+
+// Parse parses a schedule from its time zone (UTC if empty) and windows
+func Parse(timezone string, allow, deny []string) (*Schedule, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("loading time zone %q: %w", timezone, err)
+	}
+	s := &Schedule{location: location}
+	if len(allow) == 0 {
+		allow = []string{"* 00:00-24:00"}
+	}
+	for _, window := range allow {
+		if err := s.mark(window, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, window := range deny {
+		if err := s.mark(window, false); err != nil {
+			return nil, err
+		}
+	}
+	for day := range s.allowed {
+		for minute, allowed := range s.allowed[day] {
+			s.allowedBefore[day][minute+1] = s.allowedBefore[day][minute]
+			if allowed {
+				s.allowedBefore[day][minute+1]++
+			}
+		}
+	}
+	return s, nil
+}
+
+// mark sets the minutes of a window as allowed or not. Windows ending before they start span midnight
+func (s *Schedule) mark(window string, allowed bool) error {
+	fields := strings.Fields(window)
+	if len(fields) != 2 {
+		return fmt.Errorf("invalid window %q: expected \"<days> <from>-<to>\"", window)
+	}
+	days, err := parseDays(fields[0])
+	if err != nil {
+		return fmt.Errorf("invalid window %q: %w", window, err)
+	}
+	fromTo := strings.Split(fields[1], "-")
+	if len(fromTo) != 2 {
+		return fmt.Errorf("invalid window %q: expected \"<from>-<to>\" times", window)
+	}
+	from, err := parseMinute(fromTo[0])
+	if err != nil {
+		return fmt.Errorf("invalid window %q: %w", window, err)
+	}
+	to, err := parseMinute(fromTo[1])
+	if err != nil {
+		return fmt.Errorf("invalid window %q: %w", window, err)
+	}
+
+	for _, day := range days {
+		if from < to {
+			for minute := from; minute < to; minute++ {
+				s.allowed[day][minute] = allowed
+			}
+			continue
+		}
+		for minute := from; minute < minutesPerDay; minute++ {
+			s.allowed[day][minute] = allowed
+		}
+		for minute := 0; minute < to; minute++ {
+			s.allowed[(day+1)%7][minute] = allowed
+		}
+	}
+	return nil
+}
+
+// parseDays parses a comma separated list of days or day ranges, e.g. "mon-fri,sun", "*" being every day
+func parseDays(value string) ([]time.Weekday, error) {
+	if value == "*" {
+		value = "sun-sat"
+	}
+	var days []time.Weekday
+	for _, item := range strings.Split(strings.ToLower(value), ",") {
+		fromTo := strings.Split(item, "-")
+		from, ok := weekdays[fromTo[0]]
+		if !ok || len(fromTo) > 2 {
+			return nil, fmt.Errorf("invalid days %q", item)
+		}
+		to := from
+		if len(fromTo) == 2 {
+			if to, ok = weekdays[fromTo[1]]; !ok {
+				return nil, fmt.Errorf("invalid days %q", item)
+			}
+		}
+		for day := from; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseMinute parses a "HH:MM" time to the minute of the day, "24:00" being the end of the day
+func parseMinute(value string) (int, error) {
+	hoursMinutes := strings.Split(value, ":")
+	if len(hoursMinutes) != 2 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	hours, err := strconv.Atoi(hoursMinutes[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	minutes, err := strconv.Atoi(hoursMinutes[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	minute := hours*60 + minutes
+	if hours < 0 || minutes < 0 || minutes >= 60 || minute > minutesPerDay {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return minute, nil
+}
+
+// Allows returns true if jobs can be delivered at time t
+func (s *Schedule) Allows(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	t = t.In(s.location)
+	return s.allowed[t.Weekday()][t.Hour()*60+t.Minute()]
+}
+
+// ActiveDuration returns the part of the duration between from and to which is in the delivery windows
+func (s *Schedule) ActiveDuration(from, to time.Time) time.Duration {
+	if s == nil || !from.Before(to) {
+		return to.Sub(from)
+	}
+	var seconds int
+	for from, to = from.In(s.location), to.In(s.location); from.Before(to); {
+		year, month, day := from.Date()
+		nextDay := time.Date(year, month, day+1, 0, 0, 0, 0, s.location)
+		end := minutesPerDay * 60
+		if to.Before(nextDay) {
+			end = secondOfDay(to)
+			nextDay = to
+		}
+		seconds += s.allowedSecondsBefore(from.Weekday(), end) - s.allowedSecondsBefore(from.Weekday(), secondOfDay(from))
+		from = nextDay
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// allowedSecondsBefore returns the number of allowed seconds of a weekday before a second of the day
+func (s *Schedule) allowedSecondsBefore(day time.Weekday, second int) int {
+	minute := second / 60
+	seconds := s.allowedBefore[day][minute] * 60
+	if minute < minutesPerDay && s.allowed[day][minute] {
+		seconds += second % 60
+	}
+	return seconds
+}
+
+func secondOfDay(t time.Time) int {
+	return t.Hour()*3600 + t.Minute()*60 + t.Second()
+}
+
+// Schedules holds the delivery schedules of destinations
+type Schedules struct {
+	logger logger.Logger
+
+	schedulesMu sync.RWMutex
+	schedules   map[string]*Schedule
+}
+
+// NewSchedules creates an empty set of delivery schedules
+func NewSchedules() *Schedules {
+	return &Schedules{
+		logger:    logger.NewLogger().Child("router").Child("deliveryschedule"),
+		schedules: make(map[string]*Schedule),
+	}
+}
+
+// Set sets the delivery schedules of destinations. Schedules are read from Router.deliverySchedule.<destID>.timezone, allow and deny,
+// falling back to the deliverySchedule setting of the destination config. It is called whenever the backend config changes
+func (s *Schedules) Set(destinations []backendconfig.DestinationT) {
+	schedules := make(map[string]*Schedule)
+	for i := range destinations {
+		destination := &destinations[i]
+		timezone, allow, deny, ok := scheduleSettings(destination)
+		if !ok {
+			continue
+		}
+		schedule, err := Parse(timezone, allow, deny)
+		if err != nil {
+			s.logger.Errorf("Invalid delivery schedule of destination %s, ignoring it: %v", destination.ID, err)
+			continue
+		}
+		schedules[destination.ID] = schedule
+	}
+
+	s.schedulesMu.Lock()
+	defer s.schedulesMu.Unlock()
+	s.schedules = schedules
+}
+
+// Get returns the delivery schedule of a destination, nil if it doesn't have any
+func (s *Schedules) Get(destID string) *Schedule {
+	if s == nil {
+		return nil
+	}
+	s.schedulesMu.RLock()
+	defer s.schedulesMu.RUnlock()
+	return s.schedules[destID]
+}
+
+// scheduleSettings returns the delivery schedule settings of a destination, if it has any
+func scheduleSettings(destination *backendconfig.DestinationT) (timezone string, allow, deny []string, ok bool) {
+	prefix := "Router.deliverySchedule." + destination.ID
+	if config.IsSet(prefix+".allow") || config.IsSet(prefix+".deny") {
+		return config.GetString(prefix+".timezone", ""), config.Default.GetStringSlice(prefix+".allow", nil), config.Default.GetStringSlice(prefix+".deny", nil), true
+	}
+
+	settings, _ := destination.Config[DestinationConfigKey].(map[string]interface{})
+	if settings == nil {
+		return "", nil, nil, false
+	}
+	timezone, _ = settings["timezone"].(string)
+	return timezone, stringSlice(settings["allow"]), stringSlice(settings["deny"]), true
+}
+
+func stringSlice(value interface{}) []string {
+	values, _ := value.([]interface{})
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package deliveryschedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+)
+
+func TestSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// 2022-10-03 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2022, 10, day, hour, minute, 0, 0, berlin)
+	}
+
+	s, err := Parse("Europe/Berlin", []string{"mon-fri 09:00-17:00", "sat 22:00-02:00"}, []string{"* 12:00-13:00"})
+	require.NoError(t, err)
+
+	t.Run("allows", func(t *testing.T) {
+		require.True(t, s.Allows(at(3, 9, 0)))
+		require.True(t, s.Allows(at(7, 16, 59)))
+		require.False(t, s.Allows(at(3, 8, 59)))
+		require.False(t, s.Allows(at(3, 17, 0)))
+		require.False(t, s.Allows(at(3, 12, 30)), "deny windows take precedence")
+		require.True(t, s.Allows(at(8, 23, 0)), "windows can span midnight")
+		require.True(t, s.Allows(at(9, 1, 59)))
+		require.False(t, s.Allows(at(9, 2, 0)))
+		require.False(t, s.Allows(at(3, 9, 0).Add(-time.Hour).UTC()), "times are converted to the schedule time zone")
+
+		var nilSchedule *Schedule
+		require.True(t, nilSchedule.Allows(time.Now()))
+	})
+
+	t.Run("active duration", func(t *testing.T) {
+		require.Equal(t, 7*time.Hour, s.ActiveDuration(at(3, 0, 0), at(4, 0, 0)))
+		require.Equal(t, 150*time.Minute, s.ActiveDuration(at(3, 10, 30), at(3, 14, 0)))
+		require.Equal(t, 5*7*time.Hour+4*time.Hour, s.ActiveDuration(at(3, 0, 0), at(10, 0, 0)))
+		require.Equal(t, 30*time.Second, s.ActiveDuration(at(3, 8, 59), at(3, 9, 0).Add(30*time.Second)))
+		require.Zero(t, s.ActiveDuration(at(3, 12, 0), at(3, 13, 0)))
+
+		var nilSchedule *Schedule
+		require.Equal(t, time.Hour, nilSchedule.ActiveDuration(at(3, 12, 0), at(3, 13, 0)))
+	})
+
+	t.Run("every day by default", func(t *testing.T) {
+		s, err := Parse("", nil, []string{"sat,sun 00:00-24:00"})
+		require.NoError(t, err)
+		require.True(t, s.Allows(time.Date(2022, 10, 3, 0, 0, 0, 0, time.UTC)))
+		require.False(t, s.Allows(time.Date(2022, 10, 2, 12, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("invalid schedules", func(t *testing.T) {
+		for _, window := range []string{"09:00-17:00", "mon-xyz 09:00-17:00", "mon 9-17", "mon 09:00-25:00", "mon 09:60-10:00"} {
+			_, err := Parse("", []string{window}, nil)
+			require.Error(t, err, window)
+		}
+		_, err := Parse("Mars/Olympus", nil, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestSchedules(t *testing.T) {
+	config.Reset()
+	defer config.Reset()
+	config.Set("Router.deliverySchedule.overridden.allow", []string{"* 00:00-00:01"})
+
+	monday := time.Date(2022, 10, 3, 12, 0, 0, 0, time.UTC)
+	schedules := NewSchedules()
+	schedules.Set([]backendconfig.DestinationT{
+		{ID: "weekend", Config: map[string]interface{}{DestinationConfigKey: map[string]interface{}{
+			"timezone": "UTC",
+			"allow":    []interface{}{"sat-sun 00:00-24:00"},
+		}}},
+		{ID: "overridden", Config: map[string]interface{}{DestinationConfigKey: map[string]interface{}{
+			"allow": []interface{}{"* 00:00-24:00"},
+		}}},
+		{ID: "invalid", Config: map[string]interface{}{DestinationConfigKey: map[string]interface{}{
+			"allow": []interface{}{"always"},
+		}}},
+		{ID: "unscheduled", Config: map[string]interface{}{}},
+	})
+	require.False(t, schedules.Get("weekend").Allows(monday))
+	require.False(t, schedules.Get("overridden").Allows(monday), "config overrides the destination config")
+	require.Nil(t, schedules.Get("invalid"))
+	require.Nil(t, schedules.Get("unscheduled"))
+
+	schedules.Set(nil)
+	require.Nil(t, schedules.Get("weekend"))
+
+	var nilSchedules *Schedules
+	require.Nil(t, nilSchedules.Get("weekend"))
+}
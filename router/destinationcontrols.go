@@ -0,0 +1,161 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/config"
+)
+
+const (
+	destinationControlsTable = "rt_destination_controls"
+
+	// destinationPaused is the state of a destination whose jobs are not picked up by the router, until it gets resumed
+	destinationPaused = "paused"
+	// destinationDraining is the state of a destination whose jobs are aborted by the router, until it gets resumed
+	destinationDraining = "draining"
+)
+
+type destinationControl struct {
+	State  string
+	Reason string
+}
+
+// DestinationControls keeps track of the destinations paused or drained through the router admin interface.
+// Controls are persisted in postgres, so that they survive restarts, and are periodically reloaded from there.
+type DestinationControls struct {
+	dbHandle        *sql.DB
+	refreshInterval time.Duration
+
+	controlsMu sync.RWMutex
+	controls   map[string]destinationControl // destinationID -> control
+}
+
+// NewDestinationControls creates the destination controls table, if it doesn't exist, and loads the current controls
+func NewDestinationControls(ctx context.Context, connInfo string) (*DestinationControls, error) {
+	dbHandle, err := sql.Open("postgres", connInfo)
+	if err != nil {
+		return nil, err
+	}
+	dc := &DestinationControls{dbHandle: dbHandle}
+	config.RegisterDurationConfigVariable(10, &dc.refreshInterval, true, time.Second, "Router.destinationControls.refreshInterval")
+	if err := setupDestinationControlsTable(ctx, dbHandle); err != nil {
+		_ = dbHandle.Close()
+		return nil, err
+	}
+	if err := dc.load(ctx); err != nil {
+		_ = dbHandle.Close()
+		return nil, err
+	}
+	return dc, nil
+}
+
+// Run reloads the destination controls periodically, until the context is cancelled
+func (dc *DestinationControls) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(dc.refreshInterval):
+			if err := dc.load(ctx); err != nil && ctx.Err() == nil {
+				pkgLogger.Errorf("Failed to load destination controls: %v", err)
+			}
+		}
+	}
+}
+
+func (dc *DestinationControls) Close() {
+	_ = dc.dbHandle.Close()
+}
+
+// get returns the control of a destination, if any. It is safe to be called on a nil DestinationControls
+func (dc *DestinationControls) get(destinationID string) (destinationControl, bool) {
+	if dc == nil {
+		return destinationControl{}, false
+	}
+	dc.controlsMu.RLock()
+	defer dc.controlsMu.RUnlock()
+	control, ok := dc.controls[destinationID]
+	return control, ok
+}
+
+// pausedDestinationIDs returns the ids of the paused destinations. It is safe to be called on a nil DestinationControls
+func (dc *DestinationControls) pausedDestinationIDs() []string {
+	if dc == nil {
+		return nil
+	}
+	dc.controlsMu.RLock()
+	defer dc.controlsMu.RUnlock()
+	var destinationIDs []string
+	for destinationID, control := range dc.controls {
+		if control.State == destinationPaused {
+			destinationIDs = append(destinationIDs, destinationID)
+		}
+	}
+	return destinationIDs
+}
+
+func (dc *DestinationControls) load(ctx context.Context) error {
+	rows, err := dc.dbHandle.QueryContext(ctx, fmt.Sprintf(`SELECT destination_id, state, reason FROM %s`, destinationControlsTable))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	controls := make(map[string]destinationControl)
+	for rows.Next() {
+		var destinationID string
+		var control destinationControl
+		if err := rows.Scan(&destinationID, &control.State, &control.Reason); err != nil {
+			return err
+		}
+		controls[destinationID] = control
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	dc.controlsMu.Lock()
+	defer dc.controlsMu.Unlock()
+	for destinationID, control := range controls {
+		if dc.controls[destinationID] != control {
+			pkgLogger.Infof("Destination %s is %s", destinationID, control.State)
+		}
+	}
+	for destinationID := range dc.controls {
+		if _, ok := controls[destinationID]; !ok {
+			pkgLogger.Infof("Destination %s is resumed", destinationID)
+		}
+	}
+	dc.controls = controls
+	return nil
+}
+
+func setupDestinationControlsTable(ctx context.Context, dbHandle *sql.DB) error {
+	_, err := dbHandle.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		destination_id TEXT PRIMARY KEY,
+		state TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP NOT NULL DEFAULT NOW())`, destinationControlsTable))
+	return err
+}
+
+func setDestinationControl(ctx context.Context, dbHandle *sql.DB, destinationID string, control destinationControl) error {
+	if err := setupDestinationControlsTable(ctx, dbHandle); err != nil {
+		return err
+	}
+	_, err := dbHandle.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (destination_id, state, reason) VALUES ($1, $2, $3)
+		ON CONFLICT (destination_id) DO UPDATE SET state = EXCLUDED.state, reason = EXCLUDED.reason, updated_at = NOW()`, destinationControlsTable),
+		destinationID, control.State, control.Reason)
+	return err
+}
+
+func removeDestinationControl(ctx context.Context, dbHandle *sql.DB, destinationID string) error {
+	if err := setupDestinationControlsTable(ctx, dbHandle); err != nil {
+		return err
+	}
+	_, err := dbHandle.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE destination_id = $1`, destinationControlsTable), destinationID)
+	return err
+}
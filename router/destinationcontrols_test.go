@@ -0,0 +1,86 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/testhelper/destination"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+func TestDestinationControls(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	postgresContainer, err := destination.SetupPostgres(pool, t)
+	require.NoError(t, err)
+	config.Set("DB.host", postgresContainer.Host)
+	config.Set("DB.port", postgresContainer.Port)
+	config.Set("DB.user", postgresContainer.User)
+	config.Set("DB.name", postgresContainer.Database)
+	config.Set("DB.password", postgresContainer.Password)
+	t.Cleanup(config.Reset)
+
+	ctx := context.Background()
+	dc, err := NewDestinationControls(ctx, misc.GetConnectionString())
+	require.NoError(t, err)
+	defer dc.Close()
+	_, ok := dc.get("dest-1")
+	require.False(t, ok)
+
+	rpc := &RouterRpcHandler{jobsDBPrefix: "rt"}
+	var result string
+	require.NoError(t, rpc.PauseDestination("dest-1", &result))
+	require.NoError(t, rpc.DrainDestination(DrainDestinationArg{DestinationID: "dest-2", Reason: "decommissioned"}, &result))
+	require.NoError(t, rpc.DrainDestination(DrainDestinationArg{DestinationID: "dest-3"}, &result))
+	require.Error(t, rpc.PauseDestination("", &result))
+
+	require.NoError(t, dc.load(ctx))
+	control, ok := dc.get("dest-1")
+	require.True(t, ok)
+	require.Equal(t, destinationControl{State: destinationPaused}, control)
+	control, ok = dc.get("dest-2")
+	require.True(t, ok)
+	require.Equal(t, destinationControl{State: destinationDraining, Reason: "decommissioned"}, control)
+	control, ok = dc.get("dest-3")
+	require.True(t, ok)
+	require.Equal(t, destinationControl{State: destinationDraining, Reason: "destination drained by admin"}, control)
+
+	t.Run("controls survive restarts", func(t *testing.T) {
+		restarted, err := NewDestinationControls(ctx, misc.GetConnectionString())
+		require.NoError(t, err)
+		defer restarted.Close()
+		control, ok := restarted.get("dest-1")
+		require.True(t, ok)
+		require.Equal(t, destinationPaused, control.State)
+	})
+
+	t.Run("resumed destinations have no control", func(t *testing.T) {
+		require.NoError(t, rpc.ResumeDestination("dest-1", &result))
+		require.NoError(t, rpc.PauseDestination("dest-2", &result))
+		require.NoError(t, dc.load(ctx))
+		_, ok := dc.get("dest-1")
+		require.False(t, ok)
+		control, ok := dc.get("dest-2")
+		require.True(t, ok)
+		require.Equal(t, destinationControl{State: destinationPaused}, control)
+	})
+
+	t.Run("routers of this server reload their controls right away", func(t *testing.T) {
+		defer func(prev *Admin) { adminInstance = prev }(adminInstance)
+		adminInstance = &Admin{handles: map[string]*HandleT{"GA": {destinationControls: dc}, "AM": {}}}
+		require.NoError(t, rpc.PauseDestination("dest-4", &result))
+		require.Equal(t, "destination dest-4 paused", result)
+		control, ok := dc.get("dest-4")
+		require.True(t, ok)
+		require.Equal(t, destinationControl{State: destinationPaused}, control)
+	})
+
+	t.Run("batch router doesn't support destination controls", func(t *testing.T) {
+		brpc := &RouterRpcHandler{jobsDBPrefix: "batch_rt"}
+		require.Error(t, brpc.PauseDestination("dest-1", &result))
+	})
+}
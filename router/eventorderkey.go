@@ -0,0 +1,177 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+const (
+	// eventOrderKeyConfigKey is the key of the destination config holding the json path of the property its events are ordered by.
+	// The path applies to the payload of the router job, i.e. the destination request built by the processor transformation
+	// (e.g. body.JSON.groupId for REST requests), not to the original event
+	eventOrderKeyConfigKey = "eventOrderKey"
+	// disableEventOrderConfigKey is the key of the destination config disabling the ordering of its events
+	disableEventOrderConfigKey = "disableEventOrder"
+
+	// keyOrderKeyPrefix and userOrderKeyPrefix namespace the order keys of events ordered by a payload property and by user id,
+	// so that a property value equal to some user id doesn't merge their orderings
+	keyOrderKeyPrefix  = "k:"
+	userOrderKeyPrefix = "u:"
+)
+
+// eventOrderSettingT is how the events of a destination are ordered. By default, events are ordered per user id
+type eventOrderSettingT struct {
+	disabled bool
+	keyPath  string // json path of the router job payload property which events are ordered by, instead of the user id
+}
+
+// setEventOrderSettings sets the event order settings of destinations. Settings are read from Router.eventOrder.<destID>.key and disabled,
+// falling back to the eventOrderKey and disableEventOrder settings of the destination config. It is called whenever the backend config changes.
+// Settings take effect once applied by applyEventOrderSettings
+func (rt *HandleT) setEventOrderSettings(destinations []backendconfig.DestinationT) {
+	settings := make(map[string]eventOrderSettingT)
+	for i := range destinations {
+		destination := &destinations[i]
+		var setting eventOrderSettingT
+		setting.keyPath, _ = destination.Config[eventOrderKeyConfigKey].(string)
+		setting.disabled, _ = destination.Config[disableEventOrderConfigKey].(bool)
+		prefix := "Router.eventOrder." + destination.ID
+		if config.IsSet(prefix + ".key") {
+			setting.keyPath = config.GetString(prefix+".key", "")
+		}
+		if config.IsSet(prefix + ".disabled") {
+			setting.disabled = config.GetBool(prefix+".disabled", false)
+		}
+		if setting != (eventOrderSettingT{}) {
+			settings[destination.ID] = setting
+		}
+	}
+
+	rt.eventOrderSettingsMu.Lock()
+	defer rt.eventOrderSettingsMu.Unlock()
+	rt.pendingEventOrderSettings = settings
+}
+
+// applyEventOrderSettings applies the pending event order settings of the destinations whose ordered jobs have drained.
+// Changing the order key of a destination while a barrier holds a failed job under its previous key, or while jobs ordered by it are in flight,
+// would let later jobs overtake them. Thus a changed setting waits until its destination has no barriers and no ordered jobs in flight:
+// meanwhile its jobs keep being ordered by the previous setting and, once it has no barriers, no more of its jobs are picked up so that it drains.
+// It is called before picking up jobs, after syncing the barriers
+func (rt *HandleT) applyEventOrderSettings() {
+	rt.eventOrderSettingsMu.Lock()
+	defer rt.eventOrderSettingsMu.Unlock()
+	rt.drainingEventOrder = nil
+	if rt.pendingEventOrderSettings == nil {
+		return
+	}
+	if rt.eventOrderSettings == nil {
+		rt.eventOrderSettings = make(map[string]eventOrderSettingT)
+	}
+
+	destinationIDs := make(map[string]struct{})
+	for destinationID := range rt.eventOrderSettings {
+		destinationIDs[destinationID] = struct{}{}
+	}
+	for destinationID := range rt.pendingEventOrderSettings {
+		destinationIDs[destinationID] = struct{}{}
+	}
+	pending := false
+	for destinationID := range destinationIDs {
+		setting := rt.pendingEventOrderSettings[destinationID]
+		if setting == rt.eventOrderSettings[destinationID] {
+			continue
+		}
+		if rt.hasEventOrderBarriers(destinationID) {
+			pending = true
+			continue
+		}
+		if rt.orderedJobsInFlight[destinationID] > 0 {
+			if rt.drainingEventOrder == nil {
+				rt.drainingEventOrder = make(map[string]struct{})
+			}
+			rt.drainingEventOrder[destinationID] = struct{}{}
+			pending = true
+			continue
+		}
+		if setting == (eventOrderSettingT{}) {
+			delete(rt.eventOrderSettings, destinationID)
+		} else {
+			rt.eventOrderSettings[destinationID] = setting
+		}
+	}
+	if !pending {
+		rt.pendingEventOrderSettings = nil
+	}
+}
+
+// hasEventOrderBarriers returns true if any worker has a barrier for an order key of the destination
+func (rt *HandleT) hasEventOrderBarriers(destinationID string) bool {
+	for _, worker := range rt.workers {
+		if worker.barrier.Any(func(orderKey string) bool { return orderKeyDestinationID(orderKey) == destinationID }) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventOrderDraining returns true if no more ordered jobs of the destination must be picked up, until its event order setting is applied
+func (rt *HandleT) eventOrderDraining(destinationID string) bool {
+	rt.eventOrderSettingsMu.RLock()
+	defer rt.eventOrderSettingsMu.RUnlock()
+	_, ok := rt.drainingEventOrder[destinationID]
+	return ok
+}
+
+// orderedJobPicked counts an ordered job of the destination as in flight, until its status is updated
+func (rt *HandleT) orderedJobPicked(destinationID string) {
+	rt.eventOrderSettingsMu.Lock()
+	defer rt.eventOrderSettingsMu.Unlock()
+	if rt.orderedJobsInFlight == nil {
+		rt.orderedJobsInFlight = make(map[string]int)
+	}
+	rt.orderedJobsInFlight[destinationID]++
+}
+
+// orderedJobUpdated stops counting a job ordered by the order key as in flight, once its status has been updated
+func (rt *HandleT) orderedJobUpdated(orderKey string) {
+	destinationID := orderKeyDestinationID(orderKey)
+	rt.eventOrderSettingsMu.Lock()
+	defer rt.eventOrderSettingsMu.Unlock()
+	if rt.orderedJobsInFlight[destinationID]--; rt.orderedJobsInFlight[destinationID] <= 0 {
+		delete(rt.orderedJobsInFlight, destinationID)
+	}
+}
+
+// orderKeyDestinationID returns the destination id of an order key, its last part
+func orderKeyDestinationID(orderKey string) string {
+	return orderKey[strings.LastIndex(orderKey, ":")+1:]
+}
+
+// eventOrderKey returns the key of the barrier ordering the events of a job and whether they need to be ordered at all.
+// Events are keyed by the property of their destination's event order setting, falling back to the user id if the payload doesn't have it,
+// and keys end with the destination id.
+// It is called once, when the job is picked up, and the key is carried along with the job, so that all the barrier steps of the job use the
+// same key even if the settings change in the meantime
+func (rt *HandleT) eventOrderKey(job *jobsdb.JobT, userID, destinationID string) (orderKey string, ordered bool) {
+	if !rt.guaranteeUserEventOrder {
+		return "", false
+	}
+	rt.eventOrderSettingsMu.RLock()
+	setting := rt.eventOrderSettings[destinationID]
+	rt.eventOrderSettingsMu.RUnlock()
+	if setting.disabled {
+		return "", false
+	}
+
+	if setting.keyPath != "" && job != nil {
+		if value := gjson.GetBytes(job.EventPayload, setting.keyPath).String(); value != "" {
+			return keyOrderKeyPrefix + value + ":" + destinationID, true
+		}
+	}
+	return userOrderKeyPrefix + userID + ":" + destinationID, true
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
+)
+
+func TestEventOrderKey(t *testing.T) {
+	config.Reset()
+	t.Cleanup(config.Reset)
+	config.Set("Router.eventOrder.overridden.key", "body.JSON.accountId")
+
+	rt := &HandleT{guaranteeUserEventOrder: true}
+	rt.setEventOrderSettings([]backendconfig.DestinationT{
+		{ID: "default", Config: map[string]interface{}{}},
+		{ID: "group", Config: map[string]interface{}{eventOrderKeyConfigKey: "body.JSON.groupId"}},
+		{ID: "overridden", Config: map[string]interface{}{eventOrderKeyConfigKey: "body.JSON.groupId"}},
+		{ID: "unordered", Config: map[string]interface{}{disableEventOrderConfigKey: true}},
+	})
+	rt.applyEventOrderSettings()
+	job := &jobsdb.JobT{EventPayload: []byte(`{"type": "REST", "body": {"JSON": {"groupId": "g1", "accountId": "a1"}}}`)}
+
+	orderKey := func(destinationID string, job *jobsdb.JobT) string {
+		key, ordered := rt.eventOrderKey(job, "u1", destinationID)
+		require.True(t, ordered)
+		return key
+	}
+	require.Equal(t, "u:u1:default", orderKey("default", job))
+	require.Equal(t, "u:u1:unknown", orderKey("unknown", job))
+	require.Equal(t, "k:g1:group", orderKey("group", job))
+	require.Equal(t, "u:u1:group", orderKey("group", &jobsdb.JobT{EventPayload: []byte(`{}`)}), "events without the key property are ordered by user id")
+	require.Equal(t, "u:u1:group", orderKey("group", nil))
+	require.Equal(t, "k:a1:overridden", orderKey("overridden", job), "config overrides the destination config")
+	require.NotEqual(t, orderKey("group", job), orderKey("group", &jobsdb.JobT{UserID: "g1", EventPayload: []byte(`{}`)}), "property values don't share keys with user ids")
+	require.Equal(t, "group", orderKeyDestinationID(orderKey("group", job)))
+
+	_, ordered := rt.eventOrderKey(job, "u1", "unordered")
+	require.False(t, ordered)
+
+	rt.guaranteeUserEventOrder = false
+	_, ordered = rt.eventOrderKey(job, "u1", "group")
+	require.False(t, ordered)
+}
+
+func TestEventOrderSettingsDrain(t *testing.T) {
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	rt := &HandleT{guaranteeUserEventOrder: true, workers: []*workerT{{barrier: eventorder.NewBarrier()}}}
+	setKeyPath := func(keyPath string) {
+		rt.setEventOrderSettings([]backendconfig.DestinationT{{ID: "d1", Config: map[string]interface{}{eventOrderKeyConfigKey: keyPath}}})
+		rt.applyEventOrderSettings()
+	}
+	job := &jobsdb.JobT{JobID: 1, EventPayload: []byte(`{"body": {"JSON": {"groupId": "g1", "accountId": "a1"}}}`)}
+	orderKey := func() string {
+		key, ordered := rt.eventOrderKey(job, "u1", "d1")
+		require.True(t, ordered)
+		return key
+	}
+
+	setKeyPath("body.JSON.groupId")
+	require.Equal(t, "k:g1:d1", orderKey(), "settings of destinations without ordered jobs are applied right away")
+
+	rt.orderedJobPicked("d1")
+	require.NoError(t, rt.workers[0].barrier.StateChanged(orderKey(), job.JobID, jobsdb.Failed.State))
+	setKeyPath("body.JSON.accountId")
+	require.Equal(t, "k:g1:d1", orderKey(), "the previous setting is kept while a barrier holds a job ordered by it")
+	require.False(t, rt.eventOrderDraining("d1"), "jobs are picked up while a barrier holds a failed job, so that it can be retried")
+
+	require.NoError(t, rt.workers[0].barrier.StateChanged("k:g1:d1", job.JobID, jobsdb.Succeeded.State))
+	rt.workers[0].barrier.Sync()
+	rt.applyEventOrderSettings()
+	require.Equal(t, "k:g1:d1", orderKey(), "the previous setting is kept while jobs ordered by it are in flight")
+	require.True(t, rt.eventOrderDraining("d1"), "no more jobs are picked up once there are no barriers, so that in flight jobs drain")
+
+	rt.orderedJobUpdated("k:g1:d1")
+	rt.applyEventOrderSettings()
+	require.Equal(t, "k:a1:d1", orderKey(), "the setting is applied once the destination has drained")
+	require.False(t, rt.eventOrderDraining("d1"))
+
+	rt.setEventOrderSettings(nil)
+	rt.applyEventOrderSettings()
+	require.Equal(t, "u:u1:d1", orderKey(), "settings of removed destinations are dropped")
+}
@@ -3,6 +3,7 @@ package router
 import (
 	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
 	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/router/deadletter"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	"github.com/rudderlabs/rudder-server/services/transientsource"
 )
@@ -31,12 +32,18 @@ type Factory struct {
 	ProcErrorDB      jobsdb.JobsDB
 	TransientSources transientsource.Service
 	RsourcesService  rsources.JobService
+	// DestinationControls, if set, makes routers honor the destinations paused or drained through the admin interface
+	DestinationControls *DestinationControls
+	// DeadLetterForwarder, if set, forwards aborted jobs to the dead-letter destinations of their destinations
+	DeadLetterForwarder *deadletter.Forwarder
 }
 
 func (f *Factory) New(destination *backendconfig.DestinationT, identifier string) *HandleT {
 	r := &HandleT{
-		Reporting:    f.Reporting,
-		MultitenantI: f.Multitenant,
+		Reporting:           f.Reporting,
+		MultitenantI:        f.Multitenant,
+		destinationControls: f.DestinationControls,
+		deadLetterForwarder: f.DeadLetterForwarder,
 	}
 	destConfig := getRouterConfig(destination, identifier)
 	r.Setup(f.BackendConfig, f.RouterDB, f.ProcErrorDB, destConfig, f.TransientSources, f.RsourcesService)
@@ -178,6 +178,18 @@ func (b *Barrier) Size() int {
 	return len(b.barriers)
 }
 
+// Any returns true if there is an active barrier for a key matching the provided function
+func (b *Barrier) Any(match func(key string) bool) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for key := range b.barriers {
+		if match(key) {
+			return true
+		}
+	}
+	return false
+}
+
 // String returns a string representation of the barrier
 func (b *Barrier) String() string {
 	var sb strings.Builder
@@ -27,6 +27,8 @@ func Test_Job_Failed_Scenario(t *testing.T) {
 
 	require.True(t, firstBool(barrier.Wait("user1", 2)), "job 2 for user1 should wait after job 1 has failed")
 	require.Equal(t, 1, barrier.Size(), "barrier should have size of 1")
+	require.True(t, barrier.Any(func(key string) bool { return key == "user1" }), "barrier of user1 should be active")
+	require.False(t, barrier.Any(func(key string) bool { return key == "user2" }), "barrier of user2 shouldn't exist")
 	require.Equal(t, `Barrier{map[key1:value1][{key: user1, failedJobID: 1, concurrentJobs: map[]}]}`, barrier.String(), "the barrier's string representation should be human readable")
 	require.NoError(t, barrier.StateChanged("user1", 2, jobsdb.Waiting.State))
 
@@ -51,6 +53,7 @@ func Test_Job_Failed_Scenario(t *testing.T) {
 	enter, previousFailedJobID = barrier.Enter("user1", 2)
 	require.True(t, enter, "job 2 for user1 should be accepted after barrier is synced")
 	require.Nil(t, previousFailedJobID)
+	require.False(t, barrier.Any(func(key string) bool { return key == "user1" }), "barrier of user1 should have been removed")
 }
 
 func Test_Job_Aborted_Scenario(t *testing.T) {
@@ -3,18 +3,23 @@
 package router
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
 	"github.com/rudderlabs/rudder-server/router/utils"
 	"github.com/rudderlabs/rudder-server/utils/logger"
@@ -32,11 +37,19 @@ func init() {
 type NetHandleT struct {
 	httpClient sysUtils.HTTPClientI
 	logger     logger.Logger
+
+	destType             string
+	transport            *http.Transport // transport of httpClient, the base of the transports of destinations with their own client settings
+	netClientTimeout     time.Duration
+	connectionStats      *connectionStatsT // connection stats of httpClient
+	destinationClientsMu sync.RWMutex
+	destinationSettings  map[string]destinationClientSettingsT // destinationID -> client settings of destinations with their own client settings
+	destinationClients   map[string]*destinationClientT        // destinationID -> client of destinations with their own client settings
 }
 
 // Network interface
 type NetHandleI interface {
-	SendPost(ctx context.Context, structData integrations.PostParametersT) *utils.SendPostResponse
+	SendPost(ctx context.Context, destination *backendconfig.DestinationT, structData integrations.PostParametersT) *utils.SendPostResponse
 }
 
 // temp solution for handling complex query params
@@ -58,15 +71,23 @@ func handleQueryParam(param interface{}) string {
 }
 
 // SendPost takes the EventPayload of a transformed job, gets the necessary values from the payload and makes a call to destination to push the event to it
-// this returns the statusCode, status and response body from the response of the destination call
-func (network *NetHandleT) SendPost(ctx context.Context, structData integrations.PostParametersT) *utils.SendPostResponse {
+// this returns the statusCode, status and response body from the response of the destination call.
+// Requests are signed and sent with a client certificate if the destination config has the corresponding settings
+func (network *NetHandleT) SendPost(ctx context.Context, destination *backendconfig.DestinationT, structData integrations.PostParametersT) *utils.SendPostResponse {
 	if disableEgress {
 		return &utils.SendPostResponse{
 			StatusCode:   200,
 			ResponseBody: []byte("200: outgoing disabled"),
 		}
 	}
-	client := network.httpClient
+	client, connectionStats, err := network.clientFor(destination)
+	if err != nil {
+		return &utils.SendPostResponse{
+			StatusCode:   http.StatusInternalServerError,
+			ResponseBody: []byte(fmt.Sprintf(`500 Unable to set up the http client of the destination: %s`, err.Error())),
+		}
+	}
+	ctx = httptrace.WithClientTrace(ctx, connectionStats.clientTrace())
 	postInfo := structData
 	isRest := postInfo.Type == "REST"
 
@@ -131,6 +152,16 @@ func (network *NetHandleT) SendPost(ctx context.Context, structData integrations
 			}
 		}
 
+		hmacSecret := destinationHMACSecret(destination)
+		var signedBody []byte
+		if hmacSecret != "" && payload != nil {
+			// the payload is read to be signed
+			if signedBody, err = io.ReadAll(payload); err != nil {
+				panic(err)
+			}
+			payload = bytes.NewReader(signedBody)
+		}
+
 		req, err := http.NewRequestWithContext(ctx, requestMethod, postInfo.URL, payload)
 		if err != nil {
 			network.logger.Error(fmt.Sprintf(`400 Unable to construct "%s" request for URL : "%s"`, requestMethod, postInfo.URL))
@@ -159,6 +190,9 @@ func (network *NetHandleT) SendPost(ctx context.Context, structData integrations
 		}
 
 		req.Header.Add("User-Agent", "RudderLabs")
+		if hmacSecret != "" {
+			signRequest(req, hmacSecret, signedBody, time.Now())
+		}
 
 		resp, err := client.Do(req)
 		if err != nil {
@@ -200,6 +234,7 @@ func (network *NetHandleT) SendPost(ctx context.Context, structData integrations
 			StatusCode:          resp.StatusCode,
 			ResponseBody:        respBody,
 			ResponseContentType: contentTypeHeader,
+			RetryAfter:          resp.Header.Get("Retry-After"),
 		}
 	}
 
@@ -243,5 +278,10 @@ func (network *NetHandleT) Setup(destID string, netClientTimeout time.Duration)
 	network.logger.Info(destID, ":   defaultTransportCopy.MaxIdleConns: ", defaultTransportCopy.MaxIdleConns)
 	network.logger.Info("defaultTransportCopy.MaxIdleConnsPerHost: ", defaultTransportCopy.MaxIdleConnsPerHost)
 	network.logger.Info("netClientTimeout: ", netClientTimeout)
+	network.destType = destID
+	network.connectionStats = newConnectionStats(destID, "")
+	defaultTransportCopy.DialContext = network.connectionStats.dialContext(&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}, nil)
+	network.transport = &defaultTransportCopy
+	network.netClientTimeout = netClientTimeout
 	network.httpClient = &http.Client{Transport: &defaultTransportCopy, Timeout: netClientTimeout}
 }
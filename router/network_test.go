@@ -96,7 +96,7 @@ var _ = Describe("Network", func() {
 				Body:       r,
 			}, nil)
 
-			network.SendPost(context.Background(), structData)
+			network.SendPost(context.Background(), nil, structData)
 		})
 
 		It("should respect ctx cancelation", func() {
@@ -112,7 +112,7 @@ var _ = Describe("Network", func() {
 			ctx, cancel := context.WithCancel(context.Background())
 			cancel()
 
-			resp := network.SendPost(ctx, structData)
+			resp := network.SendPost(ctx, nil, structData)
 			Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
 			fmt.Println(string(resp.ResponseBody))
 			Expect(string(resp.ResponseBody)).To(Equal("504 Unable to make \"\" request for URL : \"https://www.google-analytics.com/collect\". Error: Get \"https://www.google-analytics.com/collect\": context canceled"))
@@ -161,7 +161,7 @@ var _ = Describe("Network", func() {
 		DescribeTable("depending on the content type",
 			func(contentType string, altered bool) {
 				mockResponseContentType(contentType)
-				resp := network.SendPost(context.Background(), requestParams)
+				resp := network.SendPost(context.Background(), nil, requestParams)
 				if altered {
 					Expect(resp.ResponseBody).To(Equal([]byte("redacted due to unsupported content-type")))
 				} else {
@@ -0,0 +1,378 @@
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/services/stats"
+	"github.com/rudderlabs/rudder-server/utils/sysUtils"
+)
+
+// Keys of the destination config holding the settings of the destination's http client.
+// Each of them can be overridden by Router.http.<destID>.<key>. Settings are read whenever the backend config changes
+const (
+	httpMaxIdleConnsConfigKey        = "httpMaxIdleConns"
+	httpMaxIdleConnsPerHostConfigKey = "httpMaxIdleConnsPerHost"
+	httpMaxConnsPerHostConfigKey     = "httpMaxConnsPerHost" // maximum number of active connections per host
+	forceHTTP1ConfigKey              = "forceHTTP1"
+	disableKeepAlivesConfigKey       = "disableKeepAlives"
+	keepAliveConfigKey               = "keepAlive"   // interval of the tcp keep-alive probes, e.g. 30s
+	proxyURLConfigKey                = "proxyURL"    // url of the proxy requests are sent through
+	dnsCacheTTLConfigKey             = "dnsCacheTTL" // time resolved addresses are cached for, e.g. 1m. No caching if not set
+)
+
+// destinationClientSettingsT are the settings of a destination's http client.
+// Destinations with the zero value share the http client of their network handle
+type destinationClientSettingsT struct {
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	maxConnsPerHost     int
+	forceHTTP1          bool
+	disableKeepAlives   bool
+	keepAlive           time.Duration
+	proxyURL            string
+	dnsCacheTTL         time.Duration
+
+	clientCertificate string
+	clientKey         string
+	caCertificate     string
+}
+
+// destinationClientT is the http client of a destination with its own client settings
+type destinationClientT struct {
+	settings        destinationClientSettingsT // the settings the client was created with
+	client          *http.Client
+	connectionStats *connectionStatsT
+}
+
+// destinationClientSettings returns the http client settings of a destination
+func destinationClientSettings(destination *backendconfig.DestinationT) destinationClientSettingsT {
+	var settings destinationClientSettingsT
+	if destination == nil {
+		return settings
+	}
+	prefix := "Router.http." + destination.ID + "."
+	settings.maxIdleConns = destinationConfigInt(destination, prefix, httpMaxIdleConnsConfigKey)
+	settings.maxIdleConnsPerHost = destinationConfigInt(destination, prefix, httpMaxIdleConnsPerHostConfigKey)
+	settings.maxConnsPerHost = destinationConfigInt(destination, prefix, httpMaxConnsPerHostConfigKey)
+	settings.forceHTTP1 = destinationConfigBool(destination, prefix, forceHTTP1ConfigKey)
+	settings.disableKeepAlives = destinationConfigBool(destination, prefix, disableKeepAlivesConfigKey)
+	settings.keepAlive = destinationConfigDuration(destination, prefix, keepAliveConfigKey)
+	settings.proxyURL = destinationConfigString(destination, prefix, proxyURLConfigKey)
+	settings.dnsCacheTTL = destinationConfigDuration(destination, prefix, dnsCacheTTLConfigKey)
+
+	settings.clientCertificate, _ = destination.Config[clientCertificateConfigKey].(string)
+	settings.clientKey, _ = destination.Config[clientKeyConfigKey].(string)
+	settings.caCertificate, _ = destination.Config[caCertificateConfigKey].(string)
+	return settings
+}
+
+func destinationConfigInt(destination *backendconfig.DestinationT, prefix, key string) int {
+	if config.IsSet(prefix + key) {
+		return config.GetInt(prefix+key, 0)
+	}
+	switch value := destination.Config[key].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	case string:
+		i, _ := strconv.Atoi(value)
+		return i
+	}
+	return 0
+}
+
+func destinationConfigBool(destination *backendconfig.DestinationT, prefix, key string) bool {
+	if config.IsSet(prefix + key) {
+		return config.GetBool(prefix+key, false)
+	}
+	value, _ := destination.Config[key].(bool)
+	return value
+}
+
+func destinationConfigString(destination *backendconfig.DestinationT, prefix, key string) string {
+	if config.IsSet(prefix + key) {
+		return config.GetString(prefix+key, "")
+	}
+	value, _ := destination.Config[key].(string)
+	return value
+}
+
+func destinationConfigDuration(destination *backendconfig.DestinationT, prefix, key string) time.Duration {
+	if config.IsSet(prefix + key) {
+		return config.GetDuration(prefix+key, 0, time.Second)
+	}
+	value, _ := destination.Config[key].(string)
+	d, _ := time.ParseDuration(value)
+	return d
+}
+
+// setDestinations sets the http client settings of destinations. It is called whenever the backend config changes,
+// dropping the clients of the destinations which were removed or whose settings changed
+func (network *NetHandleT) setDestinations(destinations []backendconfig.DestinationT) {
+	settings := make(map[string]destinationClientSettingsT)
+	for i := range destinations {
+		if destinationSettings := destinationClientSettings(&destinations[i]); destinationSettings != (destinationClientSettingsT{}) {
+			settings[destinations[i].ID] = destinationSettings
+		}
+	}
+
+	network.destinationClientsMu.Lock()
+	defer network.destinationClientsMu.Unlock()
+	network.destinationSettings = settings
+	for destinationID, client := range network.destinationClients {
+		if client.settings != settings[destinationID] {
+			client.client.CloseIdleConnections()
+			delete(network.destinationClients, destinationID)
+		}
+	}
+}
+
+// clientFor returns the http client of a destination along with the stats of its connections.
+// It is the client of the network handle, unless the destination has its own client settings.
+// Clients of destinations are created on their first request and kept until their settings change
+func (network *NetHandleT) clientFor(destination *backendconfig.DestinationT) (sysUtils.HTTPClientI, *connectionStatsT, error) {
+	if destination == nil {
+		return network.httpClient, network.connectionStats, nil
+	}
+	network.destinationClientsMu.RLock()
+	_, ok := network.destinationSettings[destination.ID]
+	client := network.destinationClients[destination.ID]
+	network.destinationClientsMu.RUnlock()
+	if !ok {
+		return network.httpClient, network.connectionStats, nil
+	}
+	if client != nil {
+		return client.client, client.connectionStats, nil
+	}
+
+	network.destinationClientsMu.Lock()
+	defer network.destinationClientsMu.Unlock()
+	if client, ok := network.destinationClients[destination.ID]; ok {
+		return client.client, client.connectionStats, nil
+	}
+	settings, ok := network.destinationSettings[destination.ID]
+	if !ok {
+		return network.httpClient, network.connectionStats, nil
+	}
+	client, err := network.newDestinationClient(destination.ID, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	if network.destinationClients == nil {
+		network.destinationClients = make(map[string]*destinationClientT)
+	}
+	network.destinationClients[destination.ID] = client
+	return client.client, client.connectionStats, nil
+}
+
+// newDestinationClient creates the http client of a destination, with the transport of the network handle tuned by the destination's settings
+func (network *NetHandleT) newDestinationClient(destinationID string, settings destinationClientSettingsT) (*destinationClientT, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if network.transport != nil {
+		transport = network.transport.Clone()
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if err := setClientCertificates(transport.TLSClientConfig, settings); err != nil {
+		return nil, err
+	}
+	if settings.maxIdleConns > 0 {
+		transport.MaxIdleConns = settings.maxIdleConns
+	}
+	if settings.maxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = settings.maxIdleConnsPerHost
+	}
+	if settings.maxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = settings.maxConnsPerHost
+	}
+	if settings.forceHTTP1 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	transport.DisableKeepAlives = settings.disableKeepAlives
+	if settings.proxyURL != "" {
+		proxyURL, err := url.Parse(settings.proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if settings.keepAlive != 0 {
+		dialer.KeepAlive = settings.keepAlive
+	}
+	var cache *dnsCacheT
+	if settings.dnsCacheTTL > 0 {
+		cache = newDNSCache(settings.dnsCacheTTL)
+	}
+	connectionStats := newConnectionStats(network.destType, destinationID)
+	transport.DialContext = connectionStats.dialContext(dialer, cache)
+
+	return &destinationClientT{
+		settings:        settings,
+		client:          &http.Client{Transport: transport, Timeout: network.netClientTimeout},
+		connectionStats: connectionStats,
+	}, nil
+}
+
+// connectionStatsT keeps the stats of the connections of an http client, the number of open connections
+// and how many requests were sent over new and reused connections
+type connectionStatsT struct {
+	open            int64
+	openConnections stats.Measurement
+	newConnections  stats.Measurement
+	reused          stats.Measurement
+}
+
+func newConnectionStats(destType, destinationID string) *connectionStatsT {
+	tags := stats.Tags{"destType": destType}
+	if destinationID != "" {
+		tags["destId"] = destinationID
+	}
+	tagsWith := func(reused string) stats.Tags {
+		t := stats.Tags{"reused": reused}
+		for k, v := range tags {
+			t[k] = v
+		}
+		return t
+	}
+	return &connectionStatsT{
+		openConnections: stats.Default.NewTaggedStat("router_http_open_connections", stats.GaugeType, tags),
+		newConnections:  stats.Default.NewTaggedStat("router_http_connections_used", stats.CountType, tagsWith("false")),
+		reused:          stats.Default.NewTaggedStat("router_http_connections_used", stats.CountType, tagsWith("true")),
+	}
+}
+
+// clientTrace returns the trace recording whether the connection of a request was reused
+func (s *connectionStatsT) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if s == nil {
+				return
+			}
+			if info.Reused {
+				s.reused.Increment()
+			} else {
+				s.newConnections.Increment()
+			}
+		},
+	}
+}
+
+// dialContext returns a dial function counting the open connections, which resolves addresses through the cache if it isn't nil
+func (s *connectionStatsT) dialContext(dialer *net.Dialer, cache *dnsCacheT) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		var conn net.Conn
+		var err error
+		if cache != nil {
+			conn, err = cache.dial(ctx, dialer, network, address)
+		} else {
+			conn, err = dialer.DialContext(ctx, network, address)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.openConnections.Gauge(atomic.AddInt64(&s.open, 1))
+		return &trackedConnT{Conn: conn, onClose: func() {
+			s.openConnections.Gauge(atomic.AddInt64(&s.open, -1))
+		}}, nil
+	}
+}
+
+// trackedConnT is a connection calling onClose once it is closed
+type trackedConnT struct {
+	net.Conn
+	closeOnce sync.Once
+	onClose   func()
+}
+
+func (c *trackedConnT) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(c.onClose)
+	return err
+}
+
+// dnsCacheT caches the addresses of hosts for a ttl
+type dnsCacheT struct {
+	ttl        time.Duration
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]dnsCacheEntryT
+}
+
+type dnsCacheEntryT struct {
+	addresses []string
+	expiresAt time.Time
+}
+
+func newDNSCache(ttl time.Duration) *dnsCacheT {
+	return &dnsCacheT{
+		ttl:        ttl,
+		lookupHost: net.DefaultResolver.LookupHost,
+		now:        time.Now,
+		entries:    make(map[string]dnsCacheEntryT),
+	}
+}
+
+// lookup returns the addresses of a host, resolving them only if they aren't cached or their ttl has passed
+func (c *dnsCacheT) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expiresAt) {
+		return entry.addresses, nil
+	}
+
+	addresses, err := c.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsCacheEntryT{addresses: addresses, expiresAt: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addresses, nil
+}
+
+// dial connects to the first reachable address of the host
+func (c *dnsCacheT) dial(ctx context.Context, dialer *net.Dialer, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, address)
+	}
+	addresses, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range addresses {
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil, err
+}
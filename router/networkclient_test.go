@@ -0,0 +1,165 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+func TestDestinationClients(t *testing.T) {
+	config.Reset()
+	t.Cleanup(config.Reset)
+	config.Set("Router.http.overridden.httpMaxConnsPerHost", 5)
+
+	network := &NetHandleT{logger: logger.NOP}
+	network.Setup("WEBHOOK", 10*time.Second)
+
+	t.Run("destinations without client settings share the client of the network handle", func(t *testing.T) {
+		destination := &backendconfig.DestinationT{ID: "default", Config: map[string]interface{}{}}
+		network.setDestinations([]backendconfig.DestinationT{*destination})
+		client, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		require.Same(t, network.httpClient, client)
+		client, _, err = network.clientFor(nil)
+		require.NoError(t, err)
+		require.Same(t, network.httpClient, client)
+	})
+
+	t.Run("destination clients are tuned by their settings", func(t *testing.T) {
+		destination := &backendconfig.DestinationT{ID: "tuned", Config: map[string]interface{}{
+			httpMaxIdleConnsConfigKey:        float64(10),
+			httpMaxIdleConnsPerHostConfigKey: float64(2),
+			httpMaxConnsPerHostConfigKey:     float64(4),
+			forceHTTP1ConfigKey:              true,
+			disableKeepAlivesConfigKey:       true,
+			proxyURLConfigKey:                "http://proxy:3128",
+		}}
+		network.setDestinations([]backendconfig.DestinationT{*destination})
+		client, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		transport := client.(*http.Client).Transport.(*http.Transport)
+		require.Equal(t, 10, transport.MaxIdleConns)
+		require.Equal(t, 2, transport.MaxIdleConnsPerHost)
+		require.Equal(t, 4, transport.MaxConnsPerHost)
+		require.False(t, transport.ForceAttemptHTTP2)
+		require.Equal(t, []string{"http/1.1"}, transport.TLSClientConfig.NextProtos)
+		require.True(t, transport.DisableKeepAlives)
+		proxy, err := transport.Proxy(httptest.NewRequest(http.MethodPost, "http://destination", http.NoBody))
+		require.NoError(t, err)
+		require.Equal(t, "http://proxy:3128", proxy.String())
+		require.NotSame(t, network.transport, transport)
+		require.Equal(t, 100, network.transport.MaxIdleConns, "the shared transport is left untouched")
+	})
+
+	t.Run("config overrides the destination config", func(t *testing.T) {
+		destination := &backendconfig.DestinationT{ID: "overridden", Config: map[string]interface{}{
+			httpMaxConnsPerHostConfigKey: float64(50),
+		}}
+		network.setDestinations([]backendconfig.DestinationT{*destination})
+		client, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		require.Equal(t, 5, client.(*http.Client).Transport.(*http.Transport).MaxConnsPerHost)
+	})
+
+	t.Run("destination clients are created again when their settings change", func(t *testing.T) {
+		destination := &backendconfig.DestinationT{ID: "changing", Config: map[string]interface{}{httpMaxConnsPerHostConfigKey: float64(1)}}
+		network.setDestinations([]backendconfig.DestinationT{*destination})
+		first, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		second, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		require.Same(t, first, second)
+
+		destination.Config[httpMaxConnsPerHostConfigKey] = float64(2)
+		unchanged, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		require.Same(t, first, unchanged, "settings are read when the backend config changes")
+
+		network.setDestinations([]backendconfig.DestinationT{*destination})
+		third, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		require.NotSame(t, first, third)
+		require.Equal(t, 2, third.(*http.Client).Transport.(*http.Transport).MaxConnsPerHost)
+	})
+
+	t.Run("clients of removed destinations are dropped", func(t *testing.T) {
+		destination := &backendconfig.DestinationT{ID: "removed", Config: map[string]interface{}{httpMaxConnsPerHostConfigKey: float64(1)}}
+		network.setDestinations([]backendconfig.DestinationT{*destination})
+		_, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		require.Contains(t, network.destinationClients, destination.ID)
+
+		network.setDestinations(nil)
+		require.NotContains(t, network.destinationClients, destination.ID)
+		client, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		require.Same(t, network.httpClient, client)
+	})
+
+	t.Run("invalid proxy urls fail", func(t *testing.T) {
+		destination := &backendconfig.DestinationT{ID: "invalid", Config: map[string]interface{}{proxyURLConfigKey: "://proxy"}}
+		network.setDestinations([]backendconfig.DestinationT{*destination})
+		_, _, err := network.clientFor(destination)
+		require.Error(t, err)
+	})
+
+	t.Run("open connections are counted", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		destination := &backendconfig.DestinationT{ID: "counted", Config: map[string]interface{}{httpMaxIdleConnsConfigKey: float64(1)}}
+		network.setDestinations([]backendconfig.DestinationT{*destination})
+		client, connectionStats, err := network.clientFor(destination)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, server.URL, http.NoBody)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.EqualValues(t, 1, atomic.LoadInt64(&connectionStats.open))
+
+		client.(*http.Client).CloseIdleConnections()
+		require.Eventually(t, func() bool { return atomic.LoadInt64(&connectionStats.open) == 0 }, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestDNSCache(t *testing.T) {
+	now := time.Now()
+	var lookups int
+	cache := newDNSCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	cache.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		lookups++
+		return []string{"127.0.0.1"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		addresses, err := cache.lookup(context.Background(), "destination")
+		require.NoError(t, err)
+		require.Equal(t, []string{"127.0.0.1"}, addresses)
+	}
+	require.Equal(t, 1, lookups, "addresses are cached")
+
+	now = now.Add(time.Minute)
+	_, err := cache.lookup(context.Background(), "destination")
+	require.NoError(t, err)
+	require.Equal(t, 2, lookups, "addresses are resolved again after their ttl")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	conn, err := cache.dial(context.Background(), &net.Dialer{}, "tcp", "destination:"+port)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+}
@@ -0,0 +1,162 @@
+package router
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/integrations"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+func TestSignedMTLSRequests(t *testing.T) {
+	ca, caKey, caPEM := newTestCertificate(t, "ca", nil, nil)
+	_, _, serverPEM := newTestCertificate(t, "server", ca, caKey)
+	serverCert, err := tls.X509KeyPair(serverPEM.cert, serverPEM.key)
+	require.NoError(t, err)
+	_, _, clientPEM := newTestCertificate(t, "client", ca, caKey)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	type received struct {
+		header http.Header
+		body   []byte
+		client string
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body, client: r.TLS.PeerCertificates[0].Subject.CommonName}
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	network := &NetHandleT{logger: logger.NOP}
+	network.Setup("WEBHOOK", 10*time.Second)
+	destination := &backendconfig.DestinationT{ID: "dest", Config: map[string]interface{}{
+		hmacSecretConfigKey:        "secret",
+		clientCertificateConfigKey: string(clientPEM.cert),
+		clientKeyConfigKey:         string(clientPEM.key),
+		caCertificateConfigKey:     string(caPEM.cert),
+	}}
+	params := integrations.PostParametersT{
+		Type:          "REST",
+		URL:           server.URL,
+		RequestMethod: http.MethodPost,
+		Body:          map[string]interface{}{"JSON": map[string]interface{}{"key": "value"}, "FORM": map[string]interface{}{}, "JSON_ARRAY": map[string]interface{}{}, "XML": map[string]interface{}{}},
+	}
+	network.setDestinations([]backendconfig.DestinationT{*destination})
+
+	t.Run("signs requests and authenticates with the client certificate", func(t *testing.T) {
+		resp := network.SendPost(context.Background(), destination, params)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.ResponseBody))
+
+		r := <-requests
+		require.Equal(t, "client", r.client)
+		require.JSONEq(t, `{"key": "value"}`, string(r.body))
+		timestamp := r.header.Get(SignatureTimestampHeader)
+		require.NotEmpty(t, timestamp)
+		require.Equal(t, "sha256="+RequestSignature("secret", timestamp, r.body), r.header.Get(SignatureHeader))
+	})
+
+	t.Run("reuses the client of a destination until its certificates change", func(t *testing.T) {
+		first, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		second, _, err := network.clientFor(destination)
+		require.NoError(t, err)
+		require.Same(t, first, second)
+
+		changed := &backendconfig.DestinationT{ID: "dest", Config: map[string]interface{}{
+			clientCertificateConfigKey: string(clientPEM.cert),
+			clientKeyConfigKey:         string(clientPEM.key),
+		}}
+		network.setDestinations([]backendconfig.DestinationT{*changed})
+		third, _, err := network.clientFor(changed)
+		require.NoError(t, err)
+		require.NotSame(t, first, third)
+	})
+
+	t.Run("fails requests of destinations with invalid certificates", func(t *testing.T) {
+		invalid := &backendconfig.DestinationT{ID: "invalid", Config: map[string]interface{}{
+			clientCertificateConfigKey: "invalid",
+			clientKeyConfigKey:         "invalid",
+		}}
+		network.setDestinations([]backendconfig.DestinationT{*invalid})
+		resp := network.SendPost(context.Background(), invalid, params)
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		require.True(t, strings.Contains(string(resp.ResponseBody), "client certificate"))
+	})
+
+	t.Run("doesn't sign requests of destinations without a secret", func(t *testing.T) {
+		unsigned := &backendconfig.DestinationT{ID: "unsigned", Config: map[string]interface{}{
+			clientCertificateConfigKey: string(clientPEM.cert),
+			clientKeyConfigKey:         string(clientPEM.key),
+			caCertificateConfigKey:     string(caPEM.cert),
+		}}
+		network.setDestinations([]backendconfig.DestinationT{*unsigned})
+		resp := network.SendPost(context.Background(), unsigned, params)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.ResponseBody))
+
+		r := <-requests
+		require.Empty(t, r.header.Get(SignatureTimestampHeader))
+		require.Empty(t, r.header.Get(SignatureHeader))
+	})
+}
+
+type testPEM struct {
+	cert, key []byte
+}
+
+// newTestCertificate creates a certificate for localhost, signed by the given parent or self signed if it is nil
+func newTestCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, testPEM) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return cert, key, testPEM{
+		cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
@@ -0,0 +1,156 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+// replayedErrorCode is the error code of the status marking aborted jobs which have been replayed, so that they don't get replayed again
+const replayedErrorCode = "replayed"
+
+var replayJobsDBs struct {
+	sync.RWMutex
+	routerDB         jobsdb.JobsDB
+	batchRouterDB    jobsdb.JobsDB
+	multitenantStats tenantStats
+}
+
+// RegisterReplayJobsDBs registers the jobsdbs which the ReplayAbortedJobs admin function replays aborted jobs into,
+// along with the multitenant stats which the replayed jobs are reported to, like the jobs added by the processor
+func RegisterReplayJobsDBs(routerDB, batchRouterDB jobsdb.JobsDB, multitenantStats tenantStats) {
+	replayJobsDBs.Lock()
+	defer replayJobsDBs.Unlock()
+	replayJobsDBs.routerDB = routerDB
+	replayJobsDBs.batchRouterDB = batchRouterDB
+	replayJobsDBs.multitenantStats = multitenantStats
+}
+
+func getReplayJobsDB(prefix string) (jobsdb.JobsDB, tenantStats) {
+	replayJobsDBs.RLock()
+	defer replayJobsDBs.RUnlock()
+	if prefix == "rt" {
+		return replayJobsDBs.routerDB, replayJobsDBs.multitenantStats
+	}
+	return replayJobsDBs.batchRouterDB, replayJobsDBs.multitenantStats
+}
+
+// ReplayAbortedJobsArg is the argument of the ReplayAbortedJobs admin function
+type ReplayAbortedJobsArg struct {
+	DestinationID string
+	From, To      time.Time // range of the time jobs were aborted at, unbounded if zero
+	ErrorCode     string    // error code jobs were aborted with, any if empty
+	Limit         int       // maximum number of jobs replayed, Router.replay.maxJobs if zero
+	DryRun        bool      // only count the jobs which would be replayed
+}
+
+// ReplayAbortedJobs stores aborted jobs of a destination as new jobs, so that they are sent again.
+// Replayed jobs get a status with the replayed error code, so that they are replayed only once
+func (r *RouterRpcHandler) ReplayAbortedJobs(arg ReplayAbortedJobsArg, result *string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pkgLogger.Error(r)
+			err = fmt.Errorf("internal Rudder server error: %v", r)
+		}
+	}()
+	var db jobsdb.JobsDB
+	var multitenantStats tenantStats
+	if !arg.DryRun {
+		if db, multitenantStats = getReplayJobsDB(r.jobsDBPrefix); db == nil {
+			return fmt.Errorf("replaying jobs is not supported by this server")
+		}
+	}
+	*result, err = replayAbortedJobs(context.Background(), r.getReadOnlyJobsDB(r.jobsDBPrefix), db, multitenantStats, r.jobsDBPrefix, arg)
+	return err
+}
+
+// replayAbortedJobs replays aborted jobs into db, reporting the replayed jobs to multitenantStats for the tableType jobsdb, if it isn't nil
+func replayAbortedJobs(ctx context.Context, readonlyDB jobsdb.ReadonlyJobsDB, db jobsdb.JobsDB, multitenantStats tenantStats, tableType string, arg ReplayAbortedJobsArg) (string, error) {
+	if arg.DestinationID == "" {
+		return "", fmt.Errorf("destination id is required")
+	}
+	limit := arg.Limit
+	if limit <= 0 {
+		limit = config.GetInt("Router.replay.maxJobs", 10000)
+	}
+	jobs, err := readonlyDB.GetAbortedJobs(ctx, jobsdb.AbortedJobsParamsT{
+		DestinationID:    arg.DestinationID,
+		ErrorCode:        arg.ErrorCode,
+		ExcludeErrorCode: replayedErrorCode,
+		From:             arg.From,
+		To:               arg.To,
+		Limit:            limit,
+	})
+	if err != nil {
+		return "", err
+	}
+	var limitReached string
+	if len(jobs) == limit {
+		limitReached = fmt.Sprintf(" (limit of %d jobs reached, run it again for the remaining jobs)", limit)
+	}
+	if arg.DryRun {
+		return fmt.Sprintf("%d aborted jobs of destination %s would be replayed%s", len(jobs), arg.DestinationID, limitReached), nil
+	}
+	if len(jobs) == 0 {
+		return fmt.Sprintf("no aborted jobs of destination %s to replay", arg.DestinationID), nil
+	}
+
+	now := time.Now()
+	replays := make([]*jobsdb.JobT, 0, len(jobs))
+	statuses := make([]*jobsdb.JobStatusT, 0, len(jobs))
+	customVals := make(map[string]struct{})
+	for _, job := range jobs {
+		replays = append(replays, &jobsdb.JobT{
+			UUID:         uuid.Must(uuid.NewV4()),
+			UserID:       job.UserID,
+			CustomVal:    job.CustomVal,
+			EventCount:   job.EventCount,
+			EventPayload: job.EventPayload,
+			Parameters:   job.Parameters,
+			WorkspaceId:  job.WorkspaceId,
+			CreatedAt:    now,
+			ExpireAt:     now,
+		})
+		statuses = append(statuses, &jobsdb.JobStatusT{
+			JobID:         job.JobID,
+			JobState:      jobsdb.Aborted.State,
+			ExecTime:      now,
+			RetryTime:     now,
+			ErrorCode:     replayedErrorCode,
+			ErrorResponse: json.RawMessage(`{"reason": "job replayed by admin"}`),
+			Parameters:    json.RawMessage(`{}`),
+			WorkspaceId:   job.WorkspaceId,
+		})
+		customVals[job.CustomVal] = struct{}{}
+	}
+	// jobs are stored before they are marked as replayed, so that they are replayed at least once
+	if err := db.Store(ctx, replays); err != nil {
+		return "", fmt.Errorf("storing replayed jobs: %w", err)
+	}
+	if multitenantStats != nil {
+		addedJobs := make(map[string]map[string]int)
+		for _, job := range replays {
+			if _, ok := addedJobs[job.WorkspaceId]; !ok {
+				addedJobs[job.WorkspaceId] = make(map[string]int)
+			}
+			addedJobs[job.WorkspaceId][job.CustomVal]++
+		}
+		multitenantStats.ReportProcLoopAddStats(addedJobs, tableType)
+	}
+	customValFilters := make([]string, 0, len(customVals))
+	for customVal := range customVals {
+		customValFilters = append(customValFilters, customVal)
+	}
+	if err := db.UpdateJobStatus(ctx, statuses, customValFilters, nil); err != nil {
+		return "", fmt.Errorf("marking jobs as replayed: %w", err)
+	}
+	pkgLogger.Infof("Replayed %d aborted jobs of destination %s", len(replays), arg.DestinationID)
+	return fmt.Sprintf("%d aborted jobs of destination %s replayed%s", len(replays), arg.DestinationID, limitReached), nil
+}
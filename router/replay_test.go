@@ -0,0 +1,90 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+	mocksMultitenant "github.com/rudderlabs/rudder-server/mocks/services/multitenant"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+type abortedJobsDB struct {
+	jobsdb.ReadonlyJobsDB
+	params jobsdb.AbortedJobsParamsT
+	jobs   []*jobsdb.JobT
+}
+
+func (db *abortedJobsDB) GetAbortedJobs(_ context.Context, params jobsdb.AbortedJobsParamsT) ([]*jobsdb.JobT, error) {
+	db.params = params
+	return db.jobs, nil
+}
+
+func TestReplayAbortedJobs(t *testing.T) {
+	pkgLogger = logger.NOP
+	from := time.Now().Add(-time.Hour)
+	readonlyDB := &abortedJobsDB{jobs: []*jobsdb.JobT{
+		{JobID: 1, UserID: "u1", CustomVal: "WEBHOOK", EventCount: 1, EventPayload: json.RawMessage(`{"a": 1}`), Parameters: json.RawMessage(`{"destination_id": "d1"}`), WorkspaceId: "w1"},
+		{JobID: 2, UserID: "u2", CustomVal: "WEBHOOK", EventCount: 1, EventPayload: json.RawMessage(`{"a": 2}`), Parameters: json.RawMessage(`{"destination_id": "d1"}`), WorkspaceId: "w1"},
+	}}
+
+	t.Run("dry run only counts jobs", func(t *testing.T) {
+		result, err := replayAbortedJobs(context.Background(), readonlyDB, nil, nil, "rt", ReplayAbortedJobsArg{DestinationID: "d1", ErrorCode: "400", From: from, DryRun: true})
+		require.NoError(t, err)
+		require.Equal(t, "2 aborted jobs of destination d1 would be replayed", result)
+		require.Equal(t, jobsdb.AbortedJobsParamsT{
+			DestinationID:    "d1",
+			ErrorCode:        "400",
+			ExcludeErrorCode: replayedErrorCode,
+			From:             from,
+			Limit:            10000,
+		}, readonlyDB.params)
+	})
+
+	t.Run("jobs are stored again and marked as replayed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		db := mocksJobsDB.NewMockJobsDB(ctrl)
+		store := db.EXPECT().Store(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, jobs []*jobsdb.JobT) error {
+			require.Len(t, jobs, 2)
+			for i, job := range jobs {
+				original := readonlyDB.jobs[i]
+				require.NotEqual(t, original.UUID, job.UUID)
+				require.Zero(t, job.JobID)
+				require.Equal(t, original.UserID, job.UserID)
+				require.Equal(t, original.CustomVal, job.CustomVal)
+				require.Equal(t, original.EventPayload, job.EventPayload)
+				require.Equal(t, original.Parameters, job.Parameters)
+				require.Equal(t, original.WorkspaceId, job.WorkspaceId)
+			}
+			return nil
+		})
+		db.EXPECT().UpdateJobStatus(gomock.Any(), gomock.Any(), []string{"WEBHOOK"}, nil).After(store).DoAndReturn(
+			func(_ context.Context, statuses []*jobsdb.JobStatusT, _ []string, _ []jobsdb.ParameterFilterT) error {
+				require.Len(t, statuses, 2)
+				for i, status := range statuses {
+					require.Equal(t, readonlyDB.jobs[i].JobID, status.JobID)
+					require.Equal(t, jobsdb.Aborted.State, status.JobState)
+					require.Equal(t, replayedErrorCode, status.ErrorCode)
+				}
+				return nil
+			})
+
+		multitenantStats := mocksMultitenant.NewMockMultiTenantI(ctrl)
+		multitenantStats.EXPECT().ReportProcLoopAddStats(map[string]map[string]int{"w1": {"WEBHOOK": 2}}, "rt").After(store).Times(1)
+
+		result, err := replayAbortedJobs(context.Background(), readonlyDB, db, multitenantStats, "rt", ReplayAbortedJobsArg{DestinationID: "d1", Limit: 2})
+		require.NoError(t, err)
+		require.Equal(t, "2 aborted jobs of destination d1 replayed (limit of 2 jobs reached, run it again for the remaining jobs)", result)
+	})
+
+	t.Run("destination id is required", func(t *testing.T) {
+		_, err := replayAbortedJobs(context.Background(), readonlyDB, nil, nil, "rt", ReplayAbortedJobsArg{DryRun: true})
+		require.Error(t, err)
+	})
+}
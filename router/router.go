@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -25,6 +26,8 @@ import (
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
 	customDestinationManager "github.com/rudderlabs/rudder-server/router/customdestinationmanager"
+	"github.com/rudderlabs/rudder-server/router/deadletter"
+	"github.com/rudderlabs/rudder-server/router/deliveryschedule"
 	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
 	"github.com/rudderlabs/rudder-server/router/internal/jobiterator"
 	oauth "github.com/rudderlabs/rudder-server/router/oauthResponseHandler"
@@ -70,6 +73,7 @@ type HandleDestOAuthRespParamsT struct {
 type HandleT struct {
 	responseQ             chan jobResponseT
 	jobsDB                jobsdb.MultiTenantJobsDB
+	newJobs               <-chan struct{} // signalled when new jobs are stored for the destination, nil if jobsDB doesn't notify
 	errorDB               jobsdb.JobsDB
 	netHandle             NetHandleI
 	MultitenantI          tenantStats
@@ -90,7 +94,14 @@ type HandleT struct {
 	failuresMetric                          map[string]map[string]int
 	customDestinationManager                customDestinationManager.DestinationManager
 	throttler                               throttler.Throttler
+	circuitBreakers                         *circuitBreakers
+	deliverySchedules                       *deliveryschedule.Schedules
 	guaranteeUserEventOrder                 bool
+	eventOrderSettingsMu                    sync.RWMutex
+	eventOrderSettings                      map[string]eventOrderSettingT // destinationID -> event order setting
+	pendingEventOrderSettings               map[string]eventOrderSettingT // destinationID -> event order setting, until applied
+	drainingEventOrder                      map[string]struct{}           // destinations whose jobs aren't picked up until their pending setting is applied
+	orderedJobsInFlight                     map[string]int                // destinationID -> ordered jobs picked up and not yet updated
 	netClientTimeout                        time.Duration
 	backendProxyTimeout                     time.Duration
 	jobdDBQueryRequestTimeout               time.Duration
@@ -100,6 +111,8 @@ type HandleT struct {
 	transformer                             transformer.Transformer
 	configSubscriberLock                    sync.RWMutex
 	destinationsMap                         map[string]*routerutils.BatchDestinationT // destinationID -> destination
+	destinationControls                     *DestinationControls                      // destinations paused or drained through the admin interface, may be nil
+	deadLetterForwarder                     *deadletter.Forwarder                     // forwards aborted jobs to dead-letter destinations, may be nil
 	logger                                  logger.Logger
 	batchInputCountStat                     stats.Measurement
 	batchOutputCountStat                    stats.Measurement
@@ -144,10 +157,11 @@ type HandleT struct {
 }
 
 type jobResponseT struct {
-	status *jobsdb.JobStatusT
-	worker *workerT
-	userID string
-	JobT   *jobsdb.JobT
+	status   *jobsdb.JobStatusT
+	worker   *workerT
+	userID   string
+	orderKey string // key of the barrier ordering the job, empty if its events aren't ordered
+	JobT     *jobsdb.JobT
 }
 
 // JobParametersT struct holds source id and destination id of a job
@@ -172,6 +186,7 @@ type JobParametersT struct {
 
 type workerMessageT struct {
 	job                *jobsdb.JobT
+	orderKey           string // key of the barrier ordering the job, empty if its events aren't ordered
 	throttledAtTime    time.Time
 	workerAssignedTime time.Time
 }
@@ -357,6 +372,7 @@ func (worker *workerT) workerProcess() {
 			}
 
 			job := message.job
+			orderKey := message.orderKey
 			worker.throttledAtTime = message.throttledAtTime
 			worker.rt.logger.Debugf("[%v Router] :: performing checks to send payload.", worker.rt.destName)
 
@@ -369,6 +385,9 @@ func (worker *workerT) workerProcess() {
 			worker.rt.configSubscriberLock.RLock()
 			drain, drainReason := routerutils.ToBeDrained(job, parameters.DestinationID, toAbortDestinationIDs, worker.rt.destinationsMap)
 			worker.rt.configSubscriberLock.RUnlock()
+			if control, ok := worker.rt.destinationControls.get(parameters.DestinationID); !drain && ok && control.State == destinationDraining {
+				drain, drainReason = true, control.Reason
+			}
 			if drain {
 				status := jobsdb.JobStatusT{
 					JobID:         job.JobID,
@@ -384,7 +403,7 @@ func (worker *workerT) workerProcess() {
 				// Enhancing job parameter with the drain reason.
 				job.Parameters = routerutils.EnhanceJSON(job.Parameters, "stage", "router")
 				job.Parameters = routerutils.EnhanceJSON(job.Parameters, "reason", drainReason)
-				worker.rt.responseQ <- jobResponseT{status: &status, worker: worker, userID: userID, JobT: job}
+				worker.rt.responseQ <- jobResponseT{status: &status, worker: worker, userID: userID, orderKey: orderKey, JobT: job}
 				worker.rt.logger.Debugf(`Decrementing in throttle map for destination:%s since job:%d is marked as drained for user:%s`, parameters.DestinationID, job.JobID, userID)
 				worker.rt.throttler.Dec(parameters.DestinationID, userID, 1, worker.throttledAtTime, throttler.ALL_LEVELS)
 
@@ -398,8 +417,7 @@ func (worker *workerT) workerProcess() {
 				continue
 			}
 
-			if worker.rt.guaranteeUserEventOrder {
-				orderKey := fmt.Sprintf(`%s:%s`, userID, parameters.DestinationID)
+			if orderKey != "" {
 				if wait, previousFailedJobID := worker.barrier.Wait(orderKey, job.JobID); wait {
 					previousFailedJobIDStr := "<nil>"
 					if previousFailedJobID != nil {
@@ -421,7 +439,7 @@ func (worker *workerT) workerProcess() {
 						Parameters:    routerutils.EmptyPayload,
 						WorkspaceId:   job.WorkspaceId,
 					}
-					worker.rt.responseQ <- jobResponseT{status: &status, worker: worker, userID: userID, JobT: job}
+					worker.rt.responseQ <- jobResponseT{status: &status, worker: worker, userID: userID, orderKey: orderKey, JobT: job}
 					worker.rt.logger.Debugf(`Decrementing in throttle map for destination:%s since job:%d is marked as waiting for user:%s`, parameters.DestinationID, job.JobID, userID)
 					worker.rt.throttler.Dec(parameters.DestinationID, userID, 1, worker.throttledAtTime, throttler.ALL_LEVELS)
 					continue
@@ -440,6 +458,7 @@ func (worker *workerT) workerProcess() {
 				CreatedAt:          job.CreatedAt.Format(misc.RFC3339Milli),
 				FirstAttemptedAt:   firstAttemptedAt,
 				TransformAt:        parameters.TransformAt,
+				OrderKey:           orderKey,
 				JobT:               job,
 				WorkspaceID:        parameters.WorkspaceID,
 				WorkerAssignedTime: message.workerAssignedTime,
@@ -461,14 +480,13 @@ func (worker *workerT) workerProcess() {
 					WorkspaceId:   job.WorkspaceId,
 				}
 				worker.rt.failedEventsChan <- status
-				if worker.rt.guaranteeUserEventOrder {
-					orderKey := fmt.Sprintf(`%s:%s`, job.UserID, parameters.DestinationID)
+				if orderKey != "" {
 					worker.rt.logger.Debugf("EventOrder: [%d] job %d for key %s failed", worker.workerID, status.JobID, orderKey)
 					if err := worker.barrier.StateChanged(orderKey, job.JobID, status.JobState); err != nil {
 						panic(err)
 					}
 				}
-				worker.rt.responseQ <- jobResponseT{status: &status, worker: worker, userID: userID, JobT: job}
+				worker.rt.responseQ <- jobResponseT{status: &status, worker: worker, userID: userID, orderKey: orderKey, JobT: job}
 				continue
 			}
 			destination := batchDestination.Destination
@@ -583,7 +601,7 @@ func (worker *workerT) processDestinationJobs() {
 		u2e3 will send
 	*/
 
-	failedUserIDsMap := make(map[string]struct{})
+	failedOrderKeysMap := make(map[string]struct{})
 	apiCallsCount := make(map[string]*destJobCountsT)
 	routerJobResponses := make([]*JobResponse, 0)
 
@@ -596,7 +614,7 @@ func (worker *workerT) processDestinationJobs() {
 		var errorAt string
 		respBodyArr := make([]string, 0)
 		if destinationJob.StatusCode == 200 || destinationJob.StatusCode == 0 {
-			if worker.canSendJobToDestination(prevRespStatusCode, failedUserIDsMap, &destinationJob) {
+			if worker.canSendJobToDestination(prevRespStatusCode, failedOrderKeysMap, &destinationJob) {
 				diagnosisStartTime := time.Now()
 				destinationID := destinationJob.JobMetadataArray[0].DestinationID
 
@@ -641,7 +659,12 @@ func (worker *workerT) processDestinationJobs() {
 							panic(fmt.Errorf("different destinations are grouped together"))
 						}
 					}
-					respStatusCode, respBody = worker.rt.customDestinationManager.SendData(destinationJob.Message, destinationID)
+					if done, err := worker.rt.circuitBreakers.allow(destinationID); err != nil {
+						respStatusCode, respBody = types.RouterCircuitOpenStatusCode, err.Error()
+					} else {
+						respStatusCode, respBody = worker.rt.customDestinationManager.SendData(destinationJob.Message, destinationID)
+						done(respStatusCode)
+					}
 					errorAt = routerutils.ERROR_AT_CUST
 				} else {
 					result, err := getIterableStruct(destinationJob.Message, transformAt)
@@ -656,11 +679,14 @@ func (worker *workerT) processDestinationJobs() {
 								respStatusCode, respBodyTemp = http.StatusBadRequest, fmt.Sprintf(`400 GetPostInfoFailed with error: %s`, err.Error())
 								respBodyArr = append(respBodyArr, respBodyTemp)
 							} else {
+								var retryAfter string
 								// stat start
 								pkgLogger.Debugf(`responseTransform status :%v, %s`, worker.rt.transformerProxy, worker.rt.destName)
 								// transformer proxy start
 								errorAt = routerutils.ERROR_AT_DEL
-								if worker.rt.transformerProxy {
+								if done, err := worker.rt.circuitBreakers.allow(destinationID); err != nil {
+									respStatusCode, respBodyTemp = types.RouterCircuitOpenStatusCode, err.Error()
+								} else if worker.rt.transformerProxy {
 									jobID := destinationJob.JobMetadataArray[0].JobID
 									pkgLogger.Debugf(`[TransformerProxy] (Dest-%[1]v) {Job - %[2]v} Request started`, worker.rt.destName, jobID)
 									proxyReqparams := &transformer.ProxyRequestParams{
@@ -670,6 +696,7 @@ func (worker *workerT) processDestinationJobs() {
 									}
 									rtlTime := time.Now()
 									respStatusCode, respBodyTemp, respContentType = worker.rt.transformer.ProxyRequest(ctx, proxyReqparams)
+									done(respStatusCode)
 									worker.routerProxyStat.SendTiming(time.Since(rtlTime))
 									pkgLogger.Debugf(`[TransformerProxy] (Dest-%[1]v) {Job - %[2]v} Request ended`, worker.rt.destName, jobID)
 									authType := routerutils.GetAuthType(destinationJob.Destination)
@@ -688,13 +715,16 @@ func (worker *workerT) processDestinationJobs() {
 								} else {
 									sendCtx, cancel := context.WithTimeout(ctx, worker.rt.netClientTimeout)
 									rdlTime := time.Now()
-									resp := worker.rt.netHandle.SendPost(sendCtx, val)
+									resp := worker.rt.netHandle.SendPost(sendCtx, &destinationJob.Destination, val)
 									cancel()
 									respStatusCode, respBodyTemp, respContentType = resp.StatusCode, string(resp.ResponseBody), resp.ResponseContentType
+									done(respStatusCode)
+									retryAfter = resp.RetryAfter
 									// stat end
 									worker.routerDeliveryLatencyStat.SendTiming(time.Since(rdlTime))
 								}
 								// transformer proxy end
+								worker.rt.throttler.ResponseReceived(destinationID, respStatusCode, retryAfter, time.Now())
 								if isSuccessStatus(respStatusCode) {
 									respBodyArr = append(respBodyArr, respBodyTemp)
 								} else {
@@ -729,7 +759,7 @@ func (worker *workerT) processDestinationJobs() {
 				}
 				ch <- struct{}{}
 				timeTaken := time.Since(startedAt)
-				if respStatusCode != types.RouterTimedOutStatusCode && respStatusCode != types.RouterUnMarshalErrorCode {
+				if respStatusCode != types.RouterTimedOutStatusCode && respStatusCode != types.RouterCircuitOpenStatusCode && respStatusCode != types.RouterUnMarshalErrorCode {
 					worker.rt.MultitenantI.UpdateWorkspaceLatencyMap(worker.rt.destName, workspaceID, float64(timeTaken)/float64(time.Second))
 				}
 
@@ -739,7 +769,8 @@ func (worker *workerT) processDestinationJobs() {
 					respStatusCode = destinationResponseHandler.IsSuccessStatus(respStatusCode, respBody)
 				}
 
-				attemptedToSendTheJob = true
+				// jobs held back by an open circuit breaker were not sent, so their attempts are not counted
+				attemptedToSendTheJob = respStatusCode != types.RouterCircuitOpenStatusCode
 
 				worker.deliveryTimeStat.End()
 				deliveryLatencyStat.End()
@@ -774,7 +805,9 @@ func (worker *workerT) processDestinationJobs() {
 
 		if !isJobTerminated(respStatusCode) {
 			for _, metadata := range destinationJob.JobMetadataArray {
-				failedUserIDsMap[metadata.UserID] = struct{}{}
+				if metadata.OrderKey != "" {
+					failedOrderKeysMap[metadata.OrderKey] = struct{}{}
+				}
 			}
 		}
 
@@ -803,8 +836,8 @@ func (worker *workerT) processDestinationJobs() {
 		return routerJobResponses[i].jobID < routerJobResponses[j].jobID
 	})
 
-	// Struct to hold unique users in the batch (worker.destinationJobs)
-	userToJobIDMap := make(map[string]int64)
+	// Struct to hold unique order keys in the batch (worker.destinationJobs)
+	orderKeyToJobIDMap := make(map[string]int64)
 
 	for _, routerJobResponse := range routerJobResponses {
 		destinationJobMetadata := routerJobResponse.destinationJobMetadata
@@ -824,7 +857,8 @@ func (worker *workerT) processDestinationJobs() {
 		routerJobResponse.status = &status
 
 		if !isJobTerminated(respStatusCode) {
-			if prevFailedJobID, ok := userToJobIDMap[destinationJobMetadata.UserID]; ok {
+			orderKey := destinationJobMetadata.OrderKey
+			if prevFailedJobID, ok := orderKeyToJobIDMap[orderKey]; ok && orderKey != "" {
 				// This means more than two jobs of the same user are in the batch & the batch job is failed
 				// Only one job is marked failed and the rest are marked waiting
 				// Job order logic requires that at any point of time, we should have only one failed job per user
@@ -835,10 +869,10 @@ func (worker *workerT) processDestinationJobs() {
 
 				status.JobState = jobsdb.Waiting.State
 				status.ErrorResponse = resp
-				worker.rt.responseQ <- jobResponseT{status: &status, worker: worker, userID: destinationJobMetadata.UserID, JobT: destinationJobMetadata.JobT}
+				worker.rt.responseQ <- jobResponseT{status: &status, worker: worker, userID: destinationJobMetadata.UserID, orderKey: orderKey, JobT: destinationJobMetadata.JobT}
 				continue
 			}
-			userToJobIDMap[destinationJobMetadata.UserID] = destinationJobMetadata.JobID
+			orderKeyToJobIDMap[orderKey] = destinationJobMetadata.JobID
 		}
 
 		if attemptedToSendTheJob {
@@ -886,13 +920,14 @@ func (worker *workerT) processDestinationJobs() {
 	worker.jobCountsByDestAndUser = make(map[string]*destJobCountsT)
 }
 
-func (worker *workerT) canSendJobToDestination(prevRespStatusCode int, failedUserIDsMap map[string]struct{}, destinationJob *types.DestinationJobT) bool {
+func (worker *workerT) canSendJobToDestination(prevRespStatusCode int, failedOrderKeysMap map[string]struct{}, destinationJob *types.DestinationJobT) bool {
 	if prevRespStatusCode == 0 {
 		return true
 	}
 
-	if !worker.rt.guaranteeUserEventOrder {
-		// if guaranteeUserEventOrder is false, letting the next jobs pass
+	metadata := destinationJob.JobMetadataArray[0]
+	if metadata.OrderKey == "" {
+		// if events of the destination don't need to be ordered, letting the next jobs pass
 		return true
 	}
 
@@ -902,10 +937,13 @@ func (worker *workerT) canSendJobToDestination(prevRespStatusCode int, failedUse
 	}
 
 	// If the destinationJob has come through router transform,
-	// drop the request if it is of a failed user, else send
+	// drop the request if it is of a failed order key, else send
 	for i := range destinationJob.JobMetadataArray {
-		if _, ok := failedUserIDsMap[destinationJob.JobMetadataArray[i].UserID]; ok {
-			return false
+		metadata := destinationJob.JobMetadataArray[i]
+		if metadata.OrderKey != "" {
+			if _, ok := failedOrderKeysMap[metadata.OrderKey]; ok {
+				return false
+			}
 		}
 	}
 
@@ -1072,7 +1110,7 @@ func (worker *workerT) postStatusOnResponseQ(respStatusCode int, respBody string
 		atomic.AddUint64(&worker.rt.successCount, 1)
 		status.JobState = jobsdb.Succeeded.State
 		worker.rt.logger.Debugf("[%v Router] :: sending success status to response", worker.rt.destName)
-		worker.rt.responseQ <- jobResponseT{status: status, worker: worker, userID: destinationJobMetadata.UserID, JobT: destinationJobMetadata.JobT}
+		worker.rt.responseQ <- jobResponseT{status: status, worker: worker, userID: destinationJobMetadata.UserID, orderKey: destinationJobMetadata.OrderKey, JobT: destinationJobMetadata.JobT}
 
 		// Deleting jobID from retryForJobMap. jobID goes into retryForJobMap if it is failed with 5xx or 429.
 		// It's safe to delete from the map, even if jobID is not present.
@@ -1098,8 +1136,9 @@ func (worker *workerT) postStatusOnResponseQ(respStatusCode int, respBody string
 		worker.rt.failedEventsChan <- *status
 
 		if respStatusCode >= 500 {
-			timeElapsed := time.Since(firstAttemptedAtTime)
-			if respStatusCode != types.RouterTimedOutStatusCode && respStatusCode != types.RouterUnMarshalErrorCode {
+			// time spent outside of the delivery windows of the destination doesn't count against the retry time window
+			timeElapsed := worker.rt.deliverySchedules.Get(destinationJobMetadata.DestinationID).ActiveDuration(firstAttemptedAtTime, time.Now())
+			if respStatusCode != types.RouterTimedOutStatusCode && respStatusCode != types.RouterCircuitOpenStatusCode && respStatusCode != types.RouterUnMarshalErrorCode {
 				if timeElapsed > worker.rt.retryTimeWindow && status.AttemptNum >= worker.rt.maxFailedCountForJob {
 					status.JobState = jobsdb.Aborted.State
 					worker.retryForJobMapMutex.Lock()
@@ -1125,9 +1164,8 @@ func (worker *workerT) postStatusOnResponseQ(respStatusCode int, respBody string
 			destinationJobMetadata.JobT.Parameters = misc.UpdateJSONWithNewKeyVal(destinationJobMetadata.JobT.Parameters, "reason", status.ErrorResponse) // NOTE: Old key used was "error_response"
 		}
 
-		if worker.rt.guaranteeUserEventOrder {
+		if orderKey := destinationJobMetadata.OrderKey; orderKey != "" {
 			if status.JobState == jobsdb.Failed.State {
-				orderKey := fmt.Sprintf(`%s:%s`, destinationJobMetadata.UserID, destinationJobMetadata.DestinationID)
 				worker.rt.logger.Debugf("EventOrder: [%d] job %d for key %s failed", worker.workerID, status.JobID, orderKey)
 				if err := worker.barrier.StateChanged(orderKey, destinationJobMetadata.JobID, status.JobState); err != nil {
 					panic(err)
@@ -1135,7 +1173,7 @@ func (worker *workerT) postStatusOnResponseQ(respStatusCode int, respBody string
 			}
 		}
 		worker.rt.logger.Debugf("[%v Router] :: sending failed/aborted state as response", worker.rt.destName)
-		worker.rt.responseQ <- jobResponseT{status: status, worker: worker, userID: destinationJobMetadata.UserID, JobT: destinationJobMetadata.JobT}
+		worker.rt.responseQ <- jobResponseT{status: status, worker: worker, userID: destinationJobMetadata.UserID, orderKey: destinationJobMetadata.OrderKey, JobT: destinationJobMetadata.JobT}
 	}
 }
 
@@ -1195,7 +1233,7 @@ func (worker *workerT) sendEventDeliveryStat(destinationJobMetadata *types.JobMe
 
 func (*workerT) sendDestinationResponseToConfigBackend(payload json.RawMessage, destinationJobMetadata *types.JobMetadataT, status *jobsdb.JobStatusT, sourceIDs []string) {
 	// Sending destination response to config backend
-	if status.ErrorCode != fmt.Sprint(types.RouterUnMarshalErrorCode) && status.ErrorCode != fmt.Sprint(types.RouterTimedOutStatusCode) {
+	if status.ErrorCode != fmt.Sprint(types.RouterUnMarshalErrorCode) && status.ErrorCode != fmt.Sprint(types.RouterTimedOutStatusCode) && status.ErrorCode != fmt.Sprint(types.RouterCircuitOpenStatusCode) {
 		deliveryStatus := destinationdebugger.DeliveryStatusT{
 			DestinationID: destinationJobMetadata.DestinationID,
 			SourceID:      strings.Join(sourceIDs, ","),
@@ -1304,31 +1342,48 @@ func (rt *HandleT) stopWorkers() {
 	}
 }
 
-func (rt *HandleT) findWorker(job *jobsdb.JobT, throttledUserMap map[string]struct{}, throttledAtTime time.Time) (toSendWorker *workerT) {
+func (rt *HandleT) findWorker(job *jobsdb.JobT, throttledUserMap map[string]struct{}, throttledAtTime time.Time) (toSendWorker *workerT, orderKey string) {
 	if rt.backgroundCtx.Err() != nil {
-		return nil
+		return nil, ""
 	}
 
 	// checking if this job can be throttled
 	var parameters JobParametersT
 	userID := job.UserID
-	// checking if the user is in throttledMap. If yes, returning nil.
-	// this check is done to maintain order.
-	if _, ok := throttledUserMap[userID]; ok && rt.guaranteeUserEventOrder {
-		rt.logger.Debugf(`[%v Router] :: Skipping processing of job:%d of user:%s as user has earlier jobs in throttled map`, rt.destName, job.JobID, userID)
-		return nil
-	}
-
 	err := json.Unmarshal(job.Parameters, &parameters)
 	if err != nil {
 		rt.logger.Errorf(`[%v Router] :: Unmarshalling parameters failed with the error %v . Returning nil worker`, err)
-		return nil
+		return nil, ""
+	}
+
+	// checking if the order key is in throttledMap. If yes, returning nil.
+	// this check is done to maintain order.
+	var ordered bool
+	orderKey, ordered = rt.eventOrderKey(job, userID, parameters.DestinationID)
+	if _, ok := throttledUserMap[orderKey]; ok && ordered {
+		rt.logger.Debugf(`[%v Router] :: Skipping processing of job:%d of key:%s as key has earlier jobs in throttled map`, rt.destName, job.JobID, orderKey)
+		return nil, ""
+	}
+
+	if ordered && rt.eventOrderDraining(parameters.DestinationID) {
+		rt.logger.Debugf(`[%v Router] :: Skipping processing of job:%d of key:%s as destination:%s is draining before changing its event order setting`, rt.destName, job.JobID, orderKey, parameters.DestinationID)
+		return nil, ""
+	}
+
+	if control, ok := rt.destinationControls.get(parameters.DestinationID); ok && control.State == destinationPaused {
+		rt.logger.Debugf(`[%v Router] :: Skipping processing of job:%d of user:%s as destination:%s is paused`, rt.destName, job.JobID, userID, parameters.DestinationID)
+		return nil, ""
+	}
+
+	if !rt.deliverySchedules.Get(parameters.DestinationID).Allows(throttledAtTime) {
+		rt.logger.Debugf(`[%v Router] :: Skipping processing of job:%d of user:%s as destination:%s is outside of its delivery windows`, rt.destName, job.JobID, userID, parameters.DestinationID)
+		return nil, ""
 	}
 
 	if rt.shouldThrottle(parameters.DestinationID, userID, throttledAtTime) {
-		throttledUserMap[userID] = struct{}{}
+		throttledUserMap[orderKey] = struct{}{}
 		rt.logger.Debugf(`[%v Router] :: Skipping processing of job:%d of user:%s as throttled limits exceeded`, rt.destName, job.JobID, userID)
-		return nil
+		return nil, ""
 	}
 
 	defer func() {
@@ -1337,22 +1392,22 @@ func (rt *HandleT) findWorker(job *jobsdb.JobT, throttledUserMap map[string]stru
 		}
 	}()
 
-	if !rt.guaranteeUserEventOrder {
-		// if guaranteeUserEventOrder is false, assigning worker randomly and returning here.
+	if !ordered {
+		// if events don't need to be ordered, assigning worker randomly and returning here.
 		toSendWorker = rt.workers[rand.Intn(rt.noOfWorkers)] // skipcq: GSC-G404
 		return
 	}
 
 	//#JobOrder (see other #JobOrder comment)
-	index := rt.getWorkerPartition(userID)
+	index := rt.getWorkerPartition(orderKey)
 	worker := rt.workers[index]
 	if worker.canBackoff(job) {
-		return nil
+		return nil, ""
 	}
-	orderKey := fmt.Sprintf(`%s:%s`, userID, parameters.DestinationID)
 	enter, previousFailedJobID := worker.barrier.Enter(orderKey, job.JobID)
 	if enter {
-		rt.logger.Debugf("EventOrder: job %d of user %s is allowed to be processed", job.JobID, userID)
+		rt.logger.Debugf("EventOrder: job %d of key %s is allowed to be processed", job.JobID, orderKey)
+		rt.orderedJobPicked(parameters.DestinationID)
 		toSendWorker = worker
 		return
 	}
@@ -1360,8 +1415,8 @@ func (rt *HandleT) findWorker(job *jobsdb.JobT, throttledUserMap map[string]stru
 	if previousFailedJobID != nil {
 		previousFailedJobIDStr = strconv.FormatInt(*previousFailedJobID, 10)
 	}
-	rt.logger.Debugf("EventOrder: job %d of user %s is blocked (previousFailedJobID: %s)", job.JobID, userID, previousFailedJobIDStr)
-	return nil
+	rt.logger.Debugf("EventOrder: job %d of key %s is blocked (previousFailedJobID: %s)", job.JobID, orderKey, previousFailedJobIDStr)
+	return nil, ""
 	//#EndJobOrder
 }
 
@@ -1376,8 +1431,8 @@ func (worker *workerT) canBackoff(job *jobsdb.JobT) (shouldBackoff bool) {
 	return false
 }
 
-func (rt *HandleT) getWorkerPartition(userID string) int {
-	return misc.GetHash(userID) % rt.noOfWorkers
+func (rt *HandleT) getWorkerPartition(key string) int {
+	return misc.GetHash(key) % rt.noOfWorkers
 }
 
 func (rt *HandleT) shouldThrottle(destID, userID string, throttledAtTime time.Time) (canBeThrottled bool) {
@@ -1385,12 +1440,7 @@ func (rt *HandleT) shouldThrottle(destID, userID string, throttledAtTime time.Ti
 		return false
 	}
 
-	// No need of locks here, because this is used only by a single goroutine (generatorLoop)
-	limitReached := rt.throttler.CheckLimitReached(destID, userID, throttledAtTime)
-	if !limitReached {
-		rt.throttler.Inc(destID, userID, throttledAtTime)
-	}
-	return limitReached
+	return rt.throttler.CheckLimitReachedAndInc(destID, userID, throttledAtTime)
 }
 
 // ResetSleep  this makes the workers reset their sleep
@@ -1410,6 +1460,7 @@ func (rt *HandleT) commitStatusList(responseList *[]jobResponseT) {
 	var completedJobsList []*jobsdb.JobT
 	var statusList []*jobsdb.JobStatusT
 	var routerAbortedJobs []*jobsdb.JobT
+	deadLetters := make(map[string][]deadletter.Letter) // dead-letter target -> dead letters
 	for _, resp := range *responseList {
 		var parameters JobParametersT
 		err := json.Unmarshal(resp.JobT.Parameters, &parameters)
@@ -1444,7 +1495,7 @@ func (rt *HandleT) commitStatusList(responseList *[]jobResponseT) {
 
 		switch resp.status.JobState {
 		case jobsdb.Failed.State:
-			if resp.status.ErrorCode != strconv.Itoa(types.RouterTimedOutStatusCode) && resp.status.ErrorCode != strconv.Itoa(types.RouterUnMarshalErrorCode) {
+			if resp.status.ErrorCode != strconv.Itoa(types.RouterTimedOutStatusCode) && resp.status.ErrorCode != strconv.Itoa(types.RouterCircuitOpenStatusCode) && resp.status.ErrorCode != strconv.Itoa(types.RouterUnMarshalErrorCode) {
 				rt.MultitenantI.CalculateSuccessFailureCounts(workspaceID, rt.destName, false, false)
 				if resp.status.AttemptNum == 1 {
 					sd.Count++
@@ -1460,6 +1511,9 @@ func (rt *HandleT) commitStatusList(responseList *[]jobResponseT) {
 			sd.Count++
 			rt.MultitenantI.CalculateSuccessFailureCounts(workspaceID, rt.destName, false, true)
 			routerAbortedJobs = append(routerAbortedJobs, resp.JobT)
+			if deadLetter, target, ok := rt.newDeadLetter(resp.JobT, resp.status, parameters); ok {
+				deadLetters[target] = append(deadLetters[target], deadLetter)
+			}
 			PrepareJobRunIDAbortedEventsMap(resp.JobT.Parameters, jobRunIDAbortedEventsMap)
 			completedJobsList = append(completedJobsList, resp.JobT)
 		}
@@ -1547,6 +1601,12 @@ func (rt *HandleT) commitStatusList(responseList *[]jobResponseT) {
 				if len(jobRunIDAbortedEventsMap) > 0 {
 					GetFailedEventsManager().SaveFailedRecordIDs(jobRunIDAbortedEventsMap, tx.SqlTx())
 				}
+				// Queue the aborted jobs for forwarding to their dead-letter targets
+				for target, letters := range deadLetters {
+					if err := rt.deadLetterForwarder.Enqueue(ctx, tx.SqlTx(), target, letters); err != nil {
+						return fmt.Errorf("enqueuing dead letters for %s: %w", target, err)
+					}
+				}
 				rt.Reporting.Report(reportMetrics, tx.SqlTx())
 				return nil
 			})
@@ -1561,15 +1621,16 @@ func (rt *HandleT) commitStatusList(responseList *[]jobResponseT) {
 		//#JobOrder (see other #JobOrder comment)
 		for _, resp := range *responseList {
 			status := resp.status.JobState
-			userID := resp.userID
 			worker := resp.worker
-			if status != jobsdb.Failed.State {
-				orderKey := fmt.Sprintf(`%s:%s`, userID, gjson.GetBytes(resp.JobT.Parameters, "destination_id").String())
+			if orderKey := resp.orderKey; orderKey != "" && status != jobsdb.Failed.State {
 				worker.rt.logger.Debugf("EventOrder: [%d] job %d for key %s %s", worker.workerID, resp.status.JobID, orderKey, status)
 				if err := worker.barrier.StateChanged(orderKey, resp.status.JobID, status); err != nil {
 					panic(err)
 				}
 			}
+			if resp.orderKey != "" {
+				rt.orderedJobUpdated(resp.orderKey)
+			}
 		}
 		// End #JobOrder
 	}
@@ -1725,6 +1786,7 @@ func (rt *HandleT) collectMetrics(ctx context.Context) {
 func (rt *HandleT) generatorLoop(ctx context.Context) {
 	rt.logger.Infof("Generator started for %s and destinationID %s", rt.destName, rt.destinationId)
 
+	rt.newJobs = rt.subscribeToNewJobs(ctx)
 	timeout := time.After(10 * time.Millisecond)
 	for {
 		select {
@@ -1732,22 +1794,44 @@ func (rt *HandleT) generatorLoop(ctx context.Context) {
 			rt.logger.Infof("Generator exiting for router %s", rt.destName)
 			return
 		case <-timeout:
+		case _, ok := <-rt.newJobs:
+			if !ok { // subscription closed
+				rt.logger.Infof("Generator exiting for router %s", rt.destName)
+				return
+			}
+		}
 
-			start := time.Now()
-			processCount := rt.readAndProcess()
-			stats.Default.NewTaggedStat("router_generator_loop", stats.TimerType, stats.Tags{"destType": rt.destName}).Since(start)
-			stats.Default.NewTaggedStat("router_generator_events", stats.CountType, stats.Tags{"destType": rt.destName}).Count(processCount)
+		start := time.Now()
+		processCount := rt.readAndProcess()
+		stats.Default.NewTaggedStat("router_generator_loop", stats.TimerType, stats.Tags{"destType": rt.destName}).Since(start)
+		stats.Default.NewTaggedStat("router_generator_events", stats.CountType, stats.Tags{"destType": rt.destName}).Count(processCount)
 
-			timeElapsed := time.Since(start)
-			nextTimeout := time.Second - timeElapsed
-			if nextTimeout < fixedLoopSleep {
-				nextTimeout = fixedLoopSleep
-			}
-			timeout = time.After(nextTimeout)
+		timeElapsed := time.Since(start)
+		nextTimeout := time.Second - timeElapsed
+		if nextTimeout < fixedLoopSleep {
+			nextTimeout = fixedLoopSleep
 		}
+		timeout = time.After(nextTimeout)
 	}
 }
 
+// subscribeToNewJobs subscribes to new jobs stored for the router's destination, if jobsDB supports it.
+// The generator then reads new jobs as soon as they are stored, instead of sleeping for readSleep when there are no jobs.
+func (rt *HandleT) subscribeToNewJobs(ctx context.Context) <-chan struct{} {
+	subscriber, ok := rt.jobsDB.(jobsdb.Subscriber)
+	if !ok {
+		return nil
+	}
+	newJobs, err := subscriber.Subscribe(ctx, rt.getQueryParams(0))
+	if err != nil {
+		if !errors.Is(err, jobsdb.ErrNotificationsDisabled) {
+			rt.logger.Warnf("Failed to subscribe to new jobs for %s, polling instead: %v", rt.destName, err)
+		}
+		return nil
+	}
+	return newJobs
+}
+
 func (rt *HandleT) getQueryParams(pickUpCount int) jobsdb.GetQueryParamsT {
 	if rt.destinationId != rt.destName {
 		return jobsdb.GetQueryParamsT{
@@ -1757,16 +1841,42 @@ func (rt *HandleT) getQueryParams(pickUpCount int) jobsdb.GetQueryParamsT {
 				Value:    rt.destinationId,
 				Optional: false,
 			}},
+			ExcludedParameterFilters:      rt.excludedDestinationFilters(),
 			IgnoreCustomValFiltersInQuery: true,
 			PayloadSizeLimit:              rt.payloadLimit,
 			JobsLimit:                     pickUpCount,
 		}
 	}
 	return jobsdb.GetQueryParamsT{
-		CustomValFilters: []string{rt.destName},
-		PayloadSizeLimit: rt.payloadLimit,
-		JobsLimit:        pickUpCount,
+		CustomValFilters:         []string{rt.destName},
+		ExcludedParameterFilters: rt.excludedDestinationFilters(),
+		PayloadSizeLimit:         rt.payloadLimit,
+		JobsLimit:                pickUpCount,
+	}
+}
+
+// excludedDestinationFilters returns parameter filters matching the jobs of paused destinations and of destinations outside of their delivery windows,
+// so that they are left out of the query instead of using up the pickup limits
+func (rt *HandleT) excludedDestinationFilters() []jobsdb.ParameterFilterT {
+	rt.configSubscriberLock.RLock()
+	defer rt.configSubscriberLock.RUnlock()
+	excluded := make(map[string]struct{})
+	for _, destinationID := range rt.destinationControls.pausedDestinationIDs() {
+		if _, ok := rt.destinationsMap[destinationID]; ok {
+			excluded[destinationID] = struct{}{}
+		}
+	}
+	now := time.Now()
+	for destinationID := range rt.destinationsMap {
+		if !rt.deliverySchedules.Get(destinationID).Allows(now) {
+			excluded[destinationID] = struct{}{}
+		}
 	}
+	var filters []jobsdb.ParameterFilterT
+	for destinationID := range excluded {
+		filters = append(filters, jobsdb.ParameterFilterT{Name: "destination_id", Value: destinationID})
+	}
+	return filters
 }
 
 func (rt *HandleT) readAndProcess() int {
@@ -1775,6 +1885,7 @@ func (rt *HandleT) readAndProcess() int {
 		for idx := range rt.workers {
 			rt.workers[idx].barrier.Sync()
 		}
+		rt.applyEventOrderSettings()
 	}
 
 	timeOut := rt.routerTimeout
@@ -1804,7 +1915,9 @@ func (rt *HandleT) readAndProcess() int {
 
 	if !iterator.HasNext() {
 		rt.logger.Debugf("RT: DB Read Complete. No RT Jobs to process for destination: %s", rt.destName)
-		time.Sleep(readSleep)
+		if rt.newJobs == nil {
+			time.Sleep(readSleep)
+		}
 		return 0
 	} else {
 		iteratorStats := iterator.Stats()
@@ -1815,8 +1928,9 @@ func (rt *HandleT) readAndProcess() int {
 
 	// List of jobs which can be processed mapped per channel
 	type workerJobT struct {
-		worker *workerT
-		job    *jobsdb.JobT
+		worker   *workerT
+		job      *jobsdb.JobT
+		orderKey string
 	}
 
 	var statusList []*jobsdb.JobStatusT
@@ -1827,7 +1941,7 @@ func (rt *HandleT) readAndProcess() int {
 	for iterator.HasNext() {
 
 		job := iterator.Next()
-		w := rt.findWorker(job, throttledUserMap, throttledAtTime)
+		w, orderKey := rt.findWorker(job, throttledUserMap, throttledAtTime)
 		if w != nil {
 			status := jobsdb.JobStatusT{
 				JobID:         job.JobID,
@@ -1841,7 +1955,7 @@ func (rt *HandleT) readAndProcess() int {
 				WorkspaceId:   job.WorkspaceId,
 			}
 			statusList = append(statusList, &status)
-			toProcess = append(toProcess, workerJobT{worker: w, job: job})
+			toProcess = append(toProcess, workerJobT{worker: w, job: job, orderKey: orderKey})
 		} else {
 			iterator.Discard(job)
 		}
@@ -1867,7 +1981,7 @@ func (rt *HandleT) readAndProcess() int {
 	workerAssignedTime := time.Now()
 	// Send the jobs to the jobQ
 	for _, wrkJob := range toProcess {
-		wrkJob.worker.channel <- workerMessageT{job: wrkJob.job, throttledAtTime: throttledAtTime, workerAssignedTime: workerAssignedTime}
+		wrkJob.worker.channel <- workerMessageT{job: wrkJob.job, orderKey: wrkJob.orderKey, throttledAtTime: throttledAtTime, workerAssignedTime: workerAssignedTime}
 	}
 
 	return len(toProcess)
@@ -2014,6 +2128,8 @@ func (rt *HandleT) Setup(backendConfig backendconfig.BackendConfig, jobsDB jobsd
 	var t throttler.HandleT
 	t.SetUp(rt.destName)
 	rt.throttler = &t
+	rt.circuitBreakers = newCircuitBreakers(rt.destName)
+	rt.deliverySchedules = deliveryschedule.NewSchedules()
 
 	rt.isBackendConfigInitialized = false
 	rt.backendConfigInitialized = make(chan bool)
@@ -2119,6 +2235,16 @@ func (rt *HandleT) backendConfigSubscriber() {
 				}
 			}
 		}
+		destinations := make([]backendconfig.DestinationT, 0, len(rt.destinationsMap))
+		for _, destination := range rt.destinationsMap {
+			destinations = append(destinations, destination.Destination)
+		}
+		rt.throttler.SetDestinationLimits(destinations)
+		rt.deliverySchedules.Set(destinations)
+		rt.setEventOrderSettings(destinations)
+		if network, ok := rt.netHandle.(*NetHandleT); ok {
+			network.setDestinations(destinations)
+		}
 		if !rt.isBackendConfigInitialized {
 			rt.isBackendConfigInitialized = true
 			rt.backendConfigInitialized <- true
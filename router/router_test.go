@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 
 	"github.com/gofrs/uuid"
@@ -28,6 +31,7 @@ import (
 	mocksRouter "github.com/rudderlabs/rudder-server/mocks/router"
 	mocksTransformer "github.com/rudderlabs/rudder-server/mocks/router/transformer"
 	mocksMultitenant "github.com/rudderlabs/rudder-server/mocks/services/multitenant"
+	"github.com/rudderlabs/rudder-server/router/deliveryschedule"
 	"github.com/rudderlabs/rudder-server/router/types"
 	routerUtils "github.com/rudderlabs/rudder-server/router/utils"
 	"github.com/rudderlabs/rudder-server/services/rsources"
@@ -254,7 +258,7 @@ var _ = Describe("Router", func() {
 					assertJobStatus(unprocessedJobsList[0], statuses[1], jobsdb.Executing.State, "", `{}`, 0)
 				}).Return(nil).After(callGetAllJobs)
 
-			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any()).Times(2).Return(
+			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(
 				&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
 			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 
@@ -328,7 +332,7 @@ var _ = Describe("Router", func() {
 					assertJobStatus(unprocessedJobsList[0], statuses[0], jobsdb.Executing.State, "", `{}`, 0)
 				}).After(callGetAllJobs)
 
-			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any()).Times(1).Return(&routerUtils.SendPostResponse{StatusCode: 400, ResponseBody: []byte("")})
+			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(&routerUtils.SendPostResponse{StatusCode: 400, ResponseBody: []byte("")})
 			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 
 			c.mockProcErrorsDB.EXPECT().Store(gomock.Any(), gomock.Any()).Times(1).
@@ -448,6 +452,162 @@ var _ = Describe("Router", func() {
 			Eventually(func() bool { return routerAborted && procErrorStored }, 5*time.Second, 100*time.Millisecond).Should(Equal(true))
 		})
 
+		It("doesn't pick up jobs of paused destinations", func() {
+			mockMultitenantHandle := mocksMultitenant.NewMockMultiTenantI(c.mockCtrl)
+			router := &HandleT{
+				Reporting:    &reporting.NOOP{},
+				MultitenantI: mockMultitenantHandle,
+				destinationControls: &DestinationControls{controls: map[string]destinationControl{
+					gaDestinationID: {State: destinationPaused},
+				}},
+			}
+			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
+
+			router.Setup(c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, gaDestinationConfig, transientsource.NewEmptyService(), rsources.NewNoOpService())
+			router.jobIteratorMaxQueries = 1
+
+			parameters := fmt.Sprintf(`{"source_id": "1fMCVYZboDlYlauh4GFsEo2JU77", "destination_id": "%s", "message_id": "2f548e6d-60f6-44af-a1f4-62b3272445c3", "received_at": "2021-06-28T10:04:48.527+05:30", "transform_at": "processor"}`, gaDestinationID)
+			unprocessedJobsList := []*jobsdb.JobT{
+				{
+					UUID:         uuid.Must(uuid.NewV4()),
+					UserID:       "u1",
+					JobID:        2010,
+					CreatedAt:    time.Date(2020, 0o4, 28, 13, 26, 0o0, 0o0, time.UTC),
+					ExpireAt:     time.Date(2020, 0o4, 28, 13, 26, 0o0, 0o0, time.UTC),
+					CustomVal:    customVal["GA"],
+					EventPayload: []byte(`{}`),
+					Parameters:   []byte(parameters),
+					WorkspaceId:  workspaceID,
+				},
+			}
+
+			workspaceCount := map[string]int{workspaceID: len(unprocessedJobsList)}
+			callGetRouterPickupJobs := mockMultitenantHandle.EXPECT().GetRouterPickupJobs(customVal["GA"], gomock.Any(), gomock.Any(), gomock.Any()).Return(workspaceCount).Times(1)
+			c.mockRouterJobsDB.EXPECT().GetAllJobs(gomock.Any(), workspaceCount, jobsdb.GetQueryParamsT{
+				CustomValFilters:         []string{customVal["GA"]},
+				ExcludedParameterFilters: []jobsdb.ParameterFilterT{{Name: "destination_id", Value: gaDestinationID}},
+				PayloadSizeLimit:         router.payloadLimit,
+				JobsLimit:                workspaceCount[workspaceID],
+			}, 10, nil).Times(1).Return(&jobsdb.GetAllJobsResult{Jobs: unprocessedJobsList}, nil).After(callGetRouterPickupJobs)
+			c.mockRouterJobsDB.EXPECT().UpdateJobStatus(gomock.Any(), gomock.Any(), []string{customVal["GA"]}, nil).Times(1).
+				Do(func(ctx context.Context, statuses []*jobsdb.JobStatusT, _, _ interface{}) {
+					Expect(statuses).To(BeEmpty())
+				}).Return(nil)
+
+			<-router.backendConfigInitialized
+			count := router.readAndProcess()
+			Expect(count).To(Equal(0))
+		})
+
+		It("doesn't pick up jobs of destinations outside of their delivery windows", func() {
+			mockMultitenantHandle := mocksMultitenant.NewMockMultiTenantI(c.mockCtrl)
+			router := &HandleT{
+				Reporting:    &reporting.NOOP{},
+				MultitenantI: mockMultitenantHandle,
+			}
+			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
+
+			router.Setup(c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, gaDestinationConfig, transientsource.NewEmptyService(), rsources.NewNoOpService())
+			router.jobIteratorMaxQueries = 1
+
+			parameters := fmt.Sprintf(`{"source_id": "1fMCVYZboDlYlauh4GFsEo2JU77", "destination_id": "%s", "message_id": "2f548e6d-60f6-44af-a1f4-62b3272445c3", "received_at": "2021-06-28T10:04:48.527+05:30", "transform_at": "processor"}`, gaDestinationID)
+			unprocessedJobsList := []*jobsdb.JobT{
+				{
+					UUID:         uuid.Must(uuid.NewV4()),
+					UserID:       "u1",
+					JobID:        2010,
+					CreatedAt:    time.Date(2020, 0o4, 28, 13, 26, 0o0, 0o0, time.UTC),
+					ExpireAt:     time.Date(2020, 0o4, 28, 13, 26, 0o0, 0o0, time.UTC),
+					CustomVal:    customVal["GA"],
+					EventPayload: []byte(`{}`),
+					Parameters:   []byte(parameters),
+					WorkspaceId:  workspaceID,
+				},
+			}
+
+			workspaceCount := map[string]int{workspaceID: len(unprocessedJobsList)}
+			callGetRouterPickupJobs := mockMultitenantHandle.EXPECT().GetRouterPickupJobs(customVal["GA"], gomock.Any(), gomock.Any(), gomock.Any()).Return(workspaceCount).Times(1)
+			c.mockRouterJobsDB.EXPECT().GetAllJobs(gomock.Any(), workspaceCount, jobsdb.GetQueryParamsT{
+				CustomValFilters:         []string{customVal["GA"]},
+				ExcludedParameterFilters: []jobsdb.ParameterFilterT{{Name: "destination_id", Value: gaDestinationID}},
+				PayloadSizeLimit:         router.payloadLimit,
+				JobsLimit:                workspaceCount[workspaceID],
+			}, 10, nil).Times(1).Return(&jobsdb.GetAllJobsResult{Jobs: unprocessedJobsList}, nil).After(callGetRouterPickupJobs)
+			c.mockRouterJobsDB.EXPECT().UpdateJobStatus(gomock.Any(), gomock.Any(), []string{customVal["GA"]}, nil).Times(1).
+				Do(func(ctx context.Context, statuses []*jobsdb.JobStatusT, _, _ interface{}) {
+					Expect(statuses).To(BeEmpty())
+				}).Return(nil)
+
+			<-router.backendConfigInitialized
+			router.deliverySchedules.Set([]backendconfig.DestinationT{{
+				ID: gaDestinationID,
+				Config: map[string]interface{}{deliveryschedule.DestinationConfigKey: map[string]interface{}{
+					"deny": []interface{}{"* 00:00-24:00"},
+				}},
+			}})
+			count := router.readAndProcess()
+			Expect(count).To(Equal(0))
+		})
+
+		It("aborts jobs of drained destinations", func() {
+			mockMultitenantHandle := mocksMultitenant.NewMockMultiTenantI(c.mockCtrl)
+			router := &HandleT{
+				Reporting:    &reporting.NOOP{},
+				MultitenantI: mockMultitenantHandle,
+				destinationControls: &DestinationControls{controls: map[string]destinationControl{
+					gaDestinationID: {State: destinationDraining, Reason: "destination decommissioned"},
+				}},
+			}
+			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
+
+			router.Setup(c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, gaDestinationConfig, transientsource.NewEmptyService(), rsources.NewNoOpService())
+			mockNetHandle := mocksRouter.NewMockNetHandleI(c.mockCtrl)
+			router.netHandle = mockNetHandle
+
+			parameters := fmt.Sprintf(`{"source_id": "1fMCVYZboDlYlauh4GFsEo2JU77", "destination_id": "%s", "message_id": "2f548e6d-60f6-44af-a1f4-62b3272445c3", "received_at": "2021-06-28T10:04:48.527+05:30", "transform_at": "processor"}`, gaDestinationID)
+			unprocessedJobsList := []*jobsdb.JobT{
+				{
+					UUID:         uuid.Must(uuid.NewV4()),
+					UserID:       "u1",
+					JobID:        2010,
+					CreatedAt:    time.Date(2020, 0o4, 28, 13, 26, 0o0, 0o0, time.UTC),
+					ExpireAt:     time.Date(2020, 0o4, 28, 13, 26, 0o0, 0o0, time.UTC),
+					CustomVal:    customVal["GA"],
+					EventPayload: []byte(`{}`),
+					Parameters:   []byte(parameters),
+					WorkspaceId:  workspaceID,
+				},
+			}
+
+			workspaceCount := map[string]int{workspaceID: len(unprocessedJobsList)}
+			callGetRouterPickupJobs := mockMultitenantHandle.EXPECT().GetRouterPickupJobs(customVal["GA"], gomock.Any(), gomock.Any(), gomock.Any()).Return(workspaceCount).Times(1)
+			c.mockRouterJobsDB.EXPECT().GetAllJobs(gomock.Any(), workspaceCount, jobsdb.GetQueryParamsT{
+				CustomValFilters: []string{customVal["GA"]}, PayloadSizeLimit: router.payloadLimit, JobsLimit: workspaceCount[workspaceID],
+			}, 10, nil).Times(1).Return(&jobsdb.GetAllJobsResult{Jobs: unprocessedJobsList}, nil).After(callGetRouterPickupJobs)
+
+			var routerAborted bool
+			mockMultitenantHandle.EXPECT().CalculateSuccessFailureCounts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+			c.mockRouterJobsDB.EXPECT().UpdateJobStatus(gomock.Any(), gomock.Any(), []string{customVal["GA"]}, nil).Times(1)
+			c.mockProcErrorsDB.EXPECT().Store(gomock.Any(), gomock.Any()).Times(1)
+			c.mockRouterJobsDB.EXPECT().WithUpdateSafeTx(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, f func(tx jobsdb.UpdateSafeTx) error) {
+				_ = f(jobsdb.EmptyUpdateSafeTx())
+			}).Return(nil).Times(1)
+			c.mockRouterJobsDB.EXPECT().UpdateJobStatusInTx(gomock.Any(), gomock.Any(), gomock.Any(), []string{customVal["GA"]}, nil).Times(1).
+				Do(func(ctx context.Context, tx jobsdb.UpdateSafeTx, drainList []*jobsdb.JobStatusT, _, _ interface{}) {
+					Expect(drainList).To(HaveLen(1))
+					assertJobStatus(unprocessedJobsList[0], drainList[0], jobsdb.Aborted.State, "410", `{"reason": "destination decommissioned"}`, 0)
+					routerAborted = true
+				})
+
+			<-router.backendConfigInitialized
+			count := router.readAndProcess()
+			Expect(count).To(Equal(len(unprocessedJobsList)))
+			Eventually(func() bool { return routerAborted }, 5*time.Second, 100*time.Millisecond).Should(Equal(true))
+		})
+
 		It("can fail jobs if time is more than router timeout", func() {
 			mockMultitenantHandle := mocksMultitenant.NewMockMultiTenantI(c.mockCtrl)
 			mockNetHandle := mocksRouter.NewMockNetHandleI(c.mockCtrl)
@@ -559,7 +719,7 @@ var _ = Describe("Router", func() {
 					assertJobStatus(unprocessedJobsList[2], statuses[3], jobsdb.Executing.State, "", `{}`, 0)
 					assertJobStatus(unprocessedJobsList[3], statuses[4], jobsdb.Executing.State, "", `{}`, 0)
 				}).Return(nil).After(callAllJobs)
-			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any()).Times(0)
+			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 			done := make(chan struct{})
 			mockMultitenantHandle.EXPECT().CalculateSuccessFailureCounts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			c.mockRouterJobsDB.EXPECT().WithUpdateSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.UpdateSafeTx) error) {
@@ -574,6 +734,79 @@ var _ = Describe("Router", func() {
 			<-done
 		})
 
+		It("never aborts jobs held back by an open circuit breaker", func() {
+			mockMultitenantHandle := mocksMultitenant.NewMockMultiTenantI(c.mockCtrl)
+			mockNetHandle := mocksRouter.NewMockNetHandleI(c.mockCtrl)
+			router := &HandleT{
+				Reporting:    &reporting.NOOP{},
+				MultitenantI: mockMultitenantHandle,
+				netHandle:    mockNetHandle,
+			}
+			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
+			router.Setup(c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, gaDestinationConfig, transientsource.NewEmptyService(), rsources.NewNoOpService())
+			router.circuitBreakers.enabled = true
+			router.circuitBreakers.consecutiveFailures = 1
+			breakerDone, err := router.circuitBreakers.allow(gaDestinationID)
+			Expect(err).To(BeNil())
+			breakerDone(http.StatusInternalServerError)
+
+			gaPayload := `{"body": {"XML": {}, "FORM": {}, "JSON": {}}, "type": "REST", "files": {}, "method": "POST", "params": {"t": "event", "v": "1", "an": "RudderAndroidClient", "av": "1.0", "ds": "android-sdk", "ea": "Demo Track", "ec": "Demo Category", "el": "Demo Label", "ni": 0, "qt": 59268380964, "ul": "en-US", "cid": "anon_id", "tid": "UA-185645846-1", "uip": "[::1]", "aiid": "com.rudderlabs.android.sdk"}, "userId": "anon_id", "headers": {}, "version": "1", "endpoint": "https://www.google-analytics.com/collect"}`
+			parameters := fmt.Sprintf(`{"source_id": "1fMCVYZboDlYlauh4GFsEo2JU77", "destination_id": "%s", "message_id": "2f548e6d-60f6-44af-a1f4-62b3272445c3", "received_at": "2021-06-28T10:04:48.527+05:30", "transform_at": "processor"}`, gaDestinationID)
+			// the job has exhausted both its attempts and its retry time window
+			toRetryJobsList := []*jobsdb.JobT{
+				{
+					UUID:         uuid.Must(uuid.NewV4()),
+					UserID:       "u1",
+					JobID:        2009,
+					CreatedAt:    time.Date(2020, 0o4, 28, 13, 26, 0o0, 0o0, time.UTC),
+					ExpireAt:     time.Date(2020, 0o4, 28, 13, 26, 0o0, 0o0, time.UTC),
+					CustomVal:    customVal["GA"],
+					EventPayload: []byte(gaPayload),
+					LastJobStatus: jobsdb.JobStatusT{
+						AttemptNum:    router.maxFailedCountForJob,
+						ErrorResponse: []byte(`{"firstAttemptedAt": "2021-06-28T15:57:30.742+05:30"}`),
+					},
+					Parameters:  []byte(parameters),
+					WorkspaceId: workspaceID,
+				},
+			}
+
+			workspaceCount := map[string]int{}
+			workspaceCount[workspaceID] = len(toRetryJobsList)
+			workspaceCountOut := workspaceCount
+			callGetRouterPickupJobs := mockMultitenantHandle.EXPECT().GetRouterPickupJobs(customVal["GA"], gomock.Any(), gomock.Any(), gomock.Any()).Return(workspaceCountOut).Times(1)
+
+			payloadLimit := router.payloadLimit
+			callGetAllJobs := c.mockRouterJobsDB.EXPECT().GetAllJobs(gomock.Any(), workspaceCount,
+				jobsdb.GetQueryParamsT{CustomValFilters: []string{customVal["GA"]}, PayloadSizeLimit: payloadLimit, JobsLimit: workspaceCount[workspaceID]}, 10, nil).Times(1).Return(&jobsdb.GetAllJobsResult{Jobs: toRetryJobsList}, nil).After(callGetRouterPickupJobs)
+
+			c.mockRouterJobsDB.EXPECT().UpdateJobStatus(gomock.Any(), gomock.Any(), []string{customVal["GA"]}, nil).Times(1).
+				Do(func(ctx context.Context, statuses []*jobsdb.JobStatusT, _, _ interface{}) {
+					assertJobStatus(toRetryJobsList[0], statuses[0], jobsdb.Executing.State, "", `{}`, router.maxFailedCountForJob)
+				}).Return(nil).After(callGetAllJobs)
+
+			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMultitenantHandle.EXPECT().CalculateSuccessFailureCounts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			done := make(chan struct{})
+			c.mockRouterJobsDB.EXPECT().WithUpdateSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.UpdateSafeTx) error) {
+				_ = f(jobsdb.EmptyUpdateSafeTx())
+				close(done)
+			}).Return(nil)
+			c.mockRouterJobsDB.EXPECT().UpdateJobStatusInTx(gomock.Any(), gomock.Any(), gomock.Any(), []string{customVal["GA"]}, nil).Times(1).
+				Do(func(ctx context.Context, _ interface{}, statuses []*jobsdb.JobStatusT, _, _ interface{}) {
+					Expect(statuses[0].JobID).To(Equal(toRetryJobsList[0].JobID))
+					Expect(statuses[0].JobState).To(Equal(jobsdb.Failed.State))
+					Expect(statuses[0].ErrorCode).To(Equal(strconv.Itoa(types.RouterCircuitOpenStatusCode)))
+					Expect(statuses[0].AttemptNum).To(Equal(router.maxFailedCountForJob))
+				})
+
+			<-router.backendConfigInitialized
+			count := router.readAndProcess()
+			Expect(count).To(Equal(1))
+			<-done
+		})
+
 		It("fails jobs if destination is not found in config", func() {
 			mockMultitenantHandle := mocksMultitenant.NewMockMultiTenantI(c.mockCtrl)
 			mockNetHandle := mocksRouter.NewMockNetHandleI(c.mockCtrl)
@@ -778,7 +1011,7 @@ var _ = Describe("Router", func() {
 						assertRouterJobs(&transformMessage.Data[0], toRetryJobsList[0])
 						assertRouterJobs(&transformMessage.Data[1], unprocessedJobsList[0])
 						assertRouterJobs(&transformMessage.Data[2], unprocessedJobsList[1])
-						return []types.DestinationJobT{
+						return echoOrderKeys(transformMessage, []types.DestinationJobT{
 							{
 								Message: []byte(`{"message": "some transformed message"}`),
 								JobMetadataArray: []types.JobMetadataT{
@@ -802,10 +1035,10 @@ var _ = Describe("Router", func() {
 								Error:      `{"firstAttemptedAt": "2021-06-28T15:57:30.742+05:30"}`,
 								StatusCode: 200,
 							},
-						}
+						})
 					})
 
-			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any()).Times(1).Return(&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
+			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
 			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			done := make(chan struct{})
 			mockMultitenantHandle.EXPECT().CalculateSuccessFailureCounts(gomock.Any(), gomock.Any(), true, false).AnyTimes()
@@ -917,7 +1150,7 @@ var _ = Describe("Router", func() {
 					assertRouterJobs(&transformMessage.Data[1], unprocessedJobsList[0])
 					assertRouterJobs(&transformMessage.Data[2], unprocessedJobsList[1])
 
-					return []types.DestinationJobT{
+					return echoOrderKeys(transformMessage, []types.DestinationJobT{
 						{
 							Message: []byte(`{"message": "some transformed message"}`),
 							JobMetadataArray: []types.JobMetadataT{
@@ -952,10 +1185,10 @@ var _ = Describe("Router", func() {
 							Error:      ``,
 							StatusCode: 200,
 						},
-					}
+					})
 				})
 
-			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any()).Times(0).Return(&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
+			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(0).Return(&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
 			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			done := make(chan struct{})
 			mockMultitenantHandle.EXPECT().CalculateSuccessFailureCounts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
@@ -1118,7 +1351,7 @@ var _ = Describe("Router", func() {
 					assertRouterJobs(&transformMessage.Data[3], unprocessedJobsList[2])
 					assertRouterJobs(&transformMessage.Data[4], unprocessedJobsList[3])
 
-					return []types.DestinationJobT{
+					return echoOrderKeys(transformMessage, []types.DestinationJobT{
 						{
 							Message: []byte(`{"message": "some transformed message"}`),
 							JobMetadataArray: []types.JobMetadataT{
@@ -1185,10 +1418,10 @@ var _ = Describe("Router", func() {
 							Error:      `{"firstAttemptedAt": "2021-06-28T15:57:30.742+05:30"}`,
 							StatusCode: 200,
 						},
-					}
+					})
 				})
 
-			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any()).Times(2).Return(&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
+			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
 			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			done := make(chan struct{})
 			mockMultitenantHandle.EXPECT().CalculateSuccessFailureCounts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
@@ -1303,7 +1536,7 @@ var _ = Describe("Router", func() {
 					assertRouterJobs(&transformMessage.Data[1], unprocessedJobsList[0])
 					assertRouterJobs(&transformMessage.Data[2], unprocessedJobsList[1])
 
-					return []types.DestinationJobT{
+					return echoOrderKeys(transformMessage, []types.DestinationJobT{
 						{
 							Message: []byte(`{"message": "some transformed message"}`),
 							JobMetadataArray: []types.JobMetadataT{
@@ -1344,9 +1577,9 @@ var _ = Describe("Router", func() {
 							Error:      `{"firstAttemptedAt": "2021-06-28T15:57:30.742+05:30"}`,
 							StatusCode: 200,
 						},
-					}
+					})
 				})
-			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any()).Times(0).Return(&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
+			mockNetHandle.EXPECT().SendPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(0).Return(&routerUtils.SendPostResponse{StatusCode: 200, ResponseBody: []byte("")})
 			mockMultitenantHandle.EXPECT().UpdateWorkspaceLatencyMap(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			done := make(chan struct{})
 			mockMultitenantHandle.EXPECT().CalculateSuccessFailureCounts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
@@ -1365,9 +1598,24 @@ var _ = Describe("Router", func() {
 	})
 })
 
+// echoOrderKeys sets the order keys of the jobs of destinationJobs from the transformMessage jobs, like the transformer echoing their metadata
+func echoOrderKeys(transformMessage *types.TransformMessageT, destinationJobs []types.DestinationJobT) []types.DestinationJobT {
+	orderKeys := make(map[int64]string)
+	for i := range transformMessage.Data {
+		orderKeys[transformMessage.Data[i].JobMetadata.JobID] = transformMessage.Data[i].JobMetadata.OrderKey
+	}
+	for i := range destinationJobs {
+		for j := range destinationJobs[i].JobMetadataArray {
+			destinationJobs[i].JobMetadataArray[j].OrderKey = orderKeys[destinationJobs[i].JobMetadataArray[j].JobID]
+		}
+	}
+	return destinationJobs
+}
+
 func assertRouterJobs(routerJob *types.RouterJobT, job *jobsdb.JobT) {
 	Expect(routerJob.JobMetadata.JobID).To(Equal(job.JobID))
 	Expect(routerJob.JobMetadata.UserID).To(Equal(job.UserID))
+	Expect(routerJob.JobMetadata.OrderKey).To(Equal("u:" + job.UserID + ":" + gjson.GetBytes(job.Parameters, "destination_id").String()))
 }
 
 func assertJobStatus(job *jobsdb.JobT, status *jobsdb.JobStatusT, expectedState, errorCode, errorResponse string, attemptNum int) {
@@ -0,0 +1,138 @@
+package throttler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/services/stats"
+)
+
+// adaptiveLimiter adjusts the destination level limit of each destination id according to the responses of the destination, AIMD-style:
+// the limit is multiplied by decreaseFactor whenever the destination responds with 429 or 503 and increased by increaseFactor of the
+// configured limit once responses recover. Changes are applied at most once per time window, the configured limit being the upper bound
+type adaptiveLimiter struct {
+	enabled        bool
+	destType       string
+	minLimit       int
+	decreaseFactor float64
+	increaseFactor float64
+	maxRetryAfter  time.Duration
+
+	limitsMu sync.Mutex
+	limits   map[string]*adaptiveLimit
+}
+
+// adaptiveLimit is the effective limit of a destination id
+type adaptiveLimit struct {
+	limit        float64
+	maxLimit     float64 // the configured limit of the destination id
+	lastDecrease time.Time
+	lastIncrease time.Time
+	blockedUntil time.Time // set from the Retry-After header of the destination responses
+	gauge        stats.Measurement
+}
+
+func (a *adaptiveLimiter) setLimits(destType string) {
+	a.destType = destType
+	a.limits = make(map[string]*adaptiveLimit)
+
+	config.RegisterBoolConfigVariable(false, &a.enabled, false, fmt.Sprintf(`Router.throttler.%s.adaptive.enabled`, destType), "Router.throttler.adaptive.enabled")
+	config.RegisterIntConfigVariable(1, &a.minLimit, false, 1, fmt.Sprintf(`Router.throttler.%s.adaptive.minLimit`, destType), "Router.throttler.adaptive.minLimit")
+	config.RegisterFloat64ConfigVariable(0.5, &a.decreaseFactor, false, fmt.Sprintf(`Router.throttler.%s.adaptive.decreaseFactor`, destType), "Router.throttler.adaptive.decreaseFactor")
+	config.RegisterFloat64ConfigVariable(0.1, &a.increaseFactor, false, fmt.Sprintf(`Router.throttler.%s.adaptive.increaseFactor`, destType), "Router.throttler.adaptive.increaseFactor")
+	config.RegisterDurationConfigVariable(300, &a.maxRetryAfter, false, time.Second, fmt.Sprintf(`Router.throttler.%s.adaptive.maxRetryAfter`, destType), "Router.throttler.adaptive.maxRetryAfter")
+
+	// adaptive throttling adjusts the destination level limit, thus it only applies to destinations with one
+	if a.enabled {
+		pkgLogger.Infof(`[[ %s-router-throttler: Enabled adaptive throttler with minLimit:%d, decreaseFactor:%v, increaseFactor:%v]]`, destType, a.minLimit, a.decreaseFactor, a.increaseFactor)
+	}
+}
+
+// get returns the effective limit of a destination id, creating it with the configured limit of destLimiter if it doesn't exist.
+// The effective limit is capped whenever the configured limit changes. Callers must hold limitsMu
+func (a *adaptiveLimiter) get(destID string, destLimiter *Limiter) *adaptiveLimit {
+	maxLimit := float64(destLimiter.eventLimit)
+	l, ok := a.limits[destID]
+	if ok && l.maxLimit != maxLimit {
+		l.limit, l.maxLimit = math.Min(l.limit, maxLimit), maxLimit
+		l.gauge.Gauge(int(l.limit))
+	}
+	if !ok {
+		l = &adaptiveLimit{
+			limit:    maxLimit,
+			maxLimit: maxLimit,
+			gauge: stats.Default.NewTaggedStat("router_throttler_adaptive_limit", stats.GaugeType, stats.Tags{
+				"destType": a.destType,
+				"destId":   destID,
+			}),
+		}
+		l.gauge.Gauge(int(l.limit))
+		a.limits[destID] = l
+	}
+	return l
+}
+
+// limit returns the effective limit of a destination id and whether the destination asked not to be sent any requests till later
+func (a *adaptiveLimiter) limit(destID string, destLimiter *Limiter, currentTime time.Time) (limit int64, blocked bool) {
+	a.limitsMu.Lock()
+	defer a.limitsMu.Unlock()
+	l := a.get(destID, destLimiter)
+	return int64(l.limit), currentTime.Before(l.blockedUntil)
+}
+
+// responseReceived adjusts the effective limit of a destination id according to the status code and Retry-After header of a destination response
+func (a *adaptiveLimiter) responseReceived(destID string, destLimiter *Limiter, statusCode int, retryAfter string, currentTime time.Time) {
+	a.limitsMu.Lock()
+	defer a.limitsMu.Unlock()
+	l := a.get(destID, destLimiter)
+
+	switch {
+	case statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable:
+		if d := parseRetryAfter(retryAfter, currentTime); d > 0 {
+			if d > a.maxRetryAfter {
+				d = a.maxRetryAfter
+			}
+			if blockedUntil := currentTime.Add(d); blockedUntil.After(l.blockedUntil) {
+				l.blockedUntil = blockedUntil
+			}
+		}
+		// all the responses of a window are the outcome of the same limit, thus it is decreased once per window
+		if currentTime.Sub(l.lastDecrease) < destLimiter.timeWindow {
+			return
+		}
+		l.limit = math.Max(l.limit*a.decreaseFactor, float64(a.minLimit))
+		l.lastDecrease = currentTime
+		pkgLogger.Infof(`[[ %s-router-throttler: Decreased limit of destination %s to %d after a %d response]]`, a.destType, destID, int64(l.limit), statusCode)
+	case statusCode >= 200 && statusCode < 300:
+		if l.limit >= l.maxLimit || currentTime.Sub(l.lastDecrease) < destLimiter.timeWindow || currentTime.Sub(l.lastIncrease) < destLimiter.timeWindow {
+			return
+		}
+		l.limit = math.Min(l.limit+a.increaseFactor*l.maxLimit, l.maxLimit)
+		l.lastIncrease = currentTime
+	default:
+		return
+	}
+	l.gauge.Gauge(int(l.limit))
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or an http date.
+// It returns zero if the value is empty or invalid
+func parseRetryAfter(value string, currentTime time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date.Sub(currentTime)
+	}
+	return 0
+}
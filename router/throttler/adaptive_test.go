@@ -0,0 +1,92 @@
+package throttler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+)
+
+func TestAdaptiveThrottler(t *testing.T) {
+	config.Reset()
+	defer config.Reset()
+	config.Set("Router.throttler.TEST.limit", 100)
+	config.Set("Router.throttler.TEST.timeWindow", "1s")
+	config.Set("Router.throttler.TEST.adaptive.enabled", true)
+	config.Set("Router.throttler.TEST.adaptive.minLimit", 10)
+
+	var throttler HandleT
+	throttler.SetUp("TEST")
+	require.True(t, throttler.adaptive.enabled)
+
+	now := time.Now()
+	limit := func() int64 {
+		l, _ := throttler.adaptive.limit("dest", throttler.destLimiter, now)
+		return l
+	}
+	require.EqualValues(t, 100, limit())
+
+	t.Run("decreases the limit once per window", func(t *testing.T) {
+		throttler.ResponseReceived("dest", http.StatusTooManyRequests, "", now)
+		throttler.ResponseReceived("dest", http.StatusServiceUnavailable, "", now)
+		require.EqualValues(t, 50, limit())
+
+		now = now.Add(time.Second)
+		throttler.ResponseReceived("dest", http.StatusTooManyRequests, "", now)
+		require.EqualValues(t, 25, limit())
+		require.EqualValues(t, 100, func() int64 { l, _ := throttler.adaptive.limit("other", throttler.destLimiter, now); return l }(), "limits are per destination id")
+
+		for i := 0; i < 5; i++ {
+			now = now.Add(time.Second)
+			throttler.ResponseReceived("dest", http.StatusTooManyRequests, "", now)
+		}
+		require.EqualValues(t, 10, limit(), "limit doesn't go below minLimit")
+	})
+
+	t.Run("increases the limit once responses recover", func(t *testing.T) {
+		throttler.ResponseReceived("dest", http.StatusOK, "", now)
+		require.EqualValues(t, 10, limit(), "limit isn't increased in the window it was decreased")
+
+		now = now.Add(time.Second)
+		throttler.ResponseReceived("dest", http.StatusOK, "", now)
+		throttler.ResponseReceived("dest", http.StatusOK, "", now)
+		require.EqualValues(t, 20, limit())
+
+		throttler.ResponseReceived("dest", http.StatusBadRequest, "", now.Add(time.Second))
+		require.EqualValues(t, 20, limit(), "other responses don't change the limit")
+
+		for i := 0; i < 20; i++ {
+			now = now.Add(time.Second)
+			throttler.ResponseReceived("dest", http.StatusOK, "", now)
+		}
+		require.EqualValues(t, 100, limit(), "limit doesn't go above the configured limit")
+	})
+
+	t.Run("blocks the destination till Retry-After", func(t *testing.T) {
+		throttler.ResponseReceived("dest", http.StatusTooManyRequests, "2", now)
+		require.True(t, throttler.CheckLimitReached("dest", "user", now.Add(time.Second)))
+		require.False(t, throttler.CheckLimitReached("dest", "user", now.Add(3*time.Second)))
+		require.False(t, throttler.CheckLimitReached("other", "user", now.Add(time.Second)))
+	})
+
+	t.Run("enforces the effective limit", func(t *testing.T) {
+		now = now.Add(10 * time.Second)
+		throttler.ResponseReceived("limited", http.StatusTooManyRequests, "", now)
+		for i := 0; i < 50; i++ {
+			require.False(t, throttler.CheckLimitReached("limited", "user", now))
+			throttler.Inc("limited", "user", now)
+		}
+		require.True(t, throttler.CheckLimitReached("limited", "user", now))
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC)
+	require.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	require.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	require.Zero(t, parseRetryAfter("", now))
+	require.Zero(t, parseRetryAfter("soon", now))
+}
@@ -0,0 +1,123 @@
+package throttler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/services/ratelimiter"
+)
+
+const (
+	// destinationConfigLimitKey and destinationConfigTimeWindowKey are the keys of the destination config holding the destination level limit of a destination id
+	destinationConfigLimitKey      = "throttlingLimit"
+	destinationConfigTimeWindowKey = "throttlingTimeWindow"
+)
+
+// SetDestinationLimits sets the destination level limits of each destination id, overriding the ones of the destination type.
+// Limits are read from Router.throttler.<DEST>.<destID>.limit and timeWindow, falling back to the throttlingLimit and
+// throttlingTimeWindow settings of the destination config. It is called whenever the backend config changes
+func (throttler *HandleT) SetDestinationLimits(destinations []backendconfig.DestinationT) {
+	limiters := make(map[string]*Limiter)
+	for i := range destinations {
+		destination := &destinations[i]
+		limit, timeWindow := throttler.destinationLimits(destination)
+		if limit <= 0 || timeWindow <= 0 {
+			continue
+		}
+
+		throttler.destinationLimitersMu.RLock()
+		current, ok := throttler.destinationLimiters[destination.ID]
+		throttler.destinationLimitersMu.RUnlock()
+		if ok && current.eventLimit == limit && current.timeWindow == timeWindow {
+			limiters[destination.ID] = current
+			continue
+		}
+
+		pkgLogger.Infof(`[[ %s-router-throttler: Enabled throttler for destination %s with eventLimit:%d, timeWindowInS: %v]]`, throttler.destinationName, destination.ID, limit, timeWindow)
+		limiters[destination.ID] = &Limiter{
+			enabled:     true,
+			eventLimit:  limit,
+			timeWindow:  timeWindow,
+			ratelimiter: ratelimiter.New(throttler.destinationLimitStore(timeWindow), int64(limit), timeWindow),
+		}
+	}
+
+	throttler.destinationLimitersMu.Lock()
+	defer throttler.destinationLimitersMu.Unlock()
+	throttler.destinationLimiters = limiters
+}
+
+// destinationLimits returns the limit and time window of a destination id, zero if it doesn't have any
+func (throttler *HandleT) destinationLimits(destination *backendconfig.DestinationT) (limit int, timeWindow time.Duration) {
+	limitKey := fmt.Sprintf(`Router.throttler.%s.%s.limit`, throttler.destinationName, destination.ID)
+	if config.IsSet(limitKey) {
+		limit = config.GetInt(limitKey, 0)
+	} else {
+		limit = configInt(destination.Config[destinationConfigLimitKey])
+	}
+
+	timeWindowKey := fmt.Sprintf(`Router.throttler.%s.%s.timeWindow`, throttler.destinationName, destination.ID)
+	if config.IsSet(timeWindowKey) {
+		timeWindow = config.GetDuration(timeWindowKey, 0, time.Second)
+	} else {
+		timeWindow = configDuration(destination.Config[destinationConfigTimeWindowKey])
+	}
+	return limit, timeWindow
+}
+
+// destinationLimitStore returns the limit store of the destination id limiters with the given time window.
+// Stores are shared by all destination ids with the same time window, as limit keys contain the destination id
+func (throttler *HandleT) destinationLimitStore(timeWindow time.Duration) ratelimiter.LimitStore {
+	throttler.destinationLimitersMu.Lock()
+	defer throttler.destinationLimitersMu.Unlock()
+	store, ok := throttler.destinationLimitStores[timeWindow]
+	if !ok {
+		store = newLimitStore(2*timeWindow, 10*time.Second)
+		throttler.destinationLimitStores[timeWindow] = store
+	}
+	return store
+}
+
+// destLimiterOf returns the destination level limiter of a destination id, which is the one of the destination type if it doesn't have its own limits
+func (throttler *HandleT) destLimiterOf(destID string) *Limiter {
+	throttler.destinationLimitersMu.RLock()
+	defer throttler.destinationLimitersMu.RUnlock()
+	if limiter, ok := throttler.destinationLimiters[destID]; ok {
+		return limiter
+	}
+	return throttler.destLimiter
+}
+
+// configInt parses a limit of the destination config, which is either a number or a numeric string
+func configInt(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+	return 0
+}
+
+// configDuration parses a time window of the destination config, which is either a number of seconds or a duration string
+func configDuration(value interface{}) time.Duration {
+	switch v := value.(type) {
+	case float64:
+		return time.Duration(v * float64(time.Second))
+	case int:
+		return time.Duration(v) * time.Second
+	case string:
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		d, _ := time.ParseDuration(v)
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package throttler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+)
+
+func TestDestinationLimits(t *testing.T) {
+	config.Reset()
+	defer config.Reset()
+	config.Set("Router.throttler.TEST.overridden.limit", 1)
+	config.Set("Router.throttler.TEST.overridden.timeWindow", "1m")
+
+	var throttler HandleT
+	throttler.SetUp("TEST")
+	require.False(t, throttler.IsEnabled(), "destination type has no limits")
+
+	throttler.SetDestinationLimits([]backendconfig.DestinationT{
+		{ID: "configured", Config: map[string]interface{}{"throttlingLimit": float64(2), "throttlingTimeWindow": "1m"}},
+		{ID: "overridden", Config: map[string]interface{}{"throttlingLimit": float64(100), "throttlingTimeWindow": float64(60)}},
+		{ID: "unlimited", Config: map[string]interface{}{}},
+	})
+	require.True(t, throttler.IsEnabled())
+	require.True(t, throttler.IsDestLevelEnabled())
+	require.False(t, throttler.IsUserLevelEnabled())
+
+	now := time.Now()
+	reachLimit := func(destID string) int {
+		for i := 1; i <= 10; i++ {
+			if throttler.CheckLimitReached(destID, "user", now) {
+				return i - 1
+			}
+			throttler.Inc(destID, "user", now)
+		}
+		return -1
+	}
+	require.Equal(t, 2, reachLimit("configured"))
+	require.Equal(t, 1, reachLimit("overridden"), "config overrides the destination config")
+	require.Equal(t, -1, reachLimit("unlimited"))
+
+	t.Run("limits are reloaded", func(t *testing.T) {
+		limiter := throttler.destLimiterOf("configured")
+		throttler.SetDestinationLimits([]backendconfig.DestinationT{
+			{ID: "configured", Config: map[string]interface{}{"throttlingLimit": "2", "throttlingTimeWindow": 60}},
+			{ID: "unlimited", Config: map[string]interface{}{"throttlingLimit": float64(3), "throttlingTimeWindow": "1m"}},
+		})
+		require.Same(t, limiter, throttler.destLimiterOf("configured"), "unchanged limits keep their counters")
+		require.True(t, throttler.CheckLimitReached("configured", "user", now))
+		require.Same(t, throttler.destLimiter, throttler.destLimiterOf("overridden"), "removed destinations fall back to the destination type limits")
+		require.False(t, throttler.CheckLimitReached("overridden", "user", now))
+		require.Equal(t, 3, reachLimit("unlimited"))
+
+		throttler.SetDestinationLimits(nil)
+		require.False(t, throttler.IsEnabled())
+	})
+
+	t.Run("checks and increments counters at once", func(t *testing.T) {
+		throttler.SetDestinationLimits([]backendconfig.DestinationT{
+			{ID: "atomic", Config: map[string]interface{}{"throttlingLimit": float64(2), "throttlingTimeWindow": "1m"}},
+		})
+		require.False(t, throttler.CheckLimitReachedAndInc("atomic", "user", now))
+		require.False(t, throttler.CheckLimitReachedAndInc("atomic", "user", now))
+		require.True(t, throttler.CheckLimitReachedAndInc("atomic", "user", now))
+		require.True(t, throttler.CheckLimitReached("atomic", "user", now))
+	})
+}
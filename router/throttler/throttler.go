@@ -1,12 +1,18 @@
 package throttler
 
 import (
+	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/go-redis/redis"
+
 	"github.com/rudderlabs/rudder-server/config"
-	"github.com/rudderlabs/rudder-server/router/throttler/ratelimiter"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/services/ratelimiter"
 	"github.com/rudderlabs/rudder-server/utils/logger"
+	"github.com/rudderlabs/rudder-server/utils/misc"
 )
 
 const (
@@ -18,8 +24,11 @@ const (
 // Throttler is an interface for throttling functions
 type Throttler interface {
 	CheckLimitReached(destID, userID string, currentTime time.Time) bool
+	CheckLimitReachedAndInc(destID, userID string, currentTime time.Time) bool
 	Inc(destID, userID string, currentTime time.Time)
 	Dec(destID, userID string, count int64, currentTime time.Time, atLevel string)
+	ResponseReceived(destID string, statusCode int, retryAfter string, currentTime time.Time)
+	SetDestinationLimits(destinations []backendconfig.DestinationT)
 	IsEnabled() bool
 	IsUserLevelEnabled() bool
 	IsDestLevelEnabled() bool
@@ -44,6 +53,11 @@ type HandleT struct {
 	destinationName string
 	destLimiter     *Limiter
 	userLimiter     *Limiter
+	adaptive        *adaptiveLimiter
+
+	destinationLimitersMu  sync.RWMutex
+	destinationLimiters    map[string]*Limiter // destination level limiters of the destination ids with their own limits
+	destinationLimitStores map[time.Duration]ratelimiter.LimitStore
 }
 
 var pkgLogger logger.Logger
@@ -82,27 +96,88 @@ func (throttler *HandleT) SetUp(destName string) {
 	throttler.destinationName = destName
 	throttler.destLimiter = &Limiter{}
 	throttler.userLimiter = &Limiter{}
+	throttler.adaptive = &adaptiveLimiter{}
+	throttler.destinationLimiters = make(map[string]*Limiter)
+	throttler.destinationLimitStores = make(map[time.Duration]ratelimiter.LimitStore)
 
 	// check if it has throttling config for destination
 	throttler.setLimits()
+	throttler.adaptive.setLimits(destName)
 
 	if throttler.destLimiter.enabled {
-		dataStore := ratelimiter.NewMapLimitStore(2*throttler.destLimiter.timeWindow, 10*time.Second)
+		dataStore := newLimitStore(2*throttler.destLimiter.timeWindow, 10*time.Second)
 		throttler.destLimiter.ratelimiter = ratelimiter.New(dataStore, int64(throttler.destLimiter.eventLimit), throttler.destLimiter.timeWindow)
 	}
 
 	if throttler.userLimiter.enabled {
-		dataStore := ratelimiter.NewMapLimitStore(2*throttler.userLimiter.timeWindow, 10*time.Second)
+		dataStore := newLimitStore(2*throttler.userLimiter.timeWindow, 10*time.Second)
 		throttler.userLimiter.ratelimiter = ratelimiter.New(dataStore, int64(throttler.userLimiter.eventLimit), throttler.userLimiter.timeWindow)
 	}
 }
 
+// newLimitStore creates the limit store configured through Router.throttler.store. Limits are kept in memory by default,
+// thus each node enforces them on its own. With a redis or postgres store, limits are shared by all the nodes using it
+func newLimitStore(expirationTime, flushInterval time.Duration) ratelimiter.LimitStore {
+	switch store := config.GetString("Router.throttler.store", "memory"); store {
+	case "memory":
+		return ratelimiter.NewMapLimitStore(expirationTime, flushInterval)
+	case "redis":
+		return ratelimiter.NewRedisLimitStore(sharedRedisClient(), expirationTime)
+	case "postgres":
+		dataStore, err := ratelimiter.NewPostgresLimitStore(sharedPostgresDB(), expirationTime, flushInterval)
+		if err != nil {
+			panic(fmt.Errorf("creating postgres throttler store: %w", err))
+		}
+		return dataStore
+	default:
+		panic(fmt.Errorf("unsupported throttler store: %q", store))
+	}
+}
+
+var (
+	redisClientOnce sync.Once
+	redisClient     *redis.Client
+	postgresDBOnce  sync.Once
+	postgresDB      *sql.DB
+)
+
+// sharedRedisClient returns the redis client shared by all throttlers with a redis store
+func sharedRedisClient() *redis.Client {
+	redisClientOnce.Do(func() {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     config.GetString("Router.throttler.redis.addr", "localhost:6379"),
+			Password: config.GetString("Router.throttler.redis.password", ""),
+			DB:       config.GetInt("Router.throttler.redis.db", 0),
+		})
+	})
+	return redisClient
+}
+
+// sharedPostgresDB returns the database handle shared by all throttlers with a postgres store
+func sharedPostgresDB() *sql.DB {
+	postgresDBOnce.Do(func() {
+		var err error
+		postgresDB, err = sql.Open("postgres", misc.GetConnectionString())
+		if err != nil {
+			panic(err)
+		}
+	})
+	return postgresDB
+}
+
 // LimitReached returns true if number of events in the rolling window is less than the max events allowed, else false
 func (throttler *HandleT) CheckLimitReached(destID, userID string, currentTime time.Time) bool {
 	var destLevelLimitReached bool
-	if throttler.destLimiter.enabled {
+	if destLimiter := throttler.destLimiterOf(destID); destLimiter.enabled {
 		destKey := throttler.getDestKey(destID)
-		limitStatus, err := throttler.destLimiter.ratelimiter.Check(destKey, currentTime)
+		limit := int64(destLimiter.eventLimit)
+		if throttler.adaptive.enabled {
+			var blocked bool
+			if limit, blocked = throttler.adaptive.limit(destID, destLimiter, currentTime); blocked {
+				return true
+			}
+		}
+		limitStatus, err := destLimiter.ratelimiter.CheckWithLimit(destKey, limit, currentTime)
 		if err != nil {
 			// TODO: handle this
 			pkgLogger.Errorf(`[[ %s-router-throttler: Error checking limitStatus: %v]]`, throttler.destinationName, err)
@@ -126,34 +201,88 @@ func (throttler *HandleT) CheckLimitReached(destID, userID string, currentTime t
 	return destLevelLimitReached || userLevelLimitReached
 }
 
+// CheckLimitReachedAndInc returns true if the limits of destID or userID have been reached, else it increases the destLimiter and userLimiter counters and returns false.
+// Checking and increasing each counter is atomic, so that routers sharing a redis or postgres store can't exceed the limits together.
+// If destID or userID passed is empty, their limits are not checked.
+func (throttler *HandleT) CheckLimitReachedAndInc(destID, userID string, currentTime time.Time) bool {
+	var destIncremented bool
+	if destLimiter := throttler.destLimiterOf(destID); destLimiter.enabled && destID != "" {
+		destKey := throttler.getDestKey(destID)
+		limit := int64(destLimiter.eventLimit)
+		if throttler.adaptive.enabled {
+			var blocked bool
+			if limit, blocked = throttler.adaptive.limit(destID, destLimiter, currentTime); blocked {
+				return true
+			}
+		}
+		limitStatus, err := destLimiter.ratelimiter.IncWithLimit(destKey, limit, currentTime)
+		if err != nil {
+			pkgLogger.Errorf(`[[ %s-router-throttler: Error incrementing limit counter: %v]]`, throttler.destinationName, err)
+		} else if limitStatus.IsLimited {
+			return true
+		} else {
+			destIncremented = true
+		}
+	}
+
+	if throttler.userLimiter.enabled && userID != "" {
+		userKey := throttler.getUserKey(destID, userID)
+		limitStatus, err := throttler.userLimiter.ratelimiter.IncWithLimit(userKey, int64(throttler.userLimiter.eventLimit), currentTime)
+		if err != nil {
+			pkgLogger.Errorf(`[[ %s-router-throttler: Error incrementing limit counter: %v]]`, throttler.destinationName, err)
+		} else if limitStatus.IsLimited {
+			if destIncremented {
+				throttler.Dec(destID, userID, 1, currentTime, DESTINATION_LEVEL)
+			}
+			return true
+		}
+	}
+	return false
+}
+
 // Inc increases the destLimiter and userLimiter counters.
 // If destID or userID passed is empty, we don't increment the counters.
 func (throttler *HandleT) Inc(destID, userID string, currentTime time.Time) {
-	if throttler.destLimiter.enabled && destID != "" {
+	if destLimiter := throttler.destLimiterOf(destID); destLimiter.enabled && destID != "" {
 		destKey := throttler.getDestKey(destID)
-		_ = throttler.destLimiter.ratelimiter.Inc(destKey, currentTime)
+		if err := destLimiter.ratelimiter.Inc(destKey, currentTime); err != nil {
+			pkgLogger.Errorf(`[[ %s-router-throttler: Error incrementing limit counter: %v]]`, throttler.destinationName, err)
+		}
 	}
 	if throttler.userLimiter.enabled && userID != "" {
 		userKey := throttler.getUserKey(destID, userID)
-		_ = throttler.userLimiter.ratelimiter.Inc(userKey, currentTime)
+		if err := throttler.userLimiter.ratelimiter.Inc(userKey, currentTime); err != nil {
+			pkgLogger.Errorf(`[[ %s-router-throttler: Error incrementing limit counter: %v]]`, throttler.destinationName, err)
+		}
 	}
 }
 
 // Dec decrements the destLimiter and userLimiter counters by count passed
 // If destID or userID passed is empty, we don't decrement the counters.
 func (throttler *HandleT) Dec(destID, userID string, count int64, currentTime time.Time, atLevel string) {
-	if throttler.destLimiter.enabled && destID != "" && (atLevel == ALL_LEVELS || atLevel == DESTINATION_LEVEL) {
+	if destLimiter := throttler.destLimiterOf(destID); destLimiter.enabled && destID != "" && (atLevel == ALL_LEVELS || atLevel == DESTINATION_LEVEL) {
 		destKey := throttler.getDestKey(destID)
-		_ = throttler.destLimiter.ratelimiter.Dec(destKey, count, currentTime)
+		if err := destLimiter.ratelimiter.Dec(destKey, count, currentTime); err != nil {
+			pkgLogger.Errorf(`[[ %s-router-throttler: Error decrementing limit counter: %v]]`, throttler.destinationName, err)
+		}
 	}
 	if throttler.userLimiter.enabled && userID != "" && (atLevel == ALL_LEVELS || atLevel == USER_LEVEL) {
 		userKey := throttler.getUserKey(destID, userID)
-		_ = throttler.userLimiter.ratelimiter.Dec(userKey, count, currentTime)
+		if err := throttler.userLimiter.ratelimiter.Dec(userKey, count, currentTime); err != nil {
+			pkgLogger.Errorf(`[[ %s-router-throttler: Error decrementing limit counter: %v]]`, throttler.destinationName, err)
+		}
+	}
+}
+
+// ResponseReceived adjusts the destination level limit of destID according to the response of the destination, when adaptive throttling is enabled
+func (throttler *HandleT) ResponseReceived(destID string, statusCode int, retryAfter string, currentTime time.Time) {
+	if destLimiter := throttler.destLimiterOf(destID); throttler.adaptive.enabled && destLimiter.enabled && destID != "" {
+		throttler.adaptive.responseReceived(destID, destLimiter, statusCode, retryAfter, currentTime)
 	}
 }
 
 func (throttler *HandleT) IsEnabled() bool {
-	return throttler.destLimiter.enabled || throttler.userLimiter.enabled
+	return throttler.IsDestLevelEnabled() || throttler.userLimiter.enabled
 }
 
 func (throttler *HandleT) IsUserLevelEnabled() bool {
@@ -161,7 +290,9 @@ func (throttler *HandleT) IsUserLevelEnabled() bool {
 }
 
 func (throttler *HandleT) IsDestLevelEnabled() bool {
-	return throttler.destLimiter.enabled
+	throttler.destinationLimitersMu.RLock()
+	defer throttler.destinationLimitersMu.RUnlock()
+	return throttler.destLimiter.enabled || len(throttler.destinationLimiters) > 0
 }
 
 func (throttler *HandleT) getDestKey(destID string) string {
@@ -0,0 +1,72 @@
+package transformer
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/router/types"
+	"github.com/rudderlabs/rudder-server/services/stats"
+)
+
+// ErrFallback can be returned by embedded transformers for messages they can't transform, so that the message is sent to the transformer service instead
+var ErrFallback = errors.New("fall back to the transformer service")
+
+// DestinationTransformer does the router and batch transformations of a destination type in-process, instead of the transformer service.
+// It returns destination jobs with the same shape as the ones of the transformer service, covering all the jobs of the message
+type DestinationTransformer interface {
+	Transform(transformType string, transformMessage *types.TransformMessageT) ([]types.DestinationJobT, error)
+}
+
+// DestinationTransformerFunc is a function implementing DestinationTransformer
+type DestinationTransformerFunc func(transformType string, transformMessage *types.TransformMessageT) ([]types.DestinationJobT, error)
+
+func (f DestinationTransformerFunc) Transform(transformType string, transformMessage *types.TransformMessageT) ([]types.DestinationJobT, error) {
+	return f(transformType, transformMessage)
+}
+
+var destinationTransformers = struct {
+	sync.RWMutex
+	byDestType map[string]DestinationTransformer
+}{byDestType: make(map[string]DestinationTransformer)}
+
+// RegisterDestinationTransformer registers the embedded transformer of a destination type, replacing any previously registered one.
+// Router transformations of the type are done by it, unless Router.embeddedTransformer.<DEST>.enabled is false
+func RegisterDestinationTransformer(destType string, transformer DestinationTransformer) {
+	destinationTransformers.Lock()
+	defer destinationTransformers.Unlock()
+	destinationTransformers.byDestType[destType] = transformer
+}
+
+// DeregisterDestinationTransformer removes the embedded transformer of a destination type
+func DeregisterDestinationTransformer(destType string) {
+	destinationTransformers.Lock()
+	defer destinationTransformers.Unlock()
+	delete(destinationTransformers.byDestType, destType)
+}
+
+// embeddedTransform transforms a message with the embedded transformer of its destination type.
+// It returns false if there isn't one or it fell back to the transformer service
+func (trans *handle) embeddedTransform(transformType string, transformMessage *types.TransformMessageT) ([]types.DestinationJobT, bool) {
+	destType := transformMessage.DestType
+	destinationTransformers.RLock()
+	transformer, ok := destinationTransformers.byDestType[destType]
+	destinationTransformers.RUnlock()
+	if !ok || !config.GetBool("Router.embeddedTransformer."+destType+".enabled", config.GetBool("Router.embeddedTransformer.enabled", true)) {
+		return nil, false
+	}
+
+	tags := stats.Tags{"destType": destType, "transformType": transformType}
+	s := time.Now()
+	destinationJobs, err := transformer.Transform(transformType, transformMessage)
+	stats.Default.NewTaggedStat("router.embedded_transformer_request_time", stats.TimerType, tags).SendTiming(time.Since(s))
+	if err != nil {
+		if !errors.Is(err, ErrFallback) {
+			trans.logger.Errorf("Embedded transformer of %s failed, falling back to the transformer service: %v", destType, err)
+		}
+		stats.Default.NewTaggedStat("router.embedded_transformer_fallbacks", stats.CountType, tags).Increment()
+		return nil, false
+	}
+	return destinationJobs, true
+}
@@ -86,6 +86,12 @@ func Init() {
 
 // Transform transforms router jobs to destination jobs
 func (trans *handle) Transform(transformType string, transformMessage *types.TransformMessageT) []types.DestinationJobT {
+	if transformType == BATCH || transformType == ROUTER_TRANSFORM {
+		if destinationJobs, ok := trans.embeddedTransform(transformType, transformMessage); ok {
+			return destinationJobs
+		}
+	}
+
 	// Call remote transformation
 	rawJSON, err := jsonfast.Marshal(transformMessage)
 	if err != nil {
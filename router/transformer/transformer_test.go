@@ -496,3 +496,43 @@ func normalizeErrors(transformerResponse []types.DestinationJobT, prefix string)
 		}
 	}
 }
+
+func TestEmbeddedTransform(t *testing.T) {
+	initMocks(t)
+	config.Reset()
+	t.Cleanup(config.Reset)
+	var serviceRequests int
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serviceRequests++
+		w.Header().Add(apiVersionHeader, strconv.Itoa(utilTypes.SUPPORTED_TRANSFORMER_API_VERSION))
+		_, err := w.Write([]byte(`[{"metadata": [{"jobId": 1}], "statusCode": 200}]`))
+		require.NoError(t, err)
+	}))
+	defer svr.Close()
+	t.Setenv("DEST_TRANSFORM_URL", svr.URL)
+	tr := NewTransformer(time.Minute, time.Minute)
+
+	transformMessage := types.TransformMessageT{DestType: "EMBEDDED", Data: []types.RouterJobT{{JobMetadata: types.JobMetadataT{JobID: 1}}}}
+	embeddedResponse := []types.DestinationJobT{{JobMetadataArray: []types.JobMetadataT{{JobID: 1}}, StatusCode: http.StatusOK, Error: "embedded"}}
+	var fallback bool
+	RegisterDestinationTransformer("EMBEDDED", DestinationTransformerFunc(func(transformType string, transformMessage *types.TransformMessageT) ([]types.DestinationJobT, error) {
+		require.Equal(t, BATCH, transformType)
+		if fallback {
+			return nil, ErrFallback
+		}
+		return embeddedResponse, nil
+	}))
+	t.Cleanup(func() { DeregisterDestinationTransformer("EMBEDDED") })
+
+	require.Equal(t, embeddedResponse, tr.Transform(BATCH, &transformMessage))
+	require.Zero(t, serviceRequests, "messages are transformed in-process")
+
+	fallback = true
+	require.Equal(t, http.StatusOK, tr.Transform(BATCH, &transformMessage)[0].StatusCode)
+	require.Equal(t, 1, serviceRequests, "messages fall back to the transformer service")
+
+	fallback = false
+	config.Set("Router.embeddedTransformer.EMBEDDED.enabled", false)
+	require.Empty(t, tr.Transform(BATCH, &transformMessage)[0].Error)
+	require.Equal(t, 2, serviceRequests, "disabled embedded transformers aren't used")
+}
@@ -11,6 +11,8 @@ import (
 const (
 	RouterUnMarshalErrorCode = 599
 	RouterTimedOutStatusCode = 1113
+	// RouterCircuitOpenStatusCode is the status code of jobs short-circuited by the circuit breaker of their destination
+	RouterCircuitOpenStatusCode = 1114
 )
 
 // RouterJobT holds the router job and its related metadata
@@ -53,6 +55,7 @@ type JobMetadataT struct {
 	TransformAt        string          `json:"transformAt"`
 	WorkspaceID        string          `json:"workspaceId"`
 	Secret             json.RawMessage `json:"secret"`
+	OrderKey           string          `json:"orderKey"` // key of the barrier ordering the job, computed once when the job is picked up. Empty if its events aren't ordered
 	JobT               *jobsdb.JobT    `json:"jobsT"`
 	WorkerAssignedTime time.Time       `json:"workerAssignedTime"`
 }
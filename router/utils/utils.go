@@ -42,6 +42,7 @@ type SendPostResponse struct {
 	StatusCode          int
 	ResponseContentType string
 	ResponseBody        []byte
+	RetryAfter          string // value of the Retry-After header of the destination response, if any
 }
 
 func Init() {
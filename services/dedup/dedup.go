@@ -3,10 +3,12 @@
 package dedup
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/dgraph-io/badger/v2"
 	"github.com/dgraph-io/badger/v2/options"
 
@@ -18,16 +20,32 @@ import (
 )
 
 type DedupI interface {
-	FindDuplicates(messageIDs []string, allMessageIDsSet map[string]struct{}) (duplicateIndexes []int)
-	MarkProcessed(messageIDs []string) error
+	FindDuplicates(ctx context.Context, keys []string, allKeysSet map[string]struct{}) (duplicateIndexes []int, err error)
+	MarkProcessed(ctx context.Context, keys []KeyT) error
 	PrintHistogram()
 	Close()
 }
 
+const (
+	BadgerMode   = "badger"
+	RedisMode    = "redis"
+	PostgresMode = "postgres"
+)
+
 var (
 	dedupWindow  time.Duration
 	memOptimized bool
 	pkgLogger    logger.Logger
+
+	mode                    string
+	redisAddresses          string
+	redisPassword           string
+	redisDB                 int
+	redisKeyPrefix          string
+	postgresDSN             string
+	postgresCleanupInterval time.Duration
+	maxRetryInterval        time.Duration
+	maxRetryElapsedTime     time.Duration
 )
 
 func Init() {
@@ -39,6 +57,18 @@ func loadConfig() {
 	// Dedup time window in hours
 	config.RegisterDurationConfigVariable(3600, &dedupWindow, true, time.Second, []string{"Dedup.dedupWindow", "Dedup.dedupWindowInS"}...)
 	config.RegisterBoolConfigVariable(true, &memOptimized, false, "Dedup.memOptimized")
+	// Dedup store: badger (node-local), redis or postgres (shared across nodes)
+	config.RegisterStringConfigVariable(BadgerMode, &mode, false, "Dedup.mode")
+	config.RegisterStringConfigVariable("localhost:6379", &redisAddresses, false, "Dedup.redis.addresses")
+	config.RegisterStringConfigVariable("", &redisPassword, false, "Dedup.redis.password")
+	config.RegisterIntConfigVariable(0, &redisDB, false, 1, "Dedup.redis.db")
+	config.RegisterStringConfigVariable("rudder:dedup:", &redisKeyPrefix, false, "Dedup.redis.keyPrefix")
+	config.RegisterStringConfigVariable("", &postgresDSN, false, "Dedup.postgres.dsn")
+	config.RegisterDurationConfigVariable(5, &postgresCleanupInterval, false, time.Minute, "Dedup.postgres.cleanupInterval")
+	// Maximum interval between retries of failed redis or postgres operations
+	config.RegisterDurationConfigVariable(10, &maxRetryInterval, true, time.Second, "Dedup.maxRetryInterval")
+	// Maximum time spent retrying a failed redis or postgres operation, before its error is returned
+	config.RegisterDurationConfigVariable(60, &maxRetryElapsedTime, true, time.Second, "Dedup.maxRetryElapsedTime")
 }
 
 type loggerForBadger struct {
@@ -58,48 +88,61 @@ func DefaultRudderPath() string {
 	return fmt.Sprintf(`%v%v`, tmpDirPath, badgerPathName)
 }
 
-type OptFn func(*DedupHandleT)
+type OptFn func(*dedupOptions)
+
+// dedupOptions are common to all DedupI implementations
+type dedupOptions struct {
+	window  *time.Duration
+	clearDB bool
+}
 
 func FromConfig() OptFn {
-	return func(dht *DedupHandleT) {
-		dht.window = &dedupWindow
+	return func(o *dedupOptions) {
+		o.window = &dedupWindow
 	}
 }
 
 func WithWindow(d time.Duration) OptFn {
-	return func(dht *DedupHandleT) {
-		dht.window = &d
+	return func(o *dedupOptions) {
+		o.window = &d
 	}
 }
 
+// windowOf returns the window of key, falling back to the configured dedup window
+func (o *dedupOptions) windowOf(key KeyT) time.Duration {
+	if key.Window > 0 {
+		return key.Window
+	}
+	return *o.window
+}
+
 func WithClearDB() OptFn {
-	return func(dht *DedupHandleT) {
-		dht.clearDB = true
+	return func(o *dedupOptions) {
+		o.clearDB = true
 	}
 }
 
 type DedupHandleT struct {
+	dedupOptions
 	stats    stats.Stats
 	logger   loggerForBadger
 	badgerDB *badger.DB
-	window   *time.Duration
 	close    chan struct{}
 	gcDone   chan struct{}
 	path     string
-	clearDB  bool
 }
 
 func New(path string, fns ...OptFn) *DedupHandleT {
 	d := &DedupHandleT{
-		path:   path,
-		logger: loggerForBadger{logger.NewLogger().Child("dedup")},
-		stats:  stats.Default,
-		gcDone: make(chan struct{}),
-		close:  make(chan struct{}),
-		window: &dedupWindow,
+		path:         path,
+		logger:       loggerForBadger{logger.NewLogger().Child("dedup")},
+		stats:        stats.Default,
+		gcDone:       make(chan struct{}),
+		close:        make(chan struct{}),
+		dedupOptions: dedupOptions{window: &dedupWindow},
 	}
 	for _, fn := range fns {
-		fn(d)
+		fn(&d.dedupOptions)
 	}
 	d.openBadger()
 
@@ -170,10 +213,10 @@ func (d *DedupHandleT) gcBadgerDB() {
 	}
 }
 
-func (d *DedupHandleT) writeToBadger(messageIDs []string) error {
+func (d *DedupHandleT) writeToBadger(keys []KeyT) error {
 	txn := d.badgerDB.NewTransaction(true)
-	for _, messageID := range messageIDs {
-		e := badger.NewEntry([]byte(messageID), nil).WithTTL(*d.window)
+	for _, key := range keys {
+		e := badger.NewEntry([]byte(key.Key), nil).WithTTL(d.windowOf(key))
 		err := txn.SetEntry(e)
 		if err == badger.ErrTxnTooBig {
 			if err = txn.Commit(); err != nil {
@@ -190,13 +233,37 @@ func (d *DedupHandleT) writeToBadger(messageIDs []string) error {
 	return txn.Commit()
 }
 
-// MarkProcessed persist messageIDs in Disk, with expiry time of dedupWindow
-// Any message mark here will appear in FindDuplicates() if queried inside the dedupWindow
-func (d *DedupHandleT) MarkProcessed(messageIDs []string) error {
-	return d.writeToBadger(messageIDs)
+// MarkProcessed persist keys in Disk, with expiry time of their window, or dedupWindow if not set
+// Any key mark here will appear in FindDuplicates() if queried inside its window
+func (d *DedupHandleT) MarkProcessed(_ context.Context, keys []KeyT) error {
+	return d.writeToBadger(keys)
 }
 
-func (d *DedupHandleT) FindDuplicates(messageIDs []string, allMessageIDsSet map[string]struct{}) (duplicateIndexes []int) {
+func (d *DedupHandleT) FindDuplicates(_ context.Context, messageIDs []string, allMessageIDsSet map[string]struct{}) (duplicateIndexes []int, err error) {
+	toRemoveMessageIndexesSet := findDuplicatesInBatch(messageIDs, allMessageIDsSet)
+
+	// Dedup with badgerDB
+	var hits int
+	err = d.badgerDB.View(func(txn *badger.Txn) error {
+		for idx, messageID := range messageIDs {
+			_, err := txn.Get([]byte(messageID))
+			if err != badger.ErrKeyNotFound {
+				toRemoveMessageIndexesSet[idx] = struct{}{}
+				hits++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("badger dedup find_duplicates: %w", err)
+	}
+	reportStats(d.stats, "badger", hits, len(messageIDs)-hits)
+	return sortedIndexes(toRemoveMessageIndexesSet), nil
+}
+
+// findDuplicatesInBatch returns the indexes of messageIDs that are repeated within messageIDs itself
+// or are already present in allMessageIDsSet
+func findDuplicatesInBatch(messageIDs []string, allMessageIDsSet map[string]struct{}) map[int]struct{} {
 	toRemoveMessageIndexesSet := make(map[int]struct{})
 	// Dedup within events batch in a web request
 	messageIDSet := make(map[string]struct{})
@@ -221,27 +288,43 @@ func (d *DedupHandleT) FindDuplicates(messageIDs []string, allMessageIDsSet map[
 			toRemoveMessageIndexesSet[idx] = struct{}{}
 		}
 	}
+	return toRemoveMessageIndexesSet
+}
 
-	// Dedup with badgerDB
-	err := d.badgerDB.View(func(txn *badger.Txn) error {
-		for idx, messageID := range messageIDs {
-			_, err := txn.Get([]byte(messageID))
-			if err != badger.ErrKeyNotFound {
-				toRemoveMessageIndexesSet[idx] = struct{}{}
-			}
-		}
-		return nil
+func sortedIndexes(indexesSet map[int]struct{}) []int {
+	indexes := make([]int, 0, len(indexesSet))
+	for k := range indexesSet {
+		indexes = append(indexes, k)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// retry runs an operation of a shared dedup store until it succeeds, backing off exponentially up to maxRetryInterval,
+// so that transient redis or postgres errors don't crash the processor. It gives up once ctx is done or after maxRetryElapsedTime,
+// returning the last error of the operation
+func retry(ctx context.Context, s stats.Stats, mode, operation string, fn func() error) error {
+	bo := backoff.NewExponentialBackOff()
+	bo.MaxInterval = maxRetryInterval
+	if bo.InitialInterval > bo.MaxInterval {
+		bo.InitialInterval = bo.MaxInterval
+	}
+	bo.MaxElapsedTime = maxRetryElapsedTime
+	err := backoff.RetryNotify(fn, backoff.WithContext(bo, ctx), func(err error, wait time.Duration) {
+		pkgLogger.Warnf("%s dedup %s failed, retrying in %v: %v", mode, operation, wait, err)
+		s.NewTaggedStat("dedup_store_errors", stats.CountType, stats.Tags{"mode": mode, "operation": operation}).Increment()
 	})
 	if err != nil {
-		panic(err)
-	}
-	toRemoveMessageIndexes := make([]int, 0, len(toRemoveMessageIndexesSet))
-	for k := range toRemoveMessageIndexesSet {
-		toRemoveMessageIndexes = append(toRemoveMessageIndexes, k)
+		return fmt.Errorf("%s dedup %s: %w", mode, operation, err)
 	}
+	return nil
+}
 
-	sort.Ints(toRemoveMessageIndexes)
-	return toRemoveMessageIndexes
+// reportStats reports the number of messageIDs found (hits) and not found (misses) in the dedup store
+func reportStats(s stats.Stats, mode string, hits, misses int) {
+	tags := stats.Tags{"mode": mode}
+	s.NewTaggedStat("dedup_hits", stats.CountType, tags).Count(hits)
+	s.NewTaggedStat("dedup_misses", stats.CountType, tags).Count(misses)
 }
 
 func (d *DedupHandleT) Close() {
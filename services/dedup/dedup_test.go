@@ -1,6 +1,7 @@
 package dedup_test
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"path"
@@ -16,6 +17,22 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/logger"
 )
 
+func keys(keys ...string) []dedup.KeyT {
+	dedupKeys := make([]dedup.KeyT, len(keys))
+	for i, key := range keys {
+		dedupKeys[i] = dedup.KeyT{Key: key}
+	}
+	return dedupKeys
+}
+
+// findDuplicates returns the duplicates found by d, failing the test on errors
+func findDuplicates(t testing.TB, d dedup.DedupI, messageIDs []string, allMessageIDsSet map[string]struct{}) []int {
+	t.Helper()
+	dups, err := d.FindDuplicates(context.Background(), messageIDs, allMessageIDsSet)
+	require.NoError(t, err)
+	return dups
+}
+
 func Test_Dedup(t *testing.T) {
 	config.Reset()
 	logger.Reset()
@@ -28,28 +45,28 @@ func Test_Dedup(t *testing.T) {
 	defer d.Close()
 
 	t.Run("no duplicate if not marked as processed", func(t *testing.T) {
-		dups := d.FindDuplicates([]string{"a", "b", "c"}, nil)
+		dups := findDuplicates(t, d, []string{"a", "b", "c"}, nil)
 		require.Equal(t, []int{}, dups)
 
-		dupsAgain := d.FindDuplicates([]string{"a", "b", "c"}, nil)
+		dupsAgain := findDuplicates(t, d, []string{"a", "b", "c"}, nil)
 		require.Equal(t, []int{}, dupsAgain)
 	})
 
 	t.Run("duplicate after marked as processed", func(t *testing.T) {
-		err := d.MarkProcessed([]string{"a", "b", "c"})
+		err := d.MarkProcessed(context.Background(), keys("a", "b", "c"))
 		require.NoError(t, err)
-		dups := d.FindDuplicates([]string{"a", "b", "c"}, nil)
+		dups := findDuplicates(t, d, []string{"a", "b", "c"}, nil)
 		require.Equal(t, []int{0, 1, 2}, dups)
 
-		dupsOther := d.FindDuplicates([]string{"d", "e"}, nil)
+		dupsOther := findDuplicates(t, d, []string{"d", "e"}, nil)
 		require.Equal(t, []int{}, dupsOther)
 	})
 
 	t.Run("no duplicate if not marked as processed", func(t *testing.T) {
-		dups := d.FindDuplicates([]string{"x", "y", "z"}, map[string]struct{}{"x": {}, "z": {}})
+		dups := findDuplicates(t, d, []string{"x", "y", "z"}, map[string]struct{}{"x": {}, "z": {}})
 		require.Equal(t, []int{0, 2}, dups)
 
-		dupsAgain := d.FindDuplicates([]string{"x", "y", "z"}, nil)
+		dupsAgain := findDuplicates(t, d, []string{"x", "y", "z"}, nil)
 		require.Equal(t, []int{}, dupsAgain)
 	})
 }
@@ -65,20 +82,41 @@ func Test_Dedup_Window(t *testing.T) {
 	d := dedup.New(dbPath, dedup.WithClearDB(), dedup.WithWindow(time.Second))
 	defer d.Close()
 
-	err := d.MarkProcessed([]string{"to be deleted"})
+	err := d.MarkProcessed(context.Background(), keys("to be deleted"))
 	require.NoError(t, err)
 
-	dups := d.FindDuplicates([]string{"to be deleted"}, nil)
+	dups := findDuplicates(t, d, []string{"to be deleted"}, nil)
 	require.Equal(t, []int{0}, dups)
 
 	require.Eventually(t, func() bool {
-		return len(d.FindDuplicates([]string{"to be deleted"}, nil)) == 0
+		return len(findDuplicates(t, d, []string{"to be deleted"}, nil)) == 0
 	}, 2*time.Second, 100*time.Millisecond)
 
-	dupsAfter := d.FindDuplicates([]string{"to be deleted"}, nil)
+	dupsAfter := findDuplicates(t, d, []string{"to be deleted"}, nil)
 	require.Equal(t, []int{}, dupsAfter)
 }
 
+func Test_Dedup_KeyWindow(t *testing.T) {
+	config.Reset()
+	logger.Reset()
+
+	dbPath := os.TempDir() + "/dedup_test"
+	defer func() { _ = os.RemoveAll(dbPath) }()
+	_ = os.RemoveAll(dbPath)
+
+	d := dedup.New(dbPath, dedup.WithClearDB(), dedup.WithWindow(time.Hour))
+	defer d.Close()
+
+	err := d.MarkProcessed(context.Background(), []dedup.KeyT{{Key: "short window", Window: time.Second}, {Key: "default window"}})
+	require.NoError(t, err)
+
+	require.Equal(t, []int{0, 1}, findDuplicates(t, d, []string{"short window", "default window"}, nil))
+	require.Eventually(t, func() bool {
+		return len(findDuplicates(t, d, []string{"short window"}, nil)) == 0
+	}, 2*time.Second, 100*time.Millisecond)
+	require.Equal(t, []int{0}, findDuplicates(t, d, []string{"default window"}, nil))
+}
+
 func Test_Dedup_ClearDB(t *testing.T) {
 	config.Reset()
 	logger.Reset()
@@ -89,19 +127,19 @@ func Test_Dedup_ClearDB(t *testing.T) {
 
 	{
 		d := dedup.New(dbPath, dedup.WithClearDB(), dedup.WithWindow(time.Hour))
-		err := d.MarkProcessed([]string{"a"})
+		err := d.MarkProcessed(context.Background(), keys("a"))
 		require.NoError(t, err)
 		d.Close()
 	}
 	{
 		dNew := dedup.New(dbPath)
-		dupsAgain := dNew.FindDuplicates([]string{"a"}, nil)
+		dupsAgain := findDuplicates(t, dNew, []string{"a"}, nil)
 		require.Equal(t, []int{0}, dupsAgain)
 		dNew.Close()
 	}
 	{
 		dWithClear := dedup.New(dbPath, dedup.WithClearDB())
-		dupsAgain := dWithClear.FindDuplicates([]string{"a"}, nil)
+		dupsAgain := findDuplicates(t, dWithClear, []string{"a"}, nil)
 		require.Equal(t, []int{}, dupsAgain)
 		dWithClear.Close()
 	}
@@ -122,7 +160,7 @@ func Test_Dedup_ErrTxnTooBig(t *testing.T) {
 	for i := 0; i < size; i++ {
 		messageIDs[i] = uuid.New().String()
 	}
-	err := d.MarkProcessed(messageIDs)
+	err := d.MarkProcessed(context.Background(), keys(messageIDs...))
 	require.NoError(t, err)
 }
 
@@ -146,8 +184,8 @@ func Benchmark_Dedup(b *testing.B) {
 			msgIDs[i%batchSize] = uuid.New().String()
 
 			if i%batchSize == batchSize-1 || i == b.N-1 {
-				duplicateIndexes = d.FindDuplicates(msgIDs[:i%batchSize], nil)
-				err := d.MarkProcessed(msgIDs[:i%batchSize])
+				duplicateIndexes = findDuplicates(b, d, msgIDs[:i%batchSize], nil)
+				err := d.MarkProcessed(context.Background(), keys(msgIDs[:i%batchSize]...))
 				require.NoError(b, err)
 			}
 		}
@@ -0,0 +1,154 @@
+package dedup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-server/rruntime"
+	"github.com/rudderlabs/rudder-server/services/stats"
+)
+
+const postgresDedupTable = "dedup_message_ids"
+
+// PostgresDedupHandleT is a DedupI backed by a postgres table, shared by all nodes connected to the same database
+type PostgresDedupHandleT struct {
+	dedupOptions
+	stats           stats.Stats
+	db              *sql.DB
+	cleanupInterval time.Duration
+	close           chan struct{}
+	cleanupDone     chan struct{}
+}
+
+// NewPostgres returns a DedupI storing messageIDs in postgres, along with their expiry time.
+// Expired messageIDs are periodically deleted in the background.
+func NewPostgres(db *sql.DB, cleanupInterval time.Duration, fns ...OptFn) (*PostgresDedupHandleT, error) {
+	d := &PostgresDedupHandleT{
+		db:              db,
+		cleanupInterval: cleanupInterval,
+		stats:           stats.Default,
+		dedupOptions:    dedupOptions{window: &dedupWindow},
+		close:           make(chan struct{}),
+		cleanupDone:     make(chan struct{}),
+	}
+	for _, fn := range fns {
+		fn(&d.dedupOptions)
+	}
+	if err := d.setup(); err != nil {
+		return nil, err
+	}
+	rruntime.Go(func() {
+		d.cleanupLoop()
+		close(d.cleanupDone)
+	})
+	return d, nil
+}
+
+func (d *PostgresDedupHandleT) setup() error {
+	sqlStatement := fmt.Sprintf(`create table if not exists %[1]q (
+		message_id text primary key,
+		expire_at timestamptz not null
+	);
+	create index if not exists %[2]q on %[1]q (expire_at);`, postgresDedupTable, postgresDedupTable+"_expire_at_idx")
+	if _, err := d.db.Exec(sqlStatement); err != nil {
+		return fmt.Errorf("creating dedup table: %w", err)
+	}
+	if d.clearDB {
+		if _, err := d.db.Exec(fmt.Sprintf(`truncate table %q`, postgresDedupTable)); err != nil {
+			return fmt.Errorf("clearing dedup table: %w", err)
+		}
+	}
+	return nil
+}
+
+func (d *PostgresDedupHandleT) cleanupLoop() {
+	for {
+		select {
+		case <-d.close:
+			return
+		case <-time.After(d.cleanupInterval):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), d.cleanupInterval)
+		res, err := d.db.ExecContext(ctx, fmt.Sprintf(`delete from %q where expire_at < now()`, postgresDedupTable))
+		cancel()
+		if err != nil {
+			pkgLogger.Errorf("Failed to delete expired messageIDs from %s: %v", postgresDedupTable, err)
+			continue
+		}
+		if deleted, err := res.RowsAffected(); err == nil {
+			d.stats.NewTaggedStat("dedup_expired_deleted", stats.CountType, stats.Tags{"mode": "postgres"}).Count(int(deleted))
+		}
+	}
+}
+
+// MarkProcessed persists keys in postgres, with expiry time of their window, or dedupWindow if not set
+// Any key mark here will appear in FindDuplicates() if queried inside its window. Failed writes are retried until ctx is done or Dedup.maxRetryElapsedTime
+func (d *PostgresDedupHandleT) MarkProcessed(ctx context.Context, keys []KeyT) error {
+	// an upsert cannot affect the same row twice, so keys need to be unique; the longest window wins
+	windows := make(map[string]time.Duration, len(keys))
+	for _, key := range keys {
+		if window := d.windowOf(key); window > windows[key.Key] {
+			windows[key.Key] = window
+		}
+	}
+	uniqueKeys := make([]string, 0, len(windows))
+	windowsInSecs := make([]float64, 0, len(windows))
+	for key, window := range windows {
+		uniqueKeys = append(uniqueKeys, key)
+		windowsInSecs = append(windowsInSecs, window.Seconds())
+	}
+	sqlStatement := fmt.Sprintf(`insert into %q (message_id, expire_at)
+		select k, now() + make_interval(secs => w) from unnest($1::text[], $2::float8[]) as t(k, w)
+		on conflict (message_id) do update set expire_at = excluded.expire_at`, postgresDedupTable)
+	return retry(ctx, d.stats, "postgres", "mark_processed", func() error {
+		_, err := d.db.Exec(sqlStatement, pq.Array(uniqueKeys), pq.Array(windowsInSecs))
+		return err
+	})
+}
+
+func (d *PostgresDedupHandleT) FindDuplicates(ctx context.Context, messageIDs []string, allMessageIDsSet map[string]struct{}) (duplicateIndexes []int, err error) {
+	toRemoveMessageIndexesSet := findDuplicatesInBatch(messageIDs, allMessageIDsSet)
+
+	// Dedup with postgres
+	var found map[string]struct{}
+	err = retry(ctx, d.stats, "postgres", "find_duplicates", func() error {
+		found = make(map[string]struct{})
+		rows, err := d.db.Query(fmt.Sprintf(`select message_id from %q where message_id = any($1) and expire_at > now()`, postgresDedupTable), pq.Array(messageIDs))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var messageID string
+			if err := rows.Scan(&messageID); err != nil {
+				return err
+			}
+			found[messageID] = struct{}{}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	var hits int
+	for idx, messageID := range messageIDs {
+		if _, ok := found[messageID]; ok {
+			toRemoveMessageIndexesSet[idx] = struct{}{}
+			hits++
+		}
+	}
+	reportStats(d.stats, "postgres", hits, len(messageIDs)-hits)
+	return sortedIndexes(toRemoveMessageIndexesSet), nil
+}
+
+func (*PostgresDedupHandleT) PrintHistogram() {}
+
+func (d *PostgresDedupHandleT) Close() {
+	close(d.close)
+	<-d.cleanupDone
+	_ = d.db.Close()
+}
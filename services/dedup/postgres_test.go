@@ -0,0 +1,78 @@
+package dedup_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/services/dedup"
+	"github.com/rudderlabs/rudder-server/testhelper/destination"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+func Test_PostgresDedup(t *testing.T) {
+	config.Reset()
+	logger.Reset()
+	dedup.Init()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	postgresResource, err := destination.SetupPostgres(pool, t)
+	require.NoError(t, err)
+
+	newDB := func() *sql.DB {
+		db, err := sql.Open("postgres", postgresResource.DBDsn)
+		require.NoError(t, err)
+		return db
+	}
+
+	d, err := dedup.NewPostgres(newDB(), time.Hour, dedup.WithClearDB(), dedup.WithWindow(time.Hour))
+	require.NoError(t, err)
+	defer d.Close()
+
+	t.Run("no duplicate if not marked as processed", func(t *testing.T) {
+		dups := findDuplicates(t, d, []string{"a", "b", "c"}, nil)
+		require.Equal(t, []int{}, dups)
+	})
+
+	t.Run("duplicate after marked as processed", func(t *testing.T) {
+		err := d.MarkProcessed(context.Background(), keys("a", "b", "c", "a"))
+		require.NoError(t, err)
+		dups := findDuplicates(t, d, []string{"a", "b", "c"}, nil)
+		require.Equal(t, []int{0, 1, 2}, dups)
+
+		dupsOther := findDuplicates(t, d, []string{"d", "e"}, nil)
+		require.Equal(t, []int{}, dupsOther)
+	})
+
+	t.Run("duplicates within batch and across batches", func(t *testing.T) {
+		dups := findDuplicates(t, d, []string{"x", "y", "x", "z"}, map[string]struct{}{"z": {}})
+		require.Equal(t, []int{2, 3}, dups)
+	})
+
+	t.Run("duplicate across nodes sharing the same database", func(t *testing.T) {
+		other, err := dedup.NewPostgres(newDB(), time.Hour, dedup.WithWindow(time.Hour))
+		require.NoError(t, err)
+		defer other.Close()
+
+		require.NoError(t, other.MarkProcessed(context.Background(), keys("m")))
+		require.Equal(t, []int{1}, findDuplicates(t, d, []string{"n", "m"}, nil))
+	})
+
+	t.Run("window", func(t *testing.T) {
+		short, err := dedup.NewPostgres(newDB(), 100*time.Millisecond, dedup.WithWindow(time.Second))
+		require.NoError(t, err)
+		defer short.Close()
+
+		require.NoError(t, short.MarkProcessed(context.Background(), keys("to be deleted")))
+		require.Equal(t, []int{0}, findDuplicates(t, short, []string{"to be deleted"}, nil))
+		require.Eventually(t, func() bool {
+			return len(findDuplicates(t, short, []string{"to be deleted"}, nil)) == 0
+		}, 3*time.Second, 100*time.Millisecond)
+	})
+}
@@ -0,0 +1,113 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis"
+
+	"github.com/rudderlabs/rudder-server/services/stats"
+)
+
+// RedisDedupHandleT is a DedupI backed by redis, shared by all nodes connected to the same redis
+type RedisDedupHandleT struct {
+	dedupOptions
+	stats     stats.Stats
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+// NewRedis returns a DedupI storing messageIDs in redis, under keyPrefix and with an expiry of the dedup window
+func NewRedis(client redis.UniversalClient, keyPrefix string, fns ...OptFn) (*RedisDedupHandleT, error) {
+	d := &RedisDedupHandleT{
+		client:       client,
+		keyPrefix:    keyPrefix,
+		stats:        stats.Default,
+		dedupOptions: dedupOptions{window: &dedupWindow},
+	}
+	for _, fn := range fns {
+		fn(&d.dedupOptions)
+	}
+	if err := d.client.Ping().Err(); err != nil {
+		return nil, fmt.Errorf("pinging redis: %w", err)
+	}
+	if d.clearDB {
+		if err := d.clear(); err != nil {
+			return nil, fmt.Errorf("clearing redis dedup keys: %w", err)
+		}
+	}
+	return d, nil
+}
+
+func (d *RedisDedupHandleT) key(messageID string) string {
+	return d.keyPrefix + messageID
+}
+
+// clear deletes all keys under the configured prefix, on every master node in cluster mode
+func (d *RedisDedupHandleT) clear() error {
+	clearNode := func(client *redis.Client) error {
+		iter := client.Scan(0, d.keyPrefix+"*", 1000).Iterator()
+		for iter.Next() {
+			if err := client.Del(iter.Val()).Err(); err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+	switch client := d.client.(type) {
+	case *redis.ClusterClient:
+		return client.ForEachMaster(clearNode)
+	case *redis.Client:
+		return clearNode(client)
+	default:
+		return fmt.Errorf("unsupported redis client type %T", client)
+	}
+}
+
+// MarkProcessed persists keys in redis, with expiry time of their window, or dedupWindow if not set
+// Any key mark here will appear in FindDuplicates() if queried inside its window. Failed writes are retried until ctx is done or Dedup.maxRetryElapsedTime
+func (d *RedisDedupHandleT) MarkProcessed(ctx context.Context, keys []KeyT) error {
+	return retry(ctx, d.stats, "redis", "mark_processed", func() error {
+		pipe := d.client.Pipeline()
+		defer func() { _ = pipe.Close() }()
+		for _, key := range keys {
+			pipe.Set(d.key(key.Key), 1, d.windowOf(key))
+		}
+		_, err := pipe.Exec()
+		return err
+	})
+}
+
+func (d *RedisDedupHandleT) FindDuplicates(ctx context.Context, messageIDs []string, allMessageIDsSet map[string]struct{}) (duplicateIndexes []int, err error) {
+	toRemoveMessageIndexesSet := findDuplicatesInBatch(messageIDs, allMessageIDsSet)
+
+	// Dedup with redis
+	cmds := make([]*redis.IntCmd, len(messageIDs))
+	err = retry(ctx, d.stats, "redis", "find_duplicates", func() error {
+		pipe := d.client.Pipeline()
+		defer func() { _ = pipe.Close() }()
+		for idx, messageID := range messageIDs {
+			cmds[idx] = pipe.Exists(d.key(messageID))
+		}
+		_, err := pipe.Exec()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var hits int
+	for idx, cmd := range cmds {
+		if cmd.Val() > 0 {
+			toRemoveMessageIndexesSet[idx] = struct{}{}
+			hits++
+		}
+	}
+	reportStats(d.stats, "redis", hits, len(messageIDs)-hits)
+	return sortedIndexes(toRemoveMessageIndexesSet), nil
+}
+
+func (*RedisDedupHandleT) PrintHistogram() {}
+
+func (d *RedisDedupHandleT) Close() {
+	_ = d.client.Close()
+}
@@ -0,0 +1,183 @@
+package dedup_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	"github.com/rudderlabs/rudder-server/services/dedup"
+	"github.com/rudderlabs/rudder-server/testhelper/destination"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+func Test_RedisDedup(t *testing.T) {
+	config.Reset()
+	logger.Reset()
+	dedup.Init()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	redisResource, err := destination.SetupRedis(pool, t)
+	require.NoError(t, err)
+
+	newClient := func() redis.UniversalClient {
+		return redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{redisResource.RedisAddress}})
+	}
+
+	d, err := dedup.NewRedis(newClient(), "test:dedup:", dedup.WithClearDB(), dedup.WithWindow(time.Hour))
+	require.NoError(t, err)
+	defer d.Close()
+
+	t.Run("no duplicate if not marked as processed", func(t *testing.T) {
+		dups := findDuplicates(t, d, []string{"a", "b", "c"}, nil)
+		require.Equal(t, []int{}, dups)
+	})
+
+	t.Run("duplicate after marked as processed", func(t *testing.T) {
+		err := d.MarkProcessed(context.Background(), keys("a", "b", "c"))
+		require.NoError(t, err)
+		dups := findDuplicates(t, d, []string{"a", "b", "c"}, nil)
+		require.Equal(t, []int{0, 1, 2}, dups)
+
+		dupsOther := findDuplicates(t, d, []string{"d", "e"}, nil)
+		require.Equal(t, []int{}, dupsOther)
+	})
+
+	t.Run("duplicates within batch and across batches", func(t *testing.T) {
+		dups := findDuplicates(t, d, []string{"x", "y", "x", "z"}, map[string]struct{}{"z": {}})
+		require.Equal(t, []int{2, 3}, dups)
+	})
+
+	t.Run("duplicate across nodes sharing the same redis", func(t *testing.T) {
+		other, err := dedup.NewRedis(newClient(), "test:dedup:", dedup.WithWindow(time.Hour))
+		require.NoError(t, err)
+		defer other.Close()
+
+		require.NoError(t, other.MarkProcessed(context.Background(), keys("m")))
+		require.Equal(t, []int{1}, findDuplicates(t, d, []string{"n", "m"}, nil))
+	})
+
+	t.Run("clear db removes previously processed messageIDs", func(t *testing.T) {
+		cleared, err := dedup.NewRedis(newClient(), "test:dedup:", dedup.WithClearDB(), dedup.WithWindow(time.Hour))
+		require.NoError(t, err)
+		defer cleared.Close()
+
+		require.Equal(t, []int{}, findDuplicates(t, cleared, []string{"a", "m"}, nil))
+	})
+
+	t.Run("window", func(t *testing.T) {
+		short, err := dedup.NewRedis(newClient(), "test:dedup:window:", dedup.WithWindow(time.Second))
+		require.NoError(t, err)
+		defer short.Close()
+
+		require.NoError(t, short.MarkProcessed(context.Background(), keys("to be deleted")))
+		require.Equal(t, []int{0}, findDuplicates(t, short, []string{"to be deleted"}, nil))
+		require.Eventually(t, func() bool {
+			return len(findDuplicates(t, short, []string{"to be deleted"}, nil)) == 0
+		}, 3*time.Second, 100*time.Millisecond)
+	})
+	t.Run("retries while redis is unavailable", func(t *testing.T) {
+		config.Set("Dedup.maxRetryInterval", "50ms")
+		defer config.Set("Dedup.maxRetryInterval", "10s")
+
+		proxy := newFlakyProxy(t, redisResource.RedisAddress)
+		flaky, err := dedup.NewRedis(redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{proxy.addr}}), "test:dedup:retry:", dedup.WithWindow(time.Hour))
+		require.NoError(t, err)
+		defer flaky.Close()
+
+		proxy.setDown(true)
+		done := make(chan []int)
+		go func() {
+			require.NoError(t, flaky.MarkProcessed(context.Background(), keys("retried")))
+			done <- findDuplicates(t, flaky, []string{"retried"}, nil)
+		}()
+		time.Sleep(200 * time.Millisecond)
+		proxy.setDown(false)
+		select {
+		case dups := <-done:
+			require.Equal(t, []int{0}, dups)
+		case <-time.After(10 * time.Second):
+			t.Fatal("dedup operations were not retried once redis was available again")
+		}
+	})
+	t.Run("returns the error once retries are exhausted or the context is done", func(t *testing.T) {
+		config.Set("Dedup.maxRetryInterval", "50ms")
+		config.Set("Dedup.maxRetryElapsedTime", "200ms")
+		defer config.Set("Dedup.maxRetryInterval", "10s")
+		defer config.Set("Dedup.maxRetryElapsedTime", "60s")
+
+		proxy := newFlakyProxy(t, redisResource.RedisAddress)
+		flaky, err := dedup.NewRedis(redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{proxy.addr}}), "test:dedup:giveup:", dedup.WithWindow(time.Hour))
+		require.NoError(t, err)
+		defer flaky.Close()
+
+		proxy.setDown(true)
+		require.Error(t, flaky.MarkProcessed(context.Background(), keys("given up")))
+
+		config.Set("Dedup.maxRetryElapsedTime", "1h")
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, err = flaky.FindDuplicates(ctx, []string{"given up"}, nil)
+		require.Error(t, err)
+	})
+}
+
+// flakyProxy forwards connections to a target address, dropping all of them while down
+type flakyProxy struct {
+	addr  string
+	mu    sync.Mutex
+	down  bool
+	conns []net.Conn
+}
+
+func newFlakyProxy(t *testing.T, target string) *flakyProxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	p := &flakyProxy{addr: listener.Addr().String()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			p.mu.Lock()
+			if p.down {
+				_ = conn.Close()
+				p.mu.Unlock()
+				continue
+			}
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				_ = conn.Close()
+				p.mu.Unlock()
+				continue
+			}
+			p.conns = append(p.conns, conn, upstream)
+			p.mu.Unlock()
+			go func() { _, _ = io.Copy(upstream, conn); _ = upstream.Close() }()
+			go func() { _, _ = io.Copy(conn, upstream); _ = conn.Close() }()
+		}
+	}()
+	return p
+}
+
+func (p *flakyProxy) setDown(down bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.down = down
+	if down {
+		for _, conn := range p.conns {
+			_ = conn.Close()
+		}
+		p.conns = nil
+	}
+}
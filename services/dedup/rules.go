@@ -0,0 +1,128 @@
+package dedup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+const messageIDKeyPath = "messageId"
+
+// KeyT is a dedup key along with the window it should be remembered for.
+// A zero Window means the default dedup window.
+type KeyT struct {
+	Key    string
+	Window time.Duration
+}
+
+// Rule describes how the dedup key of an event is extracted and for how long it is remembered
+type Rule struct {
+	// KeyPath is a dot separated JSON path into the event, e.g. properties.orderId. Defaults to messageId
+	KeyPath string
+	// Window overrides the default dedup window, if positive
+	Window time.Duration
+}
+
+// Key returns the dedup key of the event.
+// Keys extracted from a path other than messageId are namespaced by sourceID, so that
+// business keys of different sources don't collide. If the path cannot be found in the
+// event, the messageId is used instead.
+func (r Rule) Key(sourceID string, event map[string]interface{}) string {
+	messageID := misc.GetStringifiedData(event[messageIDKeyPath])
+	if r.KeyPath == "" || r.KeyPath == messageIDKeyPath {
+		return messageID
+	}
+	value, ok := valueAtPath(event, r.KeyPath)
+	if !ok {
+		return messageID
+	}
+	key := misc.GetStringifiedData(value)
+	if key == "" {
+		return messageID
+	}
+	return fmt.Sprintf("%s:%s:%s", sourceID, r.KeyPath, key)
+}
+
+// valueAtPath returns the value found in event under a dot separated path, optionally prefixed with "$."
+func valueAtPath(event map[string]interface{}, keyPath string) (interface{}, bool) {
+	var current interface{} = event
+	for _, field := range strings.Split(strings.TrimPrefix(keyPath, "$."), ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[field]; !ok || current == nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// merge returns r with its empty fields filled in from fallback
+func (r Rule) merge(fallback Rule) Rule {
+	if r.KeyPath == "" {
+		r.KeyPath = fallback.KeyPath
+	}
+	if r.Window <= 0 {
+		r.Window = fallback.Window
+	}
+	return r
+}
+
+// Rules holds the dedup rules configured per source and per workspace
+type Rules struct {
+	bySourceID    map[string]Rule
+	byWorkspaceID map[string]Rule
+}
+
+// NewRules reads dedup rules from the backend config. Source level rules are read from the
+// dedupKeyPath and dedupWindow fields of the source config, workspace level rules from the
+// workspace dedup settings. Invalid windows are logged and ignored.
+func NewRules(config map[string]backendconfig.ConfigT) Rules {
+	rules := Rules{
+		bySourceID:    make(map[string]Rule),
+		byWorkspaceID: make(map[string]Rule),
+	}
+	for workspaceID, wConfig := range config {
+		settings := wConfig.Settings.Dedup
+		if rule, ok := newRule(settings.KeyPath, settings.Window); ok {
+			rules.byWorkspaceID[workspaceID] = rule
+		}
+		for i := range wConfig.Sources {
+			source := &wConfig.Sources[i]
+			keyPath, _ := source.Config["dedupKeyPath"].(string)
+			if rule, ok := newRule(keyPath, source.Config["dedupWindow"]); ok {
+				rules.bySourceID[source.ID] = rule
+			}
+		}
+	}
+	return rules
+}
+
+// newRule builds a rule out of a key path and a window, given either as a duration string or in seconds
+func newRule(keyPath string, window interface{}) (Rule, bool) {
+	rule := Rule{KeyPath: strings.TrimSpace(keyPath)}
+	switch w := window.(type) {
+	case string:
+		if w == "" {
+			break
+		}
+		d, err := time.ParseDuration(w)
+		if err != nil {
+			pkgLogger.Errorf("Invalid dedup window %q: %v", w, err)
+			break
+		}
+		rule.Window = d
+	case float64:
+		rule.Window = time.Duration(w * float64(time.Second))
+	}
+	return rule, rule.KeyPath != "" || rule.Window > 0
+}
+
+// For returns the rule applying to a source of a workspace. Source rules take precedence over workspace rules.
+func (r Rules) For(workspaceID, sourceID string) Rule {
+	return r.bySourceID[sourceID].merge(r.byWorkspaceID[workspaceID])
+}
@@ -0,0 +1,68 @@
+package dedup_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/config"
+	backendconfig "github.com/rudderlabs/rudder-server/config/backend-config"
+	"github.com/rudderlabs/rudder-server/services/dedup"
+	"github.com/rudderlabs/rudder-server/utils/logger"
+)
+
+func Test_Rule_Key(t *testing.T) {
+	event := map[string]interface{}{
+		"messageId": "message-1",
+		"properties": map[string]interface{}{
+			"orderId": "order-1",
+			"total":   float64(42),
+		},
+	}
+
+	t.Run("defaults to messageId", func(t *testing.T) {
+		require.Equal(t, "message-1", dedup.Rule{}.Key("source-1", event))
+		require.Equal(t, "message-1", dedup.Rule{KeyPath: "messageId"}.Key("source-1", event))
+	})
+
+	t.Run("key path is namespaced by source", func(t *testing.T) {
+		require.Equal(t, "source-1:properties.orderId:order-1", dedup.Rule{KeyPath: "properties.orderId"}.Key("source-1", event))
+		require.Equal(t, "source-1:$.properties.total:42", dedup.Rule{KeyPath: "$.properties.total"}.Key("source-1", event))
+	})
+
+	t.Run("falls back to messageId if key path is missing", func(t *testing.T) {
+		require.Equal(t, "message-1", dedup.Rule{KeyPath: "properties.missing"}.Key("source-1", event))
+		require.Equal(t, "message-1", dedup.Rule{KeyPath: "properties.orderId.nested"}.Key("source-1", event))
+	})
+}
+
+func Test_Rules_For(t *testing.T) {
+	config.Reset()
+	logger.Reset()
+	dedup.Init()
+
+	rules := dedup.NewRules(map[string]backendconfig.ConfigT{
+		"workspace-1": {
+			Settings: backendconfig.Settings{
+				Dedup: backendconfig.DedupSettings{KeyPath: "properties.orderId", Window: "24h"},
+			},
+			Sources: []backendconfig.SourceT{
+				{ID: "source-1", Config: map[string]interface{}{"dedupWindow": float64(60)}},
+				{ID: "source-2", Config: map[string]interface{}{"dedupKeyPath": "context.traits.accountId", "dedupWindow": "2h"}},
+				{ID: "source-3", Config: map[string]interface{}{"dedupWindow": "invalid"}},
+			},
+		},
+		"workspace-2": {
+			Sources: []backendconfig.SourceT{
+				{ID: "source-4"},
+			},
+		},
+	})
+
+	require.Equal(t, dedup.Rule{KeyPath: "properties.orderId", Window: time.Minute}, rules.For("workspace-1", "source-1"))
+	require.Equal(t, dedup.Rule{KeyPath: "context.traits.accountId", Window: 2 * time.Hour}, rules.For("workspace-1", "source-2"))
+	require.Equal(t, dedup.Rule{KeyPath: "properties.orderId", Window: 24 * time.Hour}, rules.For("workspace-1", "source-3"))
+	require.Equal(t, dedup.Rule{}, rules.For("workspace-2", "source-4"))
+	require.Equal(t, dedup.Rule{}, dedup.Rules{}.For("workspace-1", "source-1"))
+}
@@ -1,7 +1,13 @@
 package dedup
 
 import (
+	"database/sql"
+	"strings"
 	"sync"
+
+	"github.com/go-redis/redis"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
 )
 
 var (
@@ -9,7 +15,7 @@ var (
 	once         sync.Once
 )
 
-// GetInstance returns an instance of DedupI
+// GetInstance returns an instance of DedupI, backed by the store configured through Dedup.mode
 func GetInstance(clearDB *bool) DedupI {
 	pkgLogger.Info("[[ Dedup ]] Setting up Dedup Manager")
 	once.Do(func() {
@@ -18,7 +24,34 @@ func GetInstance(clearDB *bool) DedupI {
 			opts = append(opts, WithClearDB())
 		}
 
-		dedupManager = New(DefaultRudderPath(), opts...)
+		var err error
+		switch mode {
+		case RedisMode:
+			addrs := strings.Split(redisAddresses, ",")
+			for i := range addrs {
+				addrs[i] = strings.TrimSpace(addrs[i])
+			}
+			dedupManager, err = NewRedis(redis.NewUniversalClient(&redis.UniversalOptions{
+				Addrs:    addrs,
+				Password: redisPassword,
+				DB:       redisDB,
+			}), redisKeyPrefix, opts...)
+		case PostgresMode:
+			dsn := postgresDSN
+			if dsn == "" {
+				dsn = misc.GetConnectionString()
+			}
+			var db *sql.DB
+			if db, err = sql.Open("postgres", dsn); err == nil {
+				dedupManager, err = NewPostgres(db, postgresCleanupInterval, opts...)
+			}
+		default:
+			dedupManager = New(DefaultRudderPath(), opts...)
+		}
+		if err != nil {
+			panic(err)
+		}
+		pkgLogger.Infof("[[ Dedup ]] Using %q dedup store", mode)
 	})
 
 	return dedupManager
@@ -24,6 +24,7 @@ import (
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 	"google.golang.org/api/option"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
@@ -33,15 +34,17 @@ import (
 )
 
 var (
-	AzuriteEndpoint, gcsURL, minioEndpoint, azureSASTokens string
-	base64Secret                                           = base64.StdEncoding.EncodeToString([]byte(secretAccessKey))
-	bucket                                                 = "filemanager-test-1"
-	region                                                 = "us-east-1"
-	accessKeyId                                            = "MYACCESSKEY"
-	secretAccessKey                                        = "MYSECRETKEY"
-	hold                                                   bool
-	regexRequiredSuffix                                    = regexp.MustCompile(".json.gz$")
-	fileList                                               []string
+	AzuriteEndpoint, gcsURL, minioEndpoint, azureSASTokens, sftpPort string
+	base64Secret                                                     = base64.StdEncoding.EncodeToString([]byte(secretAccessKey))
+	bucket                                                           = "filemanager-test-1"
+	region                                                           = "us-east-1"
+	accessKeyId                                                      = "MYACCESSKEY"
+	secretAccessKey                                                  = "MYSECRETKEY"
+	sftpUser                                                         = "rudder"
+	sftpPassword                                                     = "password"
+	hold                                                             bool
+	regexRequiredSuffix                                              = regexp.MustCompile(".json.gz$")
+	fileList                                                         []string
 )
 
 func TestMain(m *testing.M) {
@@ -172,6 +175,36 @@ func run(m *testing.M) int {
 	}
 	fmt.Println("bucket created successfully")
 
+	// Running SFTP server
+	SFTPResource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "atmoz/sftp",
+		Tag:        "latest",
+		Cmd:        []string{fmt.Sprintf("%s:%s:::upload", sftpUser, sftpPassword)},
+	})
+	if err != nil {
+		log.Fatalf("Could not start sftp resource: %s", err)
+	}
+	defer func() {
+		if err := pool.Purge(SFTPResource); err != nil {
+			log.Printf("Could not purge resource: %s \n", err)
+		}
+	}()
+	sftpPort = SFTPResource.GetPort("22/tcp")
+	if err := pool.Retry(func() error {
+		sshClient, err := ssh.Dial("tcp", "localhost:"+sftpPort, &ssh.ClientConfig{
+			User:            sftpUser,
+			Auth:            []ssh.AuthMethod{ssh.Password(sftpPassword)},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+		if err != nil {
+			return err
+		}
+		return sshClient.Close()
+	}); err != nil {
+		log.Fatalf("Could not connect to sftp server: %s", err)
+	}
+	fmt.Println("sftp server successfully created with port: ", sftpPort)
+
 	// getting list of files in `testData` directory while will be used to testing filemanager.
 	searchDir := "./goldenDirectory"
 	err = filepath.Walk(searchDir, func(path string, f os.FileInfo, err error) error {
@@ -304,6 +337,27 @@ func TestFileManager(t *testing.T) {
 				"disableSSL":     true,
 			},
 		},
+		{
+			name:     "testing local filesystem filemanager functionality",
+			destName: "LOCAL_FS",
+			config: map[string]interface{}{
+				"rootPath": t.TempDir(),
+				"prefix":   "some-prefix",
+			},
+		},
+		{
+			name:     "testing sftp filemanager functionality",
+			destName: "SFTP",
+			config: map[string]interface{}{
+				"host":                     "localhost",
+				"port":                     sftpPort,
+				"user":                     sftpUser,
+				"password":                 sftpPassword,
+				"insecureSkipHostKeyCheck": true,
+				"rootPath":                 "upload",
+				"prefix":                   "some-prefix",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -504,6 +558,48 @@ func TestFileManager(t *testing.T) {
 	}
 }
 
+func TestLocalFSManager_keys_outside_root_path(t *testing.T) {
+	rootPath := filepath.Join(t.TempDir(), "root")
+	fm, err := (&filemanager.FileManagerFactoryT{}).New(&filemanager.SettingsT{
+		Provider: "LOCAL_FS",
+		Config:   map[string]interface{}{"rootPath": rootPath},
+	})
+	require.NoError(t, err)
+
+	secret := filepath.Join(filepath.Dir(rootPath), "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0o600))
+
+	output, err := os.CreateTemp(t.TempDir(), "download")
+	require.NoError(t, err)
+	defer func() { _ = output.Close() }()
+	require.Error(t, fm.Download(context.Background(), output, "../secret"))
+	require.Error(t, fm.DeleteObjects(context.Background(), []string{"a/../../secret"}))
+	require.FileExists(t, secret)
+
+	file, err := os.CreateTemp(t.TempDir(), "upload")
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	_, err = fm.Upload(context.Background(), file, "..", "..")
+	require.Error(t, err)
+	require.NoDirExists(t, rootPath)
+}
+
+func TestSFTPManager_requires_host_key(t *testing.T) {
+	fm, err := (&filemanager.FileManagerFactoryT{}).New(&filemanager.SettingsT{
+		Provider: "SFTP",
+		Config: map[string]interface{}{
+			"host":     "localhost",
+			"port":     sftpPort,
+			"user":     sftpUser,
+			"password": sftpPassword,
+			"rootPath": "upload",
+		},
+	})
+	require.NoError(t, err)
+	err = fm.DeleteObjects(context.Background(), []string{"key"})
+	require.ErrorContains(t, err, "no host public key configured")
+}
+
 func TestGCSManager_unsupported_credentials(t *testing.T) {
 	var config map[string]interface{}
 	err := jsoniter.Unmarshal(
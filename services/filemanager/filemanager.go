@@ -87,6 +87,14 @@ func (*FileManagerFactoryT) New(settings *SettingsT) (FileManager, error) {
 		return &DOSpacesManager{
 			Config: GetDOSpacesConfig(settings.Config),
 		}, nil
+	case "LOCAL_FS":
+		return &LocalFSManager{
+			Config: GetLocalFSConfig(settings.Config),
+		}, nil
+	case "SFTP":
+		return &SFTPManager{
+			Config: GetSFTPConfig(settings.Config),
+		}, nil
 	}
 	return nil, fmt.Errorf("%w: %s", rterror.InvalidServiceProvider, settings.Provider)
 }
@@ -136,6 +144,24 @@ func GetProviderConfigFromEnv(ctx context.Context, provider string) map[string]i
 		providerConfig["endPoint"] = config.GetString("DO_SPACES_ENDPOINT", "")
 		providerConfig["accessKeyID"] = config.GetString("DO_SPACES_ACCESS_KEY_ID", "")
 		providerConfig["accessKey"] = config.GetString("DO_SPACES_SECRET_ACCESS_KEY", "")
+
+	case "LOCAL_FS":
+		providerConfig["rootPath"] = config.GetString("JOBS_BACKUP_BUCKET", "")
+		providerConfig["prefix"] = config.GetString("JOBS_BACKUP_PREFIX", "")
+
+	case "SFTP":
+		providerConfig["rootPath"] = config.GetString("JOBS_BACKUP_BUCKET", "")
+		providerConfig["prefix"] = config.GetString("JOBS_BACKUP_PREFIX", "")
+		providerConfig["host"] = config.GetString("SFTP_HOST", "")
+		providerConfig["port"] = config.GetString("SFTP_PORT", "22")
+		providerConfig["user"] = config.GetString("SFTP_USER", "")
+		providerConfig["password"] = config.GetString("SFTP_PASSWORD", "")
+		providerConfig["hostPublicKey"] = config.GetString("SFTP_HOST_PUBLIC_KEY", "")
+		providerConfig["insecureSkipHostKeyCheck"] = config.GetBool("SFTP_INSECURE_SKIP_HOST_KEY_CHECK", false)
+		privateKey, err := os.ReadFile(config.GetString("SFTP_PRIVATE_KEY_PATH", ""))
+		if err == nil {
+			providerConfig["privateKey"] = string(privateKey)
+		}
 	}
 
 	return providerConfig
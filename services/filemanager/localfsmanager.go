@@ -0,0 +1,310 @@
+package filemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// uploadTempPattern is used for the temporary files written while an upload is in progress,
+// so that partially written objects are never listed.
+const uploadTempPattern = ".rudder-upload-*"
+
+// errKeyOutsideRootPath is returned for keys which would be stored outside of the configured root path, e.g. ones with .. elements
+var errKeyOutsideRootPath = errors.New("key is outside of the root path")
+
+// Upload copies the passed in file under the configured root path
+func (manager *LocalFSManager) Upload(ctx context.Context, file *os.File, prefixes ...string) (UploadOutput, error) {
+	if manager.Config.RootPath == "" {
+		return UploadOutput{}, errors.New("no root path configured to uploader")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, manager.getTimeout())
+	defer cancel()
+
+	if err := ctx.Err(); err != nil {
+		return UploadOutput{}, err
+	}
+
+	fileName := path.Join(manager.Config.Prefix, path.Join(prefixes...), path.Base(file.Name()))
+	destination, err := manager.filePath(fileName)
+	if err != nil {
+		return UploadOutput{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return UploadOutput{}, err
+	}
+
+	src, err := os.Open(file.Name())
+	if err != nil {
+		return UploadOutput{}, err
+	}
+	defer func() { _ = src.Close() }()
+
+	tmp, err := os.CreateTemp(filepath.Dir(destination), uploadTempPattern)
+	if err != nil {
+		return UploadOutput{}, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = io.Copy(tmp, &contextReader{ctx: ctx, r: src}); err != nil {
+		_ = tmp.Close()
+		return UploadOutput{}, err
+	}
+	if err = tmp.Close(); err != nil {
+		return UploadOutput{}, err
+	}
+	if err = os.Rename(tmp.Name(), destination); err != nil {
+		return UploadOutput{}, err
+	}
+
+	location, err := manager.objectURL(fileName)
+	if err != nil {
+		return UploadOutput{}, err
+	}
+	return UploadOutput{Location: location, ObjectName: fileName}, nil
+}
+
+func (manager *LocalFSManager) Download(ctx context.Context, output *os.File, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, manager.getTimeout())
+	defer cancel()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	filePath, err := manager.filePath(key)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrKeyNotFound
+		}
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	_, err = io.Copy(output, &contextReader{ctx: ctx, r: src})
+	return err
+}
+
+/*
+GetObjectNameFromLocation gets the object name/key name from the object location url
+
+	file:///root-path/key1 - >> key1
+*/
+func (manager *LocalFSManager) GetObjectNameFromLocation(location string) (string, error) {
+	parsedURL, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	rootPath, err := filepath.Abs(manager.Config.RootPath)
+	if err != nil {
+		return "", err
+	}
+	rootPrefix := strings.TrimSuffix(filepath.ToSlash(rootPath), "/") + "/"
+	if !strings.HasPrefix(parsedURL.Path, rootPrefix) {
+		return "", fmt.Errorf("location %q is not under root path %q", location, manager.Config.RootPath)
+	}
+	return strings.TrimPrefix(parsedURL.Path, rootPrefix), nil
+}
+
+func (manager *LocalFSManager) GetDownloadKeyFromFileLocation(location string) string {
+	key, err := manager.GetObjectNameFromLocation(location)
+	if err != nil {
+		pkgLogger.Errorf("error while getting download key from location %s: %v", location, err)
+	}
+	return key
+}
+
+func (manager *LocalFSManager) DeleteObjects(ctx context.Context, keys []string) error {
+	ctx, cancel := context.WithTimeout(ctx, manager.getTimeout())
+	defer cancel()
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		filePath, err := manager.filePath(key)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// IMPT NOTE: `ListFilesWithPrefix` keeps a continuation token like the S3 manager does. So, if you want the same set of files again,
+// create a new LocalFSManager instead of reusing the existing one.
+func (manager *LocalFSManager) ListFilesWithPrefix(ctx context.Context, startAfter, prefix string, maxItems int64) (fileObjects []*FileObject, err error) {
+	if !manager.Config.IsTruncated {
+		pkgLogger.Infof("Manager is truncated: %v so returning here", manager.Config.IsTruncated)
+		return
+	}
+	fileObjects = make([]*FileObject, 0)
+
+	ctx, cancel := context.WithTimeout(ctx, manager.getTimeout())
+	defer cancel()
+
+	var allObjects []*FileObject
+	err = filepath.WalkDir(manager.Config.RootPath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && filePath == manager.Config.RootPath {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || isUploadTempFile(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(manager.Config.RootPath, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		allObjects = append(allObjects, &FileObject{Key: key, LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	fileObjects, manager.Config.IsTruncated = pageFileObjects(allObjects, startAfter, manager.Config.ContinuationToken, maxItems)
+	if len(fileObjects) > 0 {
+		manager.Config.ContinuationToken = fileObjects[len(fileObjects)-1].Key
+	}
+	return
+}
+
+func (manager *LocalFSManager) GetConfiguredPrefix() string {
+	return manager.Config.Prefix
+}
+
+// filePath returns the path of a key on the local filesystem, rejecting keys which escape the root path
+func (manager *LocalFSManager) filePath(key string) (string, error) {
+	rootPath := filepath.Clean(manager.Config.RootPath)
+	filePath := filepath.Join(rootPath, filepath.FromSlash(key))
+	rel, err := filepath.Rel(rootPath, filePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q", errKeyOutsideRootPath, key)
+	}
+	return filePath, nil
+}
+
+func (manager *LocalFSManager) objectURL(key string) (string, error) {
+	filePath, err := manager.filePath(key)
+	if err != nil {
+		return "", err
+	}
+	filePath, err = filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filePath)}).String(), nil
+}
+
+func GetLocalFSConfig(config map[string]interface{}) *LocalFSConfig {
+	var rootPath, prefix string
+	if config["rootPath"] != nil {
+		tmp, ok := config["rootPath"].(string)
+		if ok {
+			rootPath = tmp
+		}
+	}
+	if config["prefix"] != nil {
+		tmp, ok := config["prefix"].(string)
+		if ok {
+			prefix = tmp
+		}
+	}
+	return &LocalFSConfig{
+		RootPath:    rootPath,
+		Prefix:      prefix,
+		IsTruncated: true,
+	}
+}
+
+type LocalFSManager struct {
+	Config  *LocalFSConfig
+	timeout time.Duration
+}
+
+func (manager *LocalFSManager) SetTimeout(timeout time.Duration) {
+	manager.timeout = timeout
+}
+
+func (manager *LocalFSManager) getTimeout() time.Duration {
+	if manager.timeout > 0 {
+		return manager.timeout
+	}
+
+	return getBatchRouterTimeoutConfig("LOCAL_FS")
+}
+
+type LocalFSConfig struct {
+	RootPath          string
+	Prefix            string
+	ContinuationToken string
+	IsTruncated       bool
+}
+
+// pageFileObjects sorts objects by key and returns at most maxItems of them, starting after
+// both startAfter and the continuation token, along with whether more objects are left.
+func pageFileObjects(objects []*FileObject, startAfter, continuationToken string, maxItems int64) ([]*FileObject, bool) {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	after := startAfter
+	if continuationToken > after {
+		after = continuationToken
+	}
+	start := sort.Search(len(objects), func(i int) bool {
+		return objects[i].Key > after
+	})
+	objects = objects[start:]
+	if maxItems > 0 && int64(len(objects)) > maxItems {
+		return objects[:maxItems], true
+	}
+	return objects, false
+}
+
+func isUploadTempFile(name string) bool {
+	matched, _ := path.Match(uploadTempPattern, name)
+	return matched
+}
+
+// contextReader stops reading as soon as its context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
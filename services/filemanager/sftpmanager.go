@@ -0,0 +1,401 @@
+package filemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Upload copies the passed in file to the configured root path of the sftp server
+func (manager *SFTPManager) Upload(ctx context.Context, file *os.File, prefixes ...string) (UploadOutput, error) {
+	ctx, cancel := context.WithTimeout(ctx, manager.getTimeout())
+	defer cancel()
+
+	if err := ctx.Err(); err != nil {
+		return UploadOutput{}, err
+	}
+
+	client, err := manager.getClient()
+	if err != nil {
+		return UploadOutput{}, err
+	}
+
+	fileName := path.Join(manager.Config.Prefix, path.Join(prefixes...), path.Base(file.Name()))
+	destination, err := manager.filePath(fileName)
+	if err != nil {
+		return UploadOutput{}, err
+	}
+	if err = client.MkdirAll(path.Dir(destination)); err != nil {
+		return UploadOutput{}, err
+	}
+
+	src, err := os.Open(file.Name())
+	if err != nil {
+		return UploadOutput{}, err
+	}
+	defer func() { _ = src.Close() }()
+
+	// concurrent uploads of the same file must not write to the same temporary file
+	tmpName := path.Join(path.Dir(destination), strings.Replace(uploadTempPattern, "*", path.Base(destination)+"-"+uuid.Must(uuid.NewV4()).String(), 1))
+	tmp, err := client.Create(tmpName)
+	if err != nil {
+		return UploadOutput{}, err
+	}
+	defer func() { _ = client.Remove(tmpName) }()
+
+	if _, err = io.Copy(tmp, &contextReader{ctx: ctx, r: src}); err != nil {
+		_ = tmp.Close()
+		return UploadOutput{}, err
+	}
+	if err = tmp.Close(); err != nil {
+		return UploadOutput{}, err
+	}
+	if err = client.PosixRename(tmpName, destination); err != nil {
+		return UploadOutput{}, err
+	}
+
+	return UploadOutput{Location: manager.objectURL(fileName), ObjectName: fileName}, nil
+}
+
+func (manager *SFTPManager) Download(ctx context.Context, output *os.File, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, manager.getTimeout())
+	defer cancel()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	client, err := manager.getClient()
+	if err != nil {
+		return err
+	}
+
+	filePath, err := manager.filePath(key)
+	if err != nil {
+		return err
+	}
+	src, err := client.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrKeyNotFound
+		}
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	_, err = io.Copy(output, &contextReader{ctx: ctx, r: src})
+	return err
+}
+
+/*
+GetObjectNameFromLocation gets the object name/key name from the object location url
+
+	sftp://host:port/root-path/key1 - >> key1
+*/
+func (manager *SFTPManager) GetObjectNameFromLocation(location string) (string, error) {
+	parsedURL, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	rootPrefix := strings.TrimSuffix(path.Join("/", manager.Config.RootPath), "/") + "/"
+	if !strings.HasPrefix(parsedURL.Path, rootPrefix) {
+		return "", fmt.Errorf("location %q is not under root path %q", location, manager.Config.RootPath)
+	}
+	return strings.TrimPrefix(parsedURL.Path, rootPrefix), nil
+}
+
+func (manager *SFTPManager) GetDownloadKeyFromFileLocation(location string) string {
+	key, err := manager.GetObjectNameFromLocation(location)
+	if err != nil {
+		pkgLogger.Errorf("error while getting download key from location %s: %v", location, err)
+	}
+	return key
+}
+
+func (manager *SFTPManager) DeleteObjects(ctx context.Context, keys []string) error {
+	ctx, cancel := context.WithTimeout(ctx, manager.getTimeout())
+	defer cancel()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	client, err := manager.getClient()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		filePath, err := manager.filePath(key)
+		if err != nil {
+			return err
+		}
+		if err := client.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// IMPT NOTE: `ListFilesWithPrefix` keeps a continuation token like the S3 manager does. So, if you want the same set of files again,
+// create a new SFTPManager instead of reusing the existing one.
+func (manager *SFTPManager) ListFilesWithPrefix(ctx context.Context, startAfter, prefix string, maxItems int64) (fileObjects []*FileObject, err error) {
+	if !manager.Config.IsTruncated {
+		pkgLogger.Infof("Manager is truncated: %v so returning here", manager.Config.IsTruncated)
+		return
+	}
+	fileObjects = make([]*FileObject, 0)
+
+	ctx, cancel := context.WithTimeout(ctx, manager.getTimeout())
+	defer cancel()
+
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	client, err := manager.getClient()
+	if err != nil {
+		return
+	}
+
+	rootPath := manager.rootPath()
+	var allObjects []*FileObject
+	walker := client.Walk(rootPath)
+	for walker.Step() {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if err = walker.Err(); err != nil {
+			if errors.Is(err, fs.ErrNotExist) && walker.Path() == rootPath {
+				err = nil
+				break
+			}
+			return
+		}
+		info := walker.Stat()
+		if info.IsDir() || isUploadTempFile(info.Name()) {
+			continue
+		}
+		key := walker.Path()
+		if rootPath != "." {
+			key = strings.TrimPrefix(strings.TrimPrefix(key, rootPath), "/")
+		}
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		allObjects = append(allObjects, &FileObject{Key: key, LastModified: info.ModTime()})
+	}
+
+	fileObjects, manager.Config.IsTruncated = pageFileObjects(allObjects, startAfter, manager.Config.ContinuationToken, maxItems)
+	if len(fileObjects) > 0 {
+		manager.Config.ContinuationToken = fileObjects[len(fileObjects)-1].Key
+	}
+	return
+}
+
+func (manager *SFTPManager) GetConfiguredPrefix() string {
+	return manager.Config.Prefix
+}
+
+func (manager *SFTPManager) rootPath() string {
+	if manager.Config.RootPath == "" {
+		return "."
+	}
+	return path.Clean(manager.Config.RootPath)
+}
+
+// filePath returns the path of a key on the sftp server, rejecting keys which escape the root path
+func (manager *SFTPManager) filePath(key string) (string, error) {
+	rootPath := manager.rootPath()
+	filePath := path.Join(rootPath, key)
+	switch {
+	case rootPath == ".":
+		if filePath == ".." || strings.HasPrefix(filePath, "../") {
+			return "", fmt.Errorf("%w: %q", errKeyOutsideRootPath, key)
+		}
+	case filePath != rootPath && !strings.HasPrefix(filePath, strings.TrimSuffix(rootPath, "/")+"/"):
+		return "", fmt.Errorf("%w: %q", errKeyOutsideRootPath, key)
+	}
+	return filePath, nil
+}
+
+func (manager *SFTPManager) objectURL(key string) string {
+	return (&url.URL{
+		Scheme: "sftp",
+		Host:   net.JoinHostPort(manager.Config.Host, manager.Config.Port),
+		Path:   path.Join("/", manager.Config.RootPath, key),
+	}).String()
+}
+
+// getClient returns the sftp client of the manager, connecting to the server if there is no client yet or the previous connection was closed
+func (manager *SFTPManager) getClient() (*sftp.Client, error) {
+	manager.clientMu.Lock()
+	defer manager.clientMu.Unlock()
+	if manager.client != nil {
+		return manager.client, nil
+	}
+
+	clientConfig, err := manager.sshClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	sshClient, err := ssh.Dial("tcp", net.JoinHostPort(manager.Config.Host, manager.Config.Port), clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to sftp server: %w", err)
+	}
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+		return nil, fmt.Errorf("starting sftp session: %w", err)
+	}
+	manager.client, manager.sshClient = client, sshClient
+
+	// the client is dropped once its connection is closed, so that the next operation reconnects
+	go func() {
+		_ = sshClient.Wait()
+		manager.clientMu.Lock()
+		defer manager.clientMu.Unlock()
+		if manager.sshClient == sshClient {
+			_ = manager.client.Close()
+			manager.client, manager.sshClient = nil, nil
+		}
+	}()
+	return client, nil
+}
+
+// Close closes the connection to the sftp server, if any. The manager reconnects on its next operation
+func (manager *SFTPManager) Close() error {
+	manager.clientMu.Lock()
+	sshClient := manager.sshClient
+	manager.clientMu.Unlock()
+	if sshClient == nil {
+		return nil
+	}
+	return sshClient.Close()
+}
+
+func (manager *SFTPManager) sshClientConfig() (*ssh.ClientConfig, error) {
+	var authMethods []ssh.AuthMethod
+	if manager.Config.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if manager.Config.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(manager.Config.PrivateKey), []byte(manager.Config.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(manager.Config.PrivateKey))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing sftp private key: %w", err)
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+	if manager.Config.Password != "" {
+		authMethods = append(authMethods, ssh.Password(manager.Config.Password))
+	}
+	if len(authMethods) == 0 {
+		return nil, errors.New("no password or private key configured for sftp")
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case manager.Config.HostPublicKey != "":
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(manager.Config.HostPublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("parsing sftp host public key: %w", err)
+		}
+		hostKeyCallback = ssh.FixedHostKey(hostKey)
+	case manager.Config.InsecureSkipHostKeyCheck:
+		pkgLogger.Warnf("Host key verification of sftp server %s is disabled", manager.Config.Host)
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		return nil, errors.New("no host public key configured for sftp, set insecureSkipHostKeyCheck to connect without verifying the host")
+	}
+
+	return &ssh.ClientConfig{
+		User:            manager.Config.User,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         manager.getTimeout(),
+	}, nil
+}
+
+func GetSFTPConfig(config map[string]interface{}) *SFTPConfig {
+	sftpConfig := &SFTPConfig{
+		Port:        "22",
+		IsTruncated: true,
+	}
+	for key, field := range map[string]*string{
+		"host":                 &sftpConfig.Host,
+		"port":                 &sftpConfig.Port,
+		"user":                 &sftpConfig.User,
+		"password":             &sftpConfig.Password,
+		"privateKey":           &sftpConfig.PrivateKey,
+		"privateKeyPassphrase": &sftpConfig.PrivateKeyPassphrase,
+		"hostPublicKey":        &sftpConfig.HostPublicKey,
+		"rootPath":             &sftpConfig.RootPath,
+		"prefix":               &sftpConfig.Prefix,
+	} {
+		if tmp, ok := config[key].(string); ok && tmp != "" {
+			*field = tmp
+		}
+	}
+	if port, ok := config["port"].(float64); ok {
+		sftpConfig.Port = strconv.Itoa(int(port))
+	}
+	if skip, ok := config["insecureSkipHostKeyCheck"].(bool); ok {
+		sftpConfig.InsecureSkipHostKeyCheck = skip
+	}
+	return sftpConfig
+}
+
+type SFTPManager struct {
+	Config    *SFTPConfig
+	client    *sftp.Client
+	sshClient *ssh.Client
+	clientMu  sync.Mutex
+	timeout   time.Duration
+}
+
+func (manager *SFTPManager) SetTimeout(timeout time.Duration) {
+	manager.timeout = timeout
+}
+
+func (manager *SFTPManager) getTimeout() time.Duration {
+	if manager.timeout > 0 {
+		return manager.timeout
+	}
+
+	return getBatchRouterTimeoutConfig("SFTP")
+}
+
+type SFTPConfig struct {
+	Host                     string
+	Port                     string
+	User                     string
+	Password                 string
+	PrivateKey               string
+	PrivateKeyPassphrase     string
+	HostPublicKey            string
+	InsecureSkipHostKeyCheck bool
+	RootPath                 string
+	Prefix                   string
+	ContinuationToken        string
+	IsTruncated              bool
+}
@@ -0,0 +1,142 @@
+package ratelimiter
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+var (
+	cleanupLoopsMu sync.Mutex
+	cleanupLoops   = make(map[*sql.DB]*cleanupLoop) // one loop per database, shared by all its stores
+)
+
+// cleanupLoop periodically removes the expired elements of the rt_throttler_limits table, for as long as stores are using it
+type cleanupLoop struct {
+	stores int
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// PostgresLimitStore represents internal limiter data database where data are stored in a postgres table, so that they are shared by all the nodes using the same database
+type PostgresLimitStore struct {
+	db             *sql.DB
+	expirationTime time.Duration
+	closeOnce      sync.Once
+}
+
+// NewPostgresLimitStore creates new postgres data store for internal limiter data, creating its table if it doesn't exist.
+// Each element of PostgresLimitStore is set as expired after expirationTime from its last counter update. Expired elements are removed with a period specified by the flushInterval argument,
+// by a single loop shared by all the stores of the same database, which runs with the flushInterval of the first store until all of them are closed
+func NewPostgresLimitStore(db *sql.DB, expirationTime, flushInterval time.Duration) (*PostgresLimitStore, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS rt_throttler_limits (
+		key TEXT NOT NULL,
+		window_start TIMESTAMP NOT NULL,
+		value BIGINT NOT NULL,
+		expire_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (key, window_start))`); err != nil {
+		return nil, err
+	}
+	p := &PostgresLimitStore{
+		db:             db,
+		expirationTime: expirationTime,
+	}
+	startCleanupLoop(db, flushInterval)
+	return p, nil
+}
+
+func startCleanupLoop(db *sql.DB, flushInterval time.Duration) {
+	cleanupLoopsMu.Lock()
+	defer cleanupLoopsMu.Unlock()
+	if loop, ok := cleanupLoops[db]; ok {
+		loop.stores++
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	loop := &cleanupLoop{stores: 1, cancel: cancel, done: make(chan struct{})}
+	cleanupLoops[db] = loop
+	go func() {
+		defer close(loop.done)
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = db.ExecContext(ctx, `DELETE FROM rt_throttler_limits WHERE expire_at < NOW() AT TIME ZONE 'UTC'`)
+			}
+		}
+	}()
+}
+
+// Close releases the store, stopping the cleanup loop of its database once all the stores using it are closed
+func (p *PostgresLimitStore) Close() {
+	p.closeOnce.Do(func() {
+		cleanupLoopsMu.Lock()
+		loop := cleanupLoops[p.db]
+		loop.stores--
+		if loop.stores > 0 {
+			cleanupLoopsMu.Unlock()
+			return
+		}
+		delete(cleanupLoops, p.db)
+		cleanupLoopsMu.Unlock()
+		loop.cancel()
+		<-loop.done
+	})
+}
+
+// Inc increments current window limit counter for key
+func (p *PostgresLimitStore) Inc(key string, window time.Time) error {
+	return p.add(key, 1, window)
+}
+
+// Dec decrements current window limit counter for key
+func (p *PostgresLimitStore) Dec(key string, count int64, window time.Time) error {
+	return p.add(key, -count, window)
+}
+
+func (p *PostgresLimitStore) add(key string, count int64, window time.Time) error {
+	_, err := p.db.ExecContext(context.TODO(), `INSERT INTO rt_throttler_limits (key, window_start, value, expire_at) VALUES ($1, $2, GREATEST($3::BIGINT, 0), $4)
+		ON CONFLICT (key, window_start) DO UPDATE SET value = GREATEST(rt_throttler_limits.value + $3::BIGINT, 0), expire_at = EXCLUDED.expire_at`,
+		key, window.UTC(), count, time.Now().UTC().Add(p.expirationTime))
+	return err
+}
+
+// Get gets value of previous window counter and current window counter for key
+func (p *PostgresLimitStore) Get(key string, previousWindow, currentWindow time.Time) (prevValue, currValue int64, err error) {
+	err = p.db.QueryRowContext(context.TODO(), `SELECT
+		COALESCE(SUM(value) FILTER (WHERE window_start = $2), 0),
+		COALESCE(SUM(value) FILTER (WHERE window_start = $3), 0)
+		FROM rt_throttler_limits WHERE key = $1 AND window_start IN ($2, $3)`,
+		key, previousWindow.UTC(), currentWindow.UTC()).Scan(&prevValue, &currValue)
+	return prevValue, currValue, err
+}
+
+// IncIfBelowLimit atomically increments current window limit counter for key, only if the current window counter plus the
+// previous window counter weighted by previousWeight is below limit. The condition on the current window counter is checked
+// on the locked row by the upsert, so that concurrent increments can't exceed the limit
+func (p *PostgresLimitStore) IncIfBelowLimit(key string, previousWindow, currentWindow time.Time, previousWeight float64, limit int64) (incremented bool, prevValue, currValue int64, err error) {
+	prevValue, _, err = p.Get(key, previousWindow, previousWindow)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	allowance := float64(limit) - previousWeight*float64(prevValue) // the current window counter must stay below it
+	err = p.db.QueryRowContext(context.TODO(), `INSERT INTO rt_throttler_limits (key, window_start, value, expire_at)
+		SELECT $1::TEXT, $2::TIMESTAMP, 1, $4::TIMESTAMP WHERE $3::FLOAT8 > 0
+		ON CONFLICT (key, window_start) DO UPDATE SET value = rt_throttler_limits.value + 1, expire_at = EXCLUDED.expire_at
+		WHERE rt_throttler_limits.value < $3::FLOAT8
+		RETURNING value`,
+		key, currentWindow.UTC(), allowance, time.Now().UTC().Add(p.expirationTime)).Scan(&currValue)
+	switch {
+	case err == sql.ErrNoRows:
+		_, currValue, err = p.Get(key, previousWindow, currentWindow)
+		return false, prevValue, currValue, err
+	case err != nil:
+		return false, 0, 0, err
+	default:
+		return true, prevValue, currValue - 1, nil
+	}
+}
@@ -12,6 +12,10 @@ type LimitStore interface {
 	Dec(key string, count int64, window time.Time) error
 	// Get gets value of previous window counter and current window counter for key
 	Get(key string, previousWindow, currentWindow time.Time) (prevValue, currValue int64, err error)
+	// IncIfBelowLimit atomically increments current window limit counter for key, only if the current window counter plus the
+	// previous window counter weighted by previousWeight is below limit. It returns whether the counter got incremented,
+	// along with the values of previous window counter and current window counter before the increment
+	IncIfBelowLimit(key string, previousWindow, currentWindow time.Time, previousWeight float64, limit int64) (incremented bool, prevValue, currValue int64, err error)
 }
 
 // RateLimiter is a simple rate-limiter for any resources inspired by Cloudflare's approach: https://blog.cloudflare.com/counting-things-a-lot-of-different-things/
@@ -60,6 +64,12 @@ type LimitStatus struct {
 
 // Check checks status of rate-limiting for a key. It returns error when limiter data could not be read
 func (r *RateLimiter) Check(key string, currentTime time.Time) (limitStatus *LimitStatus, err error) {
+	return r.CheckWithLimit(key, r.requestsLimit, currentTime)
+}
+
+// CheckWithLimit checks status of rate-limiting for a key against requestsLimit instead of the limit declared in the constructor.
+// It returns error when limiter data could not be read
+func (r *RateLimiter) CheckWithLimit(key string, requestsLimit int64, currentTime time.Time) (limitStatus *LimitStatus, err error) {
 	if currentTime.IsZero() {
 		currentTime = time.Now()
 	}
@@ -73,9 +83,9 @@ func (r *RateLimiter) Check(key string, currentTime time.Time) (limitStatus *Lim
 
 	rate := (float64(r.windowSize)-float64(timeFromCurrWindow))/float64(r.windowSize)*float64(prevValue) + float64(currentValue)
 	limitStatus = &LimitStatus{}
-	if rate >= float64(r.requestsLimit) {
+	if rate >= float64(requestsLimit) {
 		limitStatus.IsLimited = true
-		limitDuration := r.calcLimitDuration(prevValue, currentValue, timeFromCurrWindow)
+		limitDuration := r.calcLimitDuration(requestsLimit, prevValue, currentValue, timeFromCurrWindow)
 		limitStatus.LimitDuration = &limitDuration
 	}
 	limitStatus.CurrentRate = rate
@@ -83,18 +93,43 @@ func (r *RateLimiter) Check(key string, currentTime time.Time) (limitStatus *Lim
 	return limitStatus, nil
 }
 
-func (r *RateLimiter) calcLimitDuration(prevValue, currValue int64, timeFromCurrWindow time.Duration) time.Duration {
-	// we should find x parameter in equation: x*prevValue+currentValue = r.requestsLimit
+// IncWithLimit increments limiter counter for a given key, unless it should be rate-limited against requestsLimit.
+// Checking and incrementing the counter is atomic, so that concurrent callers sharing the same data store can't exceed the limit.
+// It returns the status of rate-limiting before the increment, or error when limiter data could not be updated
+func (r *RateLimiter) IncWithLimit(key string, requestsLimit int64, currentTime time.Time) (limitStatus *LimitStatus, err error) {
+	if currentTime.IsZero() {
+		currentTime = time.Now()
+	}
+	currentWindow := currentTime.UTC().Truncate(r.windowSize)
+	previousWindow := currentWindow.Add(-r.windowSize)
+	timeFromCurrWindow := currentTime.UTC().Sub(currentWindow)
+	previousWeight := (float64(r.windowSize) - float64(timeFromCurrWindow)) / float64(r.windowSize)
+
+	incremented, prevValue, currentValue, err := r.dataStore.IncIfBelowLimit(key, previousWindow, currentWindow, previousWeight, requestsLimit)
+	if err != nil {
+		return nil, err
+	}
+	limitStatus = &LimitStatus{CurrentRate: previousWeight*float64(prevValue) + float64(currentValue)}
+	if !incremented {
+		limitStatus.IsLimited = true
+		limitDuration := r.calcLimitDuration(requestsLimit, prevValue, currentValue, timeFromCurrWindow)
+		limitStatus.LimitDuration = &limitDuration
+	}
+	return limitStatus, nil
+}
+
+func (r *RateLimiter) calcLimitDuration(requestsLimit, prevValue, currValue int64, timeFromCurrWindow time.Duration) time.Duration {
+	// we should find x parameter in equation: x*prevValue+currentValue = requestsLimit
 	// then (1.0-x)*windowSize is duration from current window start when limit can be removed
 	// then ((1.0-x)*windowSize) - timeFromCurrWindow is duration since current time to the time when limit can be removed = limitDuration
 	// --
-	// if prevValue is zero then unblock is in the next window so we should use equation x*currentValue+nextWindowValue = r.requestsLimit
+	// if prevValue is zero then unblock is in the next window so we should use equation x*currentValue+nextWindowValue = requestsLimit
 	// to calculate x parameter
 	var limitDuration time.Duration
 	if prevValue == 0 {
 		// unblock in the next window where prevValue is currValue and currValue is zero (assuming that since limit start all requests are blocked)
 		if currValue != 0 {
-			nextWindowUnblockPoint := float64(r.windowSize) * (1.0 - (float64(r.requestsLimit) / float64(currValue)))
+			nextWindowUnblockPoint := float64(r.windowSize) * (1.0 - (float64(requestsLimit) / float64(currValue)))
 			timeToNextWindow := r.windowSize - timeFromCurrWindow
 			limitDuration = timeToNextWindow + time.Duration(int64(nextWindowUnblockPoint)+1)
 		} else {
@@ -102,7 +137,7 @@ func (r *RateLimiter) calcLimitDuration(prevValue, currValue int64, timeFromCurr
 			limitDuration = -1
 		}
 	} else {
-		currWindowUnblockPoint := float64(r.windowSize) * (1.0 - (float64(r.requestsLimit-currValue) / float64(prevValue)))
+		currWindowUnblockPoint := float64(r.windowSize) * (1.0 - (float64(requestsLimit-currValue) / float64(prevValue)))
 		limitDuration = time.Duration(int64(currWindowUnblockPoint+1)) - timeFromCurrWindow
 
 	}
@@ -0,0 +1,110 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// incScript increments the counter of a key by ARGV[1], flooring it to zero, and renews its expiration to ARGV[2] milliseconds
+var incScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if value < 0 then
+	redis.call('SET', KEYS[1], 0)
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 0
+`)
+
+// incIfBelowLimitScript increments the counter of the current window KEYS[2] and renews its expiration to ARGV[3] milliseconds,
+// only if its value plus the value of the previous window KEYS[1] weighted by ARGV[1] is below the limit ARGV[2].
+// It returns whether the counter got incremented, along with the values of both counters before the increment
+var incIfBelowLimitScript = redis.NewScript(`
+local prev = tonumber(redis.call('GET', KEYS[1]) or '0')
+local curr = tonumber(redis.call('GET', KEYS[2]) or '0')
+if prev * tonumber(ARGV[1]) + curr >= tonumber(ARGV[2]) then
+	return {0, prev, curr}
+end
+redis.call('INCR', KEYS[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[3])
+return {1, prev, curr}
+`)
+
+// RedisLimitStore represents internal limiter data database where data are stored in redis, so that they are shared by all the nodes using the same redis
+type RedisLimitStore struct {
+	client         redis.UniversalClient
+	expirationTime time.Duration
+}
+
+// NewRedisLimitStore creates new redis data store for internal limiter data. Each element of RedisLimitStore expires after expirationTime from its last counter update
+func NewRedisLimitStore(client redis.UniversalClient, expirationTime time.Duration) *RedisLimitStore {
+	return &RedisLimitStore{
+		client:         client,
+		expirationTime: expirationTime,
+	}
+}
+
+// Inc increments current window limit counter for key
+func (r *RedisLimitStore) Inc(key string, window time.Time) error {
+	return r.add(key, 1, window)
+}
+
+// Dec decrements current window limit counter for key
+func (r *RedisLimitStore) Dec(key string, count int64, window time.Time) error {
+	return r.add(key, -count, window)
+}
+
+func (r *RedisLimitStore) add(key string, count int64, window time.Time) error {
+	return incScript.Run(r.client, []string{redisKey(key, window)}, count, r.expirationTime.Milliseconds()).Err()
+}
+
+// Get gets value of previous window counter and current window counter for key
+func (r *RedisLimitStore) Get(key string, previousWindow, currentWindow time.Time) (prevValue, currValue int64, err error) {
+	values, err := r.client.MGet(redisKey(key, previousWindow), redisKey(key, currentWindow)).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	if prevValue, err = redisInt(values[0]); err != nil {
+		return 0, 0, err
+	}
+	if currValue, err = redisInt(values[1]); err != nil {
+		return 0, 0, err
+	}
+	return prevValue, currValue, nil
+}
+
+// IncIfBelowLimit atomically increments current window limit counter for key, only if the current window counter plus the
+// previous window counter weighted by previousWeight is below limit
+func (r *RedisLimitStore) IncIfBelowLimit(key string, previousWindow, currentWindow time.Time, previousWeight float64, limit int64) (incremented bool, prevValue, currValue int64, err error) {
+	result, err := incIfBelowLimitScript.Run(r.client, []string{redisKey(key, previousWindow), redisKey(key, currentWindow)},
+		strconv.FormatFloat(previousWeight, 'f', -1, 64), limit, r.expirationTime.Milliseconds()).Result()
+	if err != nil {
+		return false, 0, 0, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 3 {
+		return false, 0, 0, fmt.Errorf("unexpected script result: %v", result)
+	}
+	for _, value := range values {
+		if _, ok := value.(int64); !ok {
+			return false, 0, 0, fmt.Errorf("unexpected script result: %v", result)
+		}
+	}
+	return values[0].(int64) == 1, values[1].(int64), values[2].(int64), nil
+}
+
+// redisKey hash tags the key, so that the windows of a key belong to the same slot of a redis cluster
+func redisKey(key string, window time.Time) string {
+	return fmt.Sprintf("rt_throttler_{%s}_%s", key, window.Format(time.RFC3339))
+}
+
+// redisInt parses a value returned by MGET, where missing keys are nil
+func redisInt(value interface{}) (int64, error) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
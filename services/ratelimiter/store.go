@@ -75,6 +75,24 @@ func (m *MapLimitStore) Get(key string, previousWindow, currentWindow time.Time)
 	return prevValue, currValue, nil
 }
 
+// IncIfBelowLimit atomically increments current window limit counter for key, only if the current window counter plus the
+// previous window counter weighted by previousWeight is below limit
+func (m *MapLimitStore) IncIfBelowLimit(key string, previousWindow, currentWindow time.Time, previousWeight float64, limit int64) (incremented bool, prevValue, currValue int64, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	prevValue = m.data[mapKey(key, previousWindow)].val
+	data := m.data[mapKey(key, currentWindow)]
+	currValue = data.val
+	if previousWeight*float64(prevValue)+float64(currValue) >= float64(limit) {
+		return false, prevValue, currValue, nil
+	}
+	data.val++
+	data.lastUpdate = time.Now().UTC()
+	m.data[mapKey(key, currentWindow)] = data
+	return true, prevValue, currValue, nil
+}
+
 // Size returns current length of data map
 func (m *MapLimitStore) Size() int {
 	m.mutex.RLock()
@@ -0,0 +1,173 @@
+package ratelimiter
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/testhelper/destination"
+)
+
+func TestLimitStores(t *testing.T) {
+	t.Run("map", func(t *testing.T) {
+		testLimitStore(t, NewMapLimitStore(time.Minute, time.Minute))
+		testConcurrentIncrements(t, NewMapLimitStore(time.Minute, time.Minute))
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		pool, err := dockertest.NewPool("")
+		require.NoError(t, err)
+		redisContainer, err := destination.SetupRedis(pool, t)
+		require.NoError(t, err)
+		client := redis.NewClient(&redis.Options{Addr: redisContainer.RedisAddress})
+		defer func() { _ = client.Close() }()
+
+		testLimitStore(t, NewRedisLimitStore(client, time.Minute))
+		testConcurrentIncrements(t, NewRedisLimitStore(client, time.Minute), NewRedisLimitStore(client, time.Minute))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		pool, err := dockertest.NewPool("")
+		require.NoError(t, err)
+		postgresContainer, err := destination.SetupPostgres(pool, t)
+		require.NoError(t, err)
+
+		store, err := NewPostgresLimitStore(postgresContainer.DB, time.Minute, time.Minute)
+		require.NoError(t, err)
+		testLimitStore(t, store)
+
+		t.Run("limits are shared between stores", func(t *testing.T) {
+			other, err := NewPostgresLimitStore(postgresContainer.DB, time.Minute, time.Minute)
+			require.NoError(t, err)
+			window := time.Now().UTC().Truncate(time.Minute)
+			require.NoError(t, store.Inc("shared", window))
+			require.NoError(t, other.Inc("shared", window))
+			_, curr, err := store.Get("shared", window.Add(-time.Minute), window)
+			require.NoError(t, err)
+			require.EqualValues(t, 2, curr)
+			other.Close()
+		})
+
+		t.Run("concurrent increments don't exceed the limit", func(t *testing.T) {
+			other, err := NewPostgresLimitStore(postgresContainer.DB, time.Minute, time.Minute)
+			require.NoError(t, err)
+			defer other.Close()
+			testConcurrentIncrements(t, store, other)
+		})
+
+		t.Run("stores share a single cleanup loop, stopped once all of them are closed", func(t *testing.T) {
+			other, err := NewPostgresLimitStore(postgresContainer.DB, time.Minute, time.Minute)
+			require.NoError(t, err)
+			cleanupLoopsMu.Lock()
+			require.Len(t, cleanupLoops, 1)
+			require.Equal(t, 2, cleanupLoops[postgresContainer.DB].stores)
+			cleanupLoopsMu.Unlock()
+
+			other.Close()
+			other.Close()
+			store.Close()
+			cleanupLoopsMu.Lock()
+			require.Empty(t, cleanupLoops)
+			cleanupLoopsMu.Unlock()
+		})
+	})
+}
+
+func testLimitStore(t *testing.T, store LimitStore) {
+	t.Helper()
+	currentWindow := time.Now().UTC().Truncate(time.Minute)
+	previousWindow := currentWindow.Add(-time.Minute)
+
+	prev, curr, err := store.Get("key", previousWindow, currentWindow)
+	require.NoError(t, err)
+	require.Zero(t, prev)
+	require.Zero(t, curr)
+
+	require.NoError(t, store.Inc("key", previousWindow))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Inc("key", currentWindow))
+	}
+	require.NoError(t, store.Inc("other", currentWindow))
+	prev, curr, err = store.Get("key", previousWindow, currentWindow)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, prev)
+	require.EqualValues(t, 3, curr)
+
+	require.NoError(t, store.Dec("key", 2, currentWindow))
+	_, curr, err = store.Get("key", previousWindow, currentWindow)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, curr)
+
+	require.NoError(t, store.Dec("key", 5, currentWindow), "counters don't go below zero")
+	_, curr, err = store.Get("key", previousWindow, currentWindow)
+	require.NoError(t, err)
+	require.Zero(t, curr)
+
+	limiter := New(store, 2, time.Minute)
+	require.NoError(t, limiter.Inc("limited", time.Time{}))
+	require.NoError(t, limiter.Inc("limited", time.Time{}))
+	status, err := limiter.Check("limited", time.Time{})
+	require.NoError(t, err)
+	require.True(t, status.IsLimited)
+
+	incremented, prev, curr, err := store.IncIfBelowLimit("conditional", previousWindow, currentWindow, 0.5, 3)
+	require.NoError(t, err)
+	require.True(t, incremented)
+	require.Zero(t, prev)
+	require.Zero(t, curr)
+	require.NoError(t, store.Inc("conditional", previousWindow))
+	require.NoError(t, store.Inc("conditional", previousWindow))
+	incremented, prev, curr, err = store.IncIfBelowLimit("conditional", previousWindow, currentWindow, 0.5, 3)
+	require.NoError(t, err)
+	require.True(t, incremented, "0.5*2 + 1 is below the limit")
+	require.EqualValues(t, 2, prev)
+	require.EqualValues(t, 1, curr)
+	incremented, _, curr, err = store.IncIfBelowLimit("conditional", previousWindow, currentWindow, 0.5, 3)
+	require.NoError(t, err)
+	require.False(t, incremented, "0.5*2 + 2 reaches the limit")
+	require.EqualValues(t, 2, curr)
+	_, curr, err = store.Get("conditional", previousWindow, currentWindow)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, curr)
+
+	for i := 0; i < 2; i++ {
+		status, err = limiter.IncWithLimit("atomic", 2, time.Time{})
+		require.NoError(t, err)
+		require.False(t, status.IsLimited)
+	}
+	status, err = limiter.IncWithLimit("atomic", 2, time.Time{})
+	require.NoError(t, err)
+	require.True(t, status.IsLimited)
+	require.NotNil(t, status.LimitDuration)
+}
+
+// testConcurrentIncrements checks that concurrent increments through different stores sharing the same data never exceed the limit
+func testConcurrentIncrements(t *testing.T, stores ...LimitStore) {
+	t.Helper()
+	const limit = 10
+	window := time.Now().UTC().Truncate(time.Minute)
+	var wg sync.WaitGroup
+	var admitted int64
+	for i := 0; i < 50; i++ {
+		store := stores[i%len(stores)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			incremented, _, _, err := store.IncIfBelowLimit("concurrent", window.Add(-time.Minute), window, 1, limit)
+			require.NoError(t, err)
+			if incremented {
+				atomic.AddInt64(&admitted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, limit, admitted)
+	_, curr, err := stores[0].Get("concurrent", window.Add(-time.Minute), window)
+	require.NoError(t, err)
+	require.EqualValues(t, limit, curr)
+}
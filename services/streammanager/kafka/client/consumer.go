@@ -81,7 +81,10 @@ func (c *Consumer) Receive(ctx context.Context) (Message, error) {
 	if err != nil {
 		return Message{}, err
 	}
+	return fromKafkaMessage(msg), nil
+}
 
+func fromKafkaMessage(msg kafka.Message) Message {
 	var headers []MessageHeader
 	if l := len(msg.Headers); l > 0 {
 		headers = make([]MessageHeader, l)
@@ -101,5 +104,5 @@ func (c *Consumer) Receive(ctx context.Context) (Message, error) {
 		Offset:    msg.Offset,
 		Headers:   headers,
 		Timestamp: msg.Time,
-	}, nil
+	}
 }
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fetchMaxWait is the maximum amount of time the broker waits for messages to be available when fetching
+const fetchMaxWait = 500 * time.Millisecond
+
+// Partitions returns the partition ids of a topic
+func (c *Client) Partitions(ctx context.Context, topic string) ([]int, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("could not read partitions of topic %q: %w", topic, err)
+	}
+	ids := make([]int, len(partitions))
+	for i := range partitions {
+		ids[i] = partitions[i].ID
+	}
+	return ids, nil
+}
+
+// PartitionOffsets returns the first offset available in a partition along with the offset
+// which is going to be assigned to the next message produced to it
+func (c *Client) PartitionOffsets(ctx context.Context, topic string, partition int) (first, last int64, err error) {
+	conn, err := c.dialLeader(ctx, topic, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	return conn.ReadOffsets()
+}
+
+// Fetch reads the messages of a partition starting from offset, up to maxBytes.
+// Contrary to a consumer's Receive, Fetch doesn't wait for new messages to be produced and returns
+// no messages if offset is beyond the last message of the partition.
+func (c *Client) Fetch(ctx context.Context, topic string, partition int, offset int64, maxBytes int) ([]Message, error) {
+	conn, err := c.dialLeader(ctx, topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	last, err := conn.ReadLastOffset()
+	if err != nil {
+		return nil, fmt.Errorf("could not read last offset: %w", err)
+	}
+	if offset >= last {
+		return nil, nil
+	}
+	if _, err := conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+		return nil, fmt.Errorf("could not seek to offset %d: %w", offset, err)
+	}
+
+	batch := conn.ReadBatchWith(kafka.ReadBatchConfig{MinBytes: 1, MaxBytes: maxBytes, MaxWait: fetchMaxWait})
+	var messages []Message
+	for {
+		msg, err := batch.ReadMessage()
+		if err != nil {
+			break
+		}
+		messages = append(messages, fromKafkaMessage(msg))
+	}
+	if err := batch.Close(); err != nil {
+		return nil, fmt.Errorf("could not fetch messages from offset %d: %w", offset, err)
+	}
+	return messages, nil
+}
+
+func (c *Client) dial(ctx context.Context) (*kafka.Conn, error) {
+	var lastErr error
+	for _, addr := range c.addresses {
+		conn, err := c.dialer.DialContext(ctx, c.network, kafka.TCP(addr).String())
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf(
+		"could not dial any of the addresses %s/%s: %w", c.network, kafka.TCP(c.addresses...).String(), lastErr,
+	)
+}
+
+func (c *Client) dialLeader(ctx context.Context, topic string, partition int) (*kafka.Conn, error) {
+	var lastErr error
+	for _, addr := range c.addresses {
+		conn, err := c.dialer.DialLeader(ctx, c.network, kafka.TCP(addr).String(), topic, partition)
+		if err == nil {
+			if deadline, ok := ctx.Deadline(); ok {
+				_ = conn.SetDeadline(deadline)
+			}
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("could not dial the leader of %s/%d: %w", topic, partition, lastErr)
+}
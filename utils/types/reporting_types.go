@@ -33,6 +33,7 @@ var (
 	ROUTER                 = "router"
 	BATCH_ROUTER           = "batch_router"
 	WAREHOUSE              = "warehouse"
+	DEAD_LETTER            = "dead_letter"
 )
 
 type Client struct {
@@ -37,6 +37,7 @@ type EventSchemasI interface {
 	GetKeyCounts(w http.ResponseWriter, r *http.Request)
 	GetEventModelMetadata(w http.ResponseWriter, r *http.Request)
 	GetJsonSchemas(w http.ResponseWriter, r *http.Request)
+	JsonSchemas(writeKey string) ([]byte, error)
 }
 
 // ConfigEnvI is interface to inject env variables into config
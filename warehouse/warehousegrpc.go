@@ -121,6 +121,10 @@ func validateObjectStorageRequestBody(request *proto.ValidateObjectStorageReques
 		if !checkMapForValidKey(r.Config, "bucketName") {
 			err = fmt.Errorf("bucketName invalid or not present")
 		}
+	case "LOCAL_FS", "SFTP":
+		if !checkMapForValidKey(r.Config, "rootPath") {
+			err = fmt.Errorf("rootPath invalid or not present")
+		}
 	default:
 		err = fmt.Errorf("type: %v not supported", r.Type)
 	}